@@ -20,13 +20,22 @@ type Config struct {
 	Snowflake SnowflakeConfig `mapstructure:"snowflake"`
 	FakeData  FakeDataConfig  `mapstructure:"fake_data"`
 	Log       LogConfig       `mapstructure:"log"`
+	GroupBuy  GroupBuyConfig  `yaml:"group_buy"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Wallet    WalletConfig    `yaml:"wallet"`
+	Score     ScoreConfig     `yaml:"score"`
+	Referral  ReferralConfig  `yaml:"referral"`
+	Message   MessageConfig   `yaml:"message"`
+
+	Notification NotificationConfig `yaml:"notification"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Host   string `mapstructure:"host"`
+	Port   int    `mapstructure:"port"`
+	Mode   string `mapstructure:"mode"`
+	Domain string `mapstructure:"domain"` // 对外访问域名，用于生成邀请链接等
 }
 
 // DatabaseConfig 数据库配置
@@ -58,9 +67,103 @@ type RedisConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret             string `mapstructure:"secret"`
-	AccessTokenExpire  int    `mapstructure:"access_token_expire"`
-	RefreshTokenExpire int    `mapstructure:"refresh_token_expire"`
+	Secret             string `mapstructure:"secret" yaml:"secret"`
+	AccessTokenExpire  int    `mapstructure:"access_token_expire" yaml:"access_token_expire"`   // 访问令牌有效期（秒）
+	RefreshTokenExpire int    `mapstructure:"refresh_token_expire" yaml:"refresh_token_expire"` // 刷新令牌有效期（秒）
+	AdminTokenExpire   int    `mapstructure:"admin_token_expire" yaml:"admin_token_expire"`     // 管理员令牌有效期（秒）
+
+	Algorithm         string         `yaml:"algorithm"`           // 签名算法：HS256（默认，使用secret）、RS256、EdDSA
+	Keys              []JWTKeyConfig `yaml:"keys"`                // 非对称签名密钥，按kid区分，支持按时间轮换
+	AcceptLegacyHS256 bool           `yaml:"accept_legacy_hs256"` // 切换到非对称签名后仍接受HS256旧令牌，旧令牌全部过期后应关闭
+}
+
+// JWTKeyConfig 非对称签名密钥配置
+// 轮换时提前加入新密钥并设置active_from，新密钥会先发布到JWKS；旧密钥的retire_at应晚于新密钥生效时间加令牌最长有效期
+type JWTKeyConfig struct {
+	Kid            string `yaml:"kid"`
+	PrivateKeyFile string `yaml:"private_key_file"` // PEM私钥文件，只用于验证的旧密钥可不配置
+	PublicKeyFile  string `yaml:"public_key_file"`  // PEM公钥文件，为空时从私钥推导
+	ActiveFrom     string `yaml:"active_from"`      // 开始用于签名的时间（RFC3339），为空表示立即生效
+	RetireAt       string `yaml:"retire_at"`        // 停止验证的时间（RFC3339），为空表示不退役
+}
+
+// knownDefaultSecrets 示例配置中的默认密钥，release模式下禁止使用
+var knownDefaultSecrets = []string{
+	"your-secret-key-here-change-in-production",
+	"your-secret-key",
+	"secret",
+	"changeme",
+}
+
+// minSecretLength release模式下密钥的最小长度
+const minSecretLength = 32
+
+// AuthConfig 登录认证配置
+type AuthConfig struct {
+	MaxSessions int             `yaml:"max_sessions"` // 每个用户最大并发会话数，超出时登出最早登录的会话
+	TwoFactor   TwoFactorConfig `yaml:"two_factor"`   // 两步验证配置
+
+	MaxLoginAttempts   int `yaml:"max_login_attempts"`    // 同一账号连续登录失败多少次后锁定
+	IPMaxLoginAttempts int `yaml:"ip_max_login_attempts"` // 同一IP连续登录失败多少次后锁定
+	LockoutDuration    int `yaml:"lockout_duration"`      // 首次锁定时长（秒），再次锁定时翻倍，同时也是失败次数的统计窗口
+	MaxLockoutDuration int `yaml:"max_lockout_duration"`  // 最长锁定时长（秒），锁定等级在该时长内无新锁定时清零
+
+	PasswordReset VerifyCodeConfig    `yaml:"password_reset"` // 找回密码验证码配置
+	ContactVerify ContactVerifyConfig `yaml:"contact_verify"` // 邮箱、手机号验证配置
+}
+
+// TwoFactorConfig 两步验证配置
+type TwoFactorConfig struct {
+	Issuer        string  `yaml:"issuer"`         // 验证器App中显示的发行方名称
+	EnforcedRoles []int64 `yaml:"enforced_roles"` // 强制开启两步验证的管理员角色（1:超级管理员 2:经理 3:主管 4:业务员）
+}
+
+// VerifyCodeConfig 邮件、短信验证码配置
+type VerifyCodeConfig struct {
+	CodeExpire     int `yaml:"code_expire"`     // 验证码有效期（秒）
+	CodeLength     int `yaml:"code_length"`     // 验证码位数
+	MaxAttempts    int `yaml:"max_attempts"`    // 单个验证码最多允许的错误次数
+	ResendInterval int `yaml:"resend_interval"` // 同一账号两次发送的最小间隔（秒）
+	AccountLimit   int `yaml:"account_limit"`   // 同一账号每小时最多发送次数
+	IPLimit        int `yaml:"ip_limit"`        // 同一IP每小时最多发送次数
+}
+
+// ContactVerifyConfig 邮箱、手机号验证配置
+type ContactVerifyConfig struct {
+	RequiredBeforeLogin    bool `yaml:"required_before_login"`    // 登录前必须验证登录使用的邮箱或手机号
+	RequiredBeforeWithdraw bool `yaml:"required_before_withdraw"` // 提现前必须至少验证一个邮箱或手机号
+
+	VerifyCodeConfig `yaml:",inline"`
+}
+
+// WalletConfig 钱包配置
+type WalletConfig struct {
+	WithdrawMinAmount float64 `yaml:"withdraw_min_amount"` // 提现金额配置的最低金额，0表示不限制
+	WithdrawMaxAmount float64 `yaml:"withdraw_max_amount"` // 提现金额配置的最高金额，0表示不限制
+	StatusExpireCron  string  `yaml:"status_expire_cron"`  // 钱包冻结和提现限制到期恢复定时表达式（包含秒）
+}
+
+// ScoreConfig 经验值与信用分配置，各事件的加减分规则在后台管理
+type ScoreConfig struct {
+	MinCreditScore         int    `yaml:"min_credit_score"`           // 信用分下限
+	MaxCreditScore         int    `yaml:"max_credit_score"`           // 信用分上限
+	GroupBuyMinExperience  *int   `yaml:"group_buy_min_experience"`   // 参与拼单所需最低经验值，未配置时默认1，可配置为0表示不限制
+	GroupBuyMinCreditScore *int   `yaml:"group_buy_min_credit_score"` // 参与拼单所需最低信用分，未配置时默认60，可配置为0表示不限制
+	ScanLookbackHours      int    `yaml:"scan_lookback_hours"`        // 首次扫描（没有扫描进度记录）时扫描订单、提现状态变化的时间范围（小时）
+	EventCron              string `yaml:"event_cron"`                 // 事件扫描定时表达式（包含秒）
+}
+
+// ReferralConfig 用户推荐配置，各层级的佣金比例在后台管理
+type ReferralConfig struct {
+	MaxDepth          int    `yaml:"max_depth"`           // 记录推荐关系的最大层级，佣金规则的层级不能超过该值
+	ScanLookbackHours int    `yaml:"scan_lookback_hours"` // 首次扫描（没有扫描进度记录）时扫描已完成订单的时间范围（小时）
+	CommissionCron    string `yaml:"commission_cron"`     // 佣金发放定时表达式（包含秒）
+}
+
+// NotificationConfig 通知发送配置
+type NotificationConfig struct {
+	Provider string `yaml:"provider"` // 通知渠道实现，目前支持log（写入本地文件，用于开发测试）
+	LogFile  string `yaml:"log_file"` // log渠道的输出文件
 }
 
 // SnowflakeConfig 雪花算法配置
@@ -83,6 +186,44 @@ type FakeDataConfig struct {
 	RetentionDays   int     `mapstructure:"retention_days"`
 }
 
+// MessageConfig 消息配置
+type MessageConfig struct {
+	BroadcastCron string `yaml:"broadcast_cron"` // 群发消息发送定时表达式（包含秒）
+}
+
+// GroupBuyConfig 拼单配置
+type GroupBuyConfig struct {
+	InviteSecret  string             `yaml:"invite_secret"`   // 邀请码签名密钥，为空时使用JWT密钥
+	InviteExpire  int                `yaml:"invite_expire"`   // 邀请码有效期（秒），不超过拼单截止时间
+	InviteBaseURL string             `yaml:"invite_base_url"` // 邀请链接前缀，为空时根据server.domain生成
+	ExpireCron    string             `yaml:"expire_cron"`     // 过期拼单退款定时表达式（包含秒）
+	Templates     []GroupBuyTemplate `yaml:"templates"`       // 用户发起拼单可选的模板
+
+	TypeRules map[string]GroupBuyTypeRule `yaml:"type_rules"` // 按拼单类型（normal、flash、vip）配置的规则
+}
+
+// GroupBuyTypeRule 拼单类型规则
+type GroupBuyTypeRule struct {
+	MaxDurationMinutes int     `yaml:"max_duration_minutes"` // 最长持续时间（分钟），0表示不限制
+	MaxParticipants    int     `yaml:"max_participants"`     // 最多名额，0表示不限制
+	FirstCome          bool    `yaml:"first_come"`           // 先到先得，不为指定用户保留名额
+	MinMemberLevel     int     `yaml:"min_member_level"`     // 发起和参与所需最低会员等级，0表示不限制
+	ProfitMargin       float64 `yaml:"profit_margin"`        // 该类型的利润比例，0表示使用模板配置
+}
+
+// GroupBuyTemplate 拼单模板
+type GroupBuyTemplate struct {
+	Code            string  `yaml:"code"`              // 模板编码
+	Name            string  `yaml:"name"`              // 模板名称
+	GroupBuyType    string  `yaml:"group_buy_type"`    // 拼单类型：normal、flash、vip
+	PerPersonAmount float64 `yaml:"per_person_amount"` // 每人付款金额
+	ProfitMargin    float64 `yaml:"profit_margin"`     // 利润比例（小数）
+	DurationMinutes int     `yaml:"duration_minutes"`  // 拼单持续时间（分钟）
+	MinParticipants int     `yaml:"min_participants"`  // 最少目标人数
+	MaxParticipants int     `yaml:"max_participants"`  // 最多目标人数
+	Description     string  `yaml:"description"`       // 模板描述
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level string `mapstructure:"level"` // debug, info, warn, error
@@ -173,6 +314,63 @@ func setDefaults() {
 	if GlobalConfig.JWT.RefreshTokenExpire == 0 {
 		GlobalConfig.JWT.RefreshTokenExpire = 604800 // 7天
 	}
+	if GlobalConfig.JWT.AdminTokenExpire == 0 {
+		GlobalConfig.JWT.AdminTokenExpire = 28800 // 8小时
+	}
+
+	// 登录认证默认配置
+	if GlobalConfig.Auth.MaxSessions == 0 {
+		GlobalConfig.Auth.MaxSessions = 3
+	}
+	if GlobalConfig.Auth.TwoFactor.Issuer == "" {
+		GlobalConfig.Auth.TwoFactor.Issuer = "FataMorgana"
+	}
+	if GlobalConfig.Auth.MaxLoginAttempts == 0 {
+		GlobalConfig.Auth.MaxLoginAttempts = 5
+	}
+	if GlobalConfig.Auth.IPMaxLoginAttempts == 0 {
+		GlobalConfig.Auth.IPMaxLoginAttempts = 20
+	}
+	if GlobalConfig.Auth.LockoutDuration == 0 {
+		GlobalConfig.Auth.LockoutDuration = 1800 // 30分钟
+	}
+	if GlobalConfig.Auth.MaxLockoutDuration == 0 {
+		GlobalConfig.Auth.MaxLockoutDuration = 86400 // 1天
+	}
+	setVerifyCodeDefaults(&GlobalConfig.Auth.PasswordReset)
+	setVerifyCodeDefaults(&GlobalConfig.Auth.ContactVerify.VerifyCodeConfig)
+
+	// 经验值与信用分默认配置
+	if GlobalConfig.Score.MaxCreditScore == 0 {
+		GlobalConfig.Score.MaxCreditScore = 100
+	}
+	if GlobalConfig.Score.GroupBuyMinExperience == nil {
+		minExperience := 1
+		GlobalConfig.Score.GroupBuyMinExperience = &minExperience
+	}
+	if GlobalConfig.Score.GroupBuyMinCreditScore == nil {
+		minCreditScore := 60
+		GlobalConfig.Score.GroupBuyMinCreditScore = &minCreditScore
+	}
+	if GlobalConfig.Score.ScanLookbackHours == 0 {
+		GlobalConfig.Score.ScanLookbackHours = 24
+	}
+
+	// 用户推荐默认配置
+	if GlobalConfig.Referral.MaxDepth == 0 {
+		GlobalConfig.Referral.MaxDepth = 3
+	}
+	if GlobalConfig.Referral.ScanLookbackHours == 0 {
+		GlobalConfig.Referral.ScanLookbackHours = 24
+	}
+
+	// 通知发送默认配置
+	if GlobalConfig.Notification.Provider == "" {
+		GlobalConfig.Notification.Provider = "log"
+	}
+	if GlobalConfig.Notification.LogFile == "" {
+		GlobalConfig.Notification.LogFile = "logs/notifications.log"
+	}
 	if GlobalConfig.Snowflake.WorkerID == 0 {
 		GlobalConfig.Snowflake.WorkerID = 1
 	}
@@ -208,6 +406,56 @@ func setDefaults() {
 	if GlobalConfig.FakeData.RetentionDays == 0 {
 		GlobalConfig.FakeData.RetentionDays = 2
 	}
+
+	// 拼单配置默认值
+	if GlobalConfig.GroupBuy.InviteExpire == 0 {
+		GlobalConfig.GroupBuy.InviteExpire = 86400 // 1天
+	}
+	if len(GlobalConfig.GroupBuy.Templates) == 0 {
+		GlobalConfig.GroupBuy.Templates = []GroupBuyTemplate{
+			{Code: "normal_small", Name: "小额拼单", GroupBuyType: "normal", PerPersonAmount: 100, ProfitMargin: 0.05, DurationMinutes: 60, MinParticipants: 2, MaxParticipants: 5},
+			{Code: "normal_large", Name: "大额拼单", GroupBuyType: "normal", PerPersonAmount: 500, ProfitMargin: 0.08, DurationMinutes: 120, MinParticipants: 3, MaxParticipants: 10},
+		}
+	}
+	for i := range GlobalConfig.GroupBuy.Templates {
+		if GlobalConfig.GroupBuy.Templates[i].GroupBuyType == "" {
+			GlobalConfig.GroupBuy.Templates[i].GroupBuyType = "normal"
+		}
+	}
+	if GlobalConfig.GroupBuy.TypeRules == nil {
+		GlobalConfig.GroupBuy.TypeRules = map[string]GroupBuyTypeRule{}
+	}
+	if _, ok := GlobalConfig.GroupBuy.TypeRules["normal"]; !ok {
+		GlobalConfig.GroupBuy.TypeRules["normal"] = GroupBuyTypeRule{}
+	}
+	if _, ok := GlobalConfig.GroupBuy.TypeRules["flash"]; !ok {
+		GlobalConfig.GroupBuy.TypeRules["flash"] = GroupBuyTypeRule{MaxDurationMinutes: 30, MaxParticipants: 5, FirstCome: true}
+	}
+	if _, ok := GlobalConfig.GroupBuy.TypeRules["vip"]; !ok {
+		GlobalConfig.GroupBuy.TypeRules["vip"] = GroupBuyTypeRule{MinMemberLevel: 3, ProfitMargin: 0.12}
+	}
+}
+
+// setVerifyCodeDefaults 设置验证码配置默认值
+func setVerifyCodeDefaults(cfg *VerifyCodeConfig) {
+	if cfg.CodeExpire == 0 {
+		cfg.CodeExpire = 600 // 10分钟
+	}
+	if cfg.CodeLength == 0 {
+		cfg.CodeLength = 6
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.ResendInterval == 0 {
+		cfg.ResendInterval = 60
+	}
+	if cfg.AccountLimit == 0 {
+		cfg.AccountLimit = 5
+	}
+	if cfg.IPLimit == 0 {
+		cfg.IPLimit = 20
+	}
 }
 
 // overrideWithEnvVars 使用环境变量覆盖配置
@@ -220,6 +468,11 @@ func overrideWithEnvVars() {
 		GlobalConfig.Server.Mode = env
 	}
 
+	// JWT配置，生产环境建议通过环境变量注入密钥
+	if env := os.Getenv("JWT_SECRET"); env != "" {
+		GlobalConfig.JWT.Secret = env
+	}
+
 	// 数据库配置
 	if env := os.Getenv("DATABASE_HOST"); env != "" {
 		GlobalConfig.Database.Host = env
@@ -344,6 +597,44 @@ func ValidateConfig() error {
 	if GlobalConfig.JWT.Secret == "" {
 		return utils.NewAppError(utils.CodeJWTSecretEmpty, "JWT密钥不能为空")
 	}
+	if GlobalConfig.Server.Mode == "release" {
+		if err := checkSecretStrength("jwt.secret", GlobalConfig.JWT.Secret); err != nil {
+			return err
+		}
+		if GlobalConfig.GroupBuy.InviteSecret != "" {
+			if err := checkSecretStrength("group_buy.invite_secret", GlobalConfig.GroupBuy.InviteSecret); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
 
+// checkSecretStrength release模式下拒绝示例默认密钥和过短的密钥
+func checkSecretStrength(name, secret string) error {
+	for _, s := range knownDefaultSecrets {
+		if secret == s {
+			return utils.NewAppError(utils.CodeJWTSecretInsecure, fmt.Sprintf("%s仍是示例默认值，release模式下禁止启动", name))
+		}
+	}
+	if len(secret) < minSecretLength {
+		return utils.NewAppError(utils.CodeJWTSecretInsecure, fmt.Sprintf("%s长度不能少于%d位", name, minSecretLength))
+	}
 	return nil
 }
+
+// JWTKeySpecs 转换非对称签名密钥配置
+func (c *JWTConfig) JWTKeySpecs() []utils.JWTKeySpec {
+	specs := make([]utils.JWTKeySpec, 0, len(c.Keys))
+	for _, k := range c.Keys {
+		specs = append(specs, utils.JWTKeySpec{
+			Kid:            k.Kid,
+			PrivateKeyFile: k.PrivateKeyFile,
+			PublicKeyFile:  k.PublicKeyFile,
+			ActiveFrom:     k.ActiveFrom,
+			RetireAt:       k.RetireAt,
+		})
+	}
+	return specs
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuditController 管理员审计日志控制器
+type AdminAuditController struct {
+	auditService *services.AdminAuditService
+}
+
+// NewAdminAuditController 创建管理员审计日志控制器实例
+func NewAdminAuditController() *AdminAuditController {
+	return &AdminAuditController{
+		auditService: services.NewAdminAuditService(),
+	}
+}
+
+// Query 查询审计日志
+// @Summary 查询审计日志
+// @Description 按操作管理员、操作类型、操作对象和时间范围分页查询审计日志，按时间倒序
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.AdminAuditLogQueryRequest true "查询条件"
+// @Success 200 {object} utils.Response{data=models.AdminAuditLogQueryResponse}
+// @Router /admin/audit-logs/list [post]
+func (ac *AdminAuditController) Query(c *gin.Context) {
+	var req models.AdminAuditLogQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := ac.auditService.Query(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "查询审计日志失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Verify 校验审计日志哈希链
+// @Summary 校验审计日志
+// @Description 从第一条记录开始重新计算哈希，返回第一处被修改、删除或插入的位置
+// @Tags 管理员
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.AdminAuditVerifyResponse}
+// @Router /admin/audit-logs/verify [post]
+func (ac *AdminAuditController) Verify(c *gin.Context) {
+	resp, err := ac.auditService.Verify(c.Request.Context())
+	if err != nil {
+		utils.HandleAppError(c, err, "校验审计日志失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"errors"
+
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserController 管理员账号控制器
+type AdminUserController struct {
+	adminUserService *services.AdminUserService
+	twoFactorService *services.TwoFactorService
+}
+
+// NewAdminUserController 创建管理员账号控制器实例
+func NewAdminUserController() *AdminUserController {
+	return &AdminUserController{
+		adminUserService: services.NewAdminUserService(),
+		twoFactorService: services.NewTwoFactorService(),
+	}
+}
+
+// Login 管理员登录
+// @Summary 管理员登录
+// @Description 用户名密码登录后台，开启两步验证的管理员需同时提供动态验证码
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.AdminLoginRequest true "登录请求"
+// @Success 200 {object} utils.Response{data=models.AdminLoginResponse}
+// @Router /admin/auth/login [post]
+func (ac *AdminUserController) Login(c *gin.Context) {
+	var req models.AdminLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := ac.adminUserService.Login(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			utils.AccountLockedWithRemaining(c, lockedErr.Error(), lockedErr.RemainingSeconds())
+			return
+		}
+		utils.HandleAppError(c, err, "登录失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "登录成功", resp)
+}
+
+// Logout 管理员登出
+func (ac *AdminUserController) Logout(c *gin.Context) {
+	if err := ac.adminUserService.Logout(c.Request.Context(), middleware.GetCurrentAdminToken(c)); err != nil {
+		utils.ErrorWithMessage(c, utils.CodeRedisError, "登出失败")
+		return
+	}
+	utils.SuccessWithMessage(c, "登出成功", nil)
+}
+
+// Profile 获取当前管理员信息
+func (ac *AdminUserController) Profile(c *gin.Context) {
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+	utils.Success(c, admin.ToResponse())
+}
+
+// ChangePassword 修改当前管理员密码，成功后需重新登录
+func (ac *AdminUserController) ChangePassword(c *gin.Context) {
+	var req models.AdminChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := ac.adminUserService.ChangePassword(c.Request.Context(), admin, &req, middleware.GetCurrentAdminToken(c)); err != nil {
+		utils.HandleAppError(c, err, "修改密码失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "密码修改成功，请重新登录", nil)
+}
+
+// TwoFactorSetup 当前管理员开始绑定两步验证
+func (ac *AdminUserController) TwoFactorSetup(c *gin.Context) {
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := ac.twoFactorService.AdminSetup(c.Request.Context(), admin)
+	if err != nil {
+		utils.HandleAppError(c, err, "绑定两步验证失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// TwoFactorEnable 当前管理员校验验证码开启两步验证
+func (ac *AdminUserController) TwoFactorEnable(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := ac.twoFactorService.AdminEnable(c.Request.Context(), admin, req.Code); err != nil {
+		utils.HandleAppError(c, err, "开启两步验证失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "两步验证已开启", nil)
+}
+
+// List 下级管理员列表
+func (ac *AdminUserController) List(c *gin.Context) {
+	var req models.AdminListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := ac.adminUserService.List(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "查询管理员失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Detail 下级管理员详情
+func (ac *AdminUserController) Detail(c *gin.Context) {
+	var req models.AdminIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := ac.adminUserService.Get(c.Request.Context(), admin, req.ID)
+	if err != nil {
+		utils.HandleAppError(c, err, "查询管理员失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Create 创建下级管理员
+func (ac *AdminUserController) Create(c *gin.Context) {
+	var req models.AdminCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := ac.adminUserService.Create(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "创建管理员失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "创建成功", resp)
+}
+
+// Update 修改下级管理员
+func (ac *AdminUserController) Update(c *gin.Context) {
+	var req models.AdminUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := ac.adminUserService.Update(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "修改管理员失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "修改成功", resp)
+}
+
+// Disable 禁用下级管理员
+func (ac *AdminUserController) Disable(c *gin.Context) {
+	ac.setStatus(c, models.AdminStatusDisabled, "禁用成功")
+}
+
+// Enable 启用下级管理员
+func (ac *AdminUserController) Enable(c *gin.Context) {
+	ac.setStatus(c, models.AdminStatusActive, "启用成功")
+}
+
+// ResetPassword 重置下级管理员密码
+func (ac *AdminUserController) ResetPassword(c *gin.Context) {
+	var req models.AdminResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := ac.adminUserService.ResetPassword(c.Request.Context(), admin, &req); err != nil {
+		utils.HandleAppError(c, err, "重置密码失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "重置密码成功", nil)
+}
+
+// ResetTwoFactor 重置下级管理员的两步验证
+func (ac *AdminUserController) ResetTwoFactor(c *gin.Context) {
+	var req models.AdminIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := ac.adminUserService.ResetTwoFactor(c.Request.Context(), admin, req.ID); err != nil {
+		utils.HandleAppError(c, err, "重置两步验证失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "重置两步验证成功", nil)
+}
+
+// setStatus 修改下级管理员状态
+func (ac *AdminUserController) setStatus(c *gin.Context, status int64, successMessage string) {
+	var req models.AdminIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := ac.adminUserService.SetStatus(c.Request.Context(), admin, req.ID, status); err != nil {
+		utils.HandleAppError(c, err, "修改管理员状态失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, successMessage, nil)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AgentReportController 代理业绩报表控制器
+type AgentReportController struct {
+	reportService *services.AgentReportService
+}
+
+// NewAgentReportController 创建代理业绩报表控制器实例
+func NewAgentReportController() *AgentReportController {
+	return &AgentReportController{
+		reportService: services.NewAgentReportService(),
+	}
+}
+
+// AgentReport 代理业绩报表
+// @Summary 代理业绩报表
+// @Description 按日期范围统计各代理邀请用户的注册、审核、充值、提现、下单和活跃情况，同时给出个人和团队合计，非超级管理员只能查看自己及下级
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.AgentReportRequest true "统计条件"
+// @Success 200 {object} utils.Response{data=models.AgentReportResponse}
+// @Router /admin/reports/agents [post]
+func (rc *AgentReportController) AgentReport(c *gin.Context) {
+	var req models.AgentReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.reportService.GetAgentReport(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取代理业绩报表失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// ExportAgentReport 导出代理业绩报表
+// @Summary 导出代理业绩报表
+// @Description 以CSV文件导出代理业绩报表，统计条件与代理业绩报表相同
+// @Tags 管理员
+// @Accept json
+// @Produce text/csv
+// @Param request body models.AgentReportRequest true "统计条件"
+// @Success 200 {file} file
+// @Router /admin/reports/agents/export [post]
+func (rc *AgentReportController) ExportAgentReport(c *gin.Context) {
+	var req models.AgentReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	content, filename, err := rc.reportService.ExportAgentReport(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "导出代理业绩报表失败")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
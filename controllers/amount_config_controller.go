@@ -93,3 +93,165 @@ func (c *AmountConfigController) GetAmountConfigByID(ctx *gin.Context) {
 	// 返回成功响应
 	utils.SuccessWithMessage(ctx, "获取金额配置详情成功", config)
 }
+
+// AdminList 管理后台获取金额配置列表
+// @Summary 管理后台金额配置列表
+// @Description 获取指定类型的全部金额配置，包含未激活的配置，可按激活状态筛选
+// @Tags 金额配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AmountConfigAdminListRequest true "查询条件"
+// @Success 200 {object} utils.Response{data=[]models.AmountConfigResponse}
+// @Router /api/v2/admin/amount-config/list [post]
+func (c *AmountConfigController) AdminList(ctx *gin.Context) {
+	var request models.AmountConfigAdminListRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	configs, err := c.amountConfigService.AdminListAmountConfigs(ctx.Request.Context(), &request)
+	if err != nil {
+		utils.HandleAppError(ctx, err, "获取金额配置列表失败")
+		return
+	}
+
+	utils.Success(ctx, configs)
+}
+
+// AdminCreate 创建金额配置
+// @Summary 创建金额配置
+// @Description 同一类型下金额不能重复，提现金额需在提现限额范围内
+// @Tags 金额配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AmountConfigCreateRequest true "金额配置"
+// @Success 200 {object} utils.Response{data=models.AmountConfigResponse}
+// @Router /api/v2/admin/amount-config/create [post]
+func (c *AmountConfigController) AdminCreate(ctx *gin.Context) {
+	var request models.AmountConfigCreateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	config := &models.AmountConfig{
+		Type:        request.Type,
+		Amount:      request.Amount,
+		Description: request.Description,
+		IsActive:    request.IsActive == nil || *request.IsActive,
+		SortOrder:   request.SortOrder,
+	}
+	resp, err := c.amountConfigService.CreateAmountConfig(ctx.Request.Context(), config)
+	if err != nil {
+		utils.HandleAppError(ctx, err, "创建金额配置失败")
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "创建成功", resp)
+}
+
+// AdminUpdate 修改金额配置
+// @Summary 修改金额配置
+// @Description 修改金额、描述或排序，只修改传入的字段
+// @Tags 金额配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AmountConfigUpdateRequest true "修改内容"
+// @Success 200 {object} utils.Response{data=models.AmountConfigResponse}
+// @Router /api/v2/admin/amount-config/update [post]
+func (c *AmountConfigController) AdminUpdate(ctx *gin.Context) {
+	var request models.AmountConfigUpdateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	resp, err := c.amountConfigService.UpdateAmountConfig(ctx.Request.Context(), &request)
+	if err != nil {
+		utils.HandleAppError(ctx, err, "修改金额配置失败")
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "修改成功", resp)
+}
+
+// AdminDelete 删除金额配置
+// @Summary 删除金额配置
+// @Tags 金额配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AmountConfigIDRequest true "配置ID"
+// @Success 200 {object} utils.Response
+// @Router /api/v2/admin/amount-config/delete [post]
+func (c *AmountConfigController) AdminDelete(ctx *gin.Context) {
+	var request models.AmountConfigIDRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	if err := c.amountConfigService.DeleteAmountConfig(ctx.Request.Context(), request.ID); err != nil {
+		utils.HandleAppError(ctx, err, "删除金额配置失败")
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "删除成功", nil)
+}
+
+// AdminActivate 激活金额配置
+func (c *AmountConfigController) AdminActivate(ctx *gin.Context) {
+	c.setActive(ctx, true, "已激活")
+}
+
+// AdminDeactivate 停用金额配置
+func (c *AmountConfigController) AdminDeactivate(ctx *gin.Context) {
+	c.setActive(ctx, false, "已停用")
+}
+
+// AdminReorder 调整金额配置排序
+// @Summary 调整金额配置排序
+// @Description 按传入的ID顺序重新设置sort_order，未传入的配置排序不变
+// @Tags 金额配置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AmountConfigReorderRequest true "排序"
+// @Success 200 {object} utils.Response{data=[]models.AmountConfigResponse}
+// @Router /api/v2/admin/amount-config/reorder [post]
+func (c *AmountConfigController) AdminReorder(ctx *gin.Context) {
+	var request models.AmountConfigReorderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	configs, err := c.amountConfigService.ReorderAmountConfigs(ctx.Request.Context(), &request)
+	if err != nil {
+		utils.HandleAppError(ctx, err, "调整金额配置排序失败")
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "排序已更新", configs)
+}
+
+// setActive 修改金额配置激活状态
+func (c *AmountConfigController) setActive(ctx *gin.Context, isActive bool, successMessage string) {
+	var request models.AmountConfigIDRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	resp, err := c.amountConfigService.SetAmountConfigActive(ctx.Request.Context(), request.ID, isActive)
+	if err != nil {
+		utils.HandleAppError(ctx, err, "修改金额配置状态失败")
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, successMessage, resp)
+}
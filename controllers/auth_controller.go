@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/middleware"
 	"gin-fataMorgana/models"
@@ -18,6 +19,8 @@ import (
 type AuthController struct {
 	userService             *services.UserService
 	operationFailureService *services.OperationFailureService
+	passwordResetService    *services.PasswordResetService
+	contactVerifyService    *services.ContactVerifyService
 }
 
 // NewAuthController 创建认证控制器实例
@@ -25,6 +28,8 @@ func NewAuthController() *AuthController {
 	return &AuthController{
 		userService:             services.NewUserService(),
 		operationFailureService: services.NewOperationFailureService(),
+		passwordResetService:    services.NewPasswordResetService(),
+		contactVerifyService:    services.NewContactVerifyService(),
 	}
 }
 
@@ -119,6 +124,16 @@ func (ac *AuthController) Login(c *gin.Context) {
 			"code":  utils.CodeOperationFailed,
 		})
 
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			utils.AccountLockedWithRemaining(c, lockedErr.Error(), lockedErr.RemainingSeconds())
+			return
+		}
+		if appErr, ok := err.(*utils.AppError); ok && appErr.Code == utils.CodeContactNotVerified {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+
 		switch err.Error() {
 		case "邮箱或密码错误":
 			utils.LoginFailed(c)
@@ -134,12 +149,65 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	if tokens.TwoFactorRequired {
+		utils.SuccessWithMessage(c, "请输入动态验证码完成登录", gin.H{
+			"tokens": tokens,
+		})
+		return
+	}
+
+	utils.SuccessWithMessage(c, "登录成功", gin.H{
+		"tokens": tokens,
+	})
+}
+
+// LoginTwoFactor 两步验证登录
+// @Summary 两步验证登录
+// @Description 登录返回two_factor_required时，提交挑战令牌和动态验证码（或恢复码）完成登录
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "两步验证登录请求"
+// @Success 200 {object} utils.Response{data=models.TokenResponse}
+// @Failure 400 {object} utils.Response
+// @Router /auth/login/2fa [post]
+func (ac *AuthController) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	tokens, err := ac.userService.LoginWithTwoFactor(&req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			utils.AccountLockedWithRemaining(c, lockedErr.Error(), lockedErr.RemainingSeconds())
+			return
+		}
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "登录失败")
+		return
+	}
+
 	utils.SuccessWithMessage(c, "登录成功", gin.H{
 		"tokens": tokens,
 	})
 }
 
 // RefreshToken 刷新访问令牌
+// @Summary 刷新访问令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌，旧刷新令牌立即失效；已使用过的刷新令牌再次提交会撤销整个登录会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "刷新令牌"
+// @Success 200 {object} utils.Response{data=models.TokenResponse}
+// @Failure 401 {object} utils.Response
+// @Router /auth/refresh [post]
 func (ac *AuthController) RefreshToken(c *gin.Context) {
 	var req models.RefreshTokenRequest
 
@@ -148,20 +216,13 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	tokens, err := ac.userService.RefreshToken(&req)
+	tokens, err := ac.userService.RefreshToken(&req, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
-		switch err.Error() {
-		case "无效的刷新令牌":
-			utils.TokenInvalid(c)
-		case "用户不存在":
-			utils.UserNotFound(c)
-		case "账户已被删除，无法刷新令牌":
-			utils.ErrorWithMessage(c, utils.CodeUserNotFound, err.Error())
-		case "账户已被禁用，无法刷新令牌":
-			utils.AccountLocked(c)
-		default:
-			utils.ErrorWithMessage(c, utils.CodeOperationFailed, err.Error())
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
 		}
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "刷新令牌失败")
 		return
 	}
 
@@ -170,6 +231,185 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 	})
 }
 
+// ForgotPassword 发送找回密码验证码
+// @Summary 找回密码
+// @Description 向账号对应的邮箱或手机号发送重置密码验证码，账号未注册时同样返回成功
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "找回密码请求"
+// @Success 200 {object} utils.Response{data=models.ForgotPasswordResponse}
+// @Router /auth/forgot-password [post]
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := ac.passwordResetService.ForgotPassword(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "发送验证码失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "如果账号已注册，验证码将发送至对应的邮箱或手机", resp)
+}
+
+// ResetPassword 使用验证码重置密码
+// @Summary 重置密码
+// @Description 校验找回密码验证码并设置新密码，成功后该账号的全部登录会话失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "重置密码请求"
+// @Success 200 {object} utils.Response
+// @Router /auth/reset-password [post]
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	if err := ac.passwordResetService.ResetPassword(c.Request.Context(), &req); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "重置密码失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "密码重置成功，请重新登录", nil)
+}
+
+// SendContactCode 发送邮箱、手机号验证码
+// @Summary 发送账号验证码
+// @Description 向注册使用的邮箱或手机号发送验证码，账号未注册或已验证时同样返回成功
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.SendContactCodeRequest true "发送验证码请求"
+// @Success 200 {object} utils.Response{data=models.VerifyCodeSentResponse}
+// @Router /auth/verify-contact/send [post]
+func (ac *AuthController) SendContactCode(c *gin.Context) {
+	var req models.SendContactCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := ac.contactVerifyService.SendVerifyCode(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		utils.HandleAppError(c, err, "发送验证码失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "如果账号需要验证，验证码将发送至对应的邮箱或手机", resp)
+}
+
+// ConfirmContact 提交邮箱、手机号验证码
+// @Summary 验证账号
+// @Description 提交收到的验证码，将注册使用的邮箱或手机号标记为已验证
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.ConfirmContactRequest true "验证请求"
+// @Success 200 {object} utils.Response{data=models.UserResponse}
+// @Router /auth/verify-contact/confirm [post]
+func (ac *AuthController) ConfirmContact(c *gin.Context) {
+	var req models.ConfirmContactRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	user, err := ac.contactVerifyService.ConfirmVerifyCode(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "验证失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "验证成功", gin.H{
+		"user": user,
+	})
+}
+
+// SendChangeContactCode 更换邮箱、手机号时向新联系方式发送验证码
+// @Summary 更换邮箱或手机号（发送验证码）
+// @Description 校验当前密码（开启两步验证时还需动态验证码）后，向新邮箱或手机号发送验证码
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.ChangeContactSendRequest true "更换联系方式请求"
+// @Success 200 {object} utils.Response{data=models.VerifyCodeSentResponse}
+// @Router /auth/change-contact/send [post]
+func (ac *AuthController) SendChangeContactCode(c *gin.Context) {
+	var req models.ChangeContactSendRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := ac.contactVerifyService.SendChangeCode(c.Request.Context(), uid, &req, c.ClientIP())
+	if err != nil {
+		utils.HandleAppError(c, err, "发送验证码失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "验证码已发送", resp)
+}
+
+// ConfirmChangeContact 提交新联系方式收到的验证码完成更换
+// @Summary 更换邮箱或手机号（确认）
+// @Description 校验新邮箱或手机号收到的验证码，更换成功后新联系方式直接标记为已验证
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body models.ChangeContactConfirmRequest true "确认更换请求"
+// @Success 200 {object} utils.Response{data=models.UserResponse}
+// @Router /auth/change-contact/confirm [post]
+func (ac *AuthController) ConfirmChangeContact(c *gin.Context) {
+	var req models.ChangeContactConfirmRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	user, err := ac.contactVerifyService.ConfirmChange(c.Request.Context(), uid, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "更换失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "更换成功", gin.H{
+		"user": user,
+	})
+}
+
 // GetProfile 获取当前用户信息
 func (ac *AuthController) GetProfile(c *gin.Context) {
 	var req models.GetProfileRequest
@@ -238,9 +478,8 @@ func (ac *AuthController) Logout(c *gin.Context) {
 		utils.LogError(c, "将token加入黑名单失败: %v", err)
 	}
 
-	// 撤销用户会话
-	err = tokenService.RevokeUserSession(ctx, uid)
-	if err != nil {
+	// 撤销当前会话，使该次登录的刷新令牌失效，其他设备不受影响
+	if err := tokenService.RevokeRefreshFamily(ctx, middleware.GetCurrentSessionID(c)); err != nil {
 		// 记录错误但不影响登出流程
 		utils.LogError(c, "撤销用户会话失败: %v", err)
 	}
@@ -281,6 +520,11 @@ func (ac *AuthController) BindBankCard(c *gin.Context) {
 			"code":  utils.CodeOperationFailed,
 		})
 
+		if appErr, ok := err.(*utils.AppError); ok && (appErr.Code == utils.CodeTwoFactorRequired || appErr.Code == utils.CodeTwoFactorInvalid) {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+
 		switch err.Error() {
 		case "用户不存在":
 			utils.UserNotFound(c)
@@ -392,6 +636,11 @@ func (ac *AuthController) ChangePassword(c *gin.Context) {
 	// 调用服务层修改密码
 	err := ac.userService.ChangePassword(&req, uid)
 	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok && (appErr.Code == utils.CodeTwoFactorRequired || appErr.Code == utils.CodeTwoFactorInvalid) {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+
 		switch err.Error() {
 		case "用户不存在":
 			utils.UserNotFound(c)
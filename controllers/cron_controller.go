@@ -33,16 +33,13 @@ func (cc *CronController) ManualGenerateOrders(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID
-	userID := middleware.GetCurrentUser(c)
-	if userID == 0 {
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
 		utils.Unauthorized(c)
 		return
 	}
 
-	// 检查用户权限（这里可以添加管理员权限检查）
-	// TODO: 添加管理员权限验证
-
 	var req struct {
 		Count int `json:"count" binding:"required,min=1,max=1000"`
 	}
@@ -78,16 +75,13 @@ func (cc *CronController) ManualCleanup(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID
-	userID := middleware.GetCurrentUser(c)
-	if userID == 0 {
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
 		utils.Unauthorized(c)
 		return
 	}
 
-	// 检查用户权限（这里可以添加管理员权限检查）
-	// TODO: 添加管理员权限验证
-
 	// 手动清理数据
 	stats, err := cc.cronService.ManualCleanup()
 	if err != nil {
@@ -111,16 +105,13 @@ func (cc *CronController) GetCronStatus(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID
-	userID := middleware.GetCurrentUser(c)
-	if userID == 0 {
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
 		utils.Unauthorized(c)
 		return
 	}
 
-	// 检查用户权限（这里可以添加管理员权限检查）
-	// TODO: 添加管理员权限验证
-
 	// 获取定时任务状态
 	status := cc.cronService.GetCronStatus()
 
@@ -137,16 +128,13 @@ func (cc *CronController) ManualUpdateLeaderboardCache(c *gin.Context) {
 		return
 	}
 
-	// 获取当前用户ID
-	userID := middleware.GetCurrentUser(c)
-	if userID == 0 {
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
 		utils.Unauthorized(c)
 		return
 	}
 
-	// 检查用户权限（这里可以添加管理员权限检查）
-	// TODO: 添加管理员权限验证
-
 	// 手动更新热榜缓存
 	err := cc.cronService.ManualUpdateLeaderboardCache()
 	if err != nil {
@@ -157,4 +145,152 @@ func (cc *CronController) ManualUpdateLeaderboardCache(c *gin.Context) {
 	utils.SuccessWithMessage(c, "手动更新热榜缓存成功", gin.H{
 		"update_time": "now",
 	})
-} 
\ No newline at end of file
+} 
+// ManualProcessExpiredGroupBuys 手动处理过期拼单退款
+func (cc *CronController) ManualProcessExpiredGroupBuys(c *gin.Context) {
+	// 检查是否有定时任务服务
+	if cc.cronService == nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "定时任务服务未初始化")
+		return
+	}
+
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	// 手动处理过期拼单
+	stats, err := cc.cronService.ManualProcessExpiredGroupBuys()
+	if err != nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "处理过期拼单失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "处理过期拼单成功", gin.H{
+		"failed_group_buys":   stats.FailedGroupBuys,
+		"refunded_count":      stats.RefundedCount,
+		"refund_failed_count": stats.RefundFailedCount,
+		"refunded_amount":     stats.RefundedAmount,
+		"process_time":        stats.ProcessTime.String(),
+	})
+}
+
+// ManualProcessScoreEvents 手动扫描经验值与信用分事件
+func (cc *CronController) ManualProcessScoreEvents(c *gin.Context) {
+	// 检查是否有定时任务服务
+	if cc.cronService == nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "定时任务服务未初始化")
+		return
+	}
+
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	// 手动扫描经验值与信用分事件
+	stats, err := cc.cronService.ManualProcessScoreEvents()
+	if err != nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "扫描经验值与信用分事件失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "扫描经验值与信用分事件成功", gin.H{
+		"applied":      stats.Applied,
+		"failed_count": stats.FailedCount,
+		"process_time": stats.ProcessTime.String(),
+	})
+}
+
+// ManualProcessCommissions 手动发放推广佣金
+func (cc *CronController) ManualProcessCommissions(c *gin.Context) {
+	// 检查是否有定时任务服务
+	if cc.cronService == nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "定时任务服务未初始化")
+		return
+	}
+
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	// 手动发放推广佣金
+	stats, err := cc.cronService.ManualProcessCommissions()
+	if err != nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "发放推广佣金失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "发放推广佣金成功", gin.H{
+		"order_count":       stats.OrderCount,
+		"commission_count":  stats.CommissionCount,
+		"commission_amount": stats.CommissionAmount,
+		"failed_count":      stats.FailedCount,
+		"process_time":      stats.ProcessTime.String(),
+	})
+}
+
+// ManualProcessWalletStatusExpire 手动恢复到期的钱包冻结和提现限制
+func (cc *CronController) ManualProcessWalletStatusExpire(c *gin.Context) {
+	// 检查是否有定时任务服务
+	if cc.cronService == nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "定时任务服务未初始化")
+		return
+	}
+
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	// 手动恢复到期钱包状态
+	stats, err := cc.cronService.ManualProcessWalletStatusExpire()
+	if err != nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "恢复到期钱包状态失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "恢复到期钱包状态成功", gin.H{
+		"reverted_count": stats.RevertedCount,
+		"failed_count":   stats.FailedCount,
+		"process_time":   stats.ProcessTime.String(),
+	})
+}
+
+// ManualProcessMessageBroadcasts 手动发送到期的群发消息
+func (cc *CronController) ManualProcessMessageBroadcasts(c *gin.Context) {
+	// 检查是否有定时任务服务
+	if cc.cronService == nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "定时任务服务未初始化")
+		return
+	}
+
+	// 获取当前管理员ID，权限由路由上的RequirePermission校验
+	adminID := middleware.GetCurrentAdminID(c)
+	if adminID == 0 {
+		utils.Unauthorized(c)
+		return
+	}
+
+	// 手动发送到期的群发消息
+	stats, err := cc.cronService.ManualProcessMessageBroadcasts()
+	if err != nil {
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "发送群发消息失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "发送群发消息成功", gin.H{
+		"broadcast_count": stats.BroadcastCount,
+		"failed_count":    stats.FailedCount,
+		"process_time":    stats.ProcessTime.String(),
+	})
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	eventStreamRetry     = 3000             // 断线后客户端重连间隔（毫秒）
+	eventStreamHeartbeat = 25 * time.Second // 心跳间隔，避免代理断开空闲连接
+)
+
+// EventController 用户实时推送控制器
+type EventController struct {
+	eventService *services.UserEventService
+}
+
+// NewEventController 创建用户实时推送控制器实例
+func NewEventController() *EventController {
+	return &EventController{
+		eventService: services.NewUserEventService(),
+	}
+}
+
+// StreamToken 获取实时推送连接令牌
+// @Summary 获取实时推送连接令牌
+// @Description 浏览器EventSource无法设置Authorization请求头，先获取一次性连接令牌，再以stream_token参数建立连接；令牌1分钟内有效，每次连接（包括断线重连）需要重新获取
+// @Tags 消息推送
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=models.EventStreamTokenResponse}
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/events/stream-token [post]
+func (ec *EventController) StreamToken(c *gin.Context) {
+	accessToken := middleware.GetCurrentAccessToken(c)
+	if accessToken == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	response, err := ec.eventService.IssueStreamToken(c.Request.Context(), accessToken)
+	if err != nil {
+		utils.HandleAppError(c, err, "生成连接令牌失败")
+		return
+	}
+	utils.Success(c, response)
+}
+
+// Stream 实时事件推送
+// @Summary 实时事件推送
+// @Description 通过SSE推送新消息、余额变化、订单状态变化和拼单进度，断线重连时携带Last-Event-ID请求头（或last_event_id参数）补发断线期间的事件，收到resync事件时需要重新拉取完整数据
+// @Description 使用Authorization请求头或stream_token参数认证；每次心跳重新检查登录状态，登出、会话被撤销或账号被禁用后发送logout事件并断开连接
+// @Tags 消息推送
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param stream_token query string false "一次性连接令牌，无法设置Authorization请求头时使用"
+// @Param Last-Event-ID header string false "最后收到的事件ID"
+// @Param last_event_id query string false "最后收到的事件ID，无法设置请求头时使用"
+// @Success 200 {string} string "事件流"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/events/stream [get]
+func (ec *EventController) Stream(c *gin.Context) {
+	uid := c.GetString("uid")
+	accessToken := middleware.GetCurrentAccessToken(c)
+	if uid == "" || accessToken == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if _, _, ok := services.ParseUserEventID(lastID); lastID != "" && !ok {
+		utils.InvalidParamsWithMessage(c, "Last-Event-ID格式错误")
+		return
+	}
+
+	// 先注册连接再读取历史事件，期间发布的事件由连接缓冲，按事件ID去重
+	hub := services.GetUserEventHub()
+	client, err := hub.Subscribe(uid)
+	if err != nil {
+		utils.HandleAppError(c, err, "建立实时推送连接失败")
+		return
+	}
+	defer hub.Unsubscribe(client)
+
+	ctx := c.Request.Context()
+	events, resync, err := ec.eventService.Replay(ctx, uid, lastID)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取历史事件失败")
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭Nginx缓冲
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", eventStreamRetry); err != nil {
+		return
+	}
+	if resync {
+		if err := writeUserEvent(c, models.UserEvent{Type: models.UserEventResync, Data: []byte("{}")}); err != nil {
+			return
+		}
+	}
+	for _, event := range events {
+		if err := writeUserEvent(c, event); err != nil {
+			return
+		}
+		lastID = event.ID
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(eventStreamHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-client.Done():
+			return
+		case event := <-client.Events():
+			if lastID != "" && services.CompareUserEventID(event.ID, lastID) <= 0 {
+				continue
+			}
+			if err := writeUserEvent(c, event); err != nil {
+				return
+			}
+			lastID = event.ID
+			c.Writer.Flush()
+		case <-ticker.C:
+			if err := ec.eventService.CheckStreamSession(ctx, accessToken); err != nil {
+				writeUserEvent(c, models.UserEvent{Type: models.UserEventLogout, Data: []byte("{}")})
+				c.Writer.Flush()
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeUserEvent 按SSE格式写入一个事件，没有ID的事件不更新客户端的Last-Event-ID
+func writeUserEvent(c *gin.Context, event models.UserEvent) error {
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+	return err
+}
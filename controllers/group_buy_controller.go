@@ -101,7 +101,12 @@ func (c *GroupBuyController) JoinGroupBuy(ctx *gin.Context) {
 	}
 
 	// 调用服务层
-	response, err := c.groupBuyService.JoinGroupBuy(ctx, req.GroupBuyNo, user.Uid)
+	var response *models.JoinGroupBuyResponse
+	if req.InviteCode != "" {
+		response, err = c.groupBuyService.JoinGroupBuyByInvite(ctx, req.InviteCode, user.Uid)
+	} else {
+		response, err = c.groupBuyService.JoinGroupBuy(ctx, req.GroupBuyNo, user.Uid)
+	}
 	if err != nil {
 		// 记录操作失败
 		c.operationFailureService.RecordFailure(ctx.Request.Context(), &user.Uid, models.OperationTypeGroupBuyJoin, req, gin.H{
@@ -109,6 +114,11 @@ func (c *GroupBuyController) JoinGroupBuy(ctx *gin.Context) {
 			"code":  utils.CodeOperationFailed,
 		})
 
+		// 满员、已参与等业务错误返回对应错误码
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(ctx, appErr.Code, appErr.Message)
+			return
+		}
 		utils.ErrorWithMessage(ctx, utils.CodeDatabaseError, err.Error())
 		return
 	}
@@ -116,3 +126,159 @@ func (c *GroupBuyController) JoinGroupBuy(ctx *gin.Context) {
 	// 返回成功响应
 	utils.Success(ctx, response)
 }
+
+// GetGroupBuyTemplates 获取拼单模板列表
+// @Summary 获取拼单模板列表
+// @Description 获取用户发起拼单时可选择的模板
+// @Tags 拼单
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]models.GroupBuyTemplateResponse}
+// @Router /api/v2/groupBuy/templates [post]
+func (c *GroupBuyController) GetGroupBuyTemplates(ctx *gin.Context) {
+	utils.Success(ctx, c.groupBuyService.GetGroupBuyTemplates())
+}
+
+// CreateGroupBuy 发起拼单
+// @Summary 发起拼单
+// @Description 有拼单资格的用户选择模板和目标人数发起拼单，发起人立即付款并获得邀请码
+// @Tags 拼单
+// @Accept json
+// @Produce json
+// @Param request body models.CreateGroupBuyRequest true "发起拼单请求"
+// @Success 200 {object} utils.Response{data=models.CreateGroupBuyResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v2/groupBuy/create [post]
+func (c *GroupBuyController) CreateGroupBuy(ctx *gin.Context) {
+	var req models.CreateGroupBuyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(ctx)
+	if uid == "" {
+		utils.Unauthorized(ctx)
+		return
+	}
+
+	response, err := c.groupBuyService.CreateGroupBuy(ctx, uid, &req)
+	if err != nil {
+		// 记录操作失败
+		c.operationFailureService.RecordFailure(ctx.Request.Context(), &uid, models.OperationTypeGroupBuyCreate, req, gin.H{
+			"error": err.Error(),
+		})
+		c.handleGroupBuyError(ctx, err, "发起拼单失败")
+		return
+	}
+
+	utils.Success(ctx, response)
+}
+
+// GetGroupBuyProgress 获取拼单进度
+// @Summary 获取拼单进度
+// @Description 发起人和参与者查看拼单实时进度和参与者列表
+// @Tags 拼单
+// @Accept json
+// @Produce json
+// @Param request body models.GroupBuyNoRequest true "拼单编号"
+// @Success 200 {object} utils.Response{data=models.GroupBuyProgressResponse}
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /api/v2/groupBuy/progress [post]
+func (c *GroupBuyController) GetGroupBuyProgress(ctx *gin.Context) {
+	var req models.GroupBuyNoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(ctx)
+	if uid == "" {
+		utils.Unauthorized(ctx)
+		return
+	}
+
+	response, err := c.groupBuyService.GetGroupBuyProgress(ctx, req.GroupBuyNo, uid)
+	if err != nil {
+		c.handleGroupBuyError(ctx, err, "获取拼单进度失败")
+		return
+	}
+
+	utils.Success(ctx, response)
+}
+
+// GetGroupBuyHistory 获取拼单历史
+// @Summary 获取拼单历史
+// @Description 分页获取用户发起或参与过的拼单，包含付款金额、已到账利润和关联订单，支持按状态和类型筛选
+// @Tags 拼单
+// @Accept json
+// @Produce json
+// @Param request body models.GroupBuyHistoryRequest true "拼单历史请求"
+// @Success 200 {object} utils.Response{data=models.GroupBuyHistoryResponse}
+// @Failure 400 {object} utils.Response
+// @Router /api/v2/groupBuy/history [post]
+func (c *GroupBuyController) GetGroupBuyHistory(ctx *gin.Context) {
+	var req models.GroupBuyHistoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(ctx)
+	if uid == "" {
+		utils.Unauthorized(ctx)
+		return
+	}
+
+	response, err := c.groupBuyService.GetGroupBuyHistory(ctx, uid, &req)
+	if err != nil {
+		c.handleGroupBuyError(ctx, err, "获取拼单历史失败")
+		return
+	}
+
+	utils.Success(ctx, response)
+}
+
+// CancelGroupBuy 取消拼单
+// @Summary 取消拼单
+// @Description 发起人在其他用户付款前取消拼单，退还发起人付款
+// @Tags 拼单
+// @Accept json
+// @Produce json
+// @Param request body models.GroupBuyNoRequest true "拼单编号"
+// @Success 200 {object} utils.Response{data=models.CancelGroupBuyResponse}
+// @Failure 400 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Router /api/v2/groupBuy/cancel [post]
+func (c *GroupBuyController) CancelGroupBuy(ctx *gin.Context) {
+	var req models.GroupBuyNoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(ctx, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(ctx)
+	if uid == "" {
+		utils.Unauthorized(ctx)
+		return
+	}
+
+	response, err := c.groupBuyService.CancelGroupBuy(ctx, req.GroupBuyNo, uid)
+	if err != nil {
+		c.handleGroupBuyError(ctx, err, "取消拼单失败")
+		return
+	}
+
+	utils.Success(ctx, response)
+}
+
+// handleGroupBuyError 处理拼单服务返回的错误
+func (c *GroupBuyController) handleGroupBuyError(ctx *gin.Context, err error, defaultMessage string) {
+	if appErr, ok := err.(*utils.AppError); ok {
+		utils.ErrorWithMessage(ctx, appErr.Code, appErr.Message)
+		return
+	}
+	utils.ErrorWithMessage(ctx, utils.CodeOperationFailed, defaultMessage)
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSController 令牌公钥发布控制器
+type JWKSController struct{}
+
+// NewJWKSController 创建令牌公钥发布控制器实例
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+// GetJWKS 获取令牌验证公钥
+// @Summary 获取令牌验证公钥
+// @Description 按标准JWKS格式返回未退役的签名公钥，其他服务可据此按kid验证令牌；HS256模式下返回空集合
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} utils.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (jc *JWKSController) GetJWKS(c *gin.Context) {
+	// 直接返回标准格式，不包装统一响应结构，便于通用JWT库直接使用
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.GetJWKS())
+}
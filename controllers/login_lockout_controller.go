@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginLockoutController 登录锁定管理控制器
+type LoginLockoutController struct {
+	lockoutService *services.LoginLockoutService
+}
+
+// NewLoginLockoutController 创建登录锁定管理控制器实例
+func NewLoginLockoutController() *LoginLockoutController {
+	return &LoginLockoutController{
+		lockoutService: services.NewLoginLockoutService(),
+	}
+}
+
+// Unlock 解除登录锁定
+// @Summary 解除登录锁定
+// @Description 管理员解除账号或IP的登录锁定，同时清除失败次数和锁定等级
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.LoginUnlockRequest true "解除锁定请求"
+// @Success 200 {object} utils.Response{data=models.LoginUnlockResponse}
+// @Router /admin/login-lock/unlock [post]
+func (lc *LoginLockoutController) Unlock(c *gin.Context) {
+	var req models.LoginUnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := lc.lockoutService.Unlock(c.Request.Context(), &req, admin.Username)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "解除锁定失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "解除锁定成功", resp)
+}
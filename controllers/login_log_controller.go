@@ -41,6 +41,25 @@ func (c *LoginLogController) GetUserLoginHistory(ctx *gin.Context) {
 		size = 20
 	}
 
+	// 传入游标时按游标分页，不统计总数
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		logs, nextCursor, err := c.loginLogService.GetUserLoginHistoryByCursor(ctx, uid, cursor, size)
+		if err != nil {
+			if appErr, ok := err.(*utils.AppError); ok {
+				utils.ErrorWithMessage(ctx, appErr.Code, appErr.Message)
+				return
+			}
+			utils.ErrorWithMessage(ctx, utils.CodeOperationFailed, "获取登录历史失败")
+			return
+		}
+		utils.Success(ctx, gin.H{
+			"logs":        logs,
+			"size":        size,
+			"next_cursor": nextCursor,
+		})
+		return
+	}
+
 	logs, total, err := c.loginLogService.GetUserLoginHistory(ctx, uid, page, size)
 	if err != nil {
 		utils.ErrorWithMessage(ctx, utils.CodeOperationFailed, "获取登录历史失败")
@@ -48,10 +67,11 @@ func (c *LoginLogController) GetUserLoginHistory(ctx *gin.Context) {
 	}
 
 	utils.Success(ctx, gin.H{
-		"logs":  logs,
-		"total": total,
-		"page":  page,
-		"size":  size,
+		"logs":        logs,
+		"total":       total,
+		"page":        page,
+		"size":        size,
+		"next_cursor": services.LoginLogNextCursor(logs, int64(page*size) < total),
 	})
 }
 
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberLevelController 会员等级管理控制器
+type MemberLevelController struct {
+	memberLevelService *services.MemberLevelService
+}
+
+// NewMemberLevelController 创建会员等级管理控制器实例
+func NewMemberLevelController() *MemberLevelController {
+	return &MemberLevelController{
+		memberLevelService: services.NewMemberLevelService(),
+	}
+}
+
+// List 会员等级列表
+// @Summary 会员等级列表
+// @Description 获取全部会员等级配置，按等级从低到高排序
+// @Tags 会员等级
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]models.MemberLevelResponse}
+// @Router /admin/member-levels/list [post]
+func (mc *MemberLevelController) List(c *gin.Context) {
+	resp, err := mc.memberLevelService.AdminList(c.Request.Context())
+	if err != nil {
+		utils.HandleAppError(c, err, "获取会员等级失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Create 创建会员等级
+// @Summary 创建会员等级
+// @Description 同一要求类型下，等级越高升级要求必须越高
+// @Tags 会员等级
+// @Accept json
+// @Produce json
+// @Param request body models.MemberLevelRequest true "会员等级"
+// @Success 200 {object} utils.Response{data=models.MemberLevelResponse}
+// @Router /admin/member-levels/create [post]
+func (mc *MemberLevelController) Create(c *gin.Context) {
+	var req models.MemberLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := mc.memberLevelService.Create(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "创建会员等级失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "创建成功", resp)
+}
+
+// Update 修改会员等级
+// @Summary 修改会员等级
+// @Description 等级数值不可修改，只修改传入的字段
+// @Tags 会员等级
+// @Accept json
+// @Produce json
+// @Param request body models.MemberLevelUpdateRequest true "修改内容"
+// @Success 200 {object} utils.Response{data=models.MemberLevelResponse}
+// @Router /admin/member-levels/update [post]
+func (mc *MemberLevelController) Update(c *gin.Context) {
+	var req models.MemberLevelUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := mc.memberLevelService.Update(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "修改会员等级失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "修改成功", resp)
+}
+
+// Delete 删除会员等级
+func (mc *MemberLevelController) Delete(c *gin.Context) {
+	var req models.MemberLevelIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	if err := mc.memberLevelService.Delete(c.Request.Context(), req.ID); err != nil {
+		utils.HandleAppError(c, err, "删除会员等级失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// SetOverride 为用户固定会员等级
+// @Summary 固定用户等级
+// @Description 为指定用户固定会员等级，不再按升级要求计算，已有固定等级时覆盖
+// @Tags 会员等级
+// @Accept json
+// @Produce json
+// @Param request body models.UserLevelOverrideSetRequest true "固定等级"
+// @Success 200 {object} utils.Response{data=models.UserLevelOverride}
+// @Router /admin/member-levels/override/set [post]
+func (mc *MemberLevelController) SetOverride(c *gin.Context) {
+	var req models.UserLevelOverrideSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.memberLevelService.SetOverride(c.Request.Context(), &req, admin.Username)
+	if err != nil {
+		utils.HandleAppError(c, err, "固定用户等级失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "设置成功", resp)
+}
+
+// ClearOverride 清除用户固定的会员等级
+func (mc *MemberLevelController) ClearOverride(c *gin.Context) {
+	var req models.UserLevelOverrideClearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	if err := mc.memberLevelService.ClearOverride(c.Request.Context(), req.Uid); err != nil {
+		utils.HandleAppError(c, err, "清除用户等级失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "清除成功", nil)
+}
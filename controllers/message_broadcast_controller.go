@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MessageBroadcastController 管理后台消息发送控制器
+type MessageBroadcastController struct {
+	broadcastService *services.MessageBroadcastService
+}
+
+// NewMessageBroadcastController 创建消息发送控制器实例
+func NewMessageBroadcastController() *MessageBroadcastController {
+	return &MessageBroadcastController{
+		broadcastService: services.NewMessageBroadcastService(),
+	}
+}
+
+// Send 发送消息
+// @Summary 发送消息
+// @Description 向单个用户、用户列表、全部用户或用户分群发送站内消息，可定时发送，由后台任务分批投递
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.MessageSendRequest true "消息内容和发送目标"
+// @Success 200 {object} utils.Response{data=models.MessageBroadcast}
+// @Router /admin/messages/send [post]
+func (mc *MessageBroadcastController) Send(c *gin.Context) {
+	var req models.MessageSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	broadcast, err := mc.broadcastService.Send(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "发送消息失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "消息已提交发送", broadcast)
+}
+
+// List 群发消息列表
+// @Summary 群发消息列表
+// @Description 分页获取群发消息及发送进度，可按状态筛选
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.MessageBroadcastListRequest true "查询条件"
+// @Success 200 {object} utils.Response{data=models.MessageBroadcastListResponse}
+// @Router /admin/messages/list [post]
+func (mc *MessageBroadcastController) List(c *gin.Context) {
+	var req models.MessageBroadcastListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := mc.broadcastService.List(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取群发消息失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Detail 群发消息详情
+// @Summary 群发消息详情
+// @Description 获取群发消息及发送进度
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.MessageBroadcastIDRequest true "群发消息ID"
+// @Success 200 {object} utils.Response{data=models.MessageBroadcast}
+// @Router /admin/messages/detail [post]
+func (mc *MessageBroadcastController) Detail(c *gin.Context) {
+	var req models.MessageBroadcastIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	broadcast, err := mc.broadcastService.Detail(c.Request.Context(), req.ID)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取群发消息失败")
+		return
+	}
+
+	utils.Success(c, broadcast)
+}
+
+// Cancel 取消群发消息
+// @Summary 取消群发消息
+// @Description 取消等待发送或发送中的群发消息，已投递的用户仍会收到消息
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.MessageBroadcastIDRequest true "群发消息ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/messages/cancel [post]
+func (mc *MessageBroadcastController) Cancel(c *gin.Context) {
+	var req models.MessageBroadcastIDRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	if err := mc.broadcastService.Cancel(c.Request.Context(), req.ID); err != nil {
+		utils.HandleAppError(c, err, "取消群发消息失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "取消成功", nil)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"gin-fataMorgana/models"
 	"gin-fataMorgana/services"
 	"gin-fataMorgana/utils"
 
@@ -21,7 +22,7 @@ func NewMessageController() *MessageController {
 
 // GetUserMessage 获取用户消息推送
 // @Summary 获取用户消息推送
-// @Description 从Redis消息队列中获取一条待推送的消息，并更新消息状态为已读
+// @Description 获取最早的一条未读消息并标记为已读，消息仍保留在收件箱中
 // @Tags 消息推送
 // @Accept json
 // @Produce json
@@ -60,4 +61,193 @@ func (mc *MessageController) GetUserMessage(c *gin.Context) {
 
 	// 返回消息
 	utils.Success(c, message)
-}
\ No newline at end of file
+}
+
+// ListUserMessages 获取用户消息列表
+// @Summary 获取用户消息列表
+// @Description 按创建时间倒序游标分页获取用户收件箱，包含单发消息和群发消息，可只看未读，返回next_cursor用于获取下一页
+// @Tags 消息推送
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MessageListRequest true "分页参数"
+// @Success 200 {object} utils.Response{data=models.MessageListResponse} "成功返回消息列表"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Failure 500 {object} utils.Response "服务器错误"
+// @Router /api/v2/message/list [post]
+func (mc *MessageController) ListUserMessages(c *gin.Context) {
+	var req models.MessageListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := c.GetString("uid")
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.messageService.ListUserMessages(c.Request.Context(), uid, &req)
+	if err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+		utils.ErrorWithMessage(c, utils.CodeServer, "获取消息列表失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// GetUnreadCount 获取未读消息数
+// @Summary 获取未读消息数
+// @Description 统计用户收件箱中的未读消息数
+// @Tags 消息推送
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=models.MessageUnreadResponse} "成功返回未读消息数"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/message/unread-count [post]
+func (mc *MessageController) GetUnreadCount(c *gin.Context) {
+	uid := c.GetString("uid")
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.messageService.CountUnread(c.Request.Context(), uid)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取未读消息数失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// MarkRead 标记消息已读
+// @Summary 标记消息已读
+// @Description 批量将收件箱消息标记为已读，消息由来源和ID定位
+// @Tags 消息推送
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MessageBatchRequest true "消息列表"
+// @Success 200 {object} utils.Response{data=models.MessageBatchResponse} "成功返回更新数"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/message/read [post]
+func (mc *MessageController) MarkRead(c *gin.Context) {
+	var req models.MessageBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := c.GetString("uid")
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.messageService.MarkRead(c.Request.Context(), uid, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "标记已读失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// MarkUnread 标记消息未读
+// @Summary 标记消息未读
+// @Description 批量将收件箱消息标记为未读，消息由来源和ID定位
+// @Tags 消息推送
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MessageBatchRequest true "消息列表"
+// @Success 200 {object} utils.Response{data=models.MessageBatchResponse} "成功返回更新数"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/message/unread [post]
+func (mc *MessageController) MarkUnread(c *gin.Context) {
+	var req models.MessageBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := c.GetString("uid")
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.messageService.MarkUnread(c.Request.Context(), uid, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "标记未读失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// MarkAllRead 全部标记已读
+// @Summary 全部标记已读
+// @Description 将收件箱中的全部未读消息标记为已读
+// @Tags 消息推送
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=models.MessageBatchResponse} "成功返回更新数"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/message/read-all [post]
+func (mc *MessageController) MarkAllRead(c *gin.Context) {
+	uid := c.GetString("uid")
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.messageService.MarkAllRead(c.Request.Context(), uid)
+	if err != nil {
+		utils.HandleAppError(c, err, "全部标记已读失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// DeleteMessages 删除消息
+// @Summary 删除消息
+// @Description 批量删除收件箱消息，删除后不再出现在列表和未读数中
+// @Tags 消息推送
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MessageBatchRequest true "消息列表"
+// @Success 200 {object} utils.Response{data=models.MessageBatchResponse} "成功返回删除数"
+// @Failure 401 {object} utils.Response "认证失败"
+// @Router /api/v2/message/delete [post]
+func (mc *MessageController) DeleteMessages(c *gin.Context) {
+	var req models.MessageBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := c.GetString("uid")
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := mc.messageService.DeleteMessages(c.Request.Context(), uid, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "删除消息失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
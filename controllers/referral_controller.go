@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralController 用户推荐控制器，包含用户端的邀请码、下级和佣金接口以及管理后台的佣金规则接口
+type ReferralController struct {
+	referralService *services.ReferralService
+}
+
+// NewReferralController 创建用户推荐控制器实例
+func NewReferralController() *ReferralController {
+	return &ReferralController{
+		referralService: services.NewReferralService(),
+	}
+}
+
+// GetMyInviteCode 获取我的邀请码
+// @Summary 我的邀请码
+// @Description 获取当前用户的邀请码和下级人数，其他用户注册时填写该邀请码即成为下级
+// @Tags 用户推荐
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.MyReferralCodeResponse}
+// @Router /api/v2/referral/my-code [post]
+func (rc *ReferralController) GetMyInviteCode(c *gin.Context) {
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.referralService.GetMyInviteCode(c.Request.Context(), uid)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取邀请码失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// ListMyReferrals 获取我的下级
+// @Summary 我的下级
+// @Description 分页获取当前用户各层级的下级，可按层级筛选
+// @Tags 用户推荐
+// @Accept json
+// @Produce json
+// @Param request body models.MyReferralListRequest true "查询条件"
+// @Success 200 {object} utils.Response{data=models.MyReferralListResponse}
+// @Router /api/v2/referral/list [post]
+func (rc *ReferralController) ListMyReferrals(c *gin.Context) {
+	var req models.MyReferralListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.referralService.ListMyReferrals(c.Request.Context(), uid, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取下级列表失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// ListMyCommissions 获取我的佣金记录
+// @Summary 我的佣金
+// @Description 分页获取下级完成订单给当前用户带来的佣金，并返回已入账佣金总额
+// @Tags 用户推荐
+// @Accept json
+// @Produce json
+// @Param request body models.MyCommissionListRequest true "分页参数"
+// @Success 200 {object} utils.Response{data=models.MyCommissionListResponse}
+// @Router /api/v2/referral/commissions [post]
+func (rc *ReferralController) ListMyCommissions(c *gin.Context) {
+	var req models.MyCommissionListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.referralService.ListMyCommissions(c.Request.Context(), uid, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "获取佣金记录失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// ListCommissionRules 佣金规则列表
+// @Summary 佣金规则列表
+// @Description 获取各推荐层级的佣金比例
+// @Tags 用户推荐
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]models.CommissionRule}
+// @Router /admin/referral/rules/list [post]
+func (rc *ReferralController) ListCommissionRules(c *gin.Context) {
+	rules, err := rc.referralService.ListCommissionRules(c.Request.Context())
+	if err != nil {
+		utils.HandleAppError(c, err, "获取佣金规则失败")
+		return
+	}
+
+	utils.Success(c, rules)
+}
+
+// SaveCommissionRule 保存佣金规则
+// @Summary 保存佣金规则
+// @Description 保存某一推荐层级的佣金比例，已有规则时覆盖，只影响之后发放的佣金
+// @Tags 用户推荐
+// @Accept json
+// @Produce json
+// @Param request body models.CommissionRuleSaveRequest true "佣金规则"
+// @Success 200 {object} utils.Response{data=models.CommissionRule}
+// @Router /admin/referral/rules/save [post]
+func (rc *ReferralController) SaveCommissionRule(c *gin.Context) {
+	var req models.CommissionRuleSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	rule, err := rc.referralService.SaveCommissionRule(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "保存佣金规则失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "保存成功", rule)
+}
+
+// DeleteCommissionRule 删除佣金规则
+func (rc *ReferralController) DeleteCommissionRule(c *gin.Context) {
+	var req models.CommissionRuleDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	if err := rc.referralService.DeleteCommissionRule(c.Request.Context(), req.Depth); err != nil {
+		utils.HandleAppError(c, err, "删除佣金规则失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "删除成功", nil)
+}
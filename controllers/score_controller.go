@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScoreController 经验值与信用分管理控制器
+type ScoreController struct {
+	scoreService *services.ScoreService
+}
+
+// NewScoreController 创建经验值与信用分管理控制器实例
+func NewScoreController() *ScoreController {
+	return &ScoreController{
+		scoreService: services.NewScoreService(),
+	}
+}
+
+// ListRules 经验值与信用分规则列表
+// @Summary 经验值与信用分规则列表
+// @Description 获取每种事件对应的经验值和信用分变化
+// @Tags 经验值与信用分
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]models.ScoreRule}
+// @Router /admin/score/rules/list [post]
+func (sc *ScoreController) ListRules(c *gin.Context) {
+	rules, err := sc.scoreService.ListRules(c.Request.Context())
+	if err != nil {
+		utils.HandleAppError(c, err, "获取规则失败")
+		return
+	}
+
+	utils.Success(c, rules)
+}
+
+// UpdateRule 修改经验值与信用分规则
+// @Summary 修改经验值与信用分规则
+// @Description 只修改传入的字段，修改后新发生的事件按新规则计算，已计算的记录不受影响
+// @Tags 经验值与信用分
+// @Accept json
+// @Produce json
+// @Param request body models.ScoreRuleUpdateRequest true "规则"
+// @Success 200 {object} utils.Response{data=models.ScoreRule}
+// @Router /admin/score/rules/update [post]
+func (sc *ScoreController) UpdateRule(c *gin.Context) {
+	var req models.ScoreRuleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	rule, err := sc.scoreService.UpdateRule(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "修改规则失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "修改成功", rule)
+}
+
+// Adjust 手动调整用户经验值和信用分
+// @Summary 手动调整经验值和信用分
+// @Description 按传入的变化量调整，信用分受上下限限制，返回实际变化
+// @Tags 经验值与信用分
+// @Accept json
+// @Produce json
+// @Param request body models.ScoreAdjustRequest true "调整内容"
+// @Success 200 {object} utils.Response{data=models.UserScoreHistory}
+// @Router /admin/score/adjust [post]
+func (sc *ScoreController) Adjust(c *gin.Context) {
+	var req models.ScoreAdjustRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	history, err := sc.scoreService.Adjust(c.Request.Context(), &req, admin.Username)
+	if err != nil {
+		utils.HandleAppError(c, err, "调整失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "调整成功", history)
+}
+
+// History 经验值与信用分变更记录
+// @Summary 经验值与信用分变更记录
+// @Description 按用户、事件类型和时间范围分页查询，按时间倒序
+// @Tags 经验值与信用分
+// @Accept json
+// @Produce json
+// @Param request body models.ScoreHistoryQueryRequest true "查询条件"
+// @Success 200 {object} utils.Response{data=models.ScoreHistoryQueryResponse}
+// @Router /admin/score/history [post]
+func (sc *ScoreController) History(c *gin.Context) {
+	var req models.ScoreHistoryQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	resp, err := sc.scoreService.QueryHistory(c.Request.Context(), &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "查询变更记录失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
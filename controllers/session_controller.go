@@ -5,17 +5,22 @@ import (
 
 	"gin-fataMorgana/middleware"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
 	"gin-fataMorgana/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SessionController 会话控制器
-type SessionController struct{}
+type SessionController struct {
+	tokenService *services.TokenService
+}
 
 // NewSessionController 创建会话控制器实例
 func NewSessionController() *SessionController {
-	return &SessionController{}
+	return &SessionController{
+		tokenService: services.NewTokenService(),
+	}
 }
 
 // CheckLoginStatus 检查登录状态
@@ -82,3 +87,73 @@ func (sc *SessionController) RefreshSession(c *gin.Context) {
 		"username":     middleware.GetCurrentUsername(c),
 	})
 }
+
+// ListSessions 获取当前用户的登录会话列表
+// @Summary 获取登录会话列表
+// @Description 列出当前用户所有已登录的设备，包含设备信息、IP和最后活跃时间
+// @Tags 会话
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]services.SessionInfo}
+// @Failure 401 {object} utils.Response
+// @Router /session/list [post]
+func (sc *SessionController) ListSessions(c *gin.Context) {
+	var req models.ListSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	sessions, err := sc.tokenService.ListSessions(c.Request.Context(), uid, middleware.GetCurrentSessionID(c))
+	if err != nil {
+		utils.ErrorWithMessage(c, utils.CodeRedisError, "获取会话列表失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession 登出指定会话
+// @Summary 登出指定会话
+// @Description 登出当前用户的某个设备，该设备的访问令牌和刷新令牌立即失效
+// @Tags 会话
+// @Accept json
+// @Produce json
+// @Param request body models.RevokeSessionRequest true "会话ID"
+// @Success 200 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Router /session/revoke [post]
+func (sc *SessionController) RevokeSession(c *gin.Context) {
+	var req models.RevokeSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := sc.tokenService.RevokeSession(c.Request.Context(), uid, req.SessionID); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok {
+			utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+			return
+		}
+		utils.ErrorWithMessage(c, utils.CodeOperationFailed, "登出会话失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "会话已登出", gin.H{
+		"session_id": req.SessionID,
+	})
+}
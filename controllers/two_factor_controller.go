@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorController 两步验证控制器
+type TwoFactorController struct {
+	twoFactorService *services.TwoFactorService
+}
+
+// NewTwoFactorController 创建两步验证控制器实例
+func NewTwoFactorController() *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorService: services.NewTwoFactorService(),
+	}
+}
+
+// GetStatus 获取两步验证状态
+// @Summary 获取两步验证状态
+// @Description 查询当前用户是否开启两步验证及剩余恢复码数量
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.TwoFactorStatusResponse}
+// @Router /2fa/status [post]
+func (tc *TwoFactorController) GetStatus(c *gin.Context) {
+	var req models.TwoFactorStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	response, err := tc.twoFactorService.GetStatus(c.Request.Context(), uid)
+	if err != nil {
+		tc.handleError(c, err, "获取两步验证状态失败")
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// Setup 开始绑定两步验证
+// @Summary 开始绑定两步验证
+// @Description 生成TOTP密钥和otpauth链接，客户端据此生成二维码，验证通过后才正式开启
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response{data=models.TwoFactorSetupResponse}
+// @Router /2fa/setup [post]
+func (tc *TwoFactorController) Setup(c *gin.Context) {
+	var req models.TwoFactorSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	response, err := tc.twoFactorService.Setup(c.Request.Context(), uid)
+	if err != nil {
+		tc.handleError(c, err, "生成两步验证密钥失败")
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// Enable 开启两步验证
+// @Summary 开启两步验证
+// @Description 提交验证器App中的动态验证码完成绑定，返回只展示一次的恢复码
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "动态验证码"
+// @Success 200 {object} utils.Response{data=models.TwoFactorRecoveryCodesResponse}
+// @Router /2fa/enable [post]
+func (tc *TwoFactorController) Enable(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	response, err := tc.twoFactorService.Enable(c.Request.Context(), uid, req.Code)
+	if err != nil {
+		tc.handleError(c, err, "开启两步验证失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "两步验证已开启，请妥善保存恢复码", response)
+}
+
+// Disable 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 提交动态验证码或恢复码关闭两步验证
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "动态验证码或恢复码"
+// @Success 200 {object} utils.Response
+// @Router /2fa/disable [post]
+func (tc *TwoFactorController) Disable(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	if err := tc.twoFactorService.Disable(c.Request.Context(), uid, req.Code); err != nil {
+		tc.handleError(c, err, "关闭两步验证失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 提交动态验证码重新生成恢复码，旧恢复码全部失效
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "动态验证码"
+// @Success 200 {object} utils.Response{data=models.TwoFactorRecoveryCodesResponse}
+// @Router /2fa/recovery-codes [post]
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	uid := middleware.GetCurrentUID(c)
+	if uid == "" {
+		utils.Unauthorized(c)
+		return
+	}
+
+	response, err := tc.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), uid, req.Code)
+	if err != nil {
+		tc.handleError(c, err, "生成恢复码失败")
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// handleError 返回业务错误码，非业务错误使用默认提示
+func (tc *TwoFactorController) handleError(c *gin.Context, err error, defaultMessage string) {
+	if appErr, ok := err.(*utils.AppError); ok {
+		utils.ErrorWithMessage(c, appErr.Code, appErr.Message)
+		return
+	}
+	utils.ErrorWithMessage(c, utils.CodeOperationFailed, defaultMessage)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserReviewController 待审核用户审核控制器
+type UserReviewController struct {
+	reviewService *services.UserReviewService
+}
+
+// NewUserReviewController 创建待审核用户审核控制器实例
+func NewUserReviewController() *UserReviewController {
+	return &UserReviewController{
+		reviewService: services.NewUserReviewService(),
+	}
+}
+
+// ListPending 待审核用户列表
+// @Summary 待审核用户列表
+// @Description 按邀请码和注册日期筛选待审核用户，非超级管理员只能看到自己及下级邀请码注册的用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.PendingUserListRequest true "查询条件"
+// @Success 200 {object} utils.Response{data=models.PendingUserListResponse}
+// @Router /admin/users/pending [post]
+func (rc *UserReviewController) ListPending(c *gin.Context) {
+	var req models.PendingUserListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.reviewService.ListPending(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "查询待审核用户失败")
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Approve 审核通过
+// @Summary 审核通过
+// @Description 批量审核通过待审核用户，并通知用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.UserApproveRequest true "审核请求"
+// @Success 200 {object} utils.Response{data=models.UserReviewResponse}
+// @Router /admin/users/approve [post]
+func (rc *UserReviewController) Approve(c *gin.Context) {
+	var req models.UserApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.reviewService.Approve(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "审核失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "审核完成", resp)
+}
+
+// Reject 审核拒绝
+// @Summary 审核拒绝
+// @Description 批量拒绝待审核用户，拒绝原因会通知给用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.UserRejectRequest true "审核请求"
+// @Success 200 {object} utils.Response{data=models.UserReviewResponse}
+// @Router /admin/users/reject [post]
+func (rc *UserReviewController) Reject(c *gin.Context) {
+	var req models.UserRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := rc.reviewService.Reject(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "审核失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "审核完成", resp)
+}
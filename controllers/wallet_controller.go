@@ -47,6 +47,7 @@ func (wc *WalletController) GetUserTransactions(c *gin.Context) {
 		Page:     req.Page,
 		PageSize: req.PageSize,
 		Type:     req.Type,
+		Cursor:   req.Cursor,
 	}
 
 	// 调用服务
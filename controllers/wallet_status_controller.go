@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WalletStatusController 管理后台钱包状态控制器
+type WalletStatusController struct {
+	statusService *services.WalletStatusService
+}
+
+// NewWalletStatusController 创建钱包状态控制器实例
+func NewWalletStatusController() *WalletStatusController {
+	return &WalletStatusController{
+		statusService: services.NewWalletStatusService(),
+	}
+}
+
+// Freeze 冻结钱包
+// @Summary 冻结钱包
+// @Description 冻结用户钱包，冻结期间无法下单、充值和提现，可设置到期时间自动解除，通过站内消息告知用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.WalletRestrictRequest true "冻结信息"
+// @Success 200 {object} utils.Response{data=models.WalletStatusResponse}
+// @Router /admin/wallets/freeze [post]
+func (wc *WalletStatusController) Freeze(c *gin.Context) {
+	var req models.WalletRestrictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := wc.statusService.Freeze(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "冻结钱包失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "冻结成功", resp)
+}
+
+// BlockWithdraw 限制钱包提现
+// @Summary 限制钱包提现
+// @Description 限制用户钱包提现，其他操作不受影响，可设置到期时间自动解除，通过站内消息告知用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.WalletRestrictRequest true "限制信息"
+// @Success 200 {object} utils.Response{data=models.WalletStatusResponse}
+// @Router /admin/wallets/block-withdraw [post]
+func (wc *WalletStatusController) BlockWithdraw(c *gin.Context) {
+	var req models.WalletRestrictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := wc.statusService.BlockWithdraw(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "限制提现失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "限制提现成功", resp)
+}
+
+// Unfreeze 恢复钱包正常状态
+// @Summary 恢复钱包
+// @Description 解除钱包冻结或提现限制，通过站内消息告知用户
+// @Tags 管理员
+// @Accept json
+// @Produce json
+// @Param request body models.WalletUnfreezeRequest true "恢复信息"
+// @Success 200 {object} utils.Response{data=models.WalletStatusResponse}
+// @Router /admin/wallets/unfreeze [post]
+func (wc *WalletStatusController) Unfreeze(c *gin.Context) {
+	var req models.WalletUnfreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err)
+		return
+	}
+
+	admin := middleware.GetCurrentAdmin(c)
+	if admin == nil {
+		utils.Unauthorized(c)
+		return
+	}
+
+	resp, err := wc.statusService.Unfreeze(c.Request.Context(), admin, &req)
+	if err != nil {
+		utils.HandleAppError(c, err, "恢复钱包失败")
+		return
+	}
+
+	utils.SuccessWithMessage(c, "恢复成功", resp)
+}
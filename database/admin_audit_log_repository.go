@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gin-fataMorgana/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminAuditLogRepository 管理员审计日志仓库，只提供追加和查询，不提供修改和删除
+type AdminAuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminAuditLogRepository 创建管理员审计日志仓库实例
+func NewAdminAuditLogRepository() *AdminAuditLogRepository {
+	return &AdminAuditLogRepository{
+		db: DB,
+	}
+}
+
+// AdminAuditLogFilter 审计日志查询条件
+type AdminAuditLogFilter struct {
+	AdminID    uint
+	Action     string
+	TargetType string
+	TargetID   string
+	Start      *time.Time
+	End        *time.Time
+}
+
+// Append 追加审计日志
+// 在事务中锁定最后一条记录，保证并发写入时哈希链不分叉；fill根据上一条记录的哈希计算本条哈希
+func (r *AdminAuditLogRepository) Append(ctx context.Context, entry *models.AdminAuditLog, fill func(prevHash string)) error {
+	return r.AppendWith(ctx, entry, fill, nil)
+}
+
+// AppendWith 在同一事务中执行业务写入并追加审计日志，apply返回错误时整体回滚
+func (r *AdminAuditLogRepository) AppendWith(ctx context.Context, entry *models.AdminAuditLog, fill func(prevHash string), apply func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if apply != nil {
+			if err := apply(tx); err != nil {
+				return err
+			}
+		}
+
+		var last []models.AdminAuditLog
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Order("id DESC").
+			Limit(1).
+			Find(&last).Error
+		if err != nil {
+			return err
+		}
+
+		prevHash := ""
+		if len(last) > 0 {
+			prevHash = last[0].Hash
+		}
+		fill(prevHash)
+
+		return tx.Create(entry).Error
+	})
+}
+
+// Query 按条件分页查询审计日志，按时间倒序
+func (r *AdminAuditLogRepository) Query(ctx context.Context, filter *AdminAuditLogFilter, limit, offset int) ([]models.AdminAuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AdminAuditLog{})
+	if filter.AdminID > 0 {
+		query = query.Where("admin_id = ?", filter.AdminID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Start != nil {
+		query = query.Where("created_at >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("created_at <= ?", *filter.End)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AdminAuditLog
+	err := query.Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	return logs, total, err
+}
+
+// ListAfter 按ID顺序获取指定ID之后的审计日志，用于校验哈希链
+func (r *AdminAuditLogRepository) ListAfter(ctx context.Context, afterID uint64, limit int) ([]models.AdminAuditLog, error) {
+	var logs []models.AdminAuditLog
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
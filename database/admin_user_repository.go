@@ -7,7 +7,7 @@ import (
 	"gorm.io/gorm"
 )
 
-// AdminUserRepository 管理员用户仓库（仅用于邀请码校验）
+// AdminUserRepository 管理员用户仓库
 type AdminUserRepository struct {
 	db *gorm.DB
 }
@@ -314,3 +314,105 @@ func (r *AdminUserRepository) FindByPhone(ctx context.Context, phone string) (*m
 	}
 	return &adminUser, nil
 }
+
+// GetDescendantIDs 获取管理员的全部下级ID（按ParentID逐层向下查找，不含自身）
+func (r *AdminUserRepository) GetDescendantIDs(ctx context.Context, rootID uint) ([]uint, error) {
+	var result []uint
+	visited := map[uint]bool{rootID: true}
+	current := []uint{rootID}
+
+	for len(current) > 0 {
+		var children []uint
+		err := r.db.WithContext(ctx).Model(&models.AdminUser{}).
+			Where("parent_id IN ?", current).
+			Pluck("id", &children).Error
+		if err != nil {
+			return nil, err
+		}
+
+		current = current[:0]
+		for _, id := range children {
+			// 防止脏数据形成环导致死循环
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			result = append(result, id)
+			current = append(current, id)
+		}
+	}
+
+	return result, nil
+}
+
+// ListWithFilter 按条件分页查询角色低于minRole的管理员，ids为nil表示不限制范围
+func (r *AdminUserRepository) ListWithFilter(ctx context.Context, ids []uint, minRole, role int64, status *int64, username string, limit, offset int) ([]models.AdminUser, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AdminUser{}).Where("role > ?", minRole)
+	if ids != nil {
+		if len(ids) == 0 {
+			return []models.AdminUser{}, 0, nil
+		}
+		query = query.Where("id IN ?", ids)
+	}
+	if role > 0 {
+		query = query.Where("role = ?", role)
+	}
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	if username != "" {
+		query = query.Where("username LIKE ?", "%"+username+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var adminUsers []models.AdminUser
+	err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&adminUsers).Error
+	return adminUsers, total, err
+}
+
+// HasChildWithRoleAtMost 检查直属下级中是否有角色不低于指定角色的管理员
+func (r *AdminUserRepository) HasChildWithRoleAtMost(ctx context.Context, parentID uint, role int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AdminUser{}).
+		Where("parent_id = ? AND role <= ?", parentID, role).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// NextAdminID 获取下一个管理员唯一ID，包含已软删除的记录避免唯一索引冲突
+func (r *AdminUserRepository) NextAdminID(ctx context.Context) (uint, error) {
+	var maxID uint
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.AdminUser{}).
+		Select("COALESCE(MAX(admin_id), 0)").
+		Scan(&maxID).Error
+	if err != nil {
+		return 0, err
+	}
+	return maxID + 1, nil
+}
+
+// UpdateFields 更新管理员指定字段
+func (r *AdminUserRepository) UpdateFields(ctx context.Context, id uint, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.AdminUser{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// ListByIDs 获取指定管理员，ids为nil表示获取全部管理员
+func (r *AdminUserRepository) ListByIDs(ctx context.Context, ids []uint) ([]models.AdminUser, error) {
+	adminUsers := []models.AdminUser{}
+	query := r.db.WithContext(ctx).Model(&models.AdminUser{})
+	if ids != nil {
+		if len(ids) == 0 {
+			return adminUsers, nil
+		}
+		query = query.Where("id IN ?", ids)
+	}
+	err := query.Order("id ASC").Find(&adminUsers).Error
+	return adminUsers, err
+}
@@ -75,3 +75,73 @@ func (r *AmountConfigRepository) GetAmountConfigsByTypeAndAmount(ctx context.Con
 
 	return &config, nil
 }
+
+// ListAmountConfigs 管理后台获取金额配置列表，isActive为nil时包含未激活的配置
+func (r *AmountConfigRepository) ListAmountConfigs(ctx context.Context, configType string, isActive *bool) ([]models.AmountConfig, error) {
+	var configs []models.AmountConfig
+
+	query := r.db.WithContext(ctx).Where("type = ?", configType)
+	if isActive != nil {
+		query = query.Where("is_active = ?", *isActive)
+	}
+	err := query.Order("sort_order ASC, amount ASC").Find(&configs).Error
+
+	return configs, err
+}
+
+// FindAmountConfigByID 根据ID获取金额配置，包含未激活的配置
+func (r *AmountConfigRepository) FindAmountConfigByID(ctx context.Context, id int64) (*models.AmountConfig, error) {
+	var config models.AmountConfig
+
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&config).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// ExistsAmount 检查同一类型下是否已存在相同金额，excludeID用于修改时排除自身
+func (r *AmountConfigRepository) ExistsAmount(ctx context.Context, configType string, amount float64, excludeID int64) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&models.AmountConfig{}).
+		Where("type = ? AND amount = ? AND id <> ?", configType, amount, excludeID).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// UpdateActive 修改金额配置激活状态
+func (r *AmountConfigRepository) UpdateActive(ctx context.Context, id int64, isActive bool) error {
+	return r.db.WithContext(ctx).
+		Model(&models.AmountConfig{}).
+		Where("id = ?", id).
+		Update("is_active", isActive).Error
+}
+
+// UpdateSortOrders 按ID顺序重新设置排序，ID必须都属于指定类型
+func (r *AmountConfigRepository) UpdateSortOrders(ctx context.Context, configType string, ids []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			result := tx.Model(&models.AmountConfig{}).
+				Where("id = ? AND type = ?", id, configType).
+				Update("sort_order", i+1)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				// 排序未变化时MySQL也返回0，需要确认记录是否存在
+				var count int64
+				if err := tx.Model(&models.AmountConfig{}).Where("id = ? AND type = ?", id, configType).Count(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					return gorm.ErrRecordNotFound
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -2,12 +2,16 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GroupBuyRepository 拼单仓库
@@ -23,13 +27,13 @@ func NewGroupBuyRepository() *GroupBuyRepository {
 }
 
 // GetActiveGroupBuyDetail 获取活跃拼单详情
-// 查询条件：截止时间比当前大，状态为进行中
+// 查询条件：截止时间比当前大，状态为进行中，不包含仅限邀请参与的拼单
 // 可以按时间最近或随机返回一条数据
 func (r *GroupBuyRepository) GetActiveGroupBuyDetail(ctx context.Context, random bool) (*models.GroupBuy, error) {
 	var groupBuy models.GroupBuy
 
-	query := r.db.WithContext(ctx).Where("deadline > ? AND status = ?",
-		time.Now(), "pending")
+	query := r.db.WithContext(ctx).Where("deadline > ? AND status = ? AND invite_only = ?",
+		time.Now(), "pending", false)
 
 	if random {
 		// 随机返回一条数据
@@ -138,3 +142,343 @@ func (r *GroupBuyRepository) GetActiveGroupBuys(ctx context.Context, uid string,
 
 	return groupBuys, total, nil
 }
+
+// HasParticipant 检查用户是否已参与拼单
+func (r *GroupBuyRepository) HasParticipant(ctx context.Context, groupBuyNo, uid string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.GroupBuyParticipant{}).
+		Where("group_buy_no = ? AND uid = ?", groupBuyNo, uid).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// JoinGroupBuy 参与拼单，返回扣款后的钱包、参与后的人数和本次参与是否使拼单成团
+// 在同一事务内锁定拼单和钱包、扣减余额、创建订单、写入参与记录和交易流水，达到目标人数时同时标记成团。
+// 参与人数以实际的参与记录为准，不使用拼单上的展示人数；
+// 发起人不是注册用户的系统拼单只允许分配到的用户参与一次；
+// reservedSeats 为指定用户保留的名额，其他用户不能占用。
+func (r *GroupBuyRepository) JoinGroupBuy(ctx context.Context, groupBuyNo string, reservedSeats int, participant *models.GroupBuyParticipant, order *models.Order, transaction *models.WalletTransaction) (*models.Wallet, int, bool, error) {
+	var wallet *models.Wallet
+	joined := 0
+	completed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var groupBuy models.GroupBuy
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_buy_no = ?", groupBuyNo).
+			First(&groupBuy).Error; err != nil {
+			return err
+		}
+		if (groupBuy.Status != models.GroupBuyStatusNotStarted && groupBuy.Status != models.GroupBuyStatusPending) ||
+			!time.Now().Before(groupBuy.Deadline) {
+			return utils.NewAppError(utils.CodeGroupBuyClosed, "拼单已结束，无法参与")
+		}
+
+		// 系统生成的拼单发起人不是注册用户，只有分配到的用户可以参与
+		var creatorCount int64
+		if err := tx.Model(&models.User{}).Where("uid = ?", groupBuy.CreatorUid).Count(&creatorCount).Error; err != nil {
+			return err
+		}
+		if creatorCount == 0 && (groupBuy.Uid != participant.Uid || (groupBuy.OrderNo != nil && *groupBuy.OrderNo != "")) {
+			return utils.NewAppError(utils.CodeGroupBuyOccupied, "该拼单不属于您，无法参与")
+		}
+
+		var count int64
+		if err := tx.Model(&models.GroupBuyParticipant{}).
+			Where("group_buy_no = ? AND status IN ?", groupBuyNo,
+				[]string{models.GroupBuyParticipantStatusJoined, models.GroupBuyParticipantStatusSuccess}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) >= groupBuy.TargetParticipants {
+			return utils.NewAppError(utils.CodeGroupBuyFull, "拼单已满员")
+		}
+		if int(count)+reservedSeats >= groupBuy.TargetParticipants {
+			return utils.NewAppError(utils.CodeGroupBuyFull, "剩余名额已为指定用户保留")
+		}
+
+		// 状态2（无法提现）不影响拼单参与
+		locked, err := lockWalletInTx(tx, participant.Uid)
+		if err != nil {
+			return err
+		}
+		if locked.IsFrozen() {
+			return utils.NewAppError(utils.CodeOperationFailed, "钱包已被冻结，无法参与拼单")
+		}
+		if locked.Balance < participant.Amount {
+			return utils.NewAppError(utils.CodeOperationFailed,
+				fmt.Sprintf("余额不足，当前余额: %.2f，拼单金额: %.2f", locked.Balance, participant.Amount))
+		}
+		if err := debitWalletInTx(tx, locked, participant.Amount, transaction); err != nil {
+			return err
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(participant).Error; err != nil {
+			// 唯一索引冲突说明同一用户并发重复参与
+			if strings.Contains(err.Error(), "Duplicate entry") {
+				return utils.NewAppError(utils.CodeGroupBuyJoined, "您已参与该拼单")
+			}
+			return err
+		}
+
+		joined = int(count) + 1
+		if err := tx.Model(&models.GroupBuy{}).
+			Where("group_buy_no = ?", groupBuyNo).
+			Updates(map[string]interface{}{
+				"current_participants": joined,
+				"paid_amount":          participant.Amount * float64(joined),
+				"status":               models.GroupBuyStatusPending,
+			}).Error; err != nil {
+			return err
+		}
+
+		completed, err = completeGroupBuyIfFull(tx, groupBuyNo)
+		if err != nil {
+			return err
+		}
+		wallet = locked
+		return nil
+	})
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return wallet, joined, completed, nil
+}
+
+// CreateGroupBuyWithCreator 创建用户发起的拼单，并在同一事务内写入发起人的订单、参与记录和交易流水
+func (r *GroupBuyRepository) CreateGroupBuyWithCreator(ctx context.Context, groupBuy *models.GroupBuy, participant *models.GroupBuyParticipant, order *models.Order, transaction *models.WalletTransaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(groupBuy).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(participant).Error; err != nil {
+			return err
+		}
+		if transaction != nil {
+			if err := tx.Create(transaction).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CancelGroupBuyByCreator 发起人取消拼单，仅在没有其他用户参与时成功，返回是否取消成功
+func (r *GroupBuyRepository) CancelGroupBuyByCreator(ctx context.Context, groupBuyNo, creatorUid string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.GroupBuy{}).
+		Where("group_buy_no = ? AND creator_uid = ? AND status IN ? AND current_participants <= 1",
+			groupBuyNo, creatorUid, []string{models.GroupBuyStatusNotStarted, models.GroupBuyStatusPending}).
+		Update("status", models.GroupBuyStatusCancelled)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetParticipants 获取拼单的全部参与记录
+func (r *GroupBuyRepository) GetParticipants(ctx context.Context, groupBuyNo string) ([]models.GroupBuyParticipant, error) {
+	var participants []models.GroupBuyParticipant
+	err := r.db.WithContext(ctx).
+		Where("group_buy_no = ?", groupBuyNo).
+		Order("id ASC").
+		Find(&participants).Error
+	return participants, err
+}
+
+// CompleteGroupBuyIfFull 拼单满员时标记为已完成，返回本次是否完成成团
+func (r *GroupBuyRepository) CompleteGroupBuyIfFull(ctx context.Context, groupBuyNo string) (bool, error) {
+	completed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		completed, err = completeGroupBuyIfFull(tx, groupBuyNo)
+		return err
+	})
+	return completed, err
+}
+
+// completeGroupBuyIfFull 在事务内将满员的进行中拼单及其参与记录标记为成功
+func completeGroupBuyIfFull(tx *gorm.DB, groupBuyNo string) (bool, error) {
+	result := tx.Model(&models.GroupBuy{}).
+		Where("group_buy_no = ? AND status = ? AND current_participants >= target_participants",
+			groupBuyNo, models.GroupBuyStatusPending).
+		Update("status", models.GroupBuyStatusSuccess)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := tx.Model(&models.GroupBuyParticipant{}).
+		Where("group_buy_no = ? AND status = ?", groupBuyNo, models.GroupBuyParticipantStatusJoined).
+		Update("status", models.GroupBuyParticipantStatusSuccess).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetFullPendingGroupBuyNos 获取已满员但仍处于进行中的拼单编号，用于补偿成团状态更新
+func (r *GroupBuyRepository) GetFullPendingGroupBuyNos(ctx context.Context, limit int) ([]string, error) {
+	var groupBuyNos []string
+	err := r.db.WithContext(ctx).Model(&models.GroupBuy{}).
+		Where("status = ? AND current_participants >= target_participants", models.GroupBuyStatusPending).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("group_buy_no", &groupBuyNos).Error
+	return groupBuyNos, err
+}
+
+// GetExpiredUnfilledGroupBuys 获取已过截止时间、未满员且仍有待退款参与者的拼单
+// 包含已标记为未成团或已取消但退款未完成的拼单，便于失败后重试
+func (r *GroupBuyRepository) GetExpiredUnfilledGroupBuys(ctx context.Context, limit int) ([]models.GroupBuy, error) {
+	var groupBuys []models.GroupBuy
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND deadline <= ? AND current_participants < target_participants",
+			[]string{models.GroupBuyStatusPending, models.GroupBuyStatusFailed, models.GroupBuyStatusCancelled}, time.Now()).
+		Where("EXISTS (SELECT 1 FROM group_buy_participants p WHERE p.group_buy_no = group_buys.group_buy_no AND p.status = ?)",
+			models.GroupBuyParticipantStatusJoined).
+		Order("deadline ASC").
+		Limit(limit).
+		Find(&groupBuys).Error
+	return groupBuys, err
+}
+
+// MarkGroupBuyFailed 将进行中的拼单标记为未成团
+func (r *GroupBuyRepository) MarkGroupBuyFailed(ctx context.Context, groupBuyNo string) error {
+	return r.db.WithContext(ctx).Model(&models.GroupBuy{}).
+		Where("group_buy_no = ? AND status = ?", groupBuyNo, models.GroupBuyStatusPending).
+		Update("status", models.GroupBuyStatusFailed).Error
+}
+
+// GetParticipantsByStatus 根据状态获取拼单参与记录
+func (r *GroupBuyRepository) GetParticipantsByStatus(ctx context.Context, groupBuyNo, status string) ([]models.GroupBuyParticipant, error) {
+	var participants []models.GroupBuyParticipant
+	err := r.db.WithContext(ctx).
+		Where("group_buy_no = ? AND status = ?", groupBuyNo, status).
+		Order("id ASC").
+		Find(&participants).Error
+	return participants, err
+}
+
+// RefundParticipant 给参与者退款，返回退款后的钱包和订单是否被取消，参与记录已退款时返回nil钱包
+// 在同一事务内将参与记录标记为已退款、退回余额、写入退款流水并取消待处理的订单，
+// 参与记录使用条件更新保证同一参与记录只会被退款一次。
+func (r *GroupBuyRepository) RefundParticipant(ctx context.Context, participant *models.GroupBuyParticipant, transaction *models.WalletTransaction) (*models.Wallet, bool, error) {
+	var wallet *models.Wallet
+	orderCancelled := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.GroupBuyParticipant{}).
+			Where("id = ? AND status = ?", participant.ID, models.GroupBuyParticipantStatusJoined).
+			Updates(map[string]interface{}{
+				"status":      models.GroupBuyParticipantStatusRefunded,
+				"refunded_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		// 冻结钱包同样退款
+		locked, err := lockWalletInTx(tx, participant.Uid)
+		if err != nil {
+			return err
+		}
+		if err := creditWalletInTx(tx, locked, participant.Amount, transaction); err != nil {
+			return err
+		}
+
+		if participant.OrderNo != "" {
+			result := tx.Model(&models.Order{}).
+				Where("order_no = ? AND status = ?", participant.OrderNo, models.OrderStatusPending).
+				Update("status", models.OrderStatusCancelled)
+			if result.Error != nil {
+				return result.Error
+			}
+			orderCancelled = result.RowsAffected > 0
+		}
+
+		wallet = locked
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return wallet, orderCancelled, nil
+}
+
+// GetUserGroupBuyHistory 分页获取用户发起或参与过的拼单参与记录，status和groupBuyType为空时不筛选
+// 发起人同样写入参与记录；参与记录表之前的拼单只通过拼单的uid字段关联用户，
+// 这部分拼单只取已参与（有关联订单）的，合并为ID为0的参与记录，参与时间取拼单的更新时间
+func (r *GroupBuyRepository) GetUserGroupBuyHistory(ctx context.Context, uid, status, groupBuyType string, page, pageSize int) ([]models.GroupBuyParticipant, int64, error) {
+	filter := ""
+	var filterArgs []interface{}
+	if status != "" {
+		filter += " AND g.status = ?"
+		filterArgs = append(filterArgs, status)
+	}
+	if groupBuyType != "" {
+		filter += " AND g.group_buy_type = ?"
+		filterArgs = append(filterArgs, groupBuyType)
+	}
+
+	history := fmt.Sprintf(`
+		SELECT p.id, p.group_buy_no, p.uid, p.amount, p.order_no, p.status, p.refunded_at, p.created_at, p.updated_at
+		FROM group_buy_participants p
+		JOIN group_buys g ON g.group_buy_no = p.group_buy_no
+		WHERE p.uid = ?%[1]s
+		UNION ALL
+		SELECT 0, g.group_buy_no, g.uid, g.per_person_amount, COALESCE(g.order_no, ''),
+			CASE WHEN g.status = ? THEN ? ELSE ? END, NULL, g.updated_at, g.updated_at
+		FROM group_buys g
+		WHERE g.uid = ? AND g.order_no <> ''%[1]s
+			AND NOT EXISTS (SELECT 1 FROM group_buy_participants p WHERE p.group_buy_no = g.group_buy_no AND p.uid = g.uid)`, filter)
+	args := []interface{}{uid}
+	args = append(args, filterArgs...)
+	args = append(args, models.GroupBuyStatusSuccess, models.GroupBuyParticipantStatusSuccess, models.GroupBuyParticipantStatusJoined, uid)
+	args = append(args, filterArgs...)
+
+	var total int64
+	if err := r.db.WithContext(ctx).
+		Raw("SELECT COUNT(*) FROM ("+history+") AS history", args...).
+		Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var participants []models.GroupBuyParticipant
+	offset := (page - 1) * pageSize
+	err := r.db.WithContext(ctx).
+		Raw("SELECT * FROM ("+history+") AS history ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?",
+			append(args, pageSize, offset)...).
+		Scan(&participants).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return participants, total, nil
+}
+
+// GetGroupBuysByNos 根据拼单编号批量获取拼单
+func (r *GroupBuyRepository) GetGroupBuysByNos(ctx context.Context, groupBuyNos []string) ([]models.GroupBuy, error) {
+	var groupBuys []models.GroupBuy
+	if len(groupBuyNos) == 0 {
+		return groupBuys, nil
+	}
+	err := r.db.WithContext(ctx).Where("group_buy_no IN ?", groupBuyNos).Find(&groupBuys).Error
+	return groupBuys, err
+}
+
+// GetOrdersByNos 根据订单编号批量获取订单
+func (r *GroupBuyRepository) GetOrdersByNos(ctx context.Context, orderNos []string) ([]models.Order, error) {
+	var orders []models.Order
+	if len(orderNos) == 0 {
+		return orders, nil
+	}
+	err := r.db.WithContext(ctx).Where("order_no IN ?", orderNos).Find(&orders).Error
+	return orders, err
+}
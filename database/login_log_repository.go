@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
 	"time"
 )
 
@@ -28,13 +29,27 @@ func (r *LoginLogRepository) GetUserLoginHistory(ctx context.Context, uid string
 	var logs []models.UserLoginLog
 	err := r.db.WithContext(ctx).
 		Where("uid = ?", uid).
-		Order("login_time DESC").
+		Order("created_at DESC, id DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&logs).Error
 	return logs, err
 }
 
+// GetUserLoginHistoryByCursor 游标分页获取用户登录历史
+func (r *LoginLogRepository) GetUserLoginHistoryByCursor(ctx context.Context, uid string, cursor *utils.Cursor, pageSize int) ([]models.UserLoginLog, bool, error) {
+	var logs []models.UserLoginLog
+	err := utils.NewQueryBuilder(r.db.WithContext(ctx)).
+		Where("uid = ?", uid).
+		CursorPaginate(cursor, pageSize).
+		Find(&logs)
+	if err != nil {
+		return nil, false, err
+	}
+	n, hasNext := utils.TrimCursorPage(len(logs), pageSize)
+	return logs[:n], hasNext, nil
+}
+
 // GetUserLastLogin 获取用户最后登录记录
 func (r *LoginLogRepository) GetUserLastLogin(ctx context.Context, uid string) (*models.UserLoginLog, error) {
 	var log models.UserLoginLog
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"gin-fataMorgana/models"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MemberLevelRepository 会员等级Repository，包含等级配置和用户等级覆盖
+type MemberLevelRepository struct {
+	db *gorm.DB
+}
+
+// NewMemberLevelRepository 创建会员等级Repository实例
+func NewMemberLevelRepository() *MemberLevelRepository {
+	return &MemberLevelRepository{
+		db: DB,
+	}
+}
+
+// ListLevels 获取全部等级配置，按等级从低到高排序
+func (r *MemberLevelRepository) ListLevels(ctx context.Context) ([]models.MemberLevel, error) {
+	var levels []models.MemberLevel
+	err := r.db.WithContext(ctx).Order("level ASC").Find(&levels).Error
+	return levels, err
+}
+
+// FindLevelByID 根据ID获取等级配置
+func (r *MemberLevelRepository) FindLevelByID(ctx context.Context, id uint64) (*models.MemberLevel, error) {
+	var level models.MemberLevel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&level).Error; err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// CreateLevel 创建等级配置
+// 等级数值有唯一索引，同等级的软删除记录会先被物理删除，避免重新创建时冲突
+func (r *MemberLevelRepository) CreateLevel(ctx context.Context, level *models.MemberLevel) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("level = ? AND deleted_at IS NOT NULL", level.Level).
+			Delete(&models.MemberLevel{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(level).Error
+	})
+}
+
+// UpdateLevel 更新等级配置
+func (r *MemberLevelRepository) UpdateLevel(ctx context.Context, level *models.MemberLevel) error {
+	return r.db.WithContext(ctx).Save(level).Error
+}
+
+// DeleteLevel 软删除等级配置
+func (r *MemberLevelRepository) DeleteLevel(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.MemberLevel{}, id).Error
+}
+
+// CountLevels 统计等级配置数量
+func (r *MemberLevelRepository) CountLevels(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.MemberLevel{}).Count(&count).Error
+	return count, err
+}
+
+// FindOverride 获取用户等级覆盖，不存在时返回gorm.ErrRecordNotFound
+func (r *MemberLevelRepository) FindOverride(ctx context.Context, uid string) (*models.UserLevelOverride, error) {
+	var override models.UserLevelOverride
+	if err := r.db.WithContext(ctx).Where("uid = ?", uid).First(&override).Error; err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// UpsertOverride 设置用户等级覆盖，已存在时更新
+func (r *MemberLevelRepository) UpsertOverride(ctx context.Context, override *models.UserLevelOverride) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "remark", "created_by", "updated_at"}),
+	}).Create(override).Error
+}
+
+// DeleteOverride 删除用户等级覆盖，返回是否有记录被删除
+func (r *MemberLevelRepository) DeleteOverride(ctx context.Context, uid string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("uid = ?", uid).Delete(&models.UserLevelOverride{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountOverridesByLevel 统计固定在指定等级的用户数量
+func (r *MemberLevelRepository) CountOverridesByLevel(ctx context.Context, level int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.UserLevelOverride{}).Where("level = ?", level).Count(&count).Error
+	return count, err
+}
+
+// ListOverridesByUids 批量获取用户等级覆盖
+func (r *MemberLevelRepository) ListOverridesByUids(ctx context.Context, uids []string) ([]models.UserLevelOverride, error) {
+	var overrides []models.UserLevelOverride
+	if len(uids) == 0 {
+		return overrides, nil
+	}
+	err := r.db.WithContext(ctx).Where("uid IN ?", uids).Find(&overrides).Error
+	return overrides, err
+}
+
+// ImportLegacyLevelConfigs 在同一事务内导入旧版Redis中保存的等级规则和用户等级
+// rules 写入对应等级的升级要求，会员等级表中没有的等级按规则新建；
+// 导入后仍没有升级要求的等级依次取上一等级要求加1，保证升级要求递增，需管理员在后台调整；
+// overrides 写入等级覆盖，已有等级覆盖的用户保留管理员的设置
+func (r *MemberLevelRepository) ImportLegacyLevelConfigs(ctx context.Context, rules []models.MemberLevel, overrides []models.UserLevelOverride) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range rules {
+			var existing models.MemberLevel
+			err := tx.Unscoped().Where("level = ?", rules[i].Level).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(&rules[i]).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.MemberLevel{}).Where("id = ?", existing.ID).Updates(map[string]interface{}{
+				"requirement":      rules[i].Requirement,
+				"requirement_type": rules[i].RequirementType,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var levels []models.MemberLevel
+		if err := tx.Order("level ASC").Find(&levels).Error; err != nil {
+			return err
+		}
+		last := models.MemberLevel{RequirementType: models.LevelRequirementBalance}
+		for _, level := range levels {
+			if level.Requirement <= 0 {
+				level.Requirement = last.Requirement + 1
+				level.RequirementType = last.RequirementType
+				if err := tx.Model(&models.MemberLevel{}).Where("id = ?", level.ID).Updates(map[string]interface{}{
+					"requirement":      level.Requirement,
+					"requirement_type": level.RequirementType,
+				}).Error; err != nil {
+					return err
+				}
+				log.Printf("⚠️  会员等级%d没有升级要求，暂设为%d，请在后台调整", level.Level, level.Requirement)
+			}
+			last = level
+		}
+
+		if len(overrides) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "uid"}},
+			DoNothing: true,
+		}).CreateInBatches(overrides, 500).Error
+	})
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gin-fataMorgana/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BroadcastAudience 群发消息的候选用户条件，会员等级需要计算，由服务层过滤
+type BroadcastAudience struct {
+	Uids           []string   // 指定用户，为空表示不限
+	InviteCode     string     // 注册邀请码
+	RegisteredFrom *time.Time // 注册开始时间
+	RegisteredTo   *time.Time // 注册结束时间（不含）
+}
+
+// MessageBroadcastRepository 群发消息Repository
+type MessageBroadcastRepository struct {
+	db *gorm.DB
+}
+
+// NewMessageBroadcastRepository 创建群发消息Repository实例
+func NewMessageBroadcastRepository() *MessageBroadcastRepository {
+	return &MessageBroadcastRepository{
+		db: DB,
+	}
+}
+
+// Create 创建群发消息
+func (r *MessageBroadcastRepository) Create(ctx context.Context, broadcast *models.MessageBroadcast) error {
+	return r.db.WithContext(ctx).Create(broadcast).Error
+}
+
+// FindByID 根据ID获取群发消息
+func (r *MessageBroadcastRepository) FindByID(ctx context.Context, id uint64) (*models.MessageBroadcast, error) {
+	var broadcast models.MessageBroadcast
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&broadcast).Error; err != nil {
+		return nil, err
+	}
+	return &broadcast, nil
+}
+
+// List 分页获取群发消息，按创建时间倒序
+func (r *MessageBroadcastRepository) List(ctx context.Context, status string, limit, offset int) ([]models.MessageBroadcast, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.MessageBroadcast{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var broadcasts []models.MessageBroadcast
+	err := query.Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&broadcasts).Error
+	return broadcasts, total, err
+}
+
+// ListDueIDs 获取到达发送时间的群发消息，以及租约已过期的中断任务
+func (r *MessageBroadcastRepository) ListDueIDs(ctx context.Context, now time.Time, limit int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.WithContext(ctx).Model(&models.MessageBroadcast{}).
+		Where("(status = ? AND (scheduled_at IS NULL OR scheduled_at <= ?)) OR (status = ? AND lease_until < ?)",
+			models.BroadcastStatusPending, now, models.BroadcastStatusSending, now).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Claim 领取群发任务并设置租约，任务已被其他实例领取时返回false
+func (r *MessageBroadcastRepository) Claim(ctx context.Context, id uint64, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MessageBroadcast{}).
+		Where("id = ?", id).
+		Where("(status = ? AND (scheduled_at IS NULL OR scheduled_at <= ?)) OR (status = ? AND lease_until < ?)",
+			models.BroadcastStatusPending, now, models.BroadcastStatusSending, now).
+		Updates(map[string]interface{}{
+			"status":      models.BroadcastStatusSending,
+			"lease_until": leaseUntil,
+			"started_at":  gorm.Expr("COALESCE(started_at, ?)", now),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetTotalCount 记录开始发送时的候选用户数
+func (r *MessageBroadcastRepository) SetTotalCount(ctx context.Context, id uint64, total int64) error {
+	return r.db.WithContext(ctx).Model(&models.MessageBroadcast{}).
+		Where("id = ?", id).
+		Update("total_count", total).Error
+}
+
+// UpdateProgress 记录一批投递的进度并续约，任务已被取消时返回false
+func (r *MessageBroadcastRepository) UpdateProgress(ctx context.Context, id uint64, lastUserID uint, scanned, delivered int64, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MessageBroadcast{}).
+		Where("id = ? AND status = ?", id, models.BroadcastStatusSending).
+		Updates(map[string]interface{}{
+			"last_user_id":    lastUserID,
+			"scanned_count":   gorm.Expr("scanned_count + ?", scanned),
+			"delivered_count": gorm.Expr("delivered_count + ?", delivered),
+			"lease_until":     leaseUntil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 将发送中的群发消息标记为已完成
+func (r *MessageBroadcastRepository) Complete(ctx context.Context, id uint64, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.MessageBroadcast{}).
+		Where("id = ? AND status = ?", id, models.BroadcastStatusSending).
+		Updates(map[string]interface{}{
+			"status":       models.BroadcastStatusCompleted,
+			"completed_at": now,
+			"lease_until":  nil,
+		}).Error
+}
+
+// Cancel 取消等待发送或发送中的群发消息，已投递的消息不会撤回
+func (r *MessageBroadcastRepository) Cancel(ctx context.Context, id uint64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MessageBroadcast{}).
+		Where("id = ? AND status IN ?", id, []string{models.BroadcastStatusPending, models.BroadcastStatusSending}).
+		Updates(map[string]interface{}{
+			"status":      models.BroadcastStatusCancelled,
+			"lease_until": nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// audienceQuery 构建候选用户查询，全部用户和分群不包含已禁用的用户
+func (r *MessageBroadcastRepository) audienceQuery(ctx context.Context, audience *BroadcastAudience) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.User{}).Where("deleted_at IS NULL")
+	if len(audience.Uids) > 0 {
+		query = query.Where("uid IN ?", audience.Uids)
+	} else {
+		query = query.Where("status <> ?", models.UserStatusDisabled)
+	}
+	if audience.InviteCode != "" {
+		query = query.Where("invited_by = ?", audience.InviteCode)
+	}
+	if audience.RegisteredFrom != nil {
+		query = query.Where("created_at >= ?", *audience.RegisteredFrom)
+	}
+	if audience.RegisteredTo != nil {
+		query = query.Where("created_at < ?", *audience.RegisteredTo)
+	}
+	return query
+}
+
+// CountAudience 统计候选用户数
+func (r *MessageBroadcastRepository) CountAudience(ctx context.Context, audience *BroadcastAudience) (int64, error) {
+	var count int64
+	err := r.audienceQuery(ctx, audience).Count(&count).Error
+	return count, err
+}
+
+// ListAudience 按自增ID顺序分批获取候选用户
+func (r *MessageBroadcastRepository) ListAudience(ctx context.Context, audience *BroadcastAudience, afterID uint, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.audienceQuery(ctx, audience).
+		Select("id, uid, experience").
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// ListDeliveredUids 获取已投递过该群发消息的用户（包括已删除该消息的用户），用于任务中断后继续时跳过
+func (r *MessageBroadcastRepository) ListDeliveredUids(ctx context.Context, broadcastID uint64, uids []string) ([]string, error) {
+	delivered := []string{}
+	if len(uids) == 0 {
+		return delivered, nil
+	}
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.MessageBroadcastRecipient{}).
+		Where("broadcast_id = ? AND uid IN ?", broadcastID, uids).
+		Pluck("uid", &delivered).Error
+	return delivered, err
+}
+
+// CreateRecipients 批量写入投递记录，已存在的记录忽略
+func (r *MessageBroadcastRepository) CreateRecipients(ctx context.Context, recipients []models.MessageBroadcastRecipient) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&recipients).Error
+}
+
+// ListRecipients 获取指定用户的投递记录
+func (r *MessageBroadcastRepository) ListRecipients(ctx context.Context, broadcastID uint64, uids []string) ([]models.MessageBroadcastRecipient, error) {
+	var recipients []models.MessageBroadcastRecipient
+	err := r.db.WithContext(ctx).
+		Where("broadcast_id = ? AND uid IN ?", broadcastID, uids).
+		Find(&recipients).Error
+	return recipients, err
+}
+
+// CountUnreadRecipients 统计用户未读的群发消息数
+func (r *MessageBroadcastRepository) CountUnreadRecipients(ctx context.Context, uid string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.MessageBroadcastRecipient{}).
+		Where("uid = ? AND read_at IS NULL", uid).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRecipientsRead 将用户的群发消息投递记录标记为已读，ids为nil时标记全部，返回实际更新数
+func (r *MessageBroadcastRepository) MarkRecipientsRead(ctx context.Context, uid string, ids []int64) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.MessageBroadcastRecipient{}).Where("uid = ? AND read_at IS NULL", uid)
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+	now := time.Now()
+	result := query.Update("read_at", &now)
+	return result.RowsAffected, result.Error
+}
+
+// MarkRecipientsUnread 将用户的群发消息投递记录标记为未读，返回实际更新数
+func (r *MessageBroadcastRepository) MarkRecipientsUnread(ctx context.Context, uid string, ids []int64) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.MessageBroadcastRecipient{}).
+		Where("uid = ? AND read_at IS NOT NULL AND id IN ?", uid, ids).
+		Update("read_at", nil)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteRecipients 删除用户的群发消息投递记录（软删除），返回实际删除数
+func (r *MessageBroadcastRepository) DeleteRecipients(ctx context.Context, uid string, ids []int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("uid = ? AND id IN ?", uid, ids).
+		Delete(&models.MessageBroadcastRecipient{})
+	return result.RowsAffected, result.Error
+}
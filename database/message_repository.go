@@ -2,12 +2,26 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// InboxMessage 收件箱消息，合并单发消息和群发消息的投递记录
+// SortKey 在两个来源之间唯一（单发消息为ID*2，群发投递记录为ID*2+1），与创建时间一起作为排序和游标位置
+type InboxMessage struct {
+	Source      string     `gorm:"column:source"`
+	ID          int64      `gorm:"column:id"`
+	MessageType string     `gorm:"column:message_type"`
+	Content     string     `gorm:"column:content"`
+	ReadAt      *time.Time `gorm:"column:read_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	SortKey     int64      `gorm:"column:sort_key"`
+}
+
 // MessageRepository 消息Repository
 type MessageRepository struct {
 	db *gorm.DB
@@ -35,19 +49,6 @@ func (r *MessageRepository) GetMessageByID(ctx context.Context, id int64) (*mode
 	return &message, nil
 }
 
-// UpdateMessageStatus 更新消息状态为已读
-func (r *MessageRepository) UpdateMessageStatus(ctx context.Context, id int64) error {
-	now := time.Now()
-	return r.db.WithContext(ctx).
-		Model(&models.Message{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"status":     "read",
-			"read_at":    &now,
-			"updated_at": now,
-		}).Error
-}
-
 // CreateMessage 创建消息
 func (r *MessageRepository) CreateMessage(ctx context.Context, message *models.Message) error {
 	return r.db.WithContext(ctx).Create(message).Error
@@ -64,4 +65,115 @@ func (r *MessageRepository) GetUserMessages(ctx context.Context, uid string, lim
 		Find(&messages).Error
 
 	return messages, err
-}
\ No newline at end of file
+}
+
+// inboxQuery 构建收件箱查询，两个来源各自按索引排序取前limit条后合并，desc为false时按时间正序
+// 消息表由建表脚本创建，群发表由GORM创建，排序规则可能不同，合并时统一为消息表的排序规则
+func inboxQuery(uid string, cursor *utils.Cursor, unreadOnly, desc bool, limit int) (string, []interface{}) {
+	direction, compare := "DESC", "<"
+	if !desc {
+		direction, compare = "ASC", ">"
+	}
+
+	directWhere := "m.uid = ? AND m.status <> 'draft' AND m.deleted_at IS NULL"
+	broadcastWhere := "r.uid = ? AND r.deleted_at IS NULL"
+	directArgs := []interface{}{uid}
+	broadcastArgs := []interface{}{uid}
+	if unreadOnly {
+		directWhere += " AND m.status = 'sent'"
+		broadcastWhere += " AND r.read_at IS NULL"
+	}
+	if cursor != nil {
+		directWhere += fmt.Sprintf(" AND (m.created_at %s ? OR (m.created_at = ? AND m.id * 2 %s ?))", compare, compare)
+		broadcastWhere += fmt.Sprintf(" AND (r.created_at %s ? OR (r.created_at = ? AND r.id * 2 + 1 %s ?))", compare, compare)
+		directArgs = append(directArgs, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		broadcastArgs = append(broadcastArgs, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	query := fmt.Sprintf(`
+		(SELECT 'message' AS source, m.id, m.message_type, m.content, m.read_at, m.created_at, m.id * 2 AS sort_key
+		FROM messages m
+		WHERE %[1]s
+		ORDER BY m.created_at %[3]s, m.id %[3]s
+		LIMIT %[4]d)
+		UNION ALL
+		(SELECT 'broadcast' AS source, r.id, b.message_type COLLATE utf8mb4_unicode_ci, b.content COLLATE utf8mb4_unicode_ci, r.read_at, r.created_at, r.id * 2 + 1 AS sort_key
+		FROM message_broadcast_recipients r
+		JOIN message_broadcasts b ON b.id = r.broadcast_id
+		WHERE %[2]s
+		ORDER BY r.created_at %[3]s, r.id %[3]s
+		LIMIT %[4]d)
+		ORDER BY created_at %[3]s, sort_key %[3]s
+		LIMIT %[4]d`, directWhere, broadcastWhere, direction, limit)
+	return query, append(directArgs, broadcastArgs...)
+}
+
+// ListInbox 游标分页获取用户收件箱（不含草稿和已删除的消息），按创建时间倒序
+// 多取一条用于判断是否还有下一页
+func (r *MessageRepository) ListInbox(ctx context.Context, uid string, cursor *utils.Cursor, pageSize int, unreadOnly bool) ([]InboxMessage, bool, error) {
+	query, args := inboxQuery(uid, cursor, unreadOnly, true, pageSize+1)
+
+	var messages []InboxMessage
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&messages).Error; err != nil {
+		return nil, false, err
+	}
+
+	n, hasNext := utils.TrimCursorPage(len(messages), pageSize)
+	return messages[:n], hasNext, nil
+}
+
+// FindOldestUnread 获取用户最早的一条未读消息，跳过after之前的消息，没有时返回nil
+func (r *MessageRepository) FindOldestUnread(ctx context.Context, uid string, after *utils.Cursor) (*InboxMessage, error) {
+	query, args := inboxQuery(uid, after, true, false, 1)
+
+	var messages []InboxMessage
+	if err := r.db.WithContext(ctx).Raw(query, args...).Scan(&messages).Error; err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	return &messages[0], nil
+}
+
+// CountUnread 统计用户未读的单发消息数
+func (r *MessageRepository) CountUnread(ctx context.Context, uid string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Message{}).
+		Where("uid = ? AND status = ?", uid, "sent").
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将用户的单发消息标记为已读，ids为nil时标记全部，返回实际更新数
+func (r *MessageRepository) MarkRead(ctx context.Context, uid string, ids []int64) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Message{}).Where("uid = ? AND status = ?", uid, "sent")
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+	now := time.Now()
+	result := query.Updates(map[string]interface{}{
+		"status":  "read",
+		"read_at": &now,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// MarkUnread 将用户的单发消息标记为未读，返回实际更新数
+func (r *MessageRepository) MarkUnread(ctx context.Context, uid string, ids []int64) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Message{}).
+		Where("uid = ? AND status = ? AND id IN ?", uid, "read", ids).
+		Updates(map[string]interface{}{
+			"status":  "sent",
+			"read_at": nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteUserMessages 删除用户的单发消息（软删除），返回实际删除数
+func (r *MessageRepository) DeleteUserMessages(ctx context.Context, uid string, ids []int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("uid = ? AND status <> ? AND id IN ?", uid, "draft", ids).
+		Delete(&models.Message{})
+	return result.RowsAffected, result.Error
+}
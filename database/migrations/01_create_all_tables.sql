@@ -314,6 +314,7 @@ CREATE TABLE IF NOT EXISTS `messages` (
   `created_by` varchar(100) NOT NULL COMMENT '创建管理员用户名',
   `created_at` datetime(3) DEFAULT CURRENT_TIMESTAMP(3) COMMENT '创建时间',
   `updated_at` datetime(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3) COMMENT '更新时间',
+  `deleted_at` datetime(3) DEFAULT NULL COMMENT '用户删除时间',
   PRIMARY KEY (`id`),
   KEY `idx_uid_status` (`uid`, `status`),
   KEY `idx_uid_created_at` (`uid`, `created_at`),
@@ -16,6 +16,7 @@ import (
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -106,9 +107,19 @@ func AutoMigrate() error {
 		&models.Announcement{},
 		&models.AnnouncementBanner{},
 		&models.GroupBuy{},
+		&models.GroupBuyParticipant{},
 		&models.MemberLevel{},
 		&models.LotteryPeriod{},
 		&models.OperationFailure{},
+		&models.AdminAuditLog{},
+		&models.UserLevelOverride{},
+		&models.ScoreRule{},
+		&models.UserScoreHistory{},
+		&models.UserReferral{},
+		&models.CommissionRule{},
+		&models.ReferralCommission{},
+		&models.MessageBroadcast{},
+		&models.MessageBroadcastRecipient{},
 	)
 	if err != nil {
 		return utils.NewAppError(utils.CodeDBMigrationFailed, "数据库迁移失败")
@@ -166,10 +177,82 @@ func AutoMigrate() error {
 		log.Println("✅ 特殊表注释添加完成")
 	}
 
+	// 第五步：会员等级表为空时写入默认等级
+	if err := seedDefaultMemberLevels(); err != nil {
+		log.Printf("⚠️  初始化默认会员等级失败: %v", err)
+	}
+
+	// 第六步：写入缺少的经验值与信用分规则
+	if err := seedDefaultScoreRules(); err != nil {
+		log.Printf("⚠️  初始化经验值与信用分规则失败: %v", err)
+	}
+
+	// 第七步：消息表由建表脚本创建，单独补充用户删除消息所需的字段
+	if err := addMessageDeletedAt(); err != nil {
+		log.Printf("⚠️  添加消息删除时间字段失败: %v", err)
+	}
+
+	// 第八步：此前用户发起的拼单补充仅限邀请参与标记
+	if err := backfillGroupBuyInviteOnly(); err != nil {
+		log.Printf("⚠️  更新拼单邀请参与标记失败: %v", err)
+	}
+
 	log.Println("🎉 数据库迁移全部完成！")
 	return nil
 }
 
+// seedDefaultMemberLevels 会员等级表为空时写入默认等级，与此前代码内置的默认配置一致
+func seedDefaultMemberLevels() error {
+	var count int64
+	if err := DB.Model(&models.MemberLevel{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	levels := []models.MemberLevel{
+		{Level: 1, Name: "青铜会员", Requirement: 70000, RequirementType: models.LevelRequirementBalance, Remark: "余额达到7万升级", SingleAmount: 1},
+		{Level: 2, Name: "白银会员", Requirement: 100000, RequirementType: models.LevelRequirementBalance, Remark: "余额达到10万升级", SingleAmount: 1},
+		{Level: 3, Name: "黄金会员", Requirement: 200000, RequirementType: models.LevelRequirementBalance, Remark: "余额达到20万升级", SingleAmount: 1},
+	}
+	if err := DB.Create(&levels).Error; err != nil {
+		return err
+	}
+	log.Println("✅ 已写入默认会员等级")
+	return nil
+}
+
+// seedDefaultScoreRules 为还没有规则的事件写入默认规则，已有的规则保留管理员的修改
+func seedDefaultScoreRules() error {
+	rules := []models.ScoreRule{
+		{Event: models.ScoreEventOrderCompleted, Name: "订单完成", Experience: 10, CreditScore: 1, Enabled: true},
+		{Event: models.ScoreEventGroupBuyCompleted, Name: "拼单成团", Experience: 20, CreditScore: 2, Enabled: true},
+		{Event: models.ScoreEventOrderExpired, Name: "订单过期", Experience: 0, CreditScore: -5, Enabled: true},
+		{Event: models.ScoreEventWithdrawCancelled, Name: "提现取消", Experience: 0, CreditScore: -2, Enabled: true},
+	}
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event"}},
+		DoNothing: true,
+	}).Create(&rules).Error
+}
+
+// addMessageDeletedAt 为已有的消息表添加删除时间字段，表不存在时跳过
+func addMessageDeletedAt() error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&models.Message{}) || migrator.HasColumn(&models.Message{}, "DeletedAt") {
+		return nil
+	}
+	return migrator.AddColumn(&models.Message{}, "DeletedAt")
+}
+
+// backfillGroupBuyInviteOnly 将真实用户发起的拼单标记为仅限邀请参与，系统生成的拼单发起人不是注册用户
+func backfillGroupBuyInviteOnly() error {
+	return DB.Model(&models.GroupBuy{}).
+		Where("invite_only = ? AND creator_uid IN (?)", false, DB.Model(&models.User{}).Select("uid")).
+		Update("invite_only", true).Error
+}
+
 // createOptimizedIndexes 创建优化的复合索引
 func createOptimizedIndexes() error {
 	sqlDB, err := DB.DB()
@@ -323,16 +406,27 @@ func addTableComments() error {
 
 	// 表注释映射
 	tableComments := map[string]string{
-		"users":                "用户表 - 存储用户基本信息、认证信息、银行卡信息、经验值、信用分等",
-		"wallets":              "钱包表 - 存储用户钱包信息，包括余额、冻结余额、总收入、总支出等",
-		"wallet_transactions":  "钱包交易流水表 - 记录所有钱包交易明细，包括充值、提现、购买、拼单等操作",
-		"user_login_logs":      "用户登录日志表 - 记录用户登录历史，包括登录时间、IP地址、设备信息、登录状态等",
-		"admin_users":          "邀请码管理表 - 存储邀请码信息，用于用户注册时的邀请码校验，默认角色为业务员(4)",
-		"amount_config":        "金额配置表 - 存储充值、提现等操作的金额配置，支持排序和激活状态管理",
-		"announcements":        "公告表 - 存储系统公告信息，支持富文本内容，包括标题、纯文本内容、富文本内容、标签、状态等",
-		"announcement_banners": "公告图片表 - 存储公告相关的图片信息，支持排序和跳转链接",
-		"member_level":         "用户等级配置表 - 存储用户等级配置信息，包括等级、经验值范围、返现比例等",
-		"lottery_periods":      "游戏期数表 - 记录每期的编号、订单金额、状态和时间信息",
+		"users":                  "用户表 - 存储用户基本信息、认证信息、银行卡信息、经验值、信用分等",
+		"wallets":                "钱包表 - 存储用户钱包信息，包括余额、冻结余额、总收入、总支出等",
+		"wallet_transactions":    "钱包交易流水表 - 记录所有钱包交易明细，包括充值、提现、购买、拼单等操作",
+		"user_login_logs":        "用户登录日志表 - 记录用户登录历史，包括登录时间、IP地址、设备信息、登录状态等",
+		"admin_users":            "管理员表 - 存储后台管理员账号、角色层级和邀请码，默认角色为业务员(4)",
+		"amount_config":          "金额配置表 - 存储充值、提现等操作的金额配置，支持排序和激活状态管理",
+		"announcements":          "公告表 - 存储系统公告信息，支持富文本内容，包括标题、纯文本内容、富文本内容、标签、状态等",
+		"announcement_banners":   "公告图片表 - 存储公告相关的图片信息，支持排序和跳转链接",
+		"member_level":           "用户等级配置表 - 存储用户等级配置信息，包括等级、升级要求、返现比例等",
+		"user_level_overrides":   "用户等级覆盖表 - 管理员为指定用户固定的会员等级",
+		"lottery_periods":        "游戏期数表 - 记录每期的编号、订单金额、状态和时间信息",
+		"group_buy_participants": "拼单参与记录表 - 记录每个用户参与拼单的付款、订单和退款状态",
+		"admin_audit_logs":       "管理员审计日志表 - 只追加的哈希链，记录操作人、操作、对象、变更前后数据、IP和请求ID",
+		"score_rules":            "经验值与信用分规则表 - 每种事件对应的经验值和信用分变化",
+		"user_score_histories":   "用户经验值与信用分变更记录表 - 记录每次变更的事件、关联单号、变化量和变化后的值",
+		"user_referrals":         "用户推荐关系表 - 记录被推荐用户与每一级上级推荐人的关系和层级",
+		"commission_rules":       "推广佣金规则表 - 按推荐层级配置下级完成订单时的佣金比例",
+		"referral_commissions":   "推广佣金记录表 - 记录下级完成订单后各级推荐人获得的佣金和入账流水",
+
+		"message_broadcasts":           "群发消息表 - 管理员群发的消息内容、目标条件和投递进度，内容只保存一份",
+		"message_broadcast_recipients": "群发消息投递表 - 记录群发消息投递到的用户和读取时间",
 	}
 
 	// 为每个表添加注释
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
 )
 
 type OrderRepository struct {
@@ -31,7 +32,7 @@ func (r *OrderRepository) GetOrdersByStatus(ctx context.Context, status string,
 		return nil, 0, err
 	}
 	offset := (page - 1) * pageSize
-	err = query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error
+	err = query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&orders).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -59,13 +60,57 @@ func (r *OrderRepository) GetUserOrdersByStatus(ctx context.Context, uid string,
 		return nil, 0, err
 	}
 	offset := (page - 1) * pageSize
-	err = query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&orders).Error
+	err = query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&orders).Error
 	if err != nil {
 		return nil, 0, err
 	}
 	return orders, total, nil
 }
 
+// GetOrdersByCursor 游标分页获取订单（不统计总数），返回是否有下一页和上一页
+func (r *OrderRepository) GetOrdersByCursor(ctx context.Context, status string, cursor *utils.Cursor, pageSize int) ([]models.Order, bool, bool, error) {
+	return r.findOrdersByCursor(ctx, "", status, cursor, pageSize)
+}
+
+// GetUserOrdersByCursor 游标分页获取用户订单（不统计总数），返回是否有下一页和上一页
+func (r *OrderRepository) GetUserOrdersByCursor(ctx context.Context, uid string, status string, cursor *utils.Cursor, pageSize int) ([]models.Order, bool, bool, error) {
+	return r.findOrdersByCursor(ctx, uid, status, cursor, pageSize)
+}
+
+func (r *OrderRepository) cursorOrderQuery(ctx context.Context, uid string, status string) *utils.QueryBuilder {
+	qb := utils.NewQueryBuilder(r.db.WithContext(ctx).Model(&models.Order{})).
+		Where("created_at <= NOW()")
+	if uid != "" {
+		qb.Where("uid = ?", uid)
+	}
+	if status != "" {
+		qb.WhereStatus(status)
+	}
+	return qb
+}
+
+func (r *OrderRepository) findOrdersByCursor(ctx context.Context, uid string, status string, cursor *utils.Cursor, pageSize int) ([]models.Order, bool, bool, error) {
+	var orders []models.Order
+	if err := r.cursorOrderQuery(ctx, uid, status).CursorPaginate(cursor, pageSize).Find(&orders); err != nil {
+		return nil, false, false, err
+	}
+	n, hasNext := utils.TrimCursorPage(len(orders), pageSize)
+
+	// 游标之前（更新）是否还有记录，决定是否有上一页
+	hasPrev := false
+	if cursor != nil {
+		var prev []models.Order
+		err := r.cursorOrderQuery(ctx, uid, status).
+			Where("(created_at > ? OR (created_at = ? AND id >= ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Select("id").Limit(1).Find(&prev)
+		if err != nil {
+			return nil, false, false, err
+		}
+		hasPrev = len(prev) > 0
+	}
+	return orders[:n], hasNext, hasPrev, nil
+}
+
 func (r *OrderRepository) CreateOrder(ctx context.Context, order *models.Order) error {
 	err := r.Create(ctx, order)
 	return err
@@ -189,3 +189,36 @@ func InvalidateInviteCodeCache(ctx context.Context, inviteCode string) error {
 	key := fmt.Sprintf("invite_code_exists:%s", inviteCode)
 	return RedisClient.Del(ctx, key).Err()
 }
+
+// ScanKeys 按模式遍历匹配的Key，使用SCAN避免阻塞Redis
+func ScanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var (
+		cursor uint64
+		keys   []string
+	)
+	for {
+		batch, next, err := RedisClient.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if next == 0 {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
+// DelKeys 分批删除多个Key
+func DelKeys(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += 500 {
+		end := start + 500
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := RedisClient.Del(ctx, keys[start:end]...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
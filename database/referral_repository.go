@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReferralRepository 用户推荐Repository，包含推荐关系、佣金规则和佣金记录
+type ReferralRepository struct {
+	db *gorm.DB
+}
+
+// NewReferralRepository 创建用户推荐Repository实例
+func NewReferralRepository() *ReferralRepository {
+	return &ReferralRepository{
+		db: DB,
+	}
+}
+
+// FindUserByInviteCode 根据用户邀请码获取用户
+func (r *ReferralRepository) FindUserByInviteCode(ctx context.Context, inviteCode string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("my_invite_code = ?", inviteCode).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// InviteCodeExists 检查邀请码是否已被用户或管理员使用
+func (r *ReferralRepository) InviteCodeExists(ctx context.Context, inviteCode string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("my_invite_code = ?", inviteCode).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.AdminUser{}).Where("my_invite_code = ?", inviteCode).Count(&count).Error
+	return count > 0, err
+}
+
+// SetUserInviteCode 为还没有邀请码的用户设置邀请码，返回是否设置成功
+func (r *ReferralRepository) SetUserInviteCode(ctx context.Context, uid, inviteCode string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("uid = ? AND my_invite_code IS NULL", uid).
+		Update("my_invite_code", inviteCode)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateUserWithReferrals 在同一事务内创建用户并建立推荐关系，任一步失败整体回滚
+func (r *ReferralRepository) CreateUserWithReferrals(ctx context.Context, user *models.User, referrerUid string, maxDepth int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return createReferralsInTx(tx, user.Uid, referrerUid, maxDepth)
+	})
+}
+
+// createReferralsInTx 建立推荐关系：被推荐人与推荐人为第1层，并继承推荐人的上级，最多记录maxDepth层
+func createReferralsInTx(tx *gorm.DB, uid, referrerUid string, maxDepth int) error {
+	var ancestors []models.UserReferral
+	if err := tx.Where("uid = ? AND depth < ?", referrerUid, maxDepth).
+		Order("depth ASC").
+		Find(&ancestors).Error; err != nil {
+		return err
+	}
+
+	referrals := []models.UserReferral{{Uid: uid, AncestorUid: referrerUid, Depth: 1}}
+	for _, ancestor := range ancestors {
+		referrals = append(referrals, models.UserReferral{Uid: uid, AncestorUid: ancestor.AncestorUid, Depth: ancestor.Depth + 1})
+	}
+	return tx.Create(&referrals).Error
+}
+
+// ListAncestors 获取用户指定层级的上级推荐人，按层级从近到远排序
+func (r *ReferralRepository) ListAncestors(ctx context.Context, uid string, depths []int) ([]models.UserReferral, error) {
+	var ancestors []models.UserReferral
+	err := r.db.WithContext(ctx).
+		Where("uid = ? AND depth IN ?", uid, depths).
+		Order("depth ASC").
+		Find(&ancestors).Error
+	return ancestors, err
+}
+
+// CountReferrals 统计下级人数，depth为0时统计全部层级
+func (r *ReferralRepository) CountReferrals(ctx context.Context, ancestorUid string, depth int) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.UserReferral{}).Where("ancestor_uid = ?", ancestorUid)
+	if depth > 0 {
+		query = query.Where("depth = ?", depth)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// ListReferrals 分页获取下级及其用户名，depth为0时包含全部层级，按建立关系时间倒序
+func (r *ReferralRepository) ListReferrals(ctx context.Context, ancestorUid string, depth, limit, offset int) ([]models.MyReferralItem, int64, error) {
+	query := r.db.WithContext(ctx).Table("user_referrals AS r").
+		Joins("JOIN users u ON u.uid = r.uid").
+		Where("r.ancestor_uid = ?", ancestorUid)
+	if depth > 0 {
+		query = query.Where("r.depth = ?", depth)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.MyReferralItem
+	err := query.Select("r.uid, u.username, r.depth, r.created_at").
+		Order("r.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&items).Error
+	return items, total, err
+}
+
+// ListCommissionRules 获取全部佣金规则，按层级排序
+func (r *ReferralRepository) ListCommissionRules(ctx context.Context) ([]models.CommissionRule, error) {
+	var rules []models.CommissionRule
+	err := r.db.WithContext(ctx).Order("depth ASC").Find(&rules).Error
+	return rules, err
+}
+
+// FindCommissionRule 根据层级获取佣金规则
+func (r *ReferralRepository) FindCommissionRule(ctx context.Context, depth int) (*models.CommissionRule, error) {
+	var rule models.CommissionRule
+	if err := r.db.WithContext(ctx).Where("depth = ?", depth).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SaveCommissionRule 保存佣金规则，该层级已有规则时更新
+func (r *ReferralRepository) SaveCommissionRule(ctx context.Context, rule *models.CommissionRule) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "depth"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "enabled", "remark", "updated_at"}),
+	}).Create(rule).Error
+}
+
+// DeleteCommissionRule 删除佣金规则
+func (r *ReferralRepository) DeleteCommissionRule(ctx context.Context, depth int) error {
+	return r.db.WithContext(ctx).Where("depth = ?", depth).Delete(&models.CommissionRule{}).Error
+}
+
+// ListCommissionCandidates 获取指定时间后完成、仍有指定层级推荐人未产生佣金记录的用户订单
+func (r *ReferralRepository) ListCommissionCandidates(ctx context.Context, depths []int, since time.Time, afterID uint, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).
+		Select("id, order_no, uid, amount").
+		Where("status = ? AND is_system_order = ? AND updated_at >= ? AND id > ?", models.OrderStatusSuccess, false, since, afterID).
+		Where(`EXISTS (SELECT 1 FROM user_referrals r WHERE r.uid = orders.uid AND r.depth IN ?
+			AND NOT EXISTS (SELECT 1 FROM referral_commissions c WHERE c.order_no = orders.order_no AND c.beneficiary_uid = r.ancestor_uid))`, depths).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// CreditCommission 在同一事务内写入佣金记录、锁定推荐人钱包增加余额并写入佣金流水，返回变更后的钱包和是否写入了佣金记录
+// 同一订单和推荐人已有记录时不做任何修改；推荐人钱包冻结时只写入待入账的佣金记录，由待入账佣金补发处理
+func (r *ReferralRepository) CreditCommission(ctx context.Context, commission *models.ReferralCommission, transaction *models.WalletTransaction) (*models.Wallet, bool, error) {
+	var wallet *models.Wallet
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockWalletInTx(tx, commission.BeneficiaryUid)
+		if err != nil {
+			return err
+		}
+
+		commission.Status = models.ReferralCommissionStatusCredited
+		commission.TransactionNo = transaction.TransactionNo
+		if locked.IsFrozen() {
+			commission.Status = models.ReferralCommissionStatusPending
+			commission.TransactionNo = ""
+		}
+		if err := tx.Create(commission).Error; err != nil {
+			// 唯一索引冲突说明该佣金已经发放过
+			if strings.Contains(err.Error(), "Duplicate entry") {
+				return nil
+			}
+			return err
+		}
+		created = true
+		if commission.Status == models.ReferralCommissionStatusPending {
+			return nil
+		}
+
+		if err := creditWalletInTx(tx, locked, commission.Amount, transaction); err != nil {
+			return err
+		}
+		wallet = locked
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return wallet, created, nil
+}
+
+// ListPendingCommissions 获取待入账的佣金记录，按ID分批
+func (r *ReferralRepository) ListPendingCommissions(ctx context.Context, afterID uint64, limit int) ([]models.ReferralCommission, error) {
+	var commissions []models.ReferralCommission
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND id > ?", models.ReferralCommissionStatusPending, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&commissions).Error
+	return commissions, err
+}
+
+// CreditPendingCommission 在同一事务内为待入账的佣金锁定推荐人钱包增加余额、写入佣金流水并标记为已入账，返回变更后的钱包
+// 推荐人钱包仍冻结或佣金已入账时不做任何修改，返回nil
+func (r *ReferralRepository) CreditPendingCommission(ctx context.Context, commission *models.ReferralCommission, transaction *models.WalletTransaction) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockWalletInTx(tx, commission.BeneficiaryUid)
+		if err != nil {
+			return err
+		}
+		if locked.IsFrozen() {
+			return nil
+		}
+
+		result := tx.Model(&models.ReferralCommission{}).
+			Where("id = ? AND status = ?", commission.ID, models.ReferralCommissionStatusPending).
+			Updates(map[string]interface{}{
+				"status":         models.ReferralCommissionStatusCredited,
+				"transaction_no": transaction.TransactionNo,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := creditWalletInTx(tx, locked, commission.Amount, transaction); err != nil {
+			return err
+		}
+		wallet = locked
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// ListUserCommissions 分页获取推荐人的佣金记录，按时间倒序
+func (r *ReferralRepository) ListUserCommissions(ctx context.Context, beneficiaryUid string, limit, offset int) ([]models.ReferralCommission, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.ReferralCommission{}).Where("beneficiary_uid = ?", beneficiaryUid)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var commissions []models.ReferralCommission
+	err := query.Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&commissions).Error
+	return commissions, total, err
+}
+
+// SumCreditedCommission 统计推荐人已入账的佣金总额
+func (r *ReferralRepository) SumCreditedCommission(ctx context.Context, beneficiaryUid string) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.ReferralCommission{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("beneficiary_uid = ? AND status = ?", beneficiaryUid, models.ReferralCommissionStatusCredited).
+		Scan(&total).Error
+	return total, err
+}
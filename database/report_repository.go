@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gin-fataMorgana/models"
+
+	"gorm.io/gorm"
+)
+
+// ReportRepository 代理业绩报表Repository，各项指标按用户注册时填写的邀请码分组统计
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository 创建代理业绩报表Repository实例
+func NewReportRepository() *ReportRepository {
+	return &ReportRepository{
+		db: DB,
+	}
+}
+
+// CountRegistrations 统计时间范围内各邀请码的注册人数
+func (r *ReportRepository) CountRegistrations(ctx context.Context, inviteCodes []string, start, end time.Time) ([]models.AgentReportAggregate, error) {
+	var rows []models.AgentReportAggregate
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("invited_by AS invite_code, COUNT(*) AS count").
+		Where("invited_by IN ? AND created_at >= ? AND created_at < ?", inviteCodes, start, end).
+		Group("invited_by").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CountApprovals 根据审计日志统计时间范围内各邀请码审核通过的人数
+func (r *ReportRepository) CountApprovals(ctx context.Context, inviteCodes []string, start, end time.Time) ([]models.AgentReportAggregate, error) {
+	var rows []models.AgentReportAggregate
+	err := r.db.WithContext(ctx).Table("admin_audit_logs AS a").
+		Joins("JOIN users u ON u.uid = a.target_id").
+		Select("u.invited_by AS invite_code, COUNT(DISTINCT a.target_id) AS count").
+		Where("a.action = ? AND a.target_type = ?", models.AuditActionUserApprove, models.AuditTargetUser).
+		Where("u.invited_by IN ? AND a.created_at >= ? AND a.created_at < ?", inviteCodes, start, end).
+		Group("u.invited_by").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// SumTransactions 统计时间范围内各邀请码下用户指定类型的成功交易笔数和金额
+func (r *ReportRepository) SumTransactions(ctx context.Context, inviteCodes []string, transactionType string, start, end time.Time) ([]models.AgentReportAggregate, error) {
+	var rows []models.AgentReportAggregate
+	err := r.db.WithContext(ctx).Table("wallet_transactions AS t").
+		Joins("JOIN users u ON u.uid = t.uid").
+		Select("u.invited_by AS invite_code, COUNT(*) AS count, COALESCE(SUM(t.amount), 0) AS amount").
+		Where("t.type = ? AND t.status = ?", transactionType, models.TransactionStatusSuccess).
+		Where("u.invited_by IN ? AND t.created_at >= ? AND t.created_at < ?", inviteCodes, start, end).
+		Group("u.invited_by").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// SumOrders 统计时间范围内各邀请码下用户的下单数量和金额，不含系统订单
+func (r *ReportRepository) SumOrders(ctx context.Context, inviteCodes []string, start, end time.Time) ([]models.AgentReportAggregate, error) {
+	var rows []models.AgentReportAggregate
+	err := r.db.WithContext(ctx).Table("orders AS o").
+		Joins("JOIN users u ON u.uid = o.uid").
+		Select("u.invited_by AS invite_code, COUNT(*) AS count, COALESCE(SUM(o.amount), 0) AS amount").
+		Where("o.is_system_order = ?", false).
+		Where("u.invited_by IN ? AND o.created_at >= ? AND o.created_at < ?", inviteCodes, start, end).
+		Group("u.invited_by").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CountActiveUsers 统计时间范围内各邀请码下有成功登录记录的用户数
+func (r *ReportRepository) CountActiveUsers(ctx context.Context, inviteCodes []string, start, end time.Time) ([]models.AgentReportAggregate, error) {
+	var rows []models.AgentReportAggregate
+	err := r.db.WithContext(ctx).Table("user_login_logs AS l").
+		Joins("JOIN users u ON u.uid = l.uid").
+		Select("u.invited_by AS invite_code, COUNT(DISTINCT l.uid) AS count").
+		Where("l.status = ?", models.LoginStatusSuccess).
+		Where("u.invited_by IN ? AND l.login_time >= ? AND l.login_time < ?", inviteCodes, start, end).
+		Group("u.invited_by").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"gin-fataMorgana/models"
 
@@ -305,6 +306,90 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
 
+// UpdateTwoFactor 更新用户两步验证设置
+func (r *UserRepository) UpdateTwoFactor(ctx context.Context, uid string, enabled bool, secret, recoveryCodes string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("uid = ?", uid).Updates(map[string]interface{}{
+		"two_factor_enabled":        enabled,
+		"two_factor_secret":         secret,
+		"two_factor_recovery_codes": recoveryCodes,
+	}).Error
+}
+
+// ReplaceRecoveryCodes 恢复码仍为oldCodes时替换为newCodes，返回是否替换成功
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, uid, oldCodes, newCodes string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("uid = ? AND two_factor_enabled = ? AND two_factor_recovery_codes = ?", uid, true, oldCodes).
+		Update("two_factor_recovery_codes", newCodes)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateVerifiedContact 设置用户的邮箱或手机号并标记为已验证
+func (r *UserRepository) UpdateVerifiedContact(ctx context.Context, uid string, isEmail bool, contact string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"phone":             contact,
+		"phone_verified":    true,
+		"phone_verified_at": now,
+	}
+	if isEmail {
+		updates = map[string]interface{}{
+			"email":             contact,
+			"email_verified":    true,
+			"email_verified_at": now,
+		}
+	}
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("uid = ?", uid).Updates(updates).Error
+}
+
+// ListPendingUsers 分页查询待审核用户，inviteCodes为nil表示不限制邀请码范围
+func (r *UserRepository) ListPendingUsers(ctx context.Context, inviteCodes []string, inviteCode string, start, end *time.Time, limit, offset int) ([]models.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("status = ? AND deleted_at IS NULL", models.UserStatusPending)
+	if inviteCodes != nil {
+		if len(inviteCodes) == 0 {
+			return []models.User{}, 0, nil
+		}
+		query = query.Where("invited_by IN ?", inviteCodes)
+	}
+	if inviteCode != "" {
+		query = query.Where("invited_by = ?", inviteCode)
+	}
+	if start != nil {
+		query = query.Where("created_at >= ?", *start)
+	}
+	if end != nil {
+		query = query.Where("created_at < ?", *end)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := query.Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+	return users, total, err
+}
+
+// UpdatePendingStatus 将待审核用户更新为指定状态，用户已不是待审核状态时返回false
+func (r *UserRepository) UpdatePendingStatus(ctx context.Context, uid string, status int) (bool, error) {
+	return r.UpdatePendingStatusInTx(r.db.WithContext(ctx), uid, status)
+}
+
+// UpdatePendingStatusInTx 在指定事务中将待审核用户更新为指定状态，用户已不是待审核状态时返回false
+func (r *UserRepository) UpdatePendingStatusInTx(tx *gorm.DB, uid string, status int) (bool, error) {
+	result := tx.Model(&models.User{}).
+		Where("uid = ? AND status = ?", uid, models.UserStatusPending).
+		Update("status", status)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // FindByPhone 根据手机号查找用户
 func (r *UserRepository) FindByPhone(ctx context.Context, phone string) (*models.User, error) {
 	var user models.User
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScoreEventCandidate 待计算经验值与信用分的事件，ID为来源表的主键，用于分批扫描
+type ScoreEventCandidate struct {
+	ID    uint64
+	Uid   string
+	RefID string
+}
+
+// ScoreHistoryFilter 变更记录查询条件
+type ScoreHistoryFilter struct {
+	Uid   string
+	Event string
+	Start *time.Time
+	End   *time.Time
+}
+
+// ScoreRepository 经验值与信用分Repository，包含规则和变更记录
+type ScoreRepository struct {
+	db *gorm.DB
+}
+
+// NewScoreRepository 创建经验值与信用分Repository实例
+func NewScoreRepository() *ScoreRepository {
+	return &ScoreRepository{
+		db: DB,
+	}
+}
+
+// ListRules 获取全部规则
+func (r *ScoreRepository) ListRules(ctx context.Context) ([]models.ScoreRule, error) {
+	var rules []models.ScoreRule
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// FindRuleByEvent 根据事件类型获取规则
+func (r *ScoreRepository) FindRuleByEvent(ctx context.Context, event string) (*models.ScoreRule, error) {
+	var rule models.ScoreRule
+	if err := r.db.WithContext(ctx).Where("event = ?", event).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule 更新规则
+func (r *ScoreRepository) UpdateRule(ctx context.Context, rule *models.ScoreRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+// ApplyChange 在同一事务内锁定用户、写入变更记录并更新用户的经验值和信用分
+// 经验值不低于0，信用分限制在[minCredit, maxCredit]内，history中的变化量和变化后的值按实际结果回填
+// 同一用户、事件和关联单号已有记录时不做任何修改，返回false
+func (r *ScoreRepository) ApplyChange(ctx context.Context, history *models.UserScoreHistory, minCredit, maxCredit int) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "uid", "experience", "credit_score").
+			Where("uid = ?", history.Uid).
+			First(&user).Error; err != nil {
+			return err
+		}
+
+		experience := user.Experience + history.ExperienceChange
+		if experience < 0 {
+			experience = 0
+		}
+		credit := user.CreditScore + history.CreditScoreChange
+		if credit < minCredit {
+			credit = minCredit
+		}
+		if credit > maxCredit {
+			credit = maxCredit
+		}
+
+		history.ExperienceChange = experience - user.Experience
+		history.CreditScoreChange = credit - user.CreditScore
+		history.ExperienceAfter = experience
+		history.CreditScoreAfter = credit
+
+		if err := tx.Create(history).Error; err != nil {
+			// 唯一索引冲突说明该事件已经计算过
+			if strings.Contains(err.Error(), "Duplicate entry") {
+				return nil
+			}
+			return err
+		}
+		applied = true
+
+		if history.ExperienceChange == 0 && history.CreditScoreChange == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"experience":   experience,
+				"credit_score": credit,
+			}).Error
+	})
+	return applied, err
+}
+
+// ListOrderCandidates 获取指定时间后变为指定状态、尚未计算该事件的用户订单
+func (r *ScoreRepository) ListOrderCandidates(ctx context.Context, event, status string, since time.Time, afterID uint64, limit int) ([]ScoreEventCandidate, error) {
+	var candidates []ScoreEventCandidate
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Select("id, uid, order_no AS ref_id").
+		Where("status = ? AND is_system_order = ? AND updated_at >= ? AND id > ?", status, false, since, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM user_score_histories h WHERE h.uid = orders.uid AND h.event = ? AND h.ref_id = orders.order_no)", event).
+		Order("id ASC").
+		Limit(limit).
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+// ListGroupBuyCandidates 获取指定时间后成团、尚未计算该事件的拼单参与记录，关联单号为拼单编号
+func (r *ScoreRepository) ListGroupBuyCandidates(ctx context.Context, event string, since time.Time, afterID uint64, limit int) ([]ScoreEventCandidate, error) {
+	var candidates []ScoreEventCandidate
+	err := r.db.WithContext(ctx).Model(&models.GroupBuyParticipant{}).
+		Select("id, uid, group_buy_no AS ref_id").
+		Where("status = ? AND updated_at >= ? AND id > ?", models.GroupBuyParticipantStatusSuccess, since, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM user_score_histories h WHERE h.uid = group_buy_participants.uid AND h.event = ? AND h.ref_id = group_buy_participants.group_buy_no)", event).
+		Order("id ASC").
+		Limit(limit).
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+// ListWithdrawCandidates 获取指定时间后取消、尚未计算该事件的提现流水，关联单号为交易流水号
+func (r *ScoreRepository) ListWithdrawCandidates(ctx context.Context, event string, since time.Time, afterID uint64, limit int) ([]ScoreEventCandidate, error) {
+	var candidates []ScoreEventCandidate
+	err := r.db.WithContext(ctx).Model(&models.WalletTransaction{}).
+		Select("id, uid, transaction_no AS ref_id").
+		Where("type = ? AND status = ? AND updated_at >= ? AND id > ?",
+			models.TransactionTypeWithdraw, models.TransactionStatusCancelled, since, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM user_score_histories h WHERE h.uid = wallet_transactions.uid AND h.event = ? AND h.ref_id = wallet_transactions.transaction_no)", event).
+		Order("id ASC").
+		Limit(limit).
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+// QueryHistories 分页查询变更记录，按ID倒序
+func (r *ScoreRepository) QueryHistories(ctx context.Context, filter *ScoreHistoryFilter, limit, offset int) ([]models.UserScoreHistory, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.UserScoreHistory{})
+	if filter.Uid != "" {
+		query = query.Where("uid = ?", filter.Uid)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if filter.Start != nil {
+		query = query.Where("created_at >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("created_at <= ?", *filter.End)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var histories []models.UserScoreHistory
+	err := query.Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&histories).Error
+	return histories, total, err
+}
@@ -3,6 +3,11 @@ package database
 import (
 	"context"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // WalletRepository 钱包仓库
@@ -168,7 +173,7 @@ func (r *WalletRepository) GetTransactionsByUid(ctx context.Context, uid string,
 	offset := (page - 1) * pageSize
 
 	// 获取分页数据
-	err = query.Order("created_at DESC").
+	err = query.Order("created_at DESC, id DESC").
 		Offset(offset).
 		Limit(pageSize).
 		Find(&transactions).Error
@@ -180,6 +185,23 @@ func (r *WalletRepository) GetTransactionsByUid(ctx context.Context, uid string,
 	return transactions, total, nil
 }
 
+// GetTransactionsByUidCursor 根据UID游标分页获取用户交易记录（不统计总数）
+func (r *WalletRepository) GetTransactionsByUidCursor(ctx context.Context, uid string, cursor *utils.Cursor, pageSize int, transactionType string) ([]models.WalletTransaction, bool, error) {
+	var transactions []models.WalletTransaction
+
+	qb := utils.NewQueryBuilder(r.db.WithContext(ctx).Model(&models.WalletTransaction{})).Where("uid = ?", uid)
+	if transactionType != "" {
+		qb.Where("type = ?", transactionType)
+	}
+
+	if err := qb.CursorPaginate(cursor, pageSize).Find(&transactions); err != nil {
+		return nil, false, err
+	}
+
+	n, hasNext := utils.TrimCursorPage(len(transactions), pageSize)
+	return transactions[:n], hasNext, nil
+}
+
 // GetWithdrawSummary 获取提现汇总信息
 func (r *WalletRepository) GetWithdrawSummary(ctx context.Context, uid string) (*models.WithdrawSummary, error) {
 	var summary models.WithdrawSummary
@@ -222,3 +244,63 @@ func (r *WalletRepository) GetWithdrawSummary(ctx context.Context, uid string) (
 
 	return &summary, nil
 }
+
+// ListStatusExpiredWallets 获取冻结或限制提现已到期的钱包
+func (r *WalletRepository) ListStatusExpiredWallets(ctx context.Context, now time.Time, limit int) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	err := r.db.WithContext(ctx).
+		Where("status <> ? AND status_expires_at IS NOT NULL AND status_expires_at <= ?", models.WalletStatusNormal, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&wallets).Error
+	return wallets, err
+}
+
+// ListWalletsByUids 批量获取用户钱包
+func (r *WalletRepository) ListWalletsByUids(ctx context.Context, uids []string) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	if len(uids) == 0 {
+		return wallets, nil
+	}
+	err := r.db.WithContext(ctx).Where("uid IN ?", uids).Find(&wallets).Error
+	return wallets, err
+}
+
+// lockWalletInTx 在事务内锁定用户钱包，供需要与余额变更一起提交关联记录的Repository使用
+func lockWalletInTx(tx *gorm.DB, uid string) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uid = ?", uid).
+		First(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// creditWalletInTx 在事务内增加已锁定钱包的余额，回填交易流水的变动前后余额并写入流水
+func creditWalletInTx(tx *gorm.DB, wallet *models.Wallet, amount float64, transaction *models.WalletTransaction) error {
+	transaction.BalanceBefore = wallet.Balance
+	wallet.Recharge(amount)
+	wallet.UpdatedAt = time.Now()
+	transaction.BalanceAfter = wallet.Balance
+
+	if err := tx.Save(wallet).Error; err != nil {
+		return err
+	}
+	return tx.Create(transaction).Error
+}
+
+// debitWalletInTx 在事务内扣减已锁定钱包的余额，回填交易流水的变动前后余额并写入流水
+func debitWalletInTx(tx *gorm.DB, wallet *models.Wallet, amount float64, transaction *models.WalletTransaction) error {
+	transaction.BalanceBefore = wallet.Balance
+	if err := wallet.Withdraw(amount); err != nil {
+		return err
+	}
+	wallet.UpdatedAt = time.Now()
+	transaction.BalanceAfter = wallet.Balance
+
+	if err := tx.Save(wallet).Error; err != nil {
+		return err
+	}
+	return tx.Create(transaction).Error
+}
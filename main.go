@@ -34,6 +34,7 @@ import (
 	"gin-fataMorgana/controllers"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/middleware"
+	"gin-fataMorgana/models"
 	"gin-fataMorgana/services"
 	"gin-fataMorgana/utils"
 
@@ -70,7 +71,11 @@ func main() {
 	}
 
 	// 初始化JWT
-	utils.InitJWT(config.GlobalConfig.JWT.Secret, config.GlobalConfig.JWT.AccessTokenExpire, config.GlobalConfig.JWT.RefreshTokenExpire)
+	utils.InitJWT(config.GlobalConfig.JWT.Secret, config.GlobalConfig.JWT.AccessTokenExpire, config.GlobalConfig.JWT.RefreshTokenExpire, config.GlobalConfig.JWT.AdminTokenExpire)
+	if err := utils.InitJWTKeys(config.GlobalConfig.JWT.Algorithm, config.GlobalConfig.JWT.JWTKeySpecs(), config.GlobalConfig.JWT.AcceptLegacyHS256); err != nil {
+		log.Printf("初始化JWT签名密钥失败: %v", err)
+		os.Exit(1)
+	}
 
 	// 输出JWT配置信息
 	log.Printf("🔐 JWT配置: AccessToken过期时间=%d秒(%.1f小时), RefreshToken过期时间=%d秒(%.1f天)",
@@ -114,6 +119,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 将旧版保存在Redis中的等级规则导入会员等级表和用户等级覆盖，只执行一次
+	if imported, err := services.NewMemberLevelService().MigrateLegacyLevelConfigs(context.Background()); err != nil {
+		log.Printf("⚠️  迁移旧版用户等级配置失败，下次启动重试: %v", err)
+	} else if imported > 0 {
+		log.Printf("✅ 已将%d个旧版用户等级配置导入为用户等级覆盖", imported)
+	}
+
 	// 初始化定时任务控制器
 	cronController := controllers.NewCronController()
 
@@ -145,6 +157,13 @@ func main() {
 			TaskMinCount:        config.GlobalConfig.FakeData.TaskMinCount,
 			TaskMaxCount:        config.GlobalConfig.FakeData.TaskMaxCount,
 			RetentionDays:       config.GlobalConfig.FakeData.RetentionDays,
+
+			// 业务定时任务表达式
+			GroupBuyExpireExpr:   config.GlobalConfig.GroupBuy.ExpireCron,
+			ScoreEventExpr:       config.GlobalConfig.Score.EventCron,
+			CommissionExpr:       config.GlobalConfig.Referral.CommissionCron,
+			WalletStatusExpr:     config.GlobalConfig.Wallet.StatusExpireCron,
+			MessageBroadcastExpr: config.GlobalConfig.Message.BroadcastCron,
 		}
 
 		// 创建并启动定时任务服务
@@ -259,6 +278,19 @@ func main() {
 	shareController := controllers.NewShareController()
 	currencyController := controllers.NewCurrencyController()
 	messageController := controllers.NewMessageController()
+	twoFactorController := controllers.NewTwoFactorController()
+	loginLockoutController := controllers.NewLoginLockoutController()
+	adminUserController := controllers.NewAdminUserController()
+	jwksController := controllers.NewJWKSController()
+	userReviewController := controllers.NewUserReviewController()
+	adminAuditController := controllers.NewAdminAuditController()
+	memberLevelController := controllers.NewMemberLevelController()
+	scoreController := controllers.NewScoreController()
+	referralController := controllers.NewReferralController()
+	agentReportController := controllers.NewAgentReportController()
+	walletStatusController := controllers.NewWalletStatusController()
+	messageBroadcastController := controllers.NewMessageBroadcastController()
+	eventController := controllers.NewEventController()
 
 	// 根路径
 	r.GET("/", func(c *gin.Context) {
@@ -275,6 +307,9 @@ func main() {
 	// 健康检查
 	r.GET("/health", healthController.HealthCheck)
 
+	// 令牌验证公钥，供其他服务验证令牌
+	r.GET("/.well-known/jwks.json", jwksController.GetJWKS)
+
 	// API路由组
 	api := r.Group("/api")
 
@@ -287,21 +322,42 @@ func main() {
 	v2.GET("/health/redis", healthController.RedisHealth)
 
 	// 认证相关接口
-	v2.POST("/auth/register", authController.Register)                                               // 注册接口（已移除频率限制）
-	v2.POST("/auth/login", authController.Login)                                                     // 登录接口（已移除频率限制）
-	v2.POST("/auth/logout", middleware.AuthMiddleware(), authController.Logout)                      // 用户登出 - 撤销当前token
-	v2.POST("/auth/profile", middleware.AuthMiddleware(), authController.GetProfile)                 // 获取用户信息 - 获取当前用户完整资料
-	v2.POST("/auth/change-password", middleware.AuthMiddleware(), authController.ChangePassword)     // 修改密码
-	v2.POST("/auth/bind-bank-card", middleware.AuthMiddleware(), authController.BindBankCard)        // 绑定银行卡
-	v2.POST("/auth/get-bank-card-info", middleware.AuthMiddleware(), authController.GetBankCardInfo) // 获取银行卡信息
+	v2.POST("/auth/register", authController.Register)                                                        // 注册接口（已移除频率限制）
+	v2.POST("/auth/login", authController.Login)                                                              // 登录接口（已移除频率限制）
+	v2.POST("/auth/login/2fa", authController.LoginTwoFactor)                                                 // 两步验证登录 - 提交动态验证码完成登录
+	v2.POST("/auth/refresh", authController.RefreshToken)                                                     // 刷新令牌 - 轮换访问令牌和刷新令牌，重复使用时撤销整个会话
+	v2.POST("/auth/forgot-password", authController.ForgotPassword)                                           // 找回密码 - 发送邮箱或短信验证码
+	v2.POST("/auth/reset-password", authController.ResetPassword)                                             // 重置密码 - 校验验证码并登出全部会话
+	v2.POST("/auth/verify-contact/send", authController.SendContactCode)                                      // 发送账号验证码 - 验证注册使用的邮箱或手机号
+	v2.POST("/auth/verify-contact/confirm", authController.ConfirmContact)                                    // 验证账号 - 标记邮箱或手机号为已验证
+	v2.POST("/auth/logout", middleware.AuthMiddleware(), authController.Logout)                               // 用户登出 - 撤销当前token
+	v2.POST("/auth/profile", middleware.AuthMiddleware(), authController.GetProfile)                          // 获取用户信息 - 获取当前用户完整资料
+	v2.POST("/auth/change-password", middleware.AuthMiddleware(), authController.ChangePassword)              // 修改密码
+	v2.POST("/auth/bind-bank-card", middleware.AuthMiddleware(), authController.BindBankCard)                 // 绑定银行卡
+	v2.POST("/auth/get-bank-card-info", middleware.AuthMiddleware(), authController.GetBankCardInfo)          // 获取银行卡信息
+	v2.POST("/auth/change-contact/send", middleware.AuthMiddleware(), authController.SendChangeContactCode)   // 更换邮箱或手机号 - 向新联系方式发送验证码
+	v2.POST("/auth/change-contact/confirm", middleware.AuthMiddleware(), authController.ConfirmChangeContact) // 更换邮箱或手机号 - 校验验证码完成更换
+
+	// 两步验证路由
+	twoFactor := v2.Group("/2fa")
+	{
+		twoFactor.Use(middleware.AuthMiddleware())                                     // 需要认证
+		twoFactor.POST("/status", twoFactorController.GetStatus)                       // 两步验证状态 - 是否开启及剩余恢复码
+		twoFactor.POST("/setup", twoFactorController.Setup)                            // 开始绑定 - 返回密钥和otpauth二维码链接
+		twoFactor.POST("/enable", twoFactorController.Enable)                          // 开启两步验证 - 校验动态验证码并返回恢复码
+		twoFactor.POST("/disable", twoFactorController.Disable)                        // 关闭两步验证 - 需要动态验证码或恢复码
+		twoFactor.POST("/recovery-codes", twoFactorController.RegenerateRecoveryCodes) // 重新生成恢复码
+	}
 
 	// 会话管理路由
 	session := v2.Group("/session")
 	{
-		session.POST("/status", sessionController.CheckLoginStatus) // 检查登录状态 - 验证用户是否已登录
-		session.POST("/user", sessionController.GetCurrentUserInfo) // 获取当前用户信息 - 获取会话中的用户信息
-		session.POST("/logout", sessionController.Logout)           // 用户登出 - 清除用户会话
-		session.POST("/refresh", sessionController.RefreshSession)  // 刷新会话 - 延长会话有效期
+		session.POST("/status", sessionController.CheckLoginStatus)                           // 检查登录状态 - 验证用户是否已登录
+		session.POST("/user", sessionController.GetCurrentUserInfo)                           // 获取当前用户信息 - 获取会话中的用户信息
+		session.POST("/logout", sessionController.Logout)                                     // 用户登出 - 清除用户会话
+		session.POST("/refresh", sessionController.RefreshSession)                            // 刷新会话 - 延长会话有效期
+		session.POST("/list", middleware.AuthMiddleware(), sessionController.ListSessions)    // 会话列表 - 查看已登录的设备
+		session.POST("/revoke", middleware.AuthMiddleware(), sessionController.RevokeSession) // 登出会话 - 登出指定设备
 	}
 
 	// 钱包相关路由
@@ -329,11 +385,64 @@ func main() {
 		order.POST("/period", orderController.GetPeriodList)     // 获取期数列表 - 获取当前活跃期数和价格配置
 	}
 
+	// 管理员登录路由（无需认证）
+	adminPublic := v2.Group("/admin/auth")
+	{
+		adminPublic.POST("/login", adminUserController.Login) // 管理员登录 - 用户名密码登录，开启两步验证时需提供动态验证码
+	}
+
 	// 管理员路由
 	admin := v2.Group("/admin")
 	{
-		admin.Use(middleware.AuthMiddleware()) // 需要认证
-		// 这里可以添加管理员相关的路由
+		admin.Use(middleware.AdminAuthMiddleware())                                                                                                  // 需要管理员认证，每个接口单独声明所需权限
+		admin.POST("/auth/logout", adminUserController.Logout)                                                                                       // 管理员登出 - 当前令牌加入黑名单
+		admin.POST("/auth/profile", adminUserController.Profile)                                                                                     // 获取当前管理员信息
+		admin.POST("/auth/change-password", adminUserController.ChangePassword)                                                                      // 修改密码 - 成功后此前签发的令牌全部失效
+		admin.POST("/auth/2fa/setup", adminUserController.TwoFactorSetup)                                                                            // 开始绑定两步验证 - 角色被强制要求时需先完成绑定
+		admin.POST("/auth/2fa/enable", adminUserController.TwoFactorEnable)                                                                          // 开启两步验证 - 校验验证码后正式开启
+		admin.POST("/login-lock/unlock", middleware.RequirePermission(models.PermLoginUnlock), loginLockoutController.Unlock)                        // 解除登录锁定 - 按账号或IP解除锁定
+		admin.POST("/admins/list", middleware.RequirePermission(models.PermAdminView), adminUserController.List)                                     // 下级管理员列表 - 只包含自己下级树中角色更低的管理员
+		admin.POST("/admins/detail", middleware.RequirePermission(models.PermAdminView), adminUserController.Detail)                                 // 下级管理员详情
+		admin.POST("/admins/create", middleware.RequirePermission(models.PermAdminManage), adminUserController.Create)                               // 创建下级管理员 - 只能创建角色低于自己的管理员
+		admin.POST("/admins/update", middleware.RequirePermission(models.PermAdminManage), adminUserController.Update)                               // 修改下级管理员 - 角色、上级、备注、头像
+		admin.POST("/admins/disable", middleware.RequirePermission(models.PermAdminManage), adminUserController.Disable)                             // 禁用下级管理员 - 立即生效
+		admin.POST("/admins/enable", middleware.RequirePermission(models.PermAdminManage), adminUserController.Enable)                               // 启用下级管理员
+		admin.POST("/admins/reset-password", middleware.RequirePermission(models.PermAdminManage), adminUserController.ResetPassword)                // 重置下级管理员密码 - 其令牌全部失效
+		admin.POST("/admins/reset-2fa", middleware.RequirePermission(models.PermAdminManage), adminUserController.ResetTwoFactor)                    // 重置下级管理员两步验证 - 用于丢失验证器
+		admin.POST("/users/pending", middleware.RequirePermission(models.PermUserReview), userReviewController.ListPending)                          // 待审核用户列表 - 按邀请码和注册日期筛选
+		admin.POST("/users/approve", middleware.RequirePermission(models.PermUserReview), userReviewController.Approve)                              // 审核通过 - 支持批量，通知用户并记录审计日志
+		admin.POST("/users/reject", middleware.RequirePermission(models.PermUserReview), userReviewController.Reject)                                // 审核拒绝 - 支持批量，需填写原因
+		admin.POST("/audit-logs/list", middleware.RequirePermission(models.PermAuditView), adminAuditController.Query)                               // 审计日志查询 - 按操作人、操作、对象和时间筛选
+		admin.POST("/audit-logs/verify", middleware.RequirePermission(models.PermAuditView), adminAuditController.Verify)                            // 审计日志校验 - 校验哈希链是否完整
+		admin.POST("/amount-config/list", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminList)             // 金额配置列表 - 包含未激活的配置
+		admin.POST("/amount-config/create", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminCreate)         // 创建金额配置 - 同类型金额不能重复，提现金额受限额约束
+		admin.POST("/amount-config/update", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminUpdate)         // 修改金额配置 - 金额、描述、排序
+		admin.POST("/amount-config/delete", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminDelete)         // 删除金额配置
+		admin.POST("/amount-config/activate", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminActivate)     // 激活金额配置 - 用户端可见
+		admin.POST("/amount-config/deactivate", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminDeactivate) // 停用金额配置 - 用户端不再显示
+		admin.POST("/amount-config/reorder", middleware.RequirePermission(models.PermAmountConfigManage), amountConfigController.AdminReorder)       // 调整金额配置排序 - 按传入ID顺序设置sort_order
+		admin.POST("/member-levels/list", middleware.RequirePermission(models.PermMemberLevelManage), memberLevelController.List)                    // 会员等级列表
+		admin.POST("/member-levels/create", middleware.RequirePermission(models.PermMemberLevelManage), memberLevelController.Create)                // 创建会员等级 - 升级要求须随等级递增
+		admin.POST("/member-levels/update", middleware.RequirePermission(models.PermMemberLevelManage), memberLevelController.Update)                // 修改会员等级 - 等级数值不可修改
+		admin.POST("/member-levels/delete", middleware.RequirePermission(models.PermMemberLevelManage), memberLevelController.Delete)                // 删除会员等级 - 仍有用户固定在该等级时不允许删除
+		admin.POST("/member-levels/override/set", middleware.RequirePermission(models.PermMemberLevelManage), memberLevelController.SetOverride)     // 固定用户等级 - 不再按升级要求计算
+		admin.POST("/member-levels/override/clear", middleware.RequirePermission(models.PermMemberLevelManage), memberLevelController.ClearOverride) // 清除用户固定等级
+		admin.POST("/score/rules/list", middleware.RequirePermission(models.PermScoreManage), scoreController.ListRules)                             // 经验值与信用分规则列表
+		admin.POST("/score/rules/update", middleware.RequirePermission(models.PermScoreManage), scoreController.UpdateRule)                          // 修改经验值与信用分规则 - 只影响之后发生的事件
+		admin.POST("/score/adjust", middleware.RequirePermission(models.PermScoreManage), scoreController.Adjust)                                    // 手动调整用户经验值和信用分
+		admin.POST("/score/history", middleware.RequirePermission(models.PermScoreManage), scoreController.History)                                  // 经验值与信用分变更记录
+		admin.POST("/referral/rules/list", middleware.RequirePermission(models.PermReferralManage), referralController.ListCommissionRules)          // 推广佣金规则列表
+		admin.POST("/referral/rules/save", middleware.RequirePermission(models.PermReferralManage), referralController.SaveCommissionRule)           // 保存推广佣金规则 - 按推荐层级覆盖
+		admin.POST("/referral/rules/delete", middleware.RequirePermission(models.PermReferralManage), referralController.DeleteCommissionRule)       // 删除推广佣金规则
+		admin.POST("/reports/agents", middleware.RequirePermission(models.PermReportView), agentReportController.AgentReport)                        // 代理业绩报表 - 按日期范围统计个人和团队业绩
+		admin.POST("/reports/agents/export", middleware.RequirePermission(models.PermReportView), agentReportController.ExportAgentReport)           // 导出代理业绩报表 - CSV文件
+		admin.POST("/wallets/freeze", middleware.RequirePermission(models.PermWalletManage), walletStatusController.Freeze)                          // 冻结钱包 - 需填写原因，可设置到期自动解除
+		admin.POST("/wallets/block-withdraw", middleware.RequirePermission(models.PermWalletManage), walletStatusController.BlockWithdraw)           // 限制钱包提现 - 需填写原因，可设置到期自动解除
+		admin.POST("/wallets/unfreeze", middleware.RequirePermission(models.PermWalletManage), walletStatusController.Unfreeze)                      // 恢复钱包正常状态 - 解除冻结或提现限制
+		admin.POST("/messages/send", middleware.RequirePermission(models.PermMessageSend), messageBroadcastController.Send)                          // 发送消息 - 单个用户、用户列表、全部用户或用户分群，可定时发送
+		admin.POST("/messages/list", middleware.RequirePermission(models.PermMessageSend), messageBroadcastController.List)                          // 群发消息列表 - 包含发送进度
+		admin.POST("/messages/detail", middleware.RequirePermission(models.PermMessageSend), messageBroadcastController.Detail)                      // 群发消息详情 - 包含发送进度
+		admin.POST("/messages/cancel", middleware.RequirePermission(models.PermMessageSend), messageBroadcastController.Cancel)                      // 取消群发消息 - 已投递的用户仍会收到
 	}
 
 	// 假数据路由
@@ -368,7 +477,12 @@ func main() {
 	{
 		groupBuy.Use(middleware.AuthMiddleware())                                   // 需要认证
 		groupBuy.POST("/active-detail", groupBuyController.GetActiveGroupBuyDetail) // 获取活跃拼单详情 - 获取当前可参与的拼单信息
-		groupBuy.POST("/join", groupBuyController.JoinGroupBuy)                     // 参与拼单 - 用户参与拼单活动
+		groupBuy.POST("/join", groupBuyController.JoinGroupBuy)                     // 参与拼单 - 用户参与拼单活动（支持邀请码）
+		groupBuy.POST("/templates", groupBuyController.GetGroupBuyTemplates)        // 拼单模板 - 发起拼单可选模板
+		groupBuy.POST("/create", groupBuyController.CreateGroupBuy)                 // 发起拼单 - 返回邀请码和邀请链接
+		groupBuy.POST("/progress", groupBuyController.GetGroupBuyProgress)          // 拼单进度 - 发起人和参与者查看实时进度
+		groupBuy.POST("/cancel", groupBuyController.CancelGroupBuy)                 // 取消拼单 - 发起人在他人付款前取消
+		groupBuy.POST("/history", groupBuyController.GetGroupBuyHistory)            // 拼单历史 - 发起或参与过的拼单，支持状态和类型筛选
 	}
 
 	// 分享链接接口 - 获取分享链接
@@ -383,18 +497,45 @@ func main() {
 	// 消息推送路由
 	message := v2.Group("/message")
 	{
-		message.Use(middleware.AuthMiddleware())               // 需要认证
-		message.POST("/pop", messageController.GetUserMessage) // 获取用户消息推送
+		message.Use(middleware.AuthMiddleware())                        // 需要认证
+		message.POST("/pop", messageController.GetUserMessage)          // 获取最早的一条未读消息并标记已读
+		message.POST("/list", messageController.ListUserMessages)       // 获取用户收件箱（游标分页）
+		message.POST("/unread-count", messageController.GetUnreadCount) // 获取未读消息数
+		message.POST("/read", messageController.MarkRead)               // 批量标记已读
+		message.POST("/unread", messageController.MarkUnread)           // 批量标记未读
+		message.POST("/read-all", messageController.MarkAllRead)        // 全部标记已读
+		message.POST("/delete", messageController.DeleteMessages)       // 批量删除消息
+	}
+
+	// 实时推送路由
+	events := v2.Group("/events")
+	{
+		events.POST("/stream-token", middleware.AuthMiddleware(), eventController.StreamToken) // 获取一次性连接令牌，供无法设置请求头的EventSource使用
+		events.GET("/stream", middleware.EventStreamAuthMiddleware(), eventController.Stream)  // SSE推送新消息、余额、订单和拼单进度，支持Last-Event-ID续传
+	}
+
+	// 用户推荐路由
+	referral := v2.Group("/referral")
+	{
+		referral.Use(middleware.AuthMiddleware())                           // 需要认证
+		referral.POST("/my-code", referralController.GetMyInviteCode)       // 我的邀请码 - 其他用户注册时填写即成为下级
+		referral.POST("/list", referralController.ListMyReferrals)          // 我的下级 - 支持按层级筛选
+		referral.POST("/commissions", referralController.ListMyCommissions) // 我的佣金 - 佣金记录和已入账总额
 	}
 
 	// 定时任务管理路由
 	cron := v2.Group("/cron")
 	{
-		cron.Use(middleware.AuthMiddleware())                                               // 需要认证
-		cron.POST("/manual-generate", cronController.ManualGenerateOrders)                  // 手动生成订单
-		cron.POST("/manual-cleanup", cronController.ManualCleanup)                          // 手动清理数据
-		cron.POST("/update-leaderboard-cache", cronController.ManualUpdateLeaderboardCache) // 手动更新热榜缓存
-		cron.GET("/status", cronController.GetCronStatus)                                   // 获取定时任务状态
+		cron.Use(middleware.AdminAuthMiddleware())                                                                                                         // 需要管理员认证，每个接口单独声明所需权限
+		cron.POST("/manual-generate", middleware.RequirePermission(models.PermCronGenerateOrders), cronController.ManualGenerateOrders)                    // 手动生成订单
+		cron.POST("/manual-cleanup", middleware.RequirePermission(models.PermCronCleanup), cronController.ManualCleanup)                                   // 手动清理数据
+		cron.POST("/update-leaderboard-cache", middleware.RequirePermission(models.PermCronLeaderboardCache), cronController.ManualUpdateLeaderboardCache) // 手动更新热榜缓存
+		cron.GET("/status", middleware.RequirePermission(models.PermCronView), cronController.GetCronStatus)                                               // 获取定时任务状态
+		cron.POST("/group-buy-expire", middleware.RequirePermission(models.PermCronGroupBuyExpire), cronController.ManualProcessExpiredGroupBuys)          // 手动处理过期拼单退款
+		cron.POST("/score-events", middleware.RequirePermission(models.PermCronScoreEvents), cronController.ManualProcessScoreEvents)                      // 手动扫描经验值与信用分事件
+		cron.POST("/commission", middleware.RequirePermission(models.PermCronCommission), cronController.ManualProcessCommissions)                         // 手动发放推广佣金
+		cron.POST("/wallet-status", middleware.RequirePermission(models.PermCronWalletStatus), cronController.ManualProcessWalletStatusExpire)             // 手动恢复到期的钱包冻结和提现限制
+		cron.POST("/message-broadcast", middleware.RequirePermission(models.PermCronMessageBroadcast), cronController.ManualProcessMessageBroadcasts)      // 手动发送到期的群发消息
 	}
 
 	// 启动服务器
@@ -408,6 +549,8 @@ func main() {
 		Addr:    ":" + port,
 		Handler: r,
 	}
+	// 关闭时先断开实时推送长连接，避免等待超时
+	srv.RegisterOnShutdown(services.CloseUserEventHub)
 
 	// 优雅关闭服务器
 	go func() {
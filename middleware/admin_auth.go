@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/services"
+	"gin-fataMorgana/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware 管理员认证中间件，只接受受众为admin的令牌
+// 每次请求都重新读取管理员信息，禁用账户或调整角色后立即生效
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		tokenParts := strings.Split(authHeader, " ")
+		if authHeader == "" || len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+			abortAdminUnauthorized(c, "缺少或错误的管理员认证令牌", "MISSING_TOKEN")
+			return
+		}
+		tokenString := tokenParts[1]
+
+		claims, err := utils.ValidateAdminToken(tokenString)
+		if err != nil {
+			if appErr, ok := err.(*utils.AppError); ok && appErr.Code == utils.CodeTokenExpired {
+				abortAdminUnauthorized(c, "令牌已过期，请重新登录", "TOKEN_EXPIRED")
+				return
+			}
+			abortAdminUnauthorized(c, "无效的认证令牌", "INVALID_TOKEN")
+			return
+		}
+
+		ctx := c.Request.Context()
+		tokenService := services.NewTokenService()
+		blacklisted, err := tokenService.IsTokenBlacklisted(ctx, tokenString)
+		if err != nil || blacklisted {
+			abortAdminUnauthorized(c, "令牌已失效，请重新登录", "TOKEN_REVOKED")
+			return
+		}
+		// 修改或重置密码后，此前签发的令牌全部失效
+		revoked, err := tokenService.IsAdminTokenRevoked(ctx, claims)
+		if err != nil || revoked {
+			abortAdminUnauthorized(c, "令牌已失效，请重新登录", "TOKEN_REVOKED")
+			return
+		}
+
+		admin, err := database.NewAdminUserRepository().GetByID(ctx, claims.AdminID)
+		if err != nil {
+			abortAdminUnauthorized(c, "管理员不存在", "ADMIN_NOT_FOUND")
+			return
+		}
+		if !admin.IsActive() {
+			abortAdminUnauthorized(c, "管理员账户已被禁用", "ADMIN_DISABLED")
+			return
+		}
+
+		// 设置管理员信息到上下文
+		c.Set("admin", admin)
+		c.Set("admin_id", admin.ID)
+		c.Set("admin_role", admin.Role)
+		c.Set("admin_claims", claims)
+		c.Set("admin_token", tokenString)
+		c.Set("is_admin_authenticated", true)
+
+		// 操作人写入请求上下文，服务层记录审计日志时使用
+		c.Request = c.Request.WithContext(utils.WithAuditActor(ctx, utils.AuditActor{
+			AdminID:   admin.ID,
+			Username:  admin.Username,
+			IP:        c.ClientIP(),
+			RequestID: utils.GetRequestID(c),
+		}))
+
+		c.Next()
+	}
+}
+
+// RequirePermission 权限校验中间件，需在AdminAuthMiddleware之后使用
+// 角色被强制要求两步验证但尚未绑定的管理员，绑定前无法使用任何需要权限的接口
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := GetCurrentAdmin(c)
+		if admin == nil {
+			abortAdminUnauthorized(c, "需要管理员登录", "AUTH_REQUIRED")
+			return
+		}
+
+		if services.NewTwoFactorService().IsSetupRequiredForAdmin(admin) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":      utils.CodeTwoFactorRequired,
+				"message":   "请先绑定两步验证",
+				"error":     "TWO_FACTOR_SETUP_REQUIRED",
+				"timestamp": time.Now().Unix(),
+			})
+			c.Abort()
+			return
+		}
+
+		if !admin.HasPermission(permission) {
+			utils.LogWarn(c, "管理员权限不足 - 管理员: %d, 角色: %d, 权限: %s", admin.ID, admin.Role, permission)
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":       utils.CodeForbidden,
+				"message":    "权限不足",
+				"error":      "PERMISSION_DENIED",
+				"permission": permission,
+				"timestamp":  time.Now().Unix(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetCurrentAdmin 获取当前管理员
+func GetCurrentAdmin(c *gin.Context) *models.AdminUser {
+	admin, exists := c.Get("admin")
+	if !exists {
+		return nil
+	}
+	if a, ok := admin.(*models.AdminUser); ok {
+		return a
+	}
+	return nil
+}
+
+// GetCurrentAdminID 获取当前管理员ID
+func GetCurrentAdminID(c *gin.Context) uint {
+	adminID, exists := c.Get("admin_id")
+	if !exists {
+		return 0
+	}
+	if id, ok := adminID.(uint); ok {
+		return id
+	}
+	return 0
+}
+
+// GetCurrentAdminClaims 获取当前管理员令牌声明
+func GetCurrentAdminClaims(c *gin.Context) *utils.AdminClaims {
+	claims, exists := c.Get("admin_claims")
+	if !exists {
+		return nil
+	}
+	if cl, ok := claims.(*utils.AdminClaims); ok {
+		return cl
+	}
+	return nil
+}
+
+// GetCurrentAdminToken 获取当前管理员请求使用的令牌
+func GetCurrentAdminToken(c *gin.Context) string {
+	return c.GetString("admin_token")
+}
+
+// abortAdminUnauthorized 返回管理员认证失败
+func abortAdminUnauthorized(c *gin.Context, message, errorCode string) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"code":      401,
+		"message":   message,
+		"error":     errorCode,
+		"timestamp": time.Now().Unix(),
+	})
+	c.Abort()
+}
@@ -50,7 +50,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			errorMessage := "认证失败"
 			errorCode := "AUTH_FAILED"
 
-			if strings.Contains(err.Error(), "已在其他设备登录") {
+			if strings.Contains(err.Error(), "会话已失效") {
 				errorMessage = err.Error()
 				errorCode = "TOKEN_REVOKED"
 			} else if strings.Contains(err.Error(), "已过期") {
@@ -76,12 +76,36 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("uid", claims.Uid)
 		c.Set("username", claims.Username)
 		c.Set("claims", claims)
+		c.Set("access_token", tokenString)
 		c.Set("is_authenticated", true)
 
 		c.Next()
 	}
 }
 
+// EventStreamAuthMiddleware 实时推送认证中间件
+// 浏览器EventSource无法设置请求头，没有Authorization头时使用stream_token参数中的一次性连接令牌，换成对应的访问令牌后按AuthMiddleware认证
+func EventStreamAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		streamToken := c.Query("stream_token")
+		if c.GetHeader("Authorization") == "" && streamToken != "" {
+			accessToken, err := services.NewUserEventService().ConsumeStreamToken(context.Background(), streamToken)
+			if err != nil || accessToken == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"code":    401,
+					"message": "连接令牌无效或已过期",
+					"error":   "INVALID_STREAM_TOKEN",
+				})
+				c.Abort()
+				return
+			}
+			c.Request.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		auth(c)
+	}
+}
+
 // OptionalAuthMiddleware 可选的认证中间件（不强制要求登录）
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -136,6 +160,23 @@ func GetCurrentUID(c *gin.Context) string {
 	return uid.(string)
 }
 
+// GetCurrentSessionID 获取当前会话ID（即令牌家族ID），旧令牌没有会话ID时返回空
+func GetCurrentSessionID(c *gin.Context) string {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return ""
+	}
+	if tokenClaims, ok := claims.(*utils.Claims); ok {
+		return tokenClaims.FamilyID
+	}
+	return ""
+}
+
+// GetCurrentAccessToken 获取当前请求使用的访问令牌
+func GetCurrentAccessToken(c *gin.Context) string {
+	return c.GetString("access_token")
+}
+
 // GetCurrentUsername 获取当前用户名
 func GetCurrentUsername(c *gin.Context) string {
 	username, exists := c.Get("username")
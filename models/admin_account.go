@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// AdminLoginRequest 管理员登录请求
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	TotpCode string `json:"totp_code"` // 动态验证码，开启两步验证时必填
+}
+
+// AdminLoginResponse 管理员登录响应
+type AdminLoginResponse struct {
+	Token                  string            `json:"token"`
+	TokenType              string            `json:"token_type"`
+	ExpiresIn              int64             `json:"expires_in"`                // 令牌有效期（秒）
+	TwoFactorSetupRequired bool              `json:"two_factor_setup_required"` // 角色要求两步验证但尚未绑定，绑定前无法使用其他接口
+	Admin                  AdminUserResponse `json:"admin"`
+}
+
+// AdminUserResponse 管理员信息响应
+type AdminUserResponse struct {
+	ID               uint      `json:"id"`
+	AdminID          uint      `json:"admin_id"`
+	Username         string    `json:"username"`
+	Remark           string    `json:"remark"`
+	Status           int64     `json:"status"`
+	Avatar           string    `json:"avatar"`
+	Role             int64     `json:"role"`
+	RoleName         string    `json:"role_name"`
+	MyInviteCode     string    `json:"my_invite_code"`
+	ParentID         *uint     `json:"parent_id"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// AdminListRequest 下级管理员列表请求
+type AdminListRequest struct {
+	Page     int    `json:"page" binding:"min=1"`                 // 页码，从1开始
+	PageSize int    `json:"page_size" binding:"min=1,max=100"`    // 每页大小
+	Role     int64  `json:"role" binding:"omitempty,min=1,max=4"` // 角色筛选，0表示全部
+	Status   *int64 `json:"status" binding:"omitempty,oneof=0 1"` // 状态筛选，不传表示全部
+	Username string `json:"username" binding:"omitempty,max=50"`  // 用户名模糊搜索
+}
+
+// AdminListResponse 下级管理员列表响应
+type AdminListResponse struct {
+	Admins     []AdminUserResponse `json:"admins"`
+	Pagination PaginationInfo      `json:"pagination"`
+}
+
+// AdminIDRequest 按ID操作管理员请求
+type AdminIDRequest struct {
+	ID uint `json:"id" binding:"required,min=1"`
+}
+
+// AdminCreateRequest 创建下级管理员请求
+type AdminCreateRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Password string `json:"password" binding:"required,min=6,max=50"`
+	Role     int64  `json:"role" binding:"required,min=1,max=4"` // 只能创建比自己角色低的管理员
+	ParentID *uint  `json:"parent_id"`                           // 上级管理员ID，不传默认为当前管理员
+	Remark   string `json:"remark" binding:"max=500"`
+	Avatar   string `json:"avatar" binding:"max=255"`
+}
+
+// AdminUpdateRequest 修改下级管理员请求，只更新传入的字段
+type AdminUpdateRequest struct {
+	ID       uint    `json:"id" binding:"required,min=1"`
+	Role     *int64  `json:"role" binding:"omitempty,min=1,max=4"`
+	ParentID *uint   `json:"parent_id"`
+	Remark   *string `json:"remark" binding:"omitempty,max=500"`
+	Avatar   *string `json:"avatar" binding:"omitempty,max=255"`
+}
+
+// AdminResetPasswordRequest 重置下级管理员密码请求
+type AdminResetPasswordRequest struct {
+	ID          uint   `json:"id" binding:"required,min=1"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=50"`
+}
+
+// AdminChangePasswordRequest 修改当前管理员密码请求
+type AdminChangePasswordRequest struct {
+	OldPassword     string `json:"old_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6,max=50"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
+	TotpCode        string `json:"totp_code"` // 动态验证码，开启两步验证时必填
+}
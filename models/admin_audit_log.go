@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// AdminAuditLog 管理员操作审计日志，只追加不修改
+// 每条记录的Hash由上一条记录的Hash和本条内容计算，任何修改、删除或插入都会使后续链条校验失败
+type AdminAuditLog struct {
+	ID            uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	AdminID       uint      `json:"admin_id" gorm:"not null;index;comment:操作管理员ID，0表示系统"`
+	AdminUsername string    `json:"admin_username" gorm:"size:50;not null;index;comment:操作管理员用户名"`
+	Action        string    `json:"action" gorm:"size:64;not null;index;comment:操作类型"`
+	TargetType    string    `json:"target_type" gorm:"size:32;not null;index:idx_admin_audit_logs_target;comment:操作对象类型"`
+	TargetID      string    `json:"target_id" gorm:"size:64;index:idx_admin_audit_logs_target;comment:操作对象ID"`
+	Before        string    `json:"before" gorm:"type:text;comment:操作前数据JSON"`
+	After         string    `json:"after" gorm:"type:text;comment:操作后数据JSON"`
+	Diff          string    `json:"diff" gorm:"type:text;comment:变更字段JSON"`
+	IP            string    `json:"ip" gorm:"size:45;comment:操作IP"`
+	RequestID     string    `json:"request_id" gorm:"size:64;comment:请求ID"`
+	PrevHash      string    `json:"prev_hash" gorm:"size:64;not null;comment:上一条记录的哈希"`
+	Hash          string    `json:"hash" gorm:"size:64;not null;uniqueIndex;comment:本条记录的哈希"`
+	CreatedAt     time.Time `json:"created_at" gorm:"type:datetime(3);not null;index"`
+}
+
+// TableName 指定表名
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+// TableComment 表注释
+func (AdminAuditLog) TableComment() string {
+	return "管理员审计日志表 - 只追加的哈希链，记录操作人、操作、对象、变更前后数据、IP和请求ID"
+}
+
+// 审计操作类型
+const (
+	AuditActionUserApprove            = "user.approve"              // 审核通过用户
+	AuditActionUserReject             = "user.reject"               // 审核拒绝用户
+	AuditActionUserLevelOverride      = "user_level.override_set"   // 为用户固定会员等级
+	AuditActionUserLevelOverrideClear = "user_level.override_clear" // 清除用户固定的会员等级
+	AuditActionMemberLevelCreate      = "member_level.create"       // 创建会员等级
+	AuditActionMemberLevelUpdate      = "member_level.update"       // 修改会员等级
+	AuditActionMemberLevelDelete      = "member_level.delete"       // 删除会员等级
+	AuditActionCurrencySet            = "currency.set"              // 设置当前货币
+	AuditActionAmountCreate           = "amount_config.create"      // 创建金额配置
+	AuditActionAmountUpdate           = "amount_config.update"      // 修改金额配置
+	AuditActionAmountDelete           = "amount_config.delete"      // 删除金额配置
+	AuditActionAmountReorder          = "amount_config.reorder"     // 调整金额配置排序
+	AuditActionMessagePush            = "message.push"              // 推送用户消息
+	AuditActionScoreRuleUpdate        = "score_rule.update"         // 修改经验值与信用分规则
+	AuditActionScoreAdjust            = "score.adjust"              // 手动调整用户经验值和信用分
+	AuditActionCommissionRuleSave     = "commission_rule.save"      // 保存推广佣金规则
+	AuditActionCommissionRuleDelete   = "commission_rule.delete"    // 删除推广佣金规则
+	AuditActionWalletStatusUpdate     = "wallet.status_update"      // 修改钱包状态（冻结、限制提现、恢复正常）
+	AuditActionWalletStatusExpire     = "wallet.status_expire"      // 钱包冻结或限制提现到期自动恢复
+	AuditActionMessageBroadcast       = "message.broadcast"         // 创建群发消息
+	AuditActionMessageBroadcastCancel = "message.broadcast_cancel"  // 取消群发消息
+)
+
+// 审计操作对象类型
+const (
+	AuditTargetUser             = "user"              // 用户
+	AuditTargetCurrency         = "currency"          // 货币配置
+	AuditTargetAmountConfig     = "amount_config"     // 金额配置
+	AuditTargetMessage          = "message"           // 用户消息
+	AuditTargetMemberLevel      = "member_level"      // 会员等级
+	AuditTargetScoreRule        = "score_rule"        // 经验值与信用分规则
+	AuditTargetCommissionRule   = "commission_rule"   // 推广佣金规则
+	AuditTargetWallet           = "wallet"            // 用户钱包
+	AuditTargetMessageBroadcast = "message_broadcast" // 群发消息
+)
+
+// AdminAuditLogQueryRequest 审计日志查询请求
+type AdminAuditLogQueryRequest struct {
+	Page       int    `json:"page" binding:"min=1"`              // 页码，从1开始
+	PageSize   int    `json:"page_size" binding:"min=1,max=100"` // 每页大小
+	AdminID    uint   `json:"admin_id"`                          // 按操作管理员ID筛选
+	Action     string `json:"action" binding:"max=64"`           // 按操作类型筛选
+	TargetType string `json:"target_type" binding:"max=32"`      // 按操作对象类型筛选
+	TargetID   string `json:"target_id" binding:"max=64"`        // 按操作对象ID筛选
+	StartTime  string `json:"start_time"`                        // 开始时间（YYYY-MM-DD HH:MM:SS）
+	EndTime    string `json:"end_time"`                          // 结束时间（YYYY-MM-DD HH:MM:SS）
+}
+
+// AdminAuditLogQueryResponse 审计日志查询响应
+type AdminAuditLogQueryResponse struct {
+	Logs       []AdminAuditLog `json:"logs"`
+	Pagination PaginationInfo  `json:"pagination"`
+}
+
+// AdminAuditVerifyResponse 审计日志哈希链校验响应
+type AdminAuditVerifyResponse struct {
+	Valid      bool   `json:"valid"`                  // 链条是否完整
+	Checked    int64  `json:"checked"`                // 已校验的记录数
+	BrokenAtID uint64 `json:"broken_at_id,omitempty"` // 第一条校验失败的记录ID
+	Reason     string `json:"reason,omitempty"`       // 校验失败原因
+}
@@ -0,0 +1,74 @@
+package models
+
+// 管理后台权限，每个管理员和定时任务接口都需要声明所需权限
+const (
+	PermCronView             = "cron.view"              // 查看定时任务状态
+	PermCronGenerateOrders   = "cron.generate_orders"   // 手动生成订单
+	PermCronCleanup          = "cron.cleanup"           // 手动清理数据
+	PermCronLeaderboardCache = "cron.leaderboard_cache" // 手动更新热榜缓存
+	PermCronGroupBuyExpire   = "cron.group_buy_expire"  // 手动处理过期拼单
+	PermCronScoreEvents      = "cron.score_events"      // 手动扫描经验值与信用分事件
+	PermCronCommission       = "cron.commission"        // 手动发放推广佣金
+	PermCronWalletStatus     = "cron.wallet_status"     // 手动恢复到期的钱包冻结和提现限制
+	PermCronMessageBroadcast = "cron.message_broadcast" // 手动发送到期的群发消息
+
+	PermLoginUnlock = "security.login_unlock" // 解除登录锁定
+
+	PermAdminView   = "admin.view"   // 查看下级管理员
+	PermAdminManage = "admin.manage" // 创建、修改、禁用下级管理员
+
+	PermUserReview = "user.review" // 审核待审核用户，只能审核自己及下级邀请码注册的用户
+
+	PermReportView = "report.view" // 查看代理业绩报表，只能查看自己及下级
+
+	PermAuditView = "audit.view" // 查看和校验管理员审计日志，仅超级管理员
+
+	PermAmountConfigManage = "amount_config.manage" // 管理充值、提现金额配置，仅超级管理员
+	PermMemberLevelManage  = "member_level.manage"  // 管理会员等级和用户固定等级，仅超级管理员
+	PermScoreManage        = "score.manage"         // 管理经验值与信用分规则、手动调整和查看变更记录，仅超级管理员
+	PermReferralManage     = "referral.manage"      // 管理推广佣金规则，仅超级管理员
+	PermWalletManage       = "wallet.manage"        // 冻结、限制提现和恢复用户钱包，仅超级管理员
+	PermMessageSend        = "message.send"         // 发送和管理群发消息，仅超级管理员
+)
+
+// RolePermissions 角色权限映射，超级管理员拥有全部权限
+var RolePermissions = map[int64][]string{
+	RoleManager: {
+		PermCronView,
+		PermCronLeaderboardCache,
+		PermLoginUnlock,
+		PermAdminView,
+		PermAdminManage,
+		PermUserReview,
+		PermReportView,
+	},
+	RoleSupervisor: {
+		PermLoginUnlock,
+		PermAdminView,
+		PermAdminManage,
+		PermUserReview,
+		PermReportView,
+	},
+	RoleSalesman: {
+		PermUserReview,
+		PermReportView,
+	},
+}
+
+// HasPermission 检查角色是否拥有指定权限
+func HasPermission(role int64, permission string) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 检查管理员是否拥有指定权限
+func (a *AdminUser) HasPermission(permission string) bool {
+	return HasPermission(a.Role, permission)
+}
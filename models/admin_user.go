@@ -3,24 +3,27 @@ package models
 import (
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-// AdminUser 邀请码管理表（仅用于邀请码校验）
+// AdminUser 管理员表（后台登录账号，同时提供注册邀请码）
 type AdminUser struct {
-	ID           uint           `gorm:"primarykey" json:"id"`
-	AdminID      uint           `gorm:"not null;uniqueIndex:idx_admin_users_admin_id;comment:管理员唯一ID" json:"admin_id"`
-	Username     string         `gorm:"size:50;not null;uniqueIndex:idx_admin_users_username;comment:用户名" json:"username"`
-	Password     string         `gorm:"size:255;not null;comment:密码哈希" json:"-"`
-	Remark       string         `gorm:"size:500;comment:备注" json:"remark"`
-	Status       int64          `gorm:"default:1;comment:账户状态 1:正常 0:禁用" json:"status"`
-	Avatar       string         `gorm:"size:255;comment:头像URL" json:"avatar"`
-	Role         int64          `gorm:"not null;default:4;comment:身份角色 1:超级管理员 2:经理 3:主管 4:业务员（默认业务员）" json:"role"`
-	MyInviteCode string         `gorm:"size:6;uniqueIndex:idx_admin_users_my_invite_code;comment:我的邀请码" json:"my_invite_code"`
-	ParentID     *uint          `gorm:"index:idx_admin_users_parent_id;comment:上级用户ID" json:"parent_id"`
-	CreatedAt    time.Time      `gorm:"type:datetime(3)" json:"created_at"`
-	UpdatedAt    time.Time      `gorm:"type:datetime(3)" json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"type:datetime(3);index:idx_admin_users_deleted_at;comment:软删除时间" json:"-"`
+	ID               uint           `gorm:"primarykey" json:"id"`
+	AdminID          uint           `gorm:"not null;uniqueIndex:idx_admin_users_admin_id;comment:管理员唯一ID" json:"admin_id"`
+	Username         string         `gorm:"size:50;not null;uniqueIndex:idx_admin_users_username;comment:用户名" json:"username"`
+	Password         string         `gorm:"size:255;not null;comment:密码哈希" json:"-"`
+	Remark           string         `gorm:"size:500;comment:备注" json:"remark"`
+	Status           int64          `gorm:"default:1;comment:账户状态 1:正常 0:禁用" json:"status"`
+	Avatar           string         `gorm:"size:255;comment:头像URL" json:"avatar"`
+	Role             int64          `gorm:"not null;default:4;comment:身份角色 1:超级管理员 2:经理 3:主管 4:业务员（默认业务员）" json:"role"`
+	MyInviteCode     string         `gorm:"size:6;uniqueIndex:idx_admin_users_my_invite_code;comment:我的邀请码" json:"my_invite_code"`
+	ParentID         *uint          `gorm:"index:idx_admin_users_parent_id;comment:上级用户ID" json:"parent_id"`
+	TwoFactorEnabled bool           `gorm:"default:false;comment:是否开启两步验证" json:"two_factor_enabled"`
+	TwoFactorSecret  string         `gorm:"size:64;comment:TOTP密钥" json:"-"`
+	CreatedAt        time.Time      `gorm:"type:datetime(3)" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"type:datetime(3)" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"type:datetime(3);index:idx_admin_users_deleted_at;comment:软删除时间" json:"-"`
 }
 
 // TableName 指定表名
@@ -30,7 +33,7 @@ func (AdminUser) TableName() string {
 
 // TableComment 表注释
 func (AdminUser) TableComment() string {
-	return "邀请码管理表 - 存储邀请码信息，用于用户注册时的邀请码校验"
+	return "管理员表 - 存储后台管理员账号、角色层级和邀请码，邀请码用于用户注册校验"
 }
 
 // 管理员角色常量（使用int64枚举）
@@ -49,9 +52,55 @@ var RoleNames = map[int64]string{
 	RoleSalesman:   "业务员",
 }
 
+// 管理员账户状态
+const (
+	AdminStatusDisabled int64 = 0 // 禁用
+	AdminStatusActive   int64 = 1 // 正常
+)
+
 // IsActive 检查管理员是否激活
 func (a *AdminUser) IsActive() bool {
-	return a.Status == 1
+	return a.Status == AdminStatusActive
+}
+
+// HashPassword 加密密码
+func (a *AdminUser) HashPassword() error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(a.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	a.Password = string(hashedPassword)
+	return nil
+}
+
+// CheckPassword 验证密码
+func (a *AdminUser) CheckPassword(password string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(password))
+	return err == nil
+}
+
+// CanAssignRole 检查管理员能否分配指定角色，只能分配比自己低的角色
+func (a *AdminUser) CanAssignRole(role int64) bool {
+	return ValidateRoleID(role) && role > a.Role
+}
+
+// ToResponse 转换为响应格式
+func (a *AdminUser) ToResponse() AdminUserResponse {
+	return AdminUserResponse{
+		ID:               a.ID,
+		AdminID:          a.AdminID,
+		Username:         a.Username,
+		Remark:           a.Remark,
+		Status:           a.Status,
+		Avatar:           a.Avatar,
+		Role:             a.Role,
+		RoleName:         a.GetRoleName(),
+		MyInviteCode:     a.MyInviteCode,
+		ParentID:         a.ParentID,
+		TwoFactorEnabled: a.TwoFactorEnabled,
+		CreatedAt:        a.CreatedAt,
+		UpdatedAt:        a.UpdatedAt,
+	}
 }
 
 // GetRoleName 获取角色名称
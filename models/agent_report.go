@@ -0,0 +1,50 @@
+package models
+
+// AgentReportRequest 代理业绩报表请求
+type AgentReportRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // 统计开始日期（YYYY-MM-DD）
+	EndDate   string `json:"end_date" binding:"required"`   // 统计结束日期（YYYY-MM-DD），包含当天
+	AdminID   uint   `json:"admin_id"`                      // 只统计该管理员及其下级，不传为当前管理员的全部管理范围
+}
+
+// AgentReportMetrics 代理业绩指标，按用户注册时填写的邀请码归属到代理
+type AgentReportMetrics struct {
+	Registrations  int64   `json:"registrations"`   // 注册人数
+	Approvals      int64   `json:"approvals"`       // 审核通过人数
+	RechargeCount  int64   `json:"recharge_count"`  // 成功充值笔数
+	RechargeAmount float64 `json:"recharge_amount"` // 成功充值金额
+	WithdrawCount  int64   `json:"withdraw_count"`  // 成功提现笔数
+	WithdrawAmount float64 `json:"withdraw_amount"` // 成功提现金额
+	OrderCount     int64   `json:"order_count"`     // 下单数量，不含系统订单
+	OrderAmount    float64 `json:"order_amount"`    // 下单金额，不含系统订单
+	ActiveUsers    int64   `json:"active_users"`    // 有成功登录记录的用户数
+}
+
+// AgentReportRow 单个代理的业绩，Own为自己邀请码下的用户，Team为自己及全部下级
+type AgentReportRow struct {
+	ID         uint               `json:"id"`
+	AdminID    uint               `json:"admin_id"`
+	Username   string             `json:"username"`
+	Role       int64              `json:"role"`
+	RoleName   string             `json:"role_name"`
+	InviteCode string             `json:"invite_code"`
+	ParentID   *uint              `json:"parent_id"`
+	Level      int                `json:"level"` // 在报表树中的层级，统计根节点为0
+	Own        AgentReportMetrics `json:"own"`
+	Team       AgentReportMetrics `json:"team"`
+}
+
+// AgentReportResponse 代理业绩报表响应，代理按上下级关系深度优先排列
+type AgentReportResponse struct {
+	StartDate string             `json:"start_date"`
+	EndDate   string             `json:"end_date"`
+	Rows      []AgentReportRow   `json:"rows"`
+	Total     AgentReportMetrics `json:"total"` // 报表范围内全部代理的合计
+}
+
+// AgentReportAggregate 按邀请码分组的统计结果
+type AgentReportAggregate struct {
+	InviteCode string
+	Count      int64
+	Amount     float64
+}
@@ -21,11 +21,51 @@ func (AmountConfig) TableName() string {
 	return "amount_config"
 }
 
+// 金额配置类型
+const (
+	AmountConfigTypeRecharge = "recharge" // 充值
+	AmountConfigTypeWithdraw = "withdraw" // 提现
+)
+
 // AmountConfigRequest 金额配置请求
 type AmountConfigRequest struct {
 	Type string `json:"type" binding:"required,oneof=recharge withdraw"` // 配置类型
 }
 
+// AmountConfigAdminListRequest 管理后台金额配置列表请求，包含未激活的配置
+type AmountConfigAdminListRequest struct {
+	Type     string `json:"type" binding:"required,oneof=recharge withdraw"` // 配置类型
+	IsActive *bool  `json:"is_active"`                                       // 按激活状态筛选，不传返回全部
+}
+
+// AmountConfigCreateRequest 创建金额配置请求
+type AmountConfigCreateRequest struct {
+	Type        string  `json:"type" binding:"required,oneof=recharge withdraw"` // 配置类型
+	Amount      float64 `json:"amount" binding:"required,gt=0"`                  // 金额，最多两位小数
+	Description string  `json:"description" binding:"max=100"`                   // 描述
+	IsActive    *bool   `json:"is_active"`                                       // 是否激活，不传默认激活
+	SortOrder   int     `json:"sort_order"`                                      // 排序，越小越靠前
+}
+
+// AmountConfigUpdateRequest 修改金额配置请求，只修改传入的字段
+type AmountConfigUpdateRequest struct {
+	ID          int64    `json:"id" binding:"required,min=1"`             // 配置ID
+	Amount      *float64 `json:"amount" binding:"omitempty,gt=0"`         // 金额
+	Description *string  `json:"description" binding:"omitempty,max=100"` // 描述
+	SortOrder   *int     `json:"sort_order"`                              // 排序
+}
+
+// AmountConfigIDRequest 金额配置ID请求
+type AmountConfigIDRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"` // 配置ID
+}
+
+// AmountConfigReorderRequest 金额配置排序请求，按传入顺序重新设置sort_order
+type AmountConfigReorderRequest struct {
+	Type string  `json:"type" binding:"required,oneof=recharge withdraw"` // 配置类型
+	IDs  []int64 `json:"ids" binding:"required,min=1,max=100,dive,min=1"` // 该类型下的配置ID，按期望的显示顺序排列
+}
+
 // AmountConfigResponse 金额配置响应
 type AmountConfigResponse struct {
 	ID          int64   `json:"id"`
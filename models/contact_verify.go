@@ -0,0 +1,31 @@
+package models
+
+// SendContactCodeRequest 发送邮箱、手机号验证码请求
+type SendContactCodeRequest struct {
+	Account string `json:"account" binding:"required"` // 注册时使用的邮箱或手机号
+}
+
+// ConfirmContactRequest 提交邮箱、手机号验证码请求
+type ConfirmContactRequest struct {
+	Account string `json:"account" binding:"required"` // 注册时使用的邮箱或手机号
+	Code    string `json:"code" binding:"required"`
+}
+
+// ChangeContactSendRequest 更换邮箱、手机号时向新联系方式发送验证码
+type ChangeContactSendRequest struct {
+	NewAccount string `json:"new_account" binding:"required"` // 新邮箱或手机号
+	Password   string `json:"password" binding:"required"`    // 当前登录密码
+	TotpCode   string `json:"totp_code"`                      // 动态验证码，开启两步验证时必填
+}
+
+// ChangeContactConfirmRequest 提交新联系方式收到的验证码完成更换
+type ChangeContactConfirmRequest struct {
+	NewAccount string `json:"new_account" binding:"required"` // 新邮箱或手机号
+	Code       string `json:"code" binding:"required"`
+}
+
+// VerifyCodeSentResponse 验证码发送响应
+type VerifyCodeSentResponse struct {
+	ExpiresIn      int `json:"expires_in"`      // 验证码有效期（秒）
+	ResendInterval int `json:"resend_interval"` // 重新发送间隔（秒）
+}
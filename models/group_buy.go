@@ -9,6 +9,8 @@ const (
 	GroupBuyStatusNotStarted = "not_started" // 未开启
 	GroupBuyStatusPending    = "pending"     // 进行中
 	GroupBuyStatusSuccess    = "success"     // 已完成
+	GroupBuyStatusFailed     = "failed"      // 未成团（截止时未满员，已退款）
+	GroupBuyStatusCancelled  = "cancelled"   // 已取消（发起人取消，已退款）
 )
 
 // GroupBuyType 拼单类型枚举
@@ -25,6 +27,7 @@ type GroupBuy struct {
 	OrderNo             *string   `json:"order_no" gorm:"size:32;index;comment:关联订单编号"`
 	CreatorUid          string    `json:"creator_uid" gorm:"not null;size:8;index;comment:创建用户ID"`
 	Uid                 string    `json:"uid" gorm:"size:8;index;comment:参与用户ID"`
+	InviteOnly          bool      `json:"invite_only" gorm:"not null;default:false;comment:是否仅限通过邀请码参与"`
 	CurrentParticipants int       `json:"current_participants" gorm:"not null;default:1;comment:当前参与人数"`
 	TargetParticipants  int       `json:"target_participants" gorm:"not null;default:2;comment:目标参与人数"`
 	GroupBuyType        string    `json:"group_buy_type" gorm:"not null;size:20;default:'normal';index;comment:拼单类型"`
@@ -62,6 +65,17 @@ type GetGroupBuyDetailResponse struct {
 	ProfitMargin        float64   `json:"profit_margin"`        // 利润比例（小数）
 	RemainingAmount     float64   `json:"remaining_amount"`     // 还需要付款的金额
 	Deadline            time.Time `json:"deadline"`             // 截止时间
+
+	Rules *GroupBuyRuleInfo `json:"rules,omitempty"` // 拼单类型规则
+}
+
+// GroupBuyRuleInfo 拼单类型规则信息
+type GroupBuyRuleInfo struct {
+	FirstCome          bool `json:"first_come"`           // 是否先到先得
+	MaxDurationMinutes int  `json:"max_duration_minutes"` // 最长持续时间（分钟），0表示不限制
+	MaxParticipants    int  `json:"max_participants"`     // 最多名额，0表示不限制
+	MinMemberLevel     int  `json:"min_member_level"`     // 所需最低会员等级，0表示不限制
+	ReservedSeats      int  `json:"reserved_seats"`       // 为指定用户保留的名额
 }
 
 // GetGroupBuyListResponse 获取拼单列表响应
@@ -94,18 +108,123 @@ func (g *GroupBuy) ToDetailResponse() GetGroupBuyDetailResponse {
 	}
 }
 
+// GetStatusName 获取拼单状态名称
+func (g *GroupBuy) GetStatusName() string {
+	statusNames := map[string]string{
+		GroupBuyStatusNotStarted: "未开启",
+		GroupBuyStatusPending:    "进行中",
+		GroupBuyStatusSuccess:    "已完成",
+		GroupBuyStatusFailed:     "未成团",
+		GroupBuyStatusCancelled:  "已取消",
+	}
+	return statusNames[g.Status]
+}
+
 // JoinGroupBuyRequest 确认参与拼单请求
 type JoinGroupBuyRequest struct {
-	GroupBuyNo string `json:"group_buy_no" binding:"required"` // 拼单编号
+	GroupBuyNo string `json:"group_buy_no" binding:"required_without=InviteCode"` // 拼单编号
+	InviteCode string `json:"invite_code"`                                        // 邀请码，与拼单编号二选一
 }
 
 // JoinGroupBuyResponse 确认参与拼单响应
 type JoinGroupBuyResponse struct {
-	OrderID uint `json:"order_id"` // 订单ID
+	OrderID             uint   `json:"order_id"`             // 订单ID
+	Status              string `json:"status"`               // 参与后的拼单状态
+	CurrentParticipants int    `json:"current_participants"` // 当前参与人数
+	TargetParticipants  int    `json:"target_participants"`  // 目标参与人数
 }
 
 // GroupBuyListRequest 拼单列表请求
 type GroupBuyListRequest struct {
-	Page     int `json:"page" binding:"min=1"`              // 页码，从1开始
-	PageSize int `json:"page_size" binding:"min=1"`         // 每页大小，最小1
+	Page     int `json:"page" binding:"min=1"`      // 页码，从1开始
+	PageSize int `json:"page_size" binding:"min=1"` // 每页大小，最小1
+}
+
+// GroupBuyTemplateResponse 拼单模板响应
+type GroupBuyTemplateResponse struct {
+	Code            string  `json:"code"`              // 模板编码
+	Name            string  `json:"name"`              // 模板名称
+	GroupBuyType    string  `json:"group_buy_type"`    // 拼单类型
+	PerPersonAmount float64 `json:"per_person_amount"` // 每人付款金额
+	ProfitMargin    float64 `json:"profit_margin"`     // 利润比例（小数）
+	DurationMinutes int     `json:"duration_minutes"`  // 拼单持续时间（分钟）
+	MinParticipants int     `json:"min_participants"`  // 最少目标人数
+	MaxParticipants int     `json:"max_participants"`  // 最多目标人数
+	Description     string  `json:"description"`       // 模板描述
+}
+
+// CreateGroupBuyRequest 发起拼单请求
+type CreateGroupBuyRequest struct {
+	TemplateCode       string `json:"template_code" binding:"required"`             // 模板编码
+	TargetParticipants int    `json:"target_participants" binding:"required,min=2"` // 目标参与人数（含发起人）
+}
+
+// CreateGroupBuyResponse 发起拼单响应
+type CreateGroupBuyResponse struct {
+	GroupBuyNo     string    `json:"group_buy_no"`     // 拼单编号
+	OrderID        uint      `json:"order_id"`         // 发起人订单ID
+	InviteCode     string    `json:"invite_code"`      // 邀请码
+	InviteLink     string    `json:"invite_link"`      // 邀请链接
+	InviteExpireAt time.Time `json:"invite_expire_at"` // 邀请码过期时间
+	Deadline       time.Time `json:"deadline"`         // 拼单截止时间
+}
+
+// GroupBuyNoRequest 拼单编号请求
+type GroupBuyNoRequest struct {
+	GroupBuyNo string `json:"group_buy_no" binding:"required"` // 拼单编号
+}
+
+// GroupBuyParticipantInfo 拼单参与者信息
+type GroupBuyParticipantInfo struct {
+	Uid       string    `json:"uid"`        // 参与用户ID（脱敏）
+	IsCreator bool      `json:"is_creator"` // 是否为发起人
+	Amount    float64   `json:"amount"`     // 付款金额
+	Status    string    `json:"status"`     // 参与状态
+	JoinedAt  time.Time `json:"joined_at"`  // 参与时间
+}
+
+// GroupBuyProgressResponse 拼单进度响应
+type GroupBuyProgressResponse struct {
+	GetGroupBuyDetailResponse
+	Status        string                    `json:"status"`         // 拼单状态
+	IsCreator     bool                      `json:"is_creator"`     // 当前用户是否为发起人
+	CanCancel     bool                      `json:"can_cancel"`     // 是否可以取消
+	RemainingTime int64                     `json:"remaining_time"` // 剩余时间（秒）
+	Participants  []GroupBuyParticipantInfo `json:"participants"`   // 参与者列表
+}
+
+// CancelGroupBuyResponse 取消拼单响应
+type CancelGroupBuyResponse struct {
+	GroupBuyNo     string  `json:"group_buy_no"`    // 拼单编号
+	RefundedAmount float64 `json:"refunded_amount"` // 退款金额
+}
+
+// GroupBuyHistoryRequest 拼单历史请求
+type GroupBuyHistoryRequest struct {
+	Page         int    `json:"page" binding:"required,min=1"`                                                 // 页码，从1开始
+	PageSize     int    `json:"page_size" binding:"required,min=1,max=100"`                                    // 每页大小
+	Status       string `json:"status" binding:"omitempty,oneof=not_started pending success failed cancelled"` // 拼单状态筛选，不传为全部
+	GroupBuyType string `json:"group_buy_type" binding:"omitempty,oneof=normal flash vip"`                     // 拼单类型筛选，不传为全部
+}
+
+// GroupBuyHistoryItem 拼单历史条目
+type GroupBuyHistoryItem struct {
+	GetGroupBuyDetailResponse
+	Status            string     `json:"status"`             // 拼单状态
+	StatusName        string     `json:"status_name"`        // 拼单状态名称
+	IsCreator         bool       `json:"is_creator"`         // 是否为发起人
+	ParticipantStatus string     `json:"participant_status"` // 参与状态
+	Contribution      float64    `json:"contribution"`       // 用户付款金额
+	ProfitCredited    float64    `json:"profit_credited"`    // 已到账利润，订单完成前为0
+	OrderNo           string     `json:"order_no"`           // 关联订单编号
+	OrderStatus       string     `json:"order_status"`       // 关联订单状态
+	JoinedAt          time.Time  `json:"joined_at"`          // 参与时间
+	RefundedAt        *time.Time `json:"refunded_at"`        // 退款时间
+}
+
+// GroupBuyHistoryResponse 拼单历史响应
+type GroupBuyHistoryResponse struct {
+	HasData    bool                  `json:"has_data"`   // 是否有数据
+	List       []GroupBuyHistoryItem `json:"list"`       // 拼单历史列表
+	Pagination PaginationInfo        `json:"pagination"` // 分页信息
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// GroupBuyParticipantStatus 拼单参与状态枚举
+const (
+	GroupBuyParticipantStatusJoined   = "joined"   // 已参与（已付款，等待成团）
+	GroupBuyParticipantStatusSuccess  = "success"  // 已成团
+	GroupBuyParticipantStatusRefunded = "refunded" // 未成团已退款
+)
+
+// GroupBuyParticipant 拼单参与记录表
+type GroupBuyParticipant struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	GroupBuyNo string     `json:"group_buy_no" gorm:"not null;size:32;uniqueIndex:uk_group_buy_participant;comment:拼单编号"`
+	Uid        string     `json:"uid" gorm:"not null;size:8;uniqueIndex:uk_group_buy_participant;index;comment:参与用户ID"`
+	Amount     float64    `json:"amount" gorm:"type:decimal(15,2);not null;comment:付款金额"`
+	OrderNo    string     `json:"order_no" gorm:"size:32;index;comment:关联订单编号"`
+	Status     string     `json:"status" gorm:"not null;size:20;default:'joined';index;comment:参与状态"`
+	RefundedAt *time.Time `json:"refunded_at" gorm:"comment:退款时间"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime;index;comment:参与时间"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime;comment:更新时间"`
+}
+
+// TableName 指定表名
+func (GroupBuyParticipant) TableName() string {
+	return "group_buy_participants"
+}
+
+// TableComment 表注释
+func (GroupBuyParticipant) TableComment() string {
+	return "拼单参与记录表 - 记录每个用户参与拼单的付款、订单和退款状态"
+}
@@ -2,19 +2,28 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// 收件箱消息来源
+const (
+	MessageSourceDirect    = "message"   // 发给单个用户的消息
+	MessageSourceBroadcast = "broadcast" // 群发消息的投递记录
 )
 
 // Message 消息表
 type Message struct {
-	ID          int64      `json:"id" gorm:"primaryKey;autoIncrement;comment:唯一自增ID"`
-	UID         string     `json:"uid" gorm:"not null;index;size:8;comment:用户唯一ID"`
-	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime;type:datetime(3);comment:创建时间"`
-	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime;type:datetime(3);comment:更新时间"`
-	Status      string     `json:"status" gorm:"not null;default:draft;type:enum('draft','sent','read');comment:状态：draft-草稿/未发送，sent-已发送，read-已读"`
-	MessageType string     `json:"message_type" gorm:"not null;default:info;type:enum('warning','error','question','info');comment:消息类型：warning-警告，error-错误，question-问号，info-消息"`
-	Content     string     `json:"content" gorm:"not null;type:text;comment:消息内容"`
-	ReadAt      *time.Time `json:"read_at" gorm:"type:datetime(3);comment:用户读取时间"`
-	CreatedBy   string     `json:"created_by" gorm:"not null;size:100;comment:创建管理员用户名"`
+	ID          int64          `json:"id" gorm:"primaryKey;autoIncrement;comment:唯一自增ID"`
+	UID         string         `json:"uid" gorm:"not null;index;size:8;comment:用户唯一ID"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime;type:datetime(3);comment:创建时间"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime;type:datetime(3);comment:更新时间"`
+	Status      string         `json:"status" gorm:"not null;default:draft;type:enum('draft','sent','read');comment:状态：draft-草稿/未发送，sent-已发送，read-已读"`
+	MessageType string         `json:"message_type" gorm:"not null;default:info;type:enum('warning','error','question','info');comment:消息类型：warning-警告，error-错误，question-问号，info-消息"`
+	Content     string         `json:"content" gorm:"not null;type:text;comment:消息内容"`
+	ReadAt      *time.Time     `json:"read_at" gorm:"type:datetime(3);comment:用户读取时间"`
+	CreatedBy   string         `json:"created_by" gorm:"not null;size:100;comment:创建管理员用户名"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"type:datetime(3);comment:用户删除时间"`
 }
 
 // TableName 指定表名
@@ -22,16 +31,56 @@ func (Message) TableName() string {
 	return "messages"
 }
 
-// UserMessage Redis消息结构
-type UserMessage struct {
+// UserMessageResponse 用户消息响应
+type UserMessageResponse struct {
 	ID          int64  `json:"id"`
+	Source      string `json:"source"`
 	MessageType string `json:"message_type"`
 	Content     string `json:"content"`
-	CreatedAt   string `json:"created_at"`
 }
 
-// UserMessageResponse 用户消息响应
-type UserMessageResponse struct {
-	MessageType string `json:"message_type"`
-	Content     string `json:"content"`
-}
\ No newline at end of file
+// MessageListRequest 用户消息列表请求（游标分页）
+type MessageListRequest struct {
+	PageSize   int    `json:"page_size" binding:"min=1"` // 每页大小，最小1
+	Cursor     string `json:"cursor"`                    // 游标，首页不传
+	UnreadOnly bool   `json:"unread_only"`               // 只看未读消息
+}
+
+// MessageListItem 用户消息列表项
+type MessageListItem struct {
+	ID          int64      `json:"id"`
+	Source      string     `json:"source"` // 消息来源 message-单发消息 broadcast-群发消息，与ID一起定位消息
+	Status      string     `json:"status"`
+	MessageType string     `json:"message_type"`
+	Content     string     `json:"content"`
+	ReadAt      *time.Time `json:"read_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// MessageListResponse 用户消息列表响应
+type MessageListResponse struct {
+	Messages   []MessageListItem `json:"messages"`
+	PageSize   int               `json:"page_size"`
+	NextCursor string            `json:"next_cursor"` // 下一页游标，没有下一页时为空
+}
+
+// MessageRef 收件箱消息定位
+type MessageRef struct {
+	Source string `json:"source" binding:"required,oneof=message broadcast"` // 消息来源
+	ID     int64  `json:"id" binding:"required,min=1"`                       // 消息ID
+}
+
+// MessageBatchRequest 批量操作收件箱消息请求
+type MessageBatchRequest struct {
+	Messages []MessageRef `json:"messages" binding:"required,min=1,max=100,dive"`
+}
+
+// MessageBatchResponse 批量操作收件箱消息响应
+type MessageBatchResponse struct {
+	Affected int64 `json:"affected"` // 实际更新的消息数，状态未变化或不存在的消息不计入
+}
+
+// MessageUnreadResponse 未读消息数响应
+type MessageUnreadResponse struct {
+	Unread int64 `json:"unread"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 群发消息目标类型
+const (
+	BroadcastTargetUser    = "user"    // 单个用户
+	BroadcastTargetUsers   = "users"   // 指定用户列表
+	BroadcastTargetAll     = "all"     // 全部用户
+	BroadcastTargetSegment = "segment" // 按会员等级、邀请码、注册日期筛选的用户
+)
+
+// 群发消息状态
+const (
+	BroadcastStatusPending   = "pending"   // 等待发送，定时发送时等到发送时间
+	BroadcastStatusSending   = "sending"   // 发送中
+	BroadcastStatusCompleted = "completed" // 发送完成
+	BroadcastStatusCancelled = "cancelled" // 已取消
+)
+
+// MessageBroadcast 管理员群发消息，消息内容只保存一份，由后台任务分批投递给目标用户
+type MessageBroadcast struct {
+	ID             uint64     `gorm:"primarykey" json:"id"`
+	MessageType    string     `gorm:"size:20;not null;default:info;comment:消息类型 warning-警告 error-错误 question-问号 info-消息" json:"message_type"`
+	Content        string     `gorm:"type:text;not null;comment:消息内容" json:"content"`
+	TargetType     string     `gorm:"size:20;not null;comment:目标类型 user-单个用户 users-用户列表 all-全部用户 segment-用户分群" json:"target_type"`
+	TargetUids     string     `gorm:"type:text;comment:目标用户ID列表JSON，目标类型为user或users时使用" json:"-"`
+	MemberLevel    int        `gorm:"not null;default:0;comment:分群条件：会员等级，0为不限" json:"member_level"`
+	InviteCode     string     `gorm:"size:6;comment:分群条件：注册邀请码" json:"invite_code"`
+	RegisteredFrom *time.Time `gorm:"comment:分群条件：注册开始时间" json:"registered_from"`
+	RegisteredTo   *time.Time `gorm:"comment:分群条件：注册结束时间（不含）" json:"registered_to"`
+	ScheduledAt    *time.Time `gorm:"index;comment:定时发送时间，为空表示立即发送" json:"scheduled_at"`
+	Status         string     `gorm:"size:20;not null;default:pending;index;comment:状态 pending-等待发送 sending-发送中 completed-已完成 cancelled-已取消" json:"status"`
+	TotalCount     int64      `gorm:"not null;default:0;comment:开始发送时符合条件的候选用户数" json:"total_count"`
+	ScannedCount   int64      `gorm:"not null;default:0;comment:已处理的候选用户数" json:"scanned_count"`
+	DeliveredCount int64      `gorm:"not null;default:0;comment:已投递的用户数" json:"delivered_count"`
+	LastUserID     uint       `gorm:"not null;default:0;comment:已处理到的用户自增ID，任务中断后从此处继续" json:"-"`
+	LeaseUntil     *time.Time `gorm:"comment:发送任务租约到期时间，到期未续约视为任务中断" json:"-"`
+	CreatedBy      string     `gorm:"size:50;not null;comment:创建管理员用户名" json:"created_by"`
+	StartedAt      *time.Time `gorm:"comment:开始发送时间" json:"started_at"`
+	CompletedAt    *time.Time `gorm:"comment:发送完成时间" json:"completed_at"`
+	CreatedAt      time.Time  `gorm:"type:datetime(3);autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"type:datetime(3);autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (MessageBroadcast) TableName() string {
+	return "message_broadcasts"
+}
+
+// TableComment 表注释
+func (MessageBroadcast) TableComment() string {
+	return "群发消息表 - 管理员群发的消息内容、目标条件和投递进度，内容只保存一份"
+}
+
+// MessageBroadcastRecipient 群发消息投递记录，每个收到群发消息的用户一条，不重复保存消息内容
+// 同时保存该用户的已读和删除状态，用户删除后保留记录，避免任务继续发送时重复投递
+type MessageBroadcastRecipient struct {
+	ID          uint64         `gorm:"primarykey" json:"id"`
+	Uid         string         `gorm:"size:8;not null;uniqueIndex:uniq_broadcast_recipient,priority:1;comment:用户唯一ID" json:"uid"`
+	BroadcastID uint64         `gorm:"not null;uniqueIndex:uniq_broadcast_recipient,priority:2;index;comment:群发消息ID" json:"broadcast_id"`
+	ReadAt      *time.Time     `gorm:"type:datetime(3);comment:用户读取时间" json:"read_at"`
+	CreatedAt   time.Time      `gorm:"type:datetime(3);autoCreateTime;comment:投递时间" json:"created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"type:datetime(3);comment:用户删除时间" json:"-"`
+}
+
+// TableName 指定表名
+func (MessageBroadcastRecipient) TableName() string {
+	return "message_broadcast_recipients"
+}
+
+// TableComment 表注释
+func (MessageBroadcastRecipient) TableComment() string {
+	return "群发消息投递表 - 记录群发消息投递到的用户、读取时间和删除时间"
+}
+
+// MessageSegment 用户分群条件，多个条件同时满足
+type MessageSegment struct {
+	MemberLevel    int    `json:"member_level" binding:"min=0"` // 会员等级，0为不限
+	InviteCode     string `json:"invite_code" binding:"max=6"`  // 注册邀请码
+	RegisteredFrom string `json:"registered_from"`              // 注册开始日期（YYYY-MM-DD）
+	RegisteredTo   string `json:"registered_to"`                // 注册结束日期（YYYY-MM-DD），包含当天
+}
+
+// MessageSendRequest 管理员发送消息请求
+type MessageSendRequest struct {
+	MessageType string          `json:"message_type" binding:"required,oneof=warning error question info"` // 消息类型
+	Content     string          `json:"content" binding:"required,max=2000"`                               // 消息内容
+	TargetType  string          `json:"target_type" binding:"required,oneof=user users all segment"`       // 目标类型
+	Uids        []string        `json:"uids" binding:"omitempty,max=1000,dive,len=8"`                      // 目标用户ID，目标类型为user或users时必填
+	Segment     *MessageSegment `json:"segment"`                                                           // 分群条件，目标类型为segment时必填
+	ScheduledAt string          `json:"scheduled_at"`                                                      // 定时发送时间（YYYY-MM-DD HH:MM:SS），不传为立即发送
+}
+
+// MessageBroadcastListRequest 群发消息列表请求
+type MessageBroadcastListRequest struct {
+	Page     int    `json:"page" binding:"required,min=1"`                                        // 页码，从1开始
+	PageSize int    `json:"page_size" binding:"required,min=1,max=100"`                           // 每页大小
+	Status   string `json:"status" binding:"omitempty,oneof=pending sending completed cancelled"` // 按状态筛选
+}
+
+// MessageBroadcastListResponse 群发消息列表响应
+type MessageBroadcastListResponse struct {
+	Broadcasts []MessageBroadcast `json:"broadcasts"`
+	Pagination PaginationInfo     `json:"pagination"`
+}
+
+// MessageBroadcastIDRequest 群发消息ID请求
+type MessageBroadcastIDRequest struct {
+	ID uint64 `json:"id" binding:"required,min=1"`
+}
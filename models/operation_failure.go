@@ -80,7 +80,10 @@ const (
 	OperationTypeWalletRecharge = "wallet_recharge"
 	OperationTypeBankCardBind   = "bank_card_bind"
 	OperationTypeGroupBuyJoin   = "group_buy_join"
+	OperationTypeGroupBuyCreate = "group_buy_create"
 	OperationTypeSystemTask     = "system_task"
+	OperationTypeLoginLock      = "login_lock"
+	OperationTypeLoginUnlock    = "login_unlock"
 )
 
 // OperationFailureResponse 操作失败记录响应
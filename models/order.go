@@ -247,9 +247,10 @@ const (
 
 // GetOrderListRequest 获取订单列表请求
 type GetOrderListRequest struct {
-	Page     int `json:"page" binding:"min=1"`
-	PageSize int `json:"page_size" binding:"min=1"`
-	Status   int `json:"status" binding:"min=1,max=3"` // 1:进行中 2:已完成 3:全部
+	Page     int    `json:"page" binding:"omitempty,min=1"` // 使用游标时可不传
+	PageSize int    `json:"page_size" binding:"min=1"`
+	Status   int    `json:"status" binding:"min=1,max=3"` // 1:进行中 2:已完成 3:全部
+	Cursor   string `json:"cursor"`                       // 游标，传入时按游标分页并忽略page
 }
 
 // GetStatusByType 根据状态类型获取对应的状态值
@@ -0,0 +1,21 @@
+package models
+
+// ForgotPasswordRequest 找回密码请求（发送验证码）
+type ForgotPasswordRequest struct {
+	Account string `json:"account" binding:"required"` // 可以是邮箱或手机号
+}
+
+// ForgotPasswordResponse 找回密码响应
+// 无论账号是否存在都返回相同结果，避免通过该接口探测已注册账号
+type ForgotPasswordResponse struct {
+	ExpiresIn      int `json:"expires_in"`      // 验证码有效期（秒）
+	ResendInterval int `json:"resend_interval"` // 重新发送间隔（秒）
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Account         string `json:"account" binding:"required"` // 可以是邮箱或手机号
+	Code            string `json:"code" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6,max=50"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import "time"
+
+// 推广佣金状态
+const (
+	ReferralCommissionStatusPending  = "pending"  // 等待入账（推荐人钱包冻结，解冻后补发）
+	ReferralCommissionStatusCredited = "credited" // 已入账
+)
+
+// UserReferral 用户推荐关系表，保存被推荐人与每一级上级推荐人的关系
+// 直接推荐人的层级为1，推荐人的推荐人为2，以此类推
+type UserReferral struct {
+	ID          uint64    `gorm:"primarykey" json:"id"`
+	Uid         string    `gorm:"size:8;not null;uniqueIndex:uniq_user_referral,priority:1;comment:被推荐用户ID" json:"uid"`
+	AncestorUid string    `gorm:"size:8;not null;uniqueIndex:uniq_user_referral,priority:2;index:idx_user_referrals_ancestor,priority:1;comment:上级推荐人ID" json:"ancestor_uid"`
+	Depth       int       `gorm:"not null;index:idx_user_referrals_ancestor,priority:2;comment:推荐层级，1为直接推荐" json:"depth"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;comment:建立关系时间" json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserReferral) TableName() string {
+	return "user_referrals"
+}
+
+// TableComment 表注释
+func (UserReferral) TableComment() string {
+	return "用户推荐关系表 - 记录被推荐用户与每一级上级推荐人的关系和层级"
+}
+
+// CommissionRule 推广佣金规则，按推荐层级配置佣金比例
+type CommissionRule struct {
+	ID        uint64    `gorm:"primarykey" json:"id"`
+	Depth     int       `gorm:"not null;uniqueIndex;comment:推荐层级，1为直接推荐" json:"depth"`
+	Rate      float64   `gorm:"type:decimal(5,2);not null;default:0;comment:佣金比例（订单金额的百分比）" json:"rate"`
+	Enabled   bool      `gorm:"not null;default:true;comment:是否启用" json:"enabled"`
+	Remark    string    `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt time.Time `gorm:"type:datetime(3);autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:datetime(3);autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CommissionRule) TableName() string {
+	return "commission_rules"
+}
+
+// TableComment 表注释
+func (CommissionRule) TableComment() string {
+	return "推广佣金规则表 - 按推荐层级配置下级完成订单时的佣金比例"
+}
+
+// ReferralCommission 推广佣金记录，同一订单对同一推荐人只产生一条
+type ReferralCommission struct {
+	ID             uint64    `gorm:"primarykey" json:"id"`
+	OrderNo        string    `gorm:"size:32;not null;uniqueIndex:uniq_referral_commission,priority:1;comment:下级完成的订单编号" json:"order_no"`
+	BeneficiaryUid string    `gorm:"size:8;not null;uniqueIndex:uniq_referral_commission,priority:2;index;comment:获得佣金的推荐人ID" json:"beneficiary_uid"`
+	RefereeUid     string    `gorm:"size:8;not null;index;comment:下单的被推荐用户ID" json:"referee_uid"`
+	Depth          int       `gorm:"not null;comment:推荐层级" json:"depth"`
+	OrderAmount    float64   `gorm:"type:decimal(15,2);not null;comment:订单金额" json:"order_amount"`
+	Rate           float64   `gorm:"type:decimal(5,2);not null;comment:佣金比例（百分比）" json:"rate"`
+	Amount         float64   `gorm:"type:decimal(15,2);not null;comment:佣金金额" json:"amount"`
+	TransactionNo  string    `gorm:"size:32;comment:入账交易流水号" json:"transaction_no"`
+	Status         string    `gorm:"size:20;not null;default:'pending';index;comment:状态 pending-等待入账 credited-已入账" json:"status"`
+	CreatedAt      time.Time `gorm:"autoCreateTime;index;comment:创建时间" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime;comment:更新时间" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ReferralCommission) TableName() string {
+	return "referral_commissions"
+}
+
+// TableComment 表注释
+func (ReferralCommission) TableComment() string {
+	return "推广佣金记录表 - 记录下级完成订单后各级推荐人获得的佣金和入账流水"
+}
+
+// MyReferralCodeResponse 我的邀请码响应
+type MyReferralCodeResponse struct {
+	InviteCode  string `json:"invite_code"`  // 我的邀请码，注册时填写即成为我的下级
+	DirectCount int64  `json:"direct_count"` // 直接推荐人数
+	TeamCount   int64  `json:"team_count"`   // 全部下级人数
+}
+
+// MyReferralListRequest 我的下级列表请求
+type MyReferralListRequest struct {
+	Page     int `json:"page" binding:"required,min=1"`              // 页码，从1开始
+	PageSize int `json:"page_size" binding:"required,min=1,max=100"` // 每页大小
+	Depth    int `json:"depth" binding:"min=0"`                      // 按推荐层级筛选，0为全部
+}
+
+// MyReferralItem 我的下级
+type MyReferralItem struct {
+	Uid       string    `json:"uid"`
+	Username  string    `json:"username"` // 脱敏后的用户名
+	Depth     int       `json:"depth"`    // 推荐层级，1为直接推荐
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MyReferralListResponse 我的下级列表响应
+type MyReferralListResponse struct {
+	Referrals  []MyReferralItem `json:"referrals"`
+	Pagination PaginationInfo   `json:"pagination"`
+}
+
+// MyCommissionListRequest 我的佣金记录请求
+type MyCommissionListRequest struct {
+	Page     int `json:"page" binding:"required,min=1"`              // 页码，从1开始
+	PageSize int `json:"page_size" binding:"required,min=1,max=100"` // 每页大小
+}
+
+// MyCommissionListResponse 我的佣金记录响应
+type MyCommissionListResponse struct {
+	TotalAmount float64              `json:"total_amount"` // 已入账佣金总额
+	Commissions []ReferralCommission `json:"commissions"`
+	Pagination  PaginationInfo       `json:"pagination"`
+}
+
+// CommissionRuleSaveRequest 保存佣金规则请求，该层级已有规则时覆盖
+type CommissionRuleSaveRequest struct {
+	Depth   int     `json:"depth" binding:"required,min=1"` // 推荐层级
+	Rate    float64 `json:"rate" binding:"min=0,max=100"`   // 佣金比例（订单金额的百分比）
+	Enabled *bool   `json:"enabled"`                        // 是否启用，不传为启用
+	Remark  string  `json:"remark" binding:"max=255"`       // 备注
+}
+
+// CommissionRuleDeleteRequest 删除佣金规则请求
+type CommissionRuleDeleteRequest struct {
+	Depth int `json:"depth" binding:"required,min=1"` // 推荐层级
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// 经验值与信用分事件类型
+const (
+	ScoreEventOrderCompleted    = "order_completed"     // 订单完成
+	ScoreEventGroupBuyCompleted = "group_buy_completed" // 拼单成团
+	ScoreEventOrderExpired      = "order_expired"       // 订单过期
+	ScoreEventWithdrawCancelled = "withdraw_cancelled"  // 提现取消
+	ScoreEventAdminAdjust       = "admin_adjust"        // 管理员手动调整，不对应规则
+)
+
+// ScoreRule 经验值与信用分规则，每种事件一条，由管理员配置
+type ScoreRule struct {
+	ID          uint64    `gorm:"primarykey" json:"id"`
+	Event       string    `gorm:"size:32;not null;uniqueIndex;comment:事件类型" json:"event"`
+	Name        string    `gorm:"size:50;not null;comment:规则名称" json:"name"`
+	Experience  int       `gorm:"not null;default:0;comment:经验值变化，负数表示扣减" json:"experience"`
+	CreditScore int       `gorm:"not null;default:0;comment:信用分变化，负数表示扣减" json:"credit_score"`
+	Enabled     bool      `gorm:"not null;default:true;comment:是否启用" json:"enabled"`
+	Remark      string    `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt   time.Time `gorm:"type:datetime(3);autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"type:datetime(3);autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ScoreRule) TableName() string {
+	return "score_rules"
+}
+
+// TableComment 表注释
+func (ScoreRule) TableComment() string {
+	return "经验值与信用分规则表 - 每种事件对应的经验值和信用分变化"
+}
+
+// IsEffective 规则是否启用且会产生变化
+func (r *ScoreRule) IsEffective() bool {
+	return r.Enabled && (r.Experience != 0 || r.CreditScore != 0)
+}
+
+// ScoreRuleUpdateRequest 修改规则请求，只修改传入的字段，事件类型不可修改
+type ScoreRuleUpdateRequest struct {
+	Event       string  `json:"event" binding:"required,max=32"`                     // 事件类型
+	Experience  *int    `json:"experience" binding:"omitempty,min=-10000,max=10000"` // 经验值变化
+	CreditScore *int    `json:"credit_score" binding:"omitempty,min=-1000,max=1000"` // 信用分变化
+	Enabled     *bool   `json:"enabled"`                                             // 是否启用
+	Remark      *string `json:"remark" binding:"omitempty,max=255"`                  // 备注
+}
+
+// ScoreAdjustRequest 管理员手动调整用户经验值和信用分请求
+type ScoreAdjustRequest struct {
+	Uid         string `json:"uid" binding:"required,len=8"`                // 用户唯一ID
+	Experience  int    `json:"experience" binding:"min=-100000,max=100000"` // 经验值变化，负数表示扣减
+	CreditScore int    `json:"credit_score" binding:"min=-1000,max=1000"`   // 信用分变化，负数表示扣减
+	Remark      string `json:"remark" binding:"required,max=255"`           // 调整原因
+}
@@ -0,0 +1,40 @@
+package models
+
+// TwoFactorCodeRequest 两步验证码请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // 动态验证码或恢复码
+}
+
+// TwoFactorLoginRequest 两步验证登录请求
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"` // 登录第一步返回的挑战令牌
+	Code           string `json:"code" binding:"required"`            // 动态验证码或恢复码
+}
+
+// TwoFactorStatusRequest 获取两步验证状态请求
+type TwoFactorStatusRequest struct {
+	// 空结构体，因为获取当前用户两步验证状态不需要额外参数
+}
+
+// TwoFactorSetupRequest 开始绑定两步验证请求
+type TwoFactorSetupRequest struct {
+	// 空结构体，因为绑定当前用户两步验证不需要额外参数
+}
+
+// TwoFactorStatusResponse 两步验证状态响应
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`                  // 是否已开启
+	RemainingRecoveryCodes int  `json:"remaining_recovery_codes"` // 剩余恢复码数量
+}
+
+// TwoFactorSetupResponse 开始绑定两步验证响应
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`           // TOTP密钥，无法扫码时手动输入
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth链接，客户端生成二维码供验证器App扫描
+	ExpiresIn       int64  `json:"expires_in"`       // 绑定有效期（秒），过期需重新开始
+}
+
+// TwoFactorRecoveryCodesResponse 恢复码响应，恢复码只展示这一次
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 一次性恢复码
+}
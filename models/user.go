@@ -22,12 +22,20 @@ type User struct {
 	Email                    string     `json:"email" gorm:"uniqueIndex;not null;size:100;comment:邮箱地址"`
 	Password                 string     `json:"-" gorm:"not null;size:255;comment:密码哈希"`
 	Phone                    string     `json:"phone" gorm:"size:20;index;comment:手机号"`
+	EmailVerified            bool       `json:"email_verified" gorm:"default:false;comment:邮箱是否已验证"`
+	EmailVerifiedAt          *time.Time `json:"email_verified_at" gorm:"comment:邮箱验证时间"`
+	PhoneVerified            bool       `json:"phone_verified" gorm:"default:false;comment:手机号是否已验证"`
+	PhoneVerifiedAt          *time.Time `json:"phone_verified_at" gorm:"comment:手机号验证时间"`
 	BankCardInfo             string     `json:"bank_card_info" gorm:"type:json;comment:银行卡信息JSON"`
 	Experience               int        `json:"experience" gorm:"default:0;comment:用户经验值"`
 	CreditScore              int        `json:"credit_score" gorm:"default:100;comment:用户信用分"`
 	Status                   int        `json:"status" gorm:"default:2;comment:用户状态 0:禁用 1:正常 2:待审核"`
 	InvitedBy                string     `json:"invited_by" gorm:"size:6;index;comment:注册时填写的邀请码"`
+	MyInviteCode             *string    `json:"-" gorm:"size:6;uniqueIndex;comment:用户自己的邀请码，其他用户注册时填写即成为下级"`
 	HasGroupBuyQualification bool       `json:"has_group_buy_qualification" gorm:"default:false;comment:是否有拼单资格"`
+	TwoFactorEnabled         bool       `json:"two_factor_enabled" gorm:"default:false;comment:是否开启两步验证"`
+	TwoFactorSecret          string     `json:"-" gorm:"size:64;comment:两步验证TOTP密钥"`
+	TwoFactorRecoveryCodes   string     `json:"-" gorm:"type:text;comment:两步验证恢复码哈希JSON"`
 	Rate                     int        `json:"rate" gorm:"-"` // 用户等级进度（从Redis获取，不存储到数据库）
 	CreatedAt                time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt                time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
@@ -65,13 +73,16 @@ type UserResponse struct {
 	Username                 string    `json:"username"`
 	Email                    string    `json:"email"`
 	Phone                    string    `json:"phone"`
+	EmailVerified            bool      `json:"email_verified"` // 邮箱是否已验证
+	PhoneVerified            bool      `json:"phone_verified"` // 手机号是否已验证
 	BankCardInfo             string    `json:"bank_card_info"`
 	Experience               int       `json:"experience"`
 	CreditScore              int       `json:"credit_score"`
 	Status                   int       `json:"status"`
 	InvitedBy                string    `json:"invited_by"`
 	HasGroupBuyQualification bool      `json:"has_group_buy_qualification"`
-	Rate                     int       `json:"rate"` // 用户等级进度（整数）
+	TwoFactorEnabled         bool      `json:"two_factor_enabled"` // 是否开启两步验证
+	Rate                     int       `json:"rate"`               // 用户等级进度（整数）
 	CreatedAt                time.Time `json:"created_at"`
 }
 
@@ -81,6 +92,10 @@ type TokenResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int64  `json:"expires_in"`
+
+	// 开启两步验证时登录分两步：先返回挑战令牌，提交动态验证码后再签发令牌
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"` // 是否需要两步验证
+	ChallengeToken    string `json:"challenge_token,omitempty"`     // 两步验证挑战令牌
 }
 
 // RefreshTokenRequest 刷新Token请求
@@ -105,6 +120,7 @@ type GetProfileRequest struct {
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`       // 旧密码
 	NewPassword string `json:"new_password" binding:"required,min=6"` // 新密码
+	TotpCode    string `json:"totp_code"`                             // 动态验证码，开启两步验证时必填
 }
 
 // GetBankCardRequest 获取银行卡信息请求
@@ -122,6 +138,16 @@ type GetSessionUserRequest struct {
 	// 空结构体，因为获取会话用户信息不需要额外参数
 }
 
+// ListSessionsRequest 获取会话列表请求
+type ListSessionsRequest struct {
+	// 空结构体，因为获取当前用户会话列表不需要额外参数
+}
+
+// RevokeSessionRequest 登出指定会话请求
+type RevokeSessionRequest struct {
+	SessionID string `json:"session_id" binding:"required"` // 会话ID
+}
+
 // GetWalletRequest 获取钱包信息请求
 type GetWalletRequest struct {
 	// 空结构体，因为获取当前用户钱包信息不需要额外参数
@@ -129,9 +155,10 @@ type GetWalletRequest struct {
 
 // GetTransactionsRequest 获取交易记录请求
 type GetTransactionsRequest struct {
-	Page     int    `json:"page" binding:"min=1"`              // 页码，从1开始
-	PageSize int    `json:"page_size" binding:"min=1"`         // 每页大小，最小1
-	Type     string `json:"type"`                              // 交易类型过滤：recharge(充值)、withdraw(提现)、purchase(购买)、group_buy(拼单)、profit(利润)
+	Page     int    `json:"page" binding:"omitempty,min=1"` // 页码，从1开始，使用游标时可不传
+	PageSize int    `json:"page_size" binding:"min=1"`      // 每页大小，最小1
+	Type     string `json:"type"`                           // 交易类型过滤：recharge(充值)、withdraw(提现)、purchase(购买)、group_buy(拼单)、profit(利润)
+	Cursor   string `json:"cursor"`                         // 游标，传入时按游标分页并忽略page
 }
 
 // GetWithdrawSummaryRequest 获取提现汇总请求
@@ -168,12 +195,15 @@ func (u *User) ToResponse() UserResponse {
 		Username:                 u.Username,
 		Email:                    utils.MaskEmail(u.Email),
 		Phone:                    utils.MaskPhone(u.Phone),
+		EmailVerified:            u.EmailVerified,
+		PhoneVerified:            u.PhoneVerified,
 		BankCardInfo:             u.BankCardInfo,
 		Experience:               u.Experience,
 		CreditScore:              u.CreditScore,
 		Status:                   u.Status,
 		InvitedBy:                u.InvitedBy,
 		HasGroupBuyQualification: u.HasGroupBuyQualification,
+		TwoFactorEnabled:         u.TwoFactorEnabled,
 		Rate:                     0, // 默认值，实际值需要从Redis获取
 		CreatedAt:                u.CreatedAt,
 	}
@@ -184,6 +214,25 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive
 }
 
+// IsContactVerified 检查指定的邮箱或手机号是否为该用户已验证的联系方式
+func (u *User) IsContactVerified(account string) bool {
+	if account == "" {
+		return false
+	}
+	if account == u.Email {
+		return u.EmailVerified
+	}
+	if account == u.Phone {
+		return u.PhoneVerified
+	}
+	return false
+}
+
+// HasVerifiedContact 检查用户是否至少验证过一个邮箱或手机号
+func (u *User) HasVerifiedContact() bool {
+	return (u.Email != "" && u.EmailVerified) || (u.Phone != "" && u.PhoneVerified)
+}
+
 // CheckGroupBuyQualification 检查用户是否有拼单资格
 func (u *User) CheckGroupBuyQualification() bool {
 	return u.HasGroupBuyQualification
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 用户实时事件类型
+const (
+	UserEventMessage  = "message"   // 收到新消息
+	UserEventBalance  = "balance"   // 钱包余额或状态变化
+	UserEventOrder    = "order"     // 订单状态变化
+	UserEventGroupBuy = "group_buy" // 拼单进度变化
+	UserEventResync   = "resync"    // 断线期间的事件已过期，客户端需要重新拉取完整数据
+	UserEventLogout   = "logout"    // 登录状态已失效，服务端随后断开连接，客户端不应自动重连
+)
+
+// UserEvent 推送给用户的实时事件
+// ID 为Redis Stream条目ID（毫秒时间戳-序号），同一用户的事件ID递增，断线重连时作为Last-Event-ID续传
+type UserEvent struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// BalanceEventData 钱包变化事件内容
+type BalanceEventData struct {
+	Balance   float64   `json:"balance"`
+	Status    int       `json:"status"` // 生效中的钱包状态，限制到期后为正常
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrderEventData 订单状态变化事件内容
+type OrderEventData struct {
+	OrderNo      string  `json:"order_no"`
+	PeriodNumber string  `json:"period_number"`
+	Amount       float64 `json:"amount"`
+	Status       string  `json:"status"`
+}
+
+// GroupBuyEventData 拼单进度事件内容
+type GroupBuyEventData struct {
+	GroupBuyNo          string `json:"group_buy_no"`
+	Status              string `json:"status"`
+	CurrentParticipants int    `json:"current_participants"`
+	TargetParticipants  int    `json:"target_participants"`
+}
+
+// EventStreamTokenResponse 实时推送连接令牌响应
+type EventStreamTokenResponse struct {
+	StreamToken string `json:"stream_token"` // 一次性连接令牌，作为stream_token参数建立连接
+	ExpiresIn   int    `json:"expires_in"`   // 有效期（秒），过期或使用后失效
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// UserLevelOverride 用户等级覆盖，管理员为指定用户固定会员等级，不再按升级要求计算
+type UserLevelOverride struct {
+	ID        uint64    `gorm:"primarykey" json:"id"`
+	Uid       string    `gorm:"size:8;not null;uniqueIndex;comment:用户唯一ID" json:"uid"`
+	Level     int       `gorm:"not null;index;comment:固定的等级数值" json:"level"`
+	Remark    string    `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedBy string    `gorm:"size:50;comment:设置人" json:"created_by"`
+	CreatedAt time.Time `gorm:"type:datetime(3);autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:datetime(3);autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserLevelOverride) TableName() string {
+	return "user_level_overrides"
+}
+
+// TableComment 表注释
+func (UserLevelOverride) TableComment() string {
+	return "用户等级覆盖表 - 管理员为指定用户固定的会员等级"
+}
+
+// UserLevelOverrideSetRequest 设置用户等级覆盖请求
+type UserLevelOverrideSetRequest struct {
+	Uid    string `json:"uid" binding:"required,len=8"`   // 用户唯一ID
+	Level  int    `json:"level" binding:"required,min=1"` // 固定的等级数值，必须是已配置的等级
+	Remark string `json:"remark" binding:"max=255"`       // 备注
+}
+
+// UserLevelOverrideClearRequest 清除用户等级覆盖请求
+type UserLevelOverrideClearRequest struct {
+	Uid string `json:"uid" binding:"required,len=8"` // 用户唯一ID
+}
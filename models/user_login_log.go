@@ -20,6 +20,12 @@ type UserLoginLog struct {
 	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
+// 登录状态
+const (
+	LoginStatusFailed  = 0 // 失败
+	LoginStatusSuccess = 1 // 成功
+)
+
 // TableName 指定表名
 func (UserLoginLog) TableName() string {
 	return "user_login_logs"
@@ -66,5 +72,17 @@ func (l *UserLoginLog) ToResponse() UserLoginLogResponse {
 
 // IsSuccess 检查登录是否成功
 func (l *UserLoginLog) IsSuccess() bool {
-	return l.Status == 1
+	return l.Status == LoginStatusSuccess
+}
+
+// LoginUnlockRequest 管理员解除登录锁定请求，账号和IP至少填写一个
+type LoginUnlockRequest struct {
+	Account string `json:"account"` // 邮箱或手机号
+	IP      string `json:"ip"`      // 登录IP
+}
+
+// LoginUnlockResponse 解除登录锁定响应
+type LoginUnlockResponse struct {
+	AccountUnlocked bool `json:"account_unlocked"` // 账号此前是否处于锁定状态
+	IPUnlocked      bool `json:"ip_unlocked"`      // IP此前是否处于锁定状态
 }
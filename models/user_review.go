@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// PendingUserListRequest 待审核用户列表请求
+type PendingUserListRequest struct {
+	Page       int    `json:"page" binding:"min=1"`              // 页码，从1开始
+	PageSize   int    `json:"page_size" binding:"min=1,max=100"` // 每页大小
+	InviteCode string `json:"invite_code" binding:"max=6"`       // 按注册邀请码筛选
+	StartDate  string `json:"start_date"`                        // 注册开始日期（YYYY-MM-DD）
+	EndDate    string `json:"end_date"`                          // 注册结束日期（YYYY-MM-DD），包含当天
+}
+
+// PendingUserItem 待审核用户信息
+type PendingUserItem struct {
+	Uid       string    `json:"uid"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	InvitedBy string    `json:"invited_by"` // 注册时填写的邀请码
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PendingUserListResponse 待审核用户列表响应
+type PendingUserListResponse struct {
+	Users      []PendingUserItem `json:"users"`
+	Pagination PaginationInfo    `json:"pagination"`
+}
+
+// UserApproveRequest 审核通过请求，支持批量
+type UserApproveRequest struct {
+	Uids   []string `json:"uids" binding:"required,min=1,max=100,dive,required"`
+	Reason string   `json:"reason" binding:"max=200"` // 审核备注
+}
+
+// UserRejectRequest 审核拒绝请求，支持批量，必须填写原因
+type UserRejectRequest struct {
+	Uids   []string `json:"uids" binding:"required,min=1,max=100,dive,required"`
+	Reason string   `json:"reason" binding:"required,max=200"` // 拒绝原因，会通知给用户
+}
+
+// UserReviewFailure 审核失败的用户
+type UserReviewFailure struct {
+	Uid    string `json:"uid"`
+	Reason string `json:"reason"`
+}
+
+// UserReviewResponse 审核结果响应
+type UserReviewResponse struct {
+	Succeeded []string            `json:"succeeded"`
+	Failed    []UserReviewFailure `json:"failed"`
+}
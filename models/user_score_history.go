@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// UserScoreHistory 用户经验值与信用分变更记录，只追加不修改
+// 同一用户的同一事件和关联单号只记录一次，重复触发时不会重复加减
+type UserScoreHistory struct {
+	ID                uint64    `gorm:"primarykey" json:"id"`
+	Uid               string    `gorm:"size:8;not null;uniqueIndex:uniq_user_score_event,priority:1;comment:用户唯一ID" json:"uid"`
+	Event             string    `gorm:"size:32;not null;uniqueIndex:uniq_user_score_event,priority:2;index;comment:事件类型" json:"event"`
+	RefID             string    `gorm:"size:64;not null;uniqueIndex:uniq_user_score_event,priority:3;comment:关联单号（订单号、拼单号、交易流水号）" json:"ref_id"`
+	ExperienceChange  int       `gorm:"not null;default:0;comment:经验值实际变化" json:"experience_change"`
+	CreditScoreChange int       `gorm:"not null;default:0;comment:信用分实际变化（已按上下限截断）" json:"credit_score_change"`
+	ExperienceAfter   int       `gorm:"not null;comment:变化后的经验值" json:"experience_after"`
+	CreditScoreAfter  int       `gorm:"not null;comment:变化后的信用分" json:"credit_score_after"`
+	Operator          string    `gorm:"size:50;not null;default:'system';comment:操作人，规则触发时为system" json:"operator"`
+	Remark            string    `gorm:"size:255;comment:备注" json:"remark"`
+	CreatedAt         time.Time `gorm:"type:datetime(3);autoCreateTime;index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserScoreHistory) TableName() string {
+	return "user_score_histories"
+}
+
+// TableComment 表注释
+func (UserScoreHistory) TableComment() string {
+	return "用户经验值与信用分变更记录表 - 记录每次变更的事件、关联单号、变化量和变化后的值"
+}
+
+// ScoreHistoryQueryRequest 经验值与信用分变更记录查询请求
+type ScoreHistoryQueryRequest struct {
+	Page      int    `json:"page" binding:"min=1"`              // 页码，从1开始
+	PageSize  int    `json:"page_size" binding:"min=1,max=100"` // 每页大小
+	Uid       string `json:"uid" binding:"omitempty,len=8"`     // 按用户筛选
+	Event     string `json:"event" binding:"max=32"`            // 按事件类型筛选
+	StartTime string `json:"start_time"`                        // 开始时间（YYYY-MM-DD HH:MM:SS）
+	EndTime   string `json:"end_time"`                          // 结束时间（YYYY-MM-DD HH:MM:SS）
+}
+
+// ScoreHistoryQueryResponse 经验值与信用分变更记录查询响应
+type ScoreHistoryQueryResponse struct {
+	Histories  []UserScoreHistory `json:"histories"`
+	Pagination PaginationInfo     `json:"pagination"`
+}
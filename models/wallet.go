@@ -15,14 +15,16 @@ const (
 
 // Wallet 钱包模型
 type Wallet struct {
-	ID           uint      `gorm:"primarykey" json:"id"`
-	Uid          string    `gorm:"uniqueIndex;not null;size:8;comment:用户唯一ID" json:"uid"`                // 用户ID
-	Balance      float64   `gorm:"type:decimal(15,2);default:0.00;not null;comment:钱包余额" json:"balance"` // 总余额
-	Status       int       `gorm:"default:1;comment:钱包状态 1:正常 0:冻结 2:无法提现" json:"status"`                // 状态：1-正常，0-冻结，2-无法提现
-	Currency     string    `gorm:"default:'PHP';size:3;comment:货币类型" json:"currency"`                    // 货币类型
-	LastActiveAt time.Time `gorm:"autoUpdateTime;comment:最后活跃时间" json:"last_active_at"`                  // 最后活跃时间
-	CreatedAt    time.Time `gorm:"autoCreateTime;comment:创建时间" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime;comment:更新时间" json:"updated_at"`
+	ID              uint       `gorm:"primarykey" json:"id"`
+	Uid             string     `gorm:"uniqueIndex;not null;size:8;comment:用户唯一ID" json:"uid"`                // 用户ID
+	Balance         float64    `gorm:"type:decimal(15,2);default:0.00;not null;comment:钱包余额" json:"balance"` // 总余额
+	Status          int        `gorm:"default:1;comment:钱包状态 1:正常 0:冻结 2:无法提现" json:"status"`                // 状态：1-正常，0-冻结，2-无法提现
+	Currency        string     `gorm:"default:'PHP';size:3;comment:货币类型" json:"currency"`                    // 货币类型
+	StatusReason    string     `gorm:"size:255;comment:状态变更原因" json:"status_reason"`                         // 状态变更原因
+	StatusExpiresAt *time.Time `gorm:"index;comment:状态到期时间，到期后自动恢复正常，为空表示不自动恢复" json:"status_expires_at"`    // 冻结或限制提现的到期时间
+	LastActiveAt    time.Time  `gorm:"autoUpdateTime;comment:最后活跃时间" json:"last_active_at"`                  // 最后活跃时间
+	CreatedAt       time.Time  `gorm:"autoCreateTime;comment:创建时间" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime;comment:更新时间" json:"updated_at"`
 }
 
 // TableName 指定表名
@@ -38,14 +40,15 @@ func (Wallet) TableComment() string {
 // ToResponse 转换为响应格式
 func (w *Wallet) ToResponse() gin.H {
 	return gin.H{
-		"id":             w.ID,
-		"uid":            w.Uid,
-		"balance":        w.Balance,
-		"status":         w.Status,
-		"currency":       w.Currency,
-		"last_active_at": w.LastActiveAt,
-		"created_at":     w.CreatedAt,
-		"updated_at":     w.UpdatedAt,
+		"id":                w.ID,
+		"uid":               w.Uid,
+		"balance":           w.Balance,
+		"status":            w.EffectiveStatus(),
+		"status_expires_at": w.StatusExpiresAt,
+		"currency":          w.Currency,
+		"last_active_at":    w.LastActiveAt,
+		"created_at":        w.CreatedAt,
+		"updated_at":        w.UpdatedAt,
 	}
 }
 
@@ -70,29 +73,43 @@ func (w *Wallet) Withdraw(amount float64) error {
 	return nil
 }
 
+// EffectiveStatus 获取当前生效的状态，冻结或限制提现已到期时视为正常，不必等待定时任务恢复
+func (w *Wallet) EffectiveStatus() int {
+	if w.Status != WalletStatusNormal && w.IsStatusExpired(time.Now()) {
+		return WalletStatusNormal
+	}
+	return w.Status
+}
+
+// IsStatusExpired 检查冻结或限制提现在指定时间是否已到期
+func (w *Wallet) IsStatusExpired(now time.Time) bool {
+	return w.StatusExpiresAt != nil && !w.StatusExpiresAt.After(now)
+}
+
 // IsActive 检查钱包是否激活
 func (w *Wallet) IsActive() bool {
-	return w.Status == WalletStatusNormal
+	return w.EffectiveStatus() == WalletStatusNormal
 }
 
 // IsFrozen 检查钱包是否冻结
 func (w *Wallet) IsFrozen() bool {
-	return w.Status == WalletStatusFrozen
+	return w.EffectiveStatus() == WalletStatusFrozen
 }
 
 // IsNoWithdraw 检查钱包是否无法提现
 func (w *Wallet) IsNoWithdraw() bool {
-	return w.Status == WalletStatusNoWithdraw
+	return w.EffectiveStatus() == WalletStatusNoWithdraw
 }
 
 // CanWithdraw 检查钱包是否可以提现
 func (w *Wallet) CanWithdraw() bool {
-	return w.Status != WalletStatusNoWithdraw && w.Status != WalletStatusFrozen
+	status := w.EffectiveStatus()
+	return status != WalletStatusNoWithdraw && status != WalletStatusFrozen
 }
 
 // CanOperate 检查钱包是否可以操作（充值、消费等）
 func (w *Wallet) CanOperate() bool {
-	return w.Status != WalletStatusFrozen
+	return w.EffectiveStatus() != WalletStatusFrozen
 }
 
 // GetStatusName 获取状态名称
@@ -102,7 +119,7 @@ func (w *Wallet) GetStatusName() string {
 		WalletStatusFrozen:     "冻结",
 		WalletStatusNoWithdraw: "无法提现",
 	}
-	return statusNames[w.Status]
+	return statusNames[w.EffectiveStatus()]
 }
 
 // UpdateLastActive 更新最后活跃时间
@@ -123,3 +140,25 @@ type WalletError struct {
 func (e *WalletError) Error() string {
 	return e.Message
 }
+
+// WalletRestrictRequest 冻结钱包或限制提现请求
+type WalletRestrictRequest struct {
+	Uid       string `json:"uid" binding:"required,len=8"`      // 用户唯一ID
+	Reason    string `json:"reason" binding:"required,max=255"` // 原因，会通过站内消息告知用户
+	ExpiresAt string `json:"expires_at"`                        // 到期时间（YYYY-MM-DD HH:MM:SS），到期后自动恢复正常，不传表示不自动恢复
+}
+
+// WalletUnfreezeRequest 恢复钱包正常状态请求
+type WalletUnfreezeRequest struct {
+	Uid    string `json:"uid" binding:"required,len=8"`      // 用户唯一ID
+	Reason string `json:"reason" binding:"required,max=255"` // 原因，会通过站内消息告知用户
+}
+
+// WalletStatusResponse 钱包状态响应
+type WalletStatusResponse struct {
+	Uid             string     `json:"uid"`
+	Status          int        `json:"status"`
+	StatusName      string     `json:"status_name"`
+	StatusReason    string     `json:"status_reason"`
+	StatusExpiresAt *time.Time `json:"status_expires_at"`
+}
@@ -7,11 +7,13 @@ import (
 
 // TransactionType 交易类型枚举
 const (
-	TransactionTypeRecharge = "recharge"  // 充值
-	TransactionTypeWithdraw = "withdraw"  // 提现
-	TransactionTypeOrderBuy = "purchase"  // 购买
-	TransactionTypeGroupBuy = "group_buy" // 拼单
-	TransactionTypeProfit   = "profit"    // 利润
+	TransactionTypeRecharge       = "recharge"         // 充值
+	TransactionTypeWithdraw       = "withdraw"         // 提现
+	TransactionTypeOrderBuy       = "purchase"         // 购买
+	TransactionTypeGroupBuy       = "group_buy"        // 拼单
+	TransactionTypeProfit         = "profit"           // 利润
+	TransactionTypeGroupBuyRefund = "group_buy_refund" // 拼单退款
+	TransactionTypeCommission     = "commission"       // 推广佣金
 )
 
 // TransactionStatus 交易状态枚举
@@ -102,11 +104,13 @@ func (t *WalletTransaction) ToResponse() WalletTransactionResponse {
 // GetTypeName 获取交易类型名称
 func (t *WalletTransaction) GetTypeName() string {
 	typeNames := map[string]string{
-		TransactionTypeRecharge: "充值",
-		TransactionTypeWithdraw: "提现",
-		TransactionTypeOrderBuy: "购买订单",
-		TransactionTypeGroupBuy: "拼单",
-		TransactionTypeProfit:   "利润",
+		TransactionTypeRecharge:       "充值",
+		TransactionTypeWithdraw:       "提现",
+		TransactionTypeOrderBuy:       "购买订单",
+		TransactionTypeGroupBuy:       "拼单",
+		TransactionTypeProfit:         "利润",
+		TransactionTypeGroupBuyRefund: "拼单退款",
+		TransactionTypeCommission:     "推广佣金",
 	}
 	return typeNames[t.Type]
 }
@@ -180,6 +184,7 @@ type GetTransactionsResponse struct {
 	Total        int64               `json:"total"`
 	Page         int                 `json:"page"`
 	PageSize     int                 `json:"page_size"`
+	NextCursor   string              `json:"next_cursor"` // 下一页游标，没有下一页时为空
 }
 
 // WithdrawResponse 提现响应
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	auditAppendRetries = 3                  // 并发写入冲突（死锁）时的重试次数
+	auditVerifyBatch   = 500                // 校验哈希链时每批读取的记录数
+	auditHeadKey       = "admin_audit:head" // 最新一条记录的ID和哈希，用于发现尾部记录被删除
+)
+
+// AdminAuditService 管理员操作审计服务
+// 审计日志只追加不修改，记录之间通过哈希链关联，篡改可通过Verify发现
+type AdminAuditService struct {
+	repo *database.AdminAuditLogRepository
+}
+
+// NewAdminAuditService 创建管理员操作审计服务实例
+func NewAdminAuditService() *AdminAuditService {
+	return &AdminAuditService{
+		repo: database.NewAdminAuditLogRepository(),
+	}
+}
+
+// Record 记录管理员操作，操作人、IP和请求ID从上下文获取，before和after会序列化为JSON并计算变更字段
+// 审计失败只记录错误日志，不影响业务操作
+func (s *AdminAuditService) Record(ctx context.Context, action, targetType, targetID string, before, after interface{}) error {
+	return s.RecordWith(ctx, action, targetType, targetID, before, after, nil)
+}
+
+// RecordWith 在同一事务中执行业务写入apply并记录审计日志，二者同时成功或同时回滚
+// apply返回的错误原样返回且不重试，审计写入失败时返回写入错误
+func (s *AdminAuditService) RecordWith(ctx context.Context, action, targetType, targetID string, before, after interface{}, apply func(tx *gorm.DB) error) error {
+	actor := utils.GetAuditActor(ctx)
+	entry := &models.AdminAuditLog{
+		AdminID:       actor.AdminID,
+		AdminUsername: actor.Username,
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		Before:        marshalAuditValue(before),
+		After:         marshalAuditValue(after),
+		IP:            actor.IP,
+		RequestID:     actor.RequestID,
+	}
+	entry.Diff = buildAuditDiff(entry.Before, entry.After)
+
+	var err error
+	for i := 0; i < auditAppendRetries; i++ {
+		// 精确到毫秒，与数据库datetime(3)一致，保证重新计算哈希时结果相同
+		entry.ID = 0
+		entry.CreatedAt = time.Now().Truncate(time.Millisecond)
+		var applyErr error
+		err = s.repo.AppendWith(ctx, entry, func(prevHash string) {
+			entry.PrevHash = prevHash
+			entry.Hash = computeAuditHash(entry)
+		}, func(tx *gorm.DB) error {
+			if apply == nil {
+				return nil
+			}
+			applyErr = apply(tx)
+			return applyErr
+		})
+		if err == nil {
+			database.SetKey(ctx, auditHeadKey, fmt.Sprintf("%d:%s", entry.ID, entry.Hash), 0)
+			return nil
+		}
+		if applyErr != nil {
+			return applyErr
+		}
+	}
+
+	utils.LogError(nil, "写入审计日志失败 - 操作人: %s, 操作: %s, 对象: %s:%s, 错误: %v", actor.Username, action, targetType, targetID, err)
+	return err
+}
+
+// Query 按操作人、操作、对象和时间查询审计日志
+func (s *AdminAuditService) Query(ctx context.Context, req *models.AdminAuditLogQueryRequest) (*models.AdminAuditLogQueryResponse, error) {
+	filter := &database.AdminAuditLogFilter{
+		AdminID:    req.AdminID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+	}
+	if req.StartTime != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartTime, time.Local)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "开始时间格式错误，应为YYYY-MM-DD HH:MM:SS")
+		}
+		filter.Start = &t
+	}
+	if req.EndTime != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.EndTime, time.Local)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "结束时间格式错误，应为YYYY-MM-DD HH:MM:SS")
+		}
+		filter.End = &t
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	logs, total, err := s.repo.Query(ctx, filter, req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询审计日志失败")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+	return &models.AdminAuditLogQueryResponse{
+		Logs: logs,
+		Pagination: models.PaginationInfo{
+			CurrentPage: req.Page,
+			PageSize:    req.PageSize,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     req.Page < totalPages,
+			HasPrev:     req.Page > 1,
+		},
+	}, nil
+}
+
+// Verify 从第一条记录开始校验哈希链，返回第一处断裂的位置
+func (s *AdminAuditService) Verify(ctx context.Context) (*models.AdminAuditVerifyResponse, error) {
+	result := &models.AdminAuditVerifyResponse{Valid: true}
+	prevHash := ""
+	var lastID uint64
+
+	for {
+		logs, err := s.repo.ListAfter(ctx, lastID, auditVerifyBatch)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "读取审计日志失败")
+		}
+
+		for i := range logs {
+			log := &logs[i]
+			if log.PrevHash != prevHash {
+				return s.broken(result, log.ID, "与上一条记录的哈希不一致，记录可能被删除或插入"), nil
+			}
+			if computeAuditHash(log) != log.Hash {
+				return s.broken(result, log.ID, "记录内容与哈希不一致，记录可能被修改"), nil
+			}
+			prevHash = log.Hash
+			lastID = log.ID
+			result.Checked++
+		}
+
+		if len(logs) < auditVerifyBatch {
+			break
+		}
+	}
+
+	// 对比最新记录，发现尾部记录被删除
+	if head, err := database.GetKey(ctx, auditHeadKey); err == nil && head != "" {
+		if head != fmt.Sprintf("%d:%s", lastID, prevHash) {
+			parts := strings.SplitN(head, ":", 2)
+			var headID uint64
+			fmt.Sscanf(parts[0], "%d", &headID)
+			// 校验期间有新记录写入时最新记录会更晚，不视为断裂
+			if headID <= lastID {
+				return s.broken(result, lastID, "最后一条记录与已知的最新记录不一致，尾部记录可能被删除"), nil
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// broken 标记哈希链断裂
+func (s *AdminAuditService) broken(result *models.AdminAuditVerifyResponse, id uint64, reason string) *models.AdminAuditVerifyResponse {
+	result.Valid = false
+	result.BrokenAtID = id
+	result.Reason = reason
+	utils.LogSecurityEvent(nil, "audit_chain_broken", fmt.Sprintf("记录ID: %d, 原因: %s", id, reason))
+	return result
+}
+
+// computeAuditHash 计算审计记录哈希，内容按固定顺序序列化为JSON数组，避免字段拼接产生歧义
+func computeAuditHash(log *models.AdminAuditLog) string {
+	data, _ := json.Marshal([]interface{}{
+		log.PrevHash,
+		log.AdminID,
+		log.AdminUsername,
+		log.Action,
+		log.TargetType,
+		log.TargetID,
+		log.Before,
+		log.After,
+		log.Diff,
+		log.IP,
+		log.RequestID,
+		log.CreatedAt.UnixMilli(),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// marshalAuditValue 将操作前后数据序列化为JSON，nil返回空字符串
+func marshalAuditValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// buildAuditDiff 对比操作前后的JSON对象，返回变更字段及新旧值
+func buildAuditDiff(before, after string) string {
+	var beforeMap, afterMap map[string]interface{}
+	beforeIsObject := before == "" || json.Unmarshal([]byte(before), &beforeMap) == nil
+	afterIsObject := after == "" || json.Unmarshal([]byte(after), &afterMap) == nil
+
+	diff := make(map[string]map[string]interface{})
+	if beforeIsObject && afterIsObject {
+		for key, oldValue := range beforeMap {
+			newValue, ok := afterMap[key]
+			if !ok || !reflect.DeepEqual(oldValue, newValue) {
+				diff[key] = map[string]interface{}{"old": oldValue, "new": newValue}
+			}
+		}
+		for key, newValue := range afterMap {
+			if _, ok := beforeMap[key]; !ok {
+				diff[key] = map[string]interface{}{"old": nil, "new": newValue}
+			}
+		}
+	} else if before != after {
+		diff["value"] = map[string]interface{}{"old": json.RawMessage(nonEmptyJSON(before)), "new": json.RawMessage(nonEmptyJSON(after))}
+	}
+
+	if len(diff) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// nonEmptyJSON 空字符串转换为JSON null
+func nonEmptyJSON(value string) string {
+	if value == "" {
+		return "null"
+	}
+	return value
+}
@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+// AdminUserService 管理员账号服务
+// 管理员只能管理自己下级树中角色比自己低的管理员，超级管理员可管理全部非超级管理员
+type AdminUserService struct {
+	adminRepo        *database.AdminUserRepository
+	referralRepo     *database.ReferralRepository
+	tokenService     *TokenService
+	twoFactorService *TwoFactorService
+	lockoutService   *LoginLockoutService
+}
+
+// NewAdminUserService 创建管理员账号服务实例
+func NewAdminUserService() *AdminUserService {
+	return &AdminUserService{
+		adminRepo:        database.NewAdminUserRepository(),
+		referralRepo:     database.NewReferralRepository(),
+		tokenService:     NewTokenService(),
+		twoFactorService: NewTwoFactorService(),
+		lockoutService:   NewLoginLockoutService(),
+	}
+}
+
+// lockoutAccount 管理员登录锁定使用的账号标识，与用户账号区分
+func (s *AdminUserService) lockoutAccount(username string) string {
+	return "admin:" + username
+}
+
+// Login 管理员登录，校验密码和两步验证后签发管理员令牌
+func (s *AdminUserService) Login(ctx context.Context, req *models.AdminLoginRequest, clientIP string) (*models.AdminLoginResponse, error) {
+	username := strings.TrimSpace(req.Username)
+	lockAccount := s.lockoutAccount(username)
+	if err := s.lockoutService.CheckLocked(ctx, lockAccount, clientIP); err != nil {
+		return nil, err
+	}
+
+	admin, err := s.adminRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.lockoutService.RecordFailure(ctx, lockAccount, clientIP, "")
+			return nil, utils.NewAppError(utils.CodeAdminLoginFailed, "用户名或密码错误")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询管理员失败")
+	}
+	if !admin.CheckPassword(req.Password) {
+		s.lockoutService.RecordFailure(ctx, lockAccount, clientIP, "")
+		utils.LogSecurityEvent(nil, "admin_login_failed", fmt.Sprintf("管理员: %s, IP: %s", username, clientIP))
+		return nil, utils.NewAppError(utils.CodeAdminLoginFailed, "用户名或密码错误")
+	}
+	if !admin.IsActive() {
+		return nil, utils.NewAppError(utils.CodeAdminDisabled, "管理员账户已被禁用")
+	}
+
+	// 动态验证码错误同样计入登录失败次数，防止暴力尝试
+	if err := s.twoFactorService.RequireAdminCode(ctx, admin, req.TotpCode); err != nil {
+		if appErr, ok := err.(*utils.AppError); ok && appErr.Code == utils.CodeTwoFactorInvalid {
+			s.lockoutService.RecordFailure(ctx, lockAccount, clientIP, "")
+		}
+		return nil, err
+	}
+	s.lockoutService.Reset(ctx, lockAccount)
+
+	token, err := utils.GenerateAdminToken(admin.ID, admin.Username, admin.Role)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeOperationFailed, "生成令牌失败")
+	}
+
+	utils.LogSecurityEvent(nil, "admin_login", fmt.Sprintf("管理员: %s, 角色: %d, IP: %s", admin.Username, admin.Role, clientIP))
+
+	return &models.AdminLoginResponse{
+		Token:                  token,
+		TokenType:              "Bearer",
+		ExpiresIn:              int64(config.GlobalConfig.JWT.AdminTokenExpire),
+		TwoFactorSetupRequired: s.twoFactorService.IsSetupRequiredForAdmin(admin),
+		Admin:                  admin.ToResponse(),
+	}, nil
+}
+
+// Logout 管理员登出，当前令牌加入黑名单
+func (s *AdminUserService) Logout(ctx context.Context, token string) error {
+	return s.tokenService.AddAdminTokenToBlacklist(ctx, token)
+}
+
+// ChangePassword 修改当前管理员密码，成功后此前签发的令牌全部失效
+func (s *AdminUserService) ChangePassword(ctx context.Context, admin *models.AdminUser, req *models.AdminChangePasswordRequest, currentToken string) error {
+	if !admin.CheckPassword(req.OldPassword) {
+		return utils.NewAppError(utils.CodeCurrentPasswordWrong, "当前密码错误")
+	}
+	if req.OldPassword == req.NewPassword {
+		return utils.NewAppError(utils.CodeNewPasswordSame, "新密码不能与当前密码相同")
+	}
+	if err := s.twoFactorService.RequireAdminCode(ctx, admin, req.TotpCode); err != nil {
+		return err
+	}
+
+	if err := s.updatePassword(ctx, admin.ID, req.NewPassword); err != nil {
+		return err
+	}
+	s.tokenService.AddAdminTokenToBlacklist(ctx, currentToken)
+
+	utils.LogSecurityEvent(nil, "admin_password_changed", fmt.Sprintf("管理员: %s", admin.Username))
+	return nil
+}
+
+// List 查询当前管理员可管理的下级管理员列表
+func (s *AdminUserService) List(ctx context.Context, operator *models.AdminUser, req *models.AdminListRequest) (*models.AdminListResponse, error) {
+	var ids []uint
+	if operator.Role != models.RoleSuperAdmin {
+		descendants, err := s.adminRepo.GetDescendantIDs(ctx, operator.ID)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "查询下级管理员失败")
+		}
+		ids = descendants
+		if ids == nil {
+			ids = []uint{}
+		}
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	admins, total, err := s.adminRepo.ListWithFilter(ctx, ids, operator.Role, req.Role, req.Status, strings.TrimSpace(req.Username), req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询下级管理员失败")
+	}
+
+	responses := make([]models.AdminUserResponse, 0, len(admins))
+	for i := range admins {
+		responses = append(responses, admins[i].ToResponse())
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+	return &models.AdminListResponse{
+		Admins: responses,
+		Pagination: models.PaginationInfo{
+			CurrentPage: req.Page,
+			PageSize:    req.PageSize,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     req.Page < totalPages,
+			HasPrev:     req.Page > 1,
+		},
+	}, nil
+}
+
+// Get 查询下级管理员详情
+func (s *AdminUserService) Get(ctx context.Context, operator *models.AdminUser, id uint) (*models.AdminUserResponse, error) {
+	target, err := s.loadManaged(ctx, operator, id)
+	if err != nil {
+		return nil, err
+	}
+	response := target.ToResponse()
+	return &response, nil
+}
+
+// Create 创建下级管理员，未指定上级时挂在当前管理员下
+func (s *AdminUserService) Create(ctx context.Context, operator *models.AdminUser, req *models.AdminCreateRequest) (*models.AdminUserResponse, error) {
+	if !s.canManageRole(operator, req.Role) {
+		return nil, utils.NewAppError(utils.CodeAdminRoleInvalid, "只能创建角色低于自己的管理员")
+	}
+
+	parentID := operator.ID
+	if req.ParentID != nil {
+		parentID = *req.ParentID
+	}
+	if err := s.checkParent(ctx, operator, parentID, req.Role); err != nil {
+		return nil, err
+	}
+
+	username := strings.TrimSpace(req.Username)
+	exists, err := s.adminRepo.UsernameExists(ctx, username)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "检查用户名失败")
+	}
+	if exists {
+		return nil, utils.NewAppError(utils.CodeAdminUsernameExists, "管理员用户名已存在")
+	}
+
+	// 管理员邀请码与用户邀请码共用同一空间，需同时检查两张表
+	inviteCode, err := utils.GenerateUniqueInviteCode(func(code string) (bool, error) {
+		return s.referralRepo.InviteCodeExists(ctx, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+	adminID, err := s.adminRepo.NextAdminID(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "生成管理员ID失败")
+	}
+
+	admin := &models.AdminUser{
+		AdminID:      adminID,
+		Username:     username,
+		Password:     req.Password,
+		Remark:       req.Remark,
+		Status:       models.AdminStatusActive,
+		Avatar:       req.Avatar,
+		Role:         req.Role,
+		MyInviteCode: inviteCode,
+		ParentID:     &parentID,
+	}
+	if err := admin.HashPassword(); err != nil {
+		return nil, utils.NewAppError(utils.CodePasswordEncryptFailed, "加密密码失败")
+	}
+	if err := s.adminRepo.Create(ctx, admin); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "创建管理员失败")
+	}
+
+	utils.LogSecurityEvent(nil, "admin_created", fmt.Sprintf("操作人: %s, 管理员: %s, 角色: %d, 上级: %d", operator.Username, admin.Username, admin.Role, parentID))
+
+	response := admin.ToResponse()
+	return &response, nil
+}
+
+// Update 修改下级管理员的角色、上级、备注和头像
+func (s *AdminUserService) Update(ctx context.Context, operator *models.AdminUser, req *models.AdminUpdateRequest) (*models.AdminUserResponse, error) {
+	target, err := s.loadManaged(ctx, operator, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	role := target.Role
+	if req.Role != nil && *req.Role != target.Role {
+		if !s.canManageRole(operator, *req.Role) {
+			return nil, utils.NewAppError(utils.CodeAdminRoleInvalid, "只能分配低于自己的角色")
+		}
+		// 提升角色后不能与直属下级同级或更高
+		conflict, err := s.adminRepo.HasChildWithRoleAtMost(ctx, target.ID, *req.Role)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "检查下级管理员失败")
+		}
+		if conflict {
+			return nil, utils.NewAppError(utils.CodeAdminRoleInvalid, "该管理员存在角色不低于新角色的下级")
+		}
+		role = *req.Role
+		fields["role"] = role
+	}
+
+	parentID := target.ParentID
+	if req.ParentID != nil && (target.ParentID == nil || *req.ParentID != *target.ParentID) {
+		if *req.ParentID == target.ID {
+			return nil, utils.NewAppError(utils.CodeAdminRoleInvalid, "不能将管理员设为自己的上级")
+		}
+		// 不能挂到自己的下级下面，否则会形成环
+		descendants, err := s.adminRepo.GetDescendantIDs(ctx, target.ID)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "查询下级管理员失败")
+		}
+		if containsID(descendants, *req.ParentID) {
+			return nil, utils.NewAppError(utils.CodeAdminRoleInvalid, "不能将管理员挂到其下级之下")
+		}
+		parentID = req.ParentID
+		fields["parent_id"] = *parentID
+	}
+	if parentID != nil && (fields["role"] != nil || fields["parent_id"] != nil) {
+		if err := s.checkParent(ctx, operator, *parentID, role); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Remark != nil {
+		fields["remark"] = *req.Remark
+	}
+	if req.Avatar != nil {
+		fields["avatar"] = *req.Avatar
+	}
+
+	if len(fields) > 0 {
+		if err := s.adminRepo.UpdateFields(ctx, target.ID, fields); err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "修改管理员失败")
+		}
+		utils.LogSecurityEvent(nil, "admin_updated", fmt.Sprintf("操作人: %s, 管理员: %s, 字段: %v", operator.Username, target.Username, fields))
+	}
+
+	return s.Get(ctx, operator, target.ID)
+}
+
+// SetStatus 禁用或启用下级管理员，禁用后其令牌在下次请求时立即失效
+func (s *AdminUserService) SetStatus(ctx context.Context, operator *models.AdminUser, id uint, status int64) error {
+	target, err := s.loadManaged(ctx, operator, id)
+	if err != nil {
+		return err
+	}
+	if target.Status == status {
+		return nil
+	}
+
+	if err := s.adminRepo.UpdateFields(ctx, target.ID, map[string]interface{}{"status": status}); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "修改管理员状态失败")
+	}
+
+	event := "admin_enabled"
+	if status == models.AdminStatusDisabled {
+		event = "admin_disabled"
+	}
+	utils.LogSecurityEvent(nil, event, fmt.Sprintf("操作人: %s, 管理员: %s", operator.Username, target.Username))
+	return nil
+}
+
+// ResetPassword 重置下级管理员密码，其此前签发的令牌全部失效
+func (s *AdminUserService) ResetPassword(ctx context.Context, operator *models.AdminUser, req *models.AdminResetPasswordRequest) error {
+	target, err := s.loadManaged(ctx, operator, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.updatePassword(ctx, target.ID, req.NewPassword); err != nil {
+		return err
+	}
+
+	utils.LogSecurityEvent(nil, "admin_password_reset", fmt.Sprintf("操作人: %s, 管理员: %s", operator.Username, target.Username))
+	return nil
+}
+
+// ResetTwoFactor 重置下级管理员的两步验证，用于其丢失验证器的情况
+func (s *AdminUserService) ResetTwoFactor(ctx context.Context, operator *models.AdminUser, id uint) error {
+	target, err := s.loadManaged(ctx, operator, id)
+	if err != nil {
+		return err
+	}
+	if !target.TwoFactorEnabled {
+		return utils.NewAppError(utils.CodeTwoFactorNotEnabled, "未开启两步验证")
+	}
+	if err := s.twoFactorService.AdminReset(ctx, target.ID); err != nil {
+		return err
+	}
+
+	utils.LogSecurityEvent(nil, "admin_2fa_reset", fmt.Sprintf("操作人: %s, 管理员: %s", operator.Username, target.Username))
+	return nil
+}
+
+// updatePassword 更新密码并使该管理员此前签发的令牌全部失效
+func (s *AdminUserService) updatePassword(ctx context.Context, adminID uint, password string) error {
+	admin := &models.AdminUser{Password: password}
+	if err := admin.HashPassword(); err != nil {
+		return utils.NewAppError(utils.CodePasswordEncryptFailed, "加密密码失败")
+	}
+	if err := s.adminRepo.UpdateFields(ctx, adminID, map[string]interface{}{"password": admin.Password}); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "修改密码失败")
+	}
+	if err := s.tokenService.RevokeAdminTokens(ctx, adminID); err != nil {
+		utils.LogWarn(nil, "撤销管理员令牌失败 - 管理员ID: %d, 错误: %v", adminID, err)
+	}
+	return nil
+}
+
+// loadManaged 查询目标管理员并校验当前管理员是否有权管理
+func (s *AdminUserService) loadManaged(ctx context.Context, operator *models.AdminUser, id uint) (*models.AdminUser, error) {
+	target, err := s.adminRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeAdminNotFound, "管理员不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询管理员失败")
+	}
+
+	if target.ID == operator.ID || !s.canManageRole(operator, target.Role) {
+		return nil, utils.NewAppError(utils.CodeAdminNoAuthority, "无权管理该管理员")
+	}
+	inTree, err := s.inSubtree(ctx, operator, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !inTree {
+		return nil, utils.NewAppError(utils.CodeAdminNoAuthority, "无权管理该管理员")
+	}
+
+	return target, nil
+}
+
+// checkParent 校验上级管理员：必须是当前管理员本人或其下级，且角色高于被挂载的角色
+func (s *AdminUserService) checkParent(ctx context.Context, operator *models.AdminUser, parentID uint, role int64) error {
+	parent := operator
+	if parentID != operator.ID {
+		inTree, err := s.inSubtree(ctx, operator, parentID)
+		if err != nil {
+			return err
+		}
+		if !inTree {
+			return utils.NewAppError(utils.CodeAdminNoAuthority, "上级管理员不在管理范围内")
+		}
+		parent, err = s.adminRepo.GetByID(ctx, parentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NewAppError(utils.CodeAdminNotFound, "上级管理员不存在")
+			}
+			return utils.NewAppError(utils.CodeDatabaseError, "查询上级管理员失败")
+		}
+	}
+
+	if role <= parent.Role {
+		return utils.NewAppError(utils.CodeAdminRoleInvalid, "角色必须低于上级管理员")
+	}
+	return nil
+}
+
+// inSubtree 检查管理员是否在当前管理员的下级树中，超级管理员管理全部
+func (s *AdminUserService) inSubtree(ctx context.Context, operator *models.AdminUser, id uint) (bool, error) {
+	if operator.Role == models.RoleSuperAdmin {
+		return true, nil
+	}
+	descendants, err := s.adminRepo.GetDescendantIDs(ctx, operator.ID)
+	if err != nil {
+		return false, utils.NewAppError(utils.CodeDatabaseError, "查询下级管理员失败")
+	}
+	return containsID(descendants, id), nil
+}
+
+// canManageRole 只能管理角色比自己低的管理员
+func (s *AdminUserService) canManageRole(operator *models.AdminUser, role int64) bool {
+	return operator.CanAssignRole(role)
+}
+
+// containsID 检查ID列表是否包含指定ID
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
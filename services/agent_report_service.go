@@ -0,0 +1,386 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+)
+
+// maxAgentReportDays 单次报表最多统计的天数，避免大范围聚合拖慢数据库
+const maxAgentReportDays = 366
+
+// AgentReportService 代理业绩报表服务
+// 用户按注册时填写的邀请码归属到代理，代理按ParentID组成上下级树
+// 超级管理员可查看全部代理，其他管理员只能查看自己及下级
+type AgentReportService struct {
+	adminRepo  *database.AdminUserRepository
+	reportRepo *database.ReportRepository
+}
+
+// NewAgentReportService 创建代理业绩报表服务实例
+func NewAgentReportService() *AgentReportService {
+	return &AgentReportService{
+		adminRepo:  database.NewAdminUserRepository(),
+		reportRepo: database.NewReportRepository(),
+	}
+}
+
+// GetAgentReport 统计日期范围内各代理自己及其团队的业绩
+func (s *AgentReportService) GetAgentReport(ctx context.Context, operator *models.AdminUser, req *models.AgentReportRequest) (*models.AgentReportResponse, error) {
+	start, end, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if end.Sub(*start) > maxAgentReportDays*24*time.Hour {
+		return nil, utils.NewAppError(utils.CodeInvalidParams, fmt.Sprintf("统计范围不能超过%d天", maxAgentReportDays))
+	}
+
+	agents, err := s.scopedAgents(ctx, operator)
+	if err != nil {
+		return nil, err
+	}
+	tree := newAgentTree(agents)
+
+	// 统计根节点：指定管理员时只统计其子树，否则为管理范围内没有上级的管理员
+	var roots []uint
+	if req.AdminID > 0 {
+		if _, ok := tree.agents[req.AdminID]; !ok {
+			return nil, utils.NewAppError(utils.CodeAdminNotFound, "管理员不存在")
+		}
+		roots = []uint{req.AdminID}
+	} else {
+		roots = tree.roots()
+	}
+
+	ordered := make([]*models.AdminUser, 0, len(agents))
+	levels := make(map[uint]int, len(agents))
+	for _, root := range roots {
+		tree.walk(root, 0, func(agent *models.AdminUser, level int) {
+			ordered = append(ordered, agent)
+			levels[agent.ID] = level
+		})
+	}
+
+	inviteCodes := make([]string, 0, len(ordered))
+	for _, agent := range ordered {
+		if agent.MyInviteCode != "" {
+			inviteCodes = append(inviteCodes, agent.MyInviteCode)
+		}
+	}
+	metrics, err := s.collectMetrics(ctx, inviteCodes, *start, *end)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.AgentReportRow, 0, len(ordered))
+	index := make(map[uint]int, len(ordered))
+	for _, agent := range ordered {
+		own := metrics[agent.MyInviteCode]
+		index[agent.ID] = len(rows)
+		rows = append(rows, models.AgentReportRow{
+			ID:         agent.ID,
+			AdminID:    agent.AdminID,
+			Username:   agent.Username,
+			Role:       agent.Role,
+			RoleName:   models.RoleNames[agent.Role],
+			InviteCode: agent.MyInviteCode,
+			ParentID:   agent.ParentID,
+			Level:      levels[agent.ID],
+			Own:        own,
+			Team:       own,
+		})
+	}
+
+	// 深度优先顺序中下级一定排在上级之后，倒序累加即可得到团队合计
+	total := models.AgentReportMetrics{}
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := &rows[i]
+		addAgentMetrics(&total, &row.Own)
+		if row.Level == 0 || row.ParentID == nil {
+			continue
+		}
+		if parent, ok := index[*row.ParentID]; ok {
+			addAgentMetrics(&rows[parent].Team, &row.Team)
+		}
+	}
+	for i := range rows {
+		roundAgentMetrics(&rows[i].Own)
+		roundAgentMetrics(&rows[i].Team)
+	}
+	roundAgentMetrics(&total)
+
+	return &models.AgentReportResponse{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Rows:      rows,
+		Total:     total,
+	}, nil
+}
+
+// ExportAgentReport 导出代理业绩报表CSV，返回文件内容和文件名
+func (s *AgentReportService) ExportAgentReport(ctx context.Context, operator *models.AdminUser, req *models.AgentReportRequest) ([]byte, string, error) {
+	report, err := s.GetAgentReport(ctx, operator, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	// 写入BOM，避免Excel打开中文乱码
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"管理员ID", "用户名", "角色", "邀请码", "上级ID", "层级"}
+	for _, scope := range []string{"个人", "团队"} {
+		header = append(header,
+			scope+"注册人数", scope+"审核通过人数",
+			scope+"充值笔数", scope+"充值金额",
+			scope+"提现笔数", scope+"提现金额",
+			scope+"下单数量", scope+"下单金额",
+			scope+"活跃用户数")
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, "", utils.NewAppError(utils.CodeOperationFailed, "生成报表文件失败")
+	}
+
+	for _, row := range report.Rows {
+		parentID := ""
+		if row.ParentID != nil {
+			parentID = strconv.FormatUint(uint64(*row.ParentID), 10)
+		}
+		record := []string{
+			strconv.FormatUint(uint64(row.ID), 10),
+			csvSafeText(row.Username),
+			csvSafeText(row.RoleName),
+			csvSafeText(row.InviteCode),
+			parentID,
+			strconv.Itoa(row.Level),
+		}
+		record = append(record, agentMetricsRecord(&row.Own)...)
+		record = append(record, agentMetricsRecord(&row.Team)...)
+		if err := writer.Write(record); err != nil {
+			return nil, "", utils.NewAppError(utils.CodeOperationFailed, "生成报表文件失败")
+		}
+	}
+
+	// 合计行只填写个人列，团队列会重复统计
+	total := []string{"合计", "", "", "", "", ""}
+	total = append(total, agentMetricsRecord(&report.Total)...)
+	total = append(total, make([]string, 9)...)
+	if err := writer.Write(total); err != nil {
+		return nil, "", utils.NewAppError(utils.CodeOperationFailed, "生成报表文件失败")
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", utils.NewAppError(utils.CodeOperationFailed, "生成报表文件失败")
+	}
+
+	filename := fmt.Sprintf("agent_report_%s_%s.csv", req.StartDate, req.EndDate)
+	return buf.Bytes(), filename, nil
+}
+
+// scopedAgents 获取当前管理员可查看的代理，超级管理员可查看全部
+func (s *AgentReportService) scopedAgents(ctx context.Context, operator *models.AdminUser) ([]models.AdminUser, error) {
+	var ids []uint
+	if operator.Role != models.RoleSuperAdmin {
+		descendants, err := s.adminRepo.GetDescendantIDs(ctx, operator.ID)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "查询下级管理员失败")
+		}
+		ids = append(descendants, operator.ID)
+	}
+
+	agents, err := s.adminRepo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询管理员失败")
+	}
+	return agents, nil
+}
+
+// collectMetrics 按邀请码汇总各项业绩指标
+func (s *AgentReportService) collectMetrics(ctx context.Context, inviteCodes []string, start, end time.Time) (map[string]models.AgentReportMetrics, error) {
+	metrics := make(map[string]models.AgentReportMetrics, len(inviteCodes))
+	if len(inviteCodes) == 0 {
+		return metrics, nil
+	}
+
+	queries := []struct {
+		query func() ([]models.AgentReportAggregate, error)
+		apply func(m *models.AgentReportMetrics, row models.AgentReportAggregate)
+	}{
+		{
+			query: func() ([]models.AgentReportAggregate, error) {
+				return s.reportRepo.CountRegistrations(ctx, inviteCodes, start, end)
+			},
+			apply: func(m *models.AgentReportMetrics, row models.AgentReportAggregate) { m.Registrations = row.Count },
+		},
+		{
+			query: func() ([]models.AgentReportAggregate, error) {
+				return s.reportRepo.CountApprovals(ctx, inviteCodes, start, end)
+			},
+			apply: func(m *models.AgentReportMetrics, row models.AgentReportAggregate) { m.Approvals = row.Count },
+		},
+		{
+			query: func() ([]models.AgentReportAggregate, error) {
+				return s.reportRepo.SumTransactions(ctx, inviteCodes, models.TransactionTypeRecharge, start, end)
+			},
+			apply: func(m *models.AgentReportMetrics, row models.AgentReportAggregate) {
+				m.RechargeCount = row.Count
+				m.RechargeAmount = row.Amount
+			},
+		},
+		{
+			query: func() ([]models.AgentReportAggregate, error) {
+				return s.reportRepo.SumTransactions(ctx, inviteCodes, models.TransactionTypeWithdraw, start, end)
+			},
+			apply: func(m *models.AgentReportMetrics, row models.AgentReportAggregate) {
+				m.WithdrawCount = row.Count
+				m.WithdrawAmount = row.Amount
+			},
+		},
+		{
+			query: func() ([]models.AgentReportAggregate, error) {
+				return s.reportRepo.SumOrders(ctx, inviteCodes, start, end)
+			},
+			apply: func(m *models.AgentReportMetrics, row models.AgentReportAggregate) {
+				m.OrderCount = row.Count
+				m.OrderAmount = row.Amount
+			},
+		},
+		{
+			query: func() ([]models.AgentReportAggregate, error) {
+				return s.reportRepo.CountActiveUsers(ctx, inviteCodes, start, end)
+			},
+			apply: func(m *models.AgentReportMetrics, row models.AgentReportAggregate) { m.ActiveUsers = row.Count },
+		},
+	}
+
+	for _, q := range queries {
+		rows, err := q.query()
+		if err != nil {
+			utils.LogError(nil, "统计代理业绩失败 - 错误: %v", err)
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "统计代理业绩失败")
+		}
+		for _, row := range rows {
+			m := metrics[row.InviteCode]
+			q.apply(&m, row)
+			metrics[row.InviteCode] = m
+		}
+	}
+	return metrics, nil
+}
+
+// agentTree 管理范围内的代理上下级关系
+type agentTree struct {
+	agents   map[uint]*models.AdminUser
+	children map[uint][]uint
+	order    []uint
+}
+
+// newAgentTree 根据ParentID构建代理树，上级不在范围内的代理作为根节点
+func newAgentTree(agents []models.AdminUser) *agentTree {
+	tree := &agentTree{
+		agents:   make(map[uint]*models.AdminUser, len(agents)),
+		children: make(map[uint][]uint, len(agents)),
+		order:    make([]uint, 0, len(agents)),
+	}
+	for i := range agents {
+		tree.agents[agents[i].ID] = &agents[i]
+		tree.order = append(tree.order, agents[i].ID)
+	}
+	for _, id := range tree.order {
+		agent := tree.agents[id]
+		if agent.ParentID == nil {
+			continue
+		}
+		if _, ok := tree.agents[*agent.ParentID]; ok && *agent.ParentID != id {
+			tree.children[*agent.ParentID] = append(tree.children[*agent.ParentID], id)
+		}
+	}
+	return tree
+}
+
+// roots 获取上级不在范围内的代理
+func (t *agentTree) roots() []uint {
+	roots := []uint{}
+	for _, id := range t.order {
+		parentID := t.agents[id].ParentID
+		if parentID == nil || *parentID == id {
+			roots = append(roots, id)
+			continue
+		}
+		if _, ok := t.agents[*parentID]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// walk 从指定代理开始深度优先遍历子树
+func (t *agentTree) walk(rootID uint, level int, visit func(agent *models.AdminUser, level int)) {
+	visited := make(map[uint]bool)
+	var walk func(id uint, level int)
+	walk = func(id uint, level int) {
+		// 防止脏数据形成环导致死循环
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		visit(t.agents[id], level)
+		for _, child := range t.children[id] {
+			walk(child, level+1)
+		}
+	}
+	walk(rootID, level)
+}
+
+// addAgentMetrics 将src的指标累加到dst
+func addAgentMetrics(dst, src *models.AgentReportMetrics) {
+	dst.Registrations += src.Registrations
+	dst.Approvals += src.Approvals
+	dst.RechargeCount += src.RechargeCount
+	dst.RechargeAmount += src.RechargeAmount
+	dst.WithdrawCount += src.WithdrawCount
+	dst.WithdrawAmount += src.WithdrawAmount
+	dst.OrderCount += src.OrderCount
+	dst.OrderAmount += src.OrderAmount
+	dst.ActiveUsers += src.ActiveUsers
+}
+
+// roundAgentMetrics 金额保留两位小数，消除累加产生的浮点误差
+func roundAgentMetrics(m *models.AgentReportMetrics) {
+	m.RechargeAmount = roundAmount(m.RechargeAmount)
+	m.WithdrawAmount = roundAmount(m.WithdrawAmount)
+	m.OrderAmount = roundAmount(m.OrderAmount)
+}
+
+// csvSafeText 文本以公式字符开头时加单引号前缀，避免在Excel中打开时被当作公式执行
+func csvSafeText(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
+
+// agentMetricsRecord 将指标转换为CSV列
+func agentMetricsRecord(m *models.AgentReportMetrics) []string {
+	return []string{
+		strconv.FormatInt(m.Registrations, 10),
+		strconv.FormatInt(m.Approvals, 10),
+		strconv.FormatInt(m.RechargeCount, 10),
+		strconv.FormatFloat(m.RechargeAmount, 'f', 2, 64),
+		strconv.FormatInt(m.WithdrawCount, 10),
+		strconv.FormatFloat(m.WithdrawAmount, 'f', 2, 64),
+		strconv.FormatInt(m.OrderCount, 10),
+		strconv.FormatFloat(m.OrderAmount, 'f', 2, 64),
+		strconv.FormatInt(m.ActiveUsers, 10),
+	}
+}
@@ -2,24 +2,46 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-fataMorgana/config"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// amountConfigCacheExpire 金额配置列表缓存时间，配置变更时主动清除
+const amountConfigCacheExpire = 10 * time.Minute
+
 // AmountConfigService 金额配置服务
 type AmountConfigService struct {
-	repo *database.AmountConfigRepository
+	repo         *database.AmountConfigRepository
+	auditService *AdminAuditService
 }
 
 // NewAmountConfigService 创建金额配置服务实例
 func NewAmountConfigService() *AmountConfigService {
 	return &AmountConfigService{
-		repo: database.NewAmountConfigRepository(),
+		repo:         database.NewAmountConfigRepository(),
+		auditService: NewAdminAuditService(),
 	}
 }
 
-// GetAmountConfigsByType 根据类型获取金额配置列表
+// GetAmountConfigsByType 根据类型获取金额配置列表，优先读取缓存
 func (s *AmountConfigService) GetAmountConfigsByType(ctx context.Context, configType string) ([]*models.AmountConfigResponse, error) {
+	cacheKey := amountConfigCacheKey(configType)
+	if data, err := database.GetKey(ctx, cacheKey); err == nil && data != "" {
+		var cached []*models.AmountConfigResponse
+		if json.Unmarshal([]byte(data), &cached) == nil {
+			return cached, nil
+		}
+	}
+
 	configs, err := s.repo.GetAmountConfigsByType(ctx, configType)
 	if err != nil {
 		return nil, err
@@ -31,6 +53,10 @@ func (s *AmountConfigService) GetAmountConfigsByType(ctx context.Context, config
 		responses = append(responses, config.ToResponse())
 	}
 
+	if data, err := json.Marshal(responses); err == nil {
+		database.SetKey(ctx, cacheKey, string(data), amountConfigCacheExpire)
+	}
+
 	return responses, nil
 }
 
@@ -44,29 +70,133 @@ func (s *AmountConfigService) GetAmountConfigByID(ctx context.Context, id int64)
 	return config.ToResponse(), nil
 }
 
+// AdminListAmountConfigs 管理后台获取金额配置列表，包含未激活的配置
+func (s *AmountConfigService) AdminListAmountConfigs(ctx context.Context, req *models.AmountConfigAdminListRequest) ([]*models.AmountConfigResponse, error) {
+	configs, err := s.repo.ListAmountConfigs(ctx, req.Type, req.IsActive)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取金额配置列表失败")
+	}
+
+	responses := make([]*models.AmountConfigResponse, 0, len(configs))
+	for i := range configs {
+		responses = append(responses, configs[i].ToResponse())
+	}
+	return responses, nil
+}
+
 // CreateAmountConfig 创建金额配置
 func (s *AmountConfigService) CreateAmountConfig(ctx context.Context, config *models.AmountConfig) (*models.AmountConfigResponse, error) {
+	config.Amount = roundAmount(config.Amount)
+	if err := s.validateAmount(ctx, config.Type, config.Amount, 0); err != nil {
+		return nil, err
+	}
+
 	err := s.repo.CreateAmountConfig(ctx, config)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "创建金额配置失败")
+	}
+
+	s.invalidateCache(ctx, config.Type)
+	s.auditService.Record(ctx, models.AuditActionAmountCreate, models.AuditTargetAmountConfig, fmt.Sprint(config.ID), nil, config)
+
+	return config.ToResponse(), nil
+}
+
+// UpdateAmountConfig 更新金额配置，只修改请求中传入的字段
+func (s *AmountConfigService) UpdateAmountConfig(ctx context.Context, req *models.AmountConfigUpdateRequest) (*models.AmountConfigResponse, error) {
+	before, err := s.findAmountConfig(ctx, req.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	config := *before
+	if req.Amount != nil {
+		config.Amount = roundAmount(*req.Amount)
+		if err := s.validateAmount(ctx, config.Type, config.Amount, config.ID); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		config.Description = *req.Description
+	}
+	if req.SortOrder != nil {
+		config.SortOrder = *req.SortOrder
+	}
+
+	if err := s.repo.UpdateAmountConfig(ctx, &config); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "更新金额配置失败")
+	}
+
+	s.invalidateCache(ctx, config.Type)
+	s.auditService.Record(ctx, models.AuditActionAmountUpdate, models.AuditTargetAmountConfig, fmt.Sprint(config.ID), before, &config)
+
 	return config.ToResponse(), nil
 }
 
-// UpdateAmountConfig 更新金额配置
-func (s *AmountConfigService) UpdateAmountConfig(ctx context.Context, config *models.AmountConfig) (*models.AmountConfigResponse, error) {
-	err := s.repo.UpdateAmountConfig(ctx, config)
+// SetAmountConfigActive 激活或停用金额配置，停用后用户端不再显示
+func (s *AmountConfigService) SetAmountConfigActive(ctx context.Context, id int64, isActive bool) (*models.AmountConfigResponse, error) {
+	before, err := s.findAmountConfig(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	config := *before
+	config.IsActive = isActive
+	if before.IsActive != isActive {
+		if err := s.repo.UpdateActive(ctx, id, isActive); err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "更新金额配置状态失败")
+		}
+		s.invalidateCache(ctx, config.Type)
+		s.auditService.Record(ctx, models.AuditActionAmountUpdate, models.AuditTargetAmountConfig, fmt.Sprint(id), before, &config)
+	}
+
 	return config.ToResponse(), nil
 }
 
+// ReorderAmountConfigs 按传入的ID顺序重新设置排序，sort_order从1开始
+func (s *AmountConfigService) ReorderAmountConfigs(ctx context.Context, req *models.AmountConfigReorderRequest) ([]*models.AmountConfigResponse, error) {
+	seen := make(map[int64]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "配置ID不能重复")
+		}
+		seen[id] = true
+	}
+
+	before, err := s.repo.ListAmountConfigs(ctx, req.Type, nil)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取金额配置列表失败")
+	}
+
+	if err := s.repo.UpdateSortOrders(ctx, req.Type, req.IDs); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeAmountConfigNotFound, "配置不存在或不属于该类型")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "更新金额配置排序失败")
+	}
+
+	s.invalidateCache(ctx, req.Type)
+	s.auditService.Record(ctx, models.AuditActionAmountReorder, models.AuditTargetAmountConfig, req.Type,
+		map[string]interface{}{"sort_orders": sortOrderMap(before)},
+		map[string]interface{}{"ids": req.IDs})
+
+	return s.AdminListAmountConfigs(ctx, &models.AmountConfigAdminListRequest{Type: req.Type})
+}
+
 // DeleteAmountConfig 删除金额配置
 func (s *AmountConfigService) DeleteAmountConfig(ctx context.Context, id int64) error {
-	return s.repo.DeleteAmountConfig(ctx, id)
+	before, err := s.findAmountConfig(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAmountConfig(ctx, id); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "删除金额配置失败")
+	}
+
+	s.invalidateCache(ctx, before.Type)
+	s.auditService.Record(ctx, models.AuditActionAmountDelete, models.AuditTargetAmountConfig, fmt.Sprint(id), before, nil)
+	return nil
 }
 
 // GetAmountConfigsByTypeAndAmount 根据类型和金额获取配置
@@ -78,3 +208,66 @@ func (s *AmountConfigService) GetAmountConfigsByTypeAndAmount(ctx context.Contex
 
 	return config.ToResponse(), nil
 }
+
+// findAmountConfig 获取金额配置，包含未激活的配置
+func (s *AmountConfigService) findAmountConfig(ctx context.Context, id int64) (*models.AmountConfig, error) {
+	config, err := s.repo.FindAmountConfigByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeAmountConfigNotFound, "金额配置不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取金额配置失败")
+	}
+	return config, nil
+}
+
+// validateAmount 校验金额：同一类型下不能重复，提现金额需在提现限额范围内
+func (s *AmountConfigService) validateAmount(ctx context.Context, configType string, amount float64, excludeID int64) error {
+	if amount <= 0 {
+		return utils.NewAppError(utils.CodeInvalidParams, "金额必须大于0")
+	}
+
+	if configType == models.AmountConfigTypeWithdraw {
+		policy := config.GlobalConfig.Wallet
+		if (policy.WithdrawMinAmount > 0 && amount < policy.WithdrawMinAmount) ||
+			(policy.WithdrawMaxAmount > 0 && amount > policy.WithdrawMaxAmount) {
+			return utils.NewAppError(utils.CodeAmountConfigOutOfRange,
+				fmt.Sprintf("提现金额需在%.2f到%.2f之间", policy.WithdrawMinAmount, policy.WithdrawMaxAmount))
+		}
+	}
+
+	exists, err := s.repo.ExistsAmount(ctx, configType, amount, excludeID)
+	if err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "检查金额配置失败")
+	}
+	if exists {
+		return utils.NewAppError(utils.CodeAmountConfigDuplicate, fmt.Sprintf("该类型下已存在金额%.2f", amount))
+	}
+	return nil
+}
+
+// invalidateCache 清除指定类型的金额配置列表缓存
+func (s *AmountConfigService) invalidateCache(ctx context.Context, configType string) {
+	if err := database.DelKey(ctx, amountConfigCacheKey(configType)); err != nil {
+		utils.LogWarn(nil, "清除金额配置缓存失败 - 类型: %s, 错误: %v", configType, err)
+	}
+}
+
+// amountConfigCacheKey 金额配置列表缓存Key
+func amountConfigCacheKey(configType string) string {
+	return utils.RedisKeys.GenerateConfigCacheKey("amount_config:" + configType)
+}
+
+// roundAmount 金额保留两位小数，与数据库decimal(10,2)一致
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// sortOrderMap 记录配置ID与排序的对应关系，用于审计
+func sortOrderMap(configs []models.AmountConfig) map[string]int {
+	orders := make(map[string]int, len(configs))
+	for _, config := range configs {
+		orders[fmt.Sprint(config.ID)] = config.SortOrder
+	}
+	return orders
+}
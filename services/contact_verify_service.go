@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+// ContactVerifyService 邮箱、手机号验证服务
+type ContactVerifyService struct {
+	userRepo          *database.UserRepository
+	verifyCodeService *VerifyCodeService
+	changeCodeService *VerifyCodeService
+}
+
+// NewContactVerifyService 创建邮箱、手机号验证服务实例
+func NewContactVerifyService() *ContactVerifyService {
+	cfg := config.GlobalConfig.Auth.ContactVerify.VerifyCodeConfig
+	return &ContactVerifyService{
+		userRepo:          database.NewUserRepository(),
+		verifyCodeService: NewVerifyCodeService(VerifyPurposeContact, cfg),
+		changeCodeService: NewVerifyCodeService(VerifyPurposeContactChange, cfg),
+	}
+}
+
+// CheckLoginAllowed 配置要求登录前验证时，检查登录使用的邮箱或手机号是否已验证
+func (s *ContactVerifyService) CheckLoginAllowed(user *models.User, account string) error {
+	if !config.GlobalConfig.Auth.ContactVerify.RequiredBeforeLogin {
+		return nil
+	}
+	if !user.IsContactVerified(account) {
+		return utils.NewAppError(utils.CodeContactNotVerified, "账号未验证，请先完成邮箱或手机号验证")
+	}
+	return nil
+}
+
+// CheckWithdrawAllowed 配置要求提现前验证时，检查用户是否至少验证过一个邮箱或手机号
+func (s *ContactVerifyService) CheckWithdrawAllowed(user *models.User) error {
+	if !config.GlobalConfig.Auth.ContactVerify.RequiredBeforeWithdraw {
+		return nil
+	}
+	if !user.HasVerifiedContact() {
+		return utils.NewAppError(utils.CodeContactNotVerified, "请先完成邮箱或手机号验证后再进行提现操作")
+	}
+	return nil
+}
+
+// SendVerifyCode 向注册使用的邮箱或手机号发送验证码
+// 账号不存在或已验证时同样返回成功，不向调用方暴露账号状态
+func (s *ContactVerifyService) SendVerifyCode(ctx context.Context, req *models.SendContactCodeRequest, clientIP string) (*models.VerifyCodeSentResponse, error) {
+	account := strings.TrimSpace(req.Account)
+	if !utils.IsEmailAccount(account) && !utils.IsPhoneAccount(account) {
+		return nil, utils.NewAppError(utils.CodeAccountFormatInvalid, "账号格式错误，请输入正确的邮箱或手机号")
+	}
+
+	if err := s.verifyCodeService.CheckSendLimit(ctx, account, clientIP); err != nil {
+		return nil, err
+	}
+
+	response := &models.VerifyCodeSentResponse{
+		ExpiresIn:      s.verifyCodeService.ExpiresIn(),
+		ResendInterval: s.verifyCodeService.ResendInterval(),
+	}
+
+	user, err := findUserByAccount(ctx, s.userRepo, account)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response, nil
+		}
+		return nil, utils.NewAppError(utils.CodeUserQueryFailed, "查询用户失败")
+	}
+	if user.DeletedAt != nil || user.Status == models.UserStatusDisabled || user.IsContactVerified(account) {
+		return response, nil
+	}
+
+	if err := s.verifyCodeService.Send(ctx, account, account, user.Uid,
+		"账号验证码",
+		"您正在验证账号，验证码：%s，%s分钟内有效，请勿泄露给他人。",
+	); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ConfirmVerifyCode 校验验证码，将邮箱或手机号标记为已验证
+func (s *ContactVerifyService) ConfirmVerifyCode(ctx context.Context, req *models.ConfirmContactRequest) (*models.UserResponse, error) {
+	account := strings.TrimSpace(req.Account)
+	uid, err := s.verifyCodeService.Verify(ctx, account, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送验证码后联系方式已被更换时，验证码作废
+	isEmail := utils.IsEmailAccount(account)
+	if (isEmail && user.Email != account) || (!isEmail && user.Phone != account) {
+		return nil, utils.NewAppError(utils.CodeVerifyCodeInvalid, "验证码错误或已过期")
+	}
+
+	if err := s.userRepo.UpdateVerifiedContact(ctx, uid, isEmail, account); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "更新验证状态失败")
+	}
+
+	return s.reloadResponse(ctx, uid)
+}
+
+// SendChangeCode 更换邮箱或手机号，校验当前密码后向新联系方式发送验证码
+func (s *ContactVerifyService) SendChangeCode(ctx context.Context, uid string, req *models.ChangeContactSendRequest, clientIP string) (*models.VerifyCodeSentResponse, error) {
+	newAccount := strings.TrimSpace(req.NewAccount)
+	isEmail := utils.IsEmailAccount(newAccount)
+	if !isEmail && !utils.IsPhoneAccount(newAccount) {
+		return nil, utils.NewAppError(utils.CodeAccountFormatInvalid, "账号格式错误，请输入正确的邮箱或手机号")
+	}
+
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == models.UserStatusDisabled {
+		return nil, utils.NewAppError(utils.CodeUserDisabledLogin, "账户已被禁用")
+	}
+	if !user.CheckPassword(req.Password) {
+		return nil, utils.NewAppError(utils.CodeCurrentPasswordWrong, "当前密码错误")
+	}
+
+	// 开启两步验证的用户需要提供动态验证码
+	if err := NewTwoFactorService().RequireFreshCode(ctx, user, req.TotpCode); err != nil {
+		return nil, err
+	}
+
+	if (isEmail && user.Email == newAccount) || (!isEmail && user.Phone == newAccount) {
+		return nil, utils.NewAppError(utils.CodeContactUnchanged, "新邮箱或手机号与当前相同")
+	}
+	if err := s.checkContactAvailable(ctx, isEmail, newAccount); err != nil {
+		return nil, err
+	}
+
+	target := s.changeTarget(uid, newAccount)
+	if err := s.changeCodeService.CheckSendLimit(ctx, target, clientIP); err != nil {
+		return nil, err
+	}
+	if err := s.changeCodeService.Send(ctx, target, newAccount, uid,
+		"更换绑定验证码",
+		"您正在更换账号绑定的邮箱或手机号，验证码：%s，%s分钟内有效。如非本人操作，请忽略。",
+	); err != nil {
+		return nil, err
+	}
+
+	return &models.VerifyCodeSentResponse{
+		ExpiresIn:      s.changeCodeService.ExpiresIn(),
+		ResendInterval: s.changeCodeService.ResendInterval(),
+	}, nil
+}
+
+// ConfirmChange 校验新联系方式收到的验证码，更换邮箱或手机号并标记为已验证
+func (s *ContactVerifyService) ConfirmChange(ctx context.Context, uid string, req *models.ChangeContactConfirmRequest) (*models.UserResponse, error) {
+	newAccount := strings.TrimSpace(req.NewAccount)
+	isEmail := utils.IsEmailAccount(newAccount)
+
+	if _, err := s.changeCodeService.Verify(ctx, s.changeTarget(uid, newAccount), req.Code); err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	oldContact := user.Phone
+	if isEmail {
+		oldContact = user.Email
+	}
+
+	// 发送验证码后新联系方式可能已被其他用户占用
+	if err := s.checkContactAvailable(ctx, isEmail, newAccount); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateVerifiedContact(ctx, uid, isEmail, newAccount); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "更换联系方式失败")
+	}
+
+	if isEmail {
+		database.InvalidateEmailCache(ctx, oldContact)
+		database.InvalidateEmailCache(ctx, newAccount)
+	}
+	utils.LogSecurityEvent(nil, "contact_changed", fmt.Sprintf("UID: %s, 类型: %s", uid, contactType(isEmail)))
+
+	// 通知原联系方式，便于用户发现非本人操作
+	if oldContact != "" {
+		notification := &Notification{
+			Channel:   NotificationChannelSMS,
+			Recipient: oldContact,
+			Content:   "您的账号绑定的联系方式已更换，如非本人操作，请立即联系客服。",
+		}
+		if isEmail {
+			notification.Channel = NotificationChannelEmail
+			notification.Subject = "账号联系方式变更提醒"
+		}
+		if err := GetNotificationProvider().Send(ctx, notification); err != nil {
+			utils.LogWarn(nil, "发送联系方式变更提醒失败 - UID: %s, 错误: %v", uid, err)
+		}
+	}
+
+	return s.reloadResponse(ctx, uid)
+}
+
+// checkContactAvailable 检查邮箱或手机号是否已被注册
+func (s *ContactVerifyService) checkContactAvailable(ctx context.Context, isEmail bool, contact string) error {
+	if isEmail {
+		exists, err := s.userRepo.CheckEmailExists(ctx, contact)
+		if err != nil {
+			return utils.NewAppError(utils.CodeDatabaseError, "检查邮箱失败")
+		}
+		if exists {
+			return utils.NewAppError(utils.CodeEmailAlreadyExists, "邮箱已被注册")
+		}
+		return nil
+	}
+
+	exists, err := s.userRepo.CheckPhoneExists(ctx, contact)
+	if err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "检查手机号失败")
+	}
+	if exists {
+		return utils.NewAppError(utils.CodePhoneAlreadyExists, "手机号已被注册")
+	}
+	return nil
+}
+
+// changeTarget 更换联系方式的验证码按用户和新联系方式区分，其他用户无法使用
+func (s *ContactVerifyService) changeTarget(uid, newAccount string) string {
+	return uid + ":" + newAccount
+}
+
+// findUser 查询用户
+func (s *ContactVerifyService) findUser(ctx context.Context, uid string) (*models.User, error) {
+	user, err := s.userRepo.FindByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeUserQueryFailed, "查询用户失败")
+	}
+	return user, nil
+}
+
+// reloadResponse 重新查询用户并返回响应
+func (s *ContactVerifyService) reloadResponse(ctx context.Context, uid string) (*models.UserResponse, error) {
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// contactType 联系方式类型名称
+func contactType(isEmail bool) string {
+	if isEmail {
+		return "email"
+	}
+	return "phone"
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"time"
 
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/utils"
+
 	"github.com/robfig/cron/v3"
 )
 
@@ -14,24 +18,39 @@ type CronService struct {
 	fakeOrderService        *FakeOrderService
 	dataCleanupService      *DataCleanupService
 	leaderboardCacheService *LeaderboardCacheService
+	groupBuyService         *GroupBuyService
+	scoreService            *ScoreService
+	referralService         *ReferralService
+	walletStatusService     *WalletStatusService
+	messageBroadcastService *MessageBroadcastService
 	config                  *CronConfig
 	orderEntryID            cron.EntryID
 	cleanupEntryID          cron.EntryID
 	leaderboardEntryID      cron.EntryID
+	groupBuyExpireEntryID   cron.EntryID
+	scoreEventEntryID       cron.EntryID
+	commissionEntryID       cron.EntryID
+	walletStatusEntryID     cron.EntryID
+	messageBroadcastEntryID cron.EntryID
 }
 
 // CronConfig 定时任务配置
 type CronConfig struct {
-	Enabled             bool    `yaml:"enabled"`
-	OrderCronExpr       string  `yaml:"order_cron_expr"`       // 订单生成定时表达式
-	CleanupCronExpr     string  `yaml:"cleanup_cron_expr"`     // 数据清理定时表达式
-	LeaderboardCronExpr string  `yaml:"leaderboard_cron_expr"` // 热榜缓存更新定时表达式
-	MinOrders           int     `yaml:"min_orders"`
-	MaxOrders           int     `yaml:"max_orders"`
-	PurchaseRatio       float64 `yaml:"purchase_ratio"`
-	TaskMinCount        int     `yaml:"task_min_count"`
-	TaskMaxCount        int     `yaml:"task_max_count"`
-	RetentionDays       int     `yaml:"retention_days"`
+	Enabled              bool    `yaml:"enabled"`
+	OrderCronExpr        string  `yaml:"order_cron_expr"`        // 订单生成定时表达式
+	CleanupCronExpr      string  `yaml:"cleanup_cron_expr"`      // 数据清理定时表达式
+	LeaderboardCronExpr  string  `yaml:"leaderboard_cron_expr"`  // 热榜缓存更新定时表达式
+	GroupBuyExpireExpr   string  `yaml:"group_buy_expire_expr"`  // 过期拼单退款定时表达式
+	ScoreEventExpr       string  `yaml:"score_event_expr"`       // 经验值与信用分事件扫描定时表达式
+	CommissionExpr       string  `yaml:"commission_expr"`        // 推广佣金发放定时表达式
+	WalletStatusExpr     string  `yaml:"wallet_status_expr"`     // 钱包冻结和提现限制到期恢复定时表达式
+	MessageBroadcastExpr string  `yaml:"message_broadcast_expr"` // 群发消息发送定时表达式
+	MinOrders            int     `yaml:"min_orders"`
+	MaxOrders            int     `yaml:"max_orders"`
+	PurchaseRatio        float64 `yaml:"purchase_ratio"`
+	TaskMinCount         int     `yaml:"task_min_count"`
+	TaskMaxCount         int     `yaml:"task_max_count"`
+	RetentionDays        int     `yaml:"retention_days"`
 }
 
 // NewCronService 创建新的定时任务服务
@@ -60,6 +79,11 @@ func NewCronService(config *CronConfig) *CronService {
 		fakeOrderService:        NewFakeOrderService(fakeOrderConfig),
 		dataCleanupService:      NewDataCleanupService(cleanupConfig),
 		leaderboardCacheService: NewLeaderboardCacheService(),
+		groupBuyService:         NewGroupBuyService(),
+		scoreService:            NewScoreService(),
+		referralService:         NewReferralService(),
+		walletStatusService:     NewWalletStatusService(),
+		messageBroadcastService: NewMessageBroadcastService(),
 		config:                  config,
 	}
 }
@@ -85,6 +109,31 @@ func (s *CronService) Start() error {
 		return err
 	}
 
+	// 启动过期拼单退款定时任务
+	if err := s.StartGroupBuyExpireCron(); err != nil {
+		return err
+	}
+
+	// 启动经验值与信用分事件扫描定时任务
+	if err := s.StartScoreEventCron(); err != nil {
+		return err
+	}
+
+	// 启动推广佣金发放定时任务
+	if err := s.StartCommissionCron(); err != nil {
+		return err
+	}
+
+	// 启动钱包状态到期恢复定时任务
+	if err := s.StartWalletStatusCron(); err != nil {
+		return err
+	}
+
+	// 启动群发消息发送定时任务
+	if err := s.StartMessageBroadcastCron(); err != nil {
+		return err
+	}
+
 	// 启动cron调度器
 	s.cron.Start()
 
@@ -167,6 +216,121 @@ func (s *CronService) StopLeaderboardCacheCron() {
 	}
 }
 
+// StartGroupBuyExpireCron 启动过期拼单退款定时任务
+func (s *CronService) StartGroupBuyExpireCron() error {
+	if s.config.GroupBuyExpireExpr == "" {
+		s.config.GroupBuyExpireExpr = "0 * * * * *" // 默认每分钟（包含秒）
+	}
+
+	entryID, err := s.cron.AddFunc(s.config.GroupBuyExpireExpr, s.processExpiredGroupBuys)
+	if err != nil {
+		return err
+	}
+
+	s.groupBuyExpireEntryID = entryID
+	return nil
+}
+
+// StopGroupBuyExpireCron 停止过期拼单退款定时任务
+func (s *CronService) StopGroupBuyExpireCron() {
+	if s.groupBuyExpireEntryID != 0 {
+		s.cron.Remove(s.groupBuyExpireEntryID)
+		s.groupBuyExpireEntryID = 0
+	}
+}
+
+// StartScoreEventCron 启动经验值与信用分事件扫描定时任务
+func (s *CronService) StartScoreEventCron() error {
+	if s.config.ScoreEventExpr == "" {
+		s.config.ScoreEventExpr = "30 * * * * *" // 默认每分钟第30秒（包含秒），与过期拼单任务错开
+	}
+
+	entryID, err := s.cron.AddFunc(s.config.ScoreEventExpr, s.processScoreEvents)
+	if err != nil {
+		return err
+	}
+
+	s.scoreEventEntryID = entryID
+	return nil
+}
+
+// StopScoreEventCron 停止经验值与信用分事件扫描定时任务
+func (s *CronService) StopScoreEventCron() {
+	if s.scoreEventEntryID != 0 {
+		s.cron.Remove(s.scoreEventEntryID)
+		s.scoreEventEntryID = 0
+	}
+}
+
+// StartCommissionCron 启动推广佣金发放定时任务
+func (s *CronService) StartCommissionCron() error {
+	if s.config.CommissionExpr == "" {
+		s.config.CommissionExpr = "0 */5 * * * *" // 默认每5分钟（包含秒）
+	}
+
+	entryID, err := s.cron.AddFunc(s.config.CommissionExpr, s.processCommissions)
+	if err != nil {
+		return err
+	}
+
+	s.commissionEntryID = entryID
+	return nil
+}
+
+// StopCommissionCron 停止推广佣金发放定时任务
+func (s *CronService) StopCommissionCron() {
+	if s.commissionEntryID != 0 {
+		s.cron.Remove(s.commissionEntryID)
+		s.commissionEntryID = 0
+	}
+}
+
+// StartWalletStatusCron 启动钱包状态到期恢复定时任务
+func (s *CronService) StartWalletStatusCron() error {
+	if s.config.WalletStatusExpr == "" {
+		s.config.WalletStatusExpr = "15 * * * * *" // 默认每分钟（包含秒）
+	}
+
+	entryID, err := s.cron.AddFunc(s.config.WalletStatusExpr, s.processWalletStatusExpire)
+	if err != nil {
+		return err
+	}
+
+	s.walletStatusEntryID = entryID
+	return nil
+}
+
+// StopWalletStatusCron 停止钱包状态到期恢复定时任务
+func (s *CronService) StopWalletStatusCron() {
+	if s.walletStatusEntryID != 0 {
+		s.cron.Remove(s.walletStatusEntryID)
+		s.walletStatusEntryID = 0
+	}
+}
+
+// StartMessageBroadcastCron 启动群发消息发送定时任务
+func (s *CronService) StartMessageBroadcastCron() error {
+	if s.config.MessageBroadcastExpr == "" {
+		s.config.MessageBroadcastExpr = "*/10 * * * * *" // 默认每10秒（包含秒）
+	}
+
+	entryID, err := s.cron.AddFunc(s.config.MessageBroadcastExpr, s.processMessageBroadcasts)
+	if err != nil {
+		return err
+	}
+
+	s.messageBroadcastEntryID = entryID
+	return nil
+}
+
+// StopMessageBroadcastCron 停止群发消息发送定时任务
+func (s *CronService) StopMessageBroadcastCron() {
+	if s.messageBroadcastEntryID != 0 {
+		s.cron.Remove(s.messageBroadcastEntryID)
+		s.messageBroadcastEntryID = 0
+	}
+}
+
 // generateFakeOrders 生成假订单（定时任务回调函数）
 func (s *CronService) generateFakeOrders() {
 	defer func() {
@@ -243,6 +407,108 @@ func (s *CronService) updateLeaderboardCache() {
 	_ = duration
 }
 
+// processExpiredGroupBuys 处理过期未成团拼单并退款（定时任务回调函数）
+func (s *CronService) processExpiredGroupBuys() {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(nil, "处理过期拼单发生panic: %v", r)
+		}
+	}()
+
+	stats, err := s.groupBuyService.ProcessExpiredGroupBuys(context.Background())
+	if err != nil {
+		utils.LogError(nil, "处理过期拼单失败: %v", err)
+		return
+	}
+
+	if stats.CompletedGroupBuys > 0 {
+		utils.LogInfo(nil, "满员拼单补偿成团 - 拼单数: %d", stats.CompletedGroupBuys)
+	}
+	// 退款涉及用户资金，有处理记录时记录日志
+	if stats.FailedGroupBuys > 0 {
+		utils.LogInfo(nil, "过期拼单处理完成 - 拼单数: %d, 退款人数: %d, 退款失败: %d, 退款金额: %.2f",
+			stats.FailedGroupBuys, stats.RefundedCount, stats.RefundFailedCount, stats.RefundedAmount)
+	}
+}
+
+// processScoreEvents 扫描订单、拼单和提现状态变化并计算经验值与信用分（定时任务回调函数）
+func (s *CronService) processScoreEvents() {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(nil, "扫描经验值与信用分事件发生panic: %v", r)
+		}
+	}()
+
+	stats, err := s.scoreService.ScanEvents(context.Background())
+	if err != nil {
+		utils.LogError(nil, "扫描经验值与信用分事件失败: %v", err)
+		return
+	}
+
+	if len(stats.Applied) > 0 || stats.FailedCount > 0 {
+		utils.LogInfo(nil, "经验值与信用分事件处理完成 - 计算: %v, 失败: %d", stats.Applied, stats.FailedCount)
+	}
+}
+
+// processCommissions 为已完成的下级订单发放推广佣金（定时任务回调函数）
+func (s *CronService) processCommissions() {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(nil, "发放推广佣金发生panic: %v", r)
+		}
+	}()
+
+	stats, err := s.referralService.ScanCommissions(context.Background())
+	if err != nil {
+		utils.LogError(nil, "发放推广佣金失败: %v", err)
+		return
+	}
+
+	// 佣金涉及用户资金，有处理记录时记录日志
+	if stats.CommissionCount > 0 || stats.PendingCount > 0 || stats.FailedCount > 0 {
+		utils.LogInfo(nil, "推广佣金发放完成 - 订单数: %d, 佣金笔数: %d, 佣金金额: %.2f, 待入账: %d, 失败: %d",
+			stats.OrderCount, stats.CommissionCount, stats.CommissionAmount, stats.PendingCount, stats.FailedCount)
+	}
+}
+
+// processWalletStatusExpire 将到期的钱包冻结和提现限制恢复正常（定时任务回调函数）
+func (s *CronService) processWalletStatusExpire() {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(nil, "恢复到期钱包状态发生panic: %v", r)
+		}
+	}()
+
+	stats, err := s.walletStatusService.RevertExpired(context.Background())
+	if err != nil {
+		utils.LogError(nil, "恢复到期钱包状态失败: %v", err)
+		return
+	}
+
+	if stats.RevertedCount > 0 || stats.FailedCount > 0 {
+		utils.LogInfo(nil, "到期钱包状态恢复完成 - 恢复: %d, 失败: %d", stats.RevertedCount, stats.FailedCount)
+	}
+}
+
+// processMessageBroadcasts 发送到期的群发消息并继续中断的发送任务（定时任务回调函数）
+func (s *CronService) processMessageBroadcasts() {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(nil, "发送群发消息发生panic: %v", r)
+		}
+	}()
+
+	stats, err := s.messageBroadcastService.ProcessDue(context.Background())
+	if err != nil {
+		utils.LogError(nil, "发送群发消息失败: %v", err)
+		return
+	}
+
+	if stats.BroadcastCount > 0 || stats.FailedCount > 0 {
+		utils.LogInfo(nil, "群发消息处理完成 - 处理: %d, 失败: %d", stats.BroadcastCount, stats.FailedCount)
+	}
+}
+
 // GetCronStatus 获取定时任务状态
 func (s *CronService) GetCronStatus() map[string]interface{} {
 	entries := s.cron.Entries()
@@ -273,3 +539,56 @@ func (s *CronService) ManualCleanup() (*CleanupStats, error) {
 func (s *CronService) ManualUpdateLeaderboardCache() error {
 	return s.leaderboardCacheService.UpdateLeaderboardCache()
 }
+
+// ManualProcessExpiredGroupBuys 手动处理过期拼单退款
+func (s *CronService) ManualProcessExpiredGroupBuys() (*GroupBuyRefundStats, error) {
+	return s.groupBuyService.ProcessExpiredGroupBuys(context.Background())
+}
+
+// ManualProcessScoreEvents 手动扫描经验值与信用分事件
+func (s *CronService) ManualProcessScoreEvents() (*ScoreScanStats, error) {
+	return s.scoreService.ScanEvents(context.Background())
+}
+
+// ManualProcessCommissions 手动发放推广佣金
+func (s *CronService) ManualProcessCommissions() (*CommissionScanStats, error) {
+	return s.referralService.ScanCommissions(context.Background())
+}
+
+// ManualProcessWalletStatusExpire 手动恢复到期的钱包冻结和提现限制
+func (s *CronService) ManualProcessWalletStatusExpire() (*WalletStatusExpireStats, error) {
+	return s.walletStatusService.RevertExpired(context.Background())
+}
+
+// ManualProcessMessageBroadcasts 手动发送到期的群发消息
+func (s *CronService) ManualProcessMessageBroadcasts() (*BroadcastProcessStats, error) {
+	return s.messageBroadcastService.ProcessDue(context.Background())
+}
+
+// scanWatermarkOverlap 从扫描进度继续时向前多扫描的时长，覆盖事务提交延迟和服务器时钟误差
+const scanWatermarkOverlap = 5 * time.Minute
+
+// scanSince 定时扫描的起始时间，有扫描进度时从进度继续，没有进度或读取失败时向前扫描lookbackHours小时
+// 扫描进度不受时间范围限制，定时任务停止较长时间后恢复也不会漏掉事件
+func scanSince(ctx context.Context, name string, now time.Time, lookbackHours int) time.Time {
+	value, err := database.GetKeyOrDefault(ctx, scanWatermarkKey(name), "")
+	if err != nil {
+		utils.LogWarn(nil, "读取扫描进度失败 - 任务: %s, 错误: %v", name, err)
+	}
+	if err != nil || value == "" {
+		return now.Add(-time.Duration(lookbackHours) * time.Hour)
+	}
+	return parseUnixField(value).Add(-scanWatermarkOverlap)
+}
+
+// saveScanWatermark 记录本次扫描的开始时间作为扫描进度
+func saveScanWatermark(ctx context.Context, name string, startTime time.Time) {
+	if err := database.SetKey(ctx, scanWatermarkKey(name), startTime.Unix(), 0); err != nil {
+		utils.LogWarn(nil, "保存扫描进度失败 - 任务: %s, 错误: %v", name, err)
+	}
+}
+
+// scanWatermarkKey 扫描进度Key
+func scanWatermarkKey(name string) string {
+	return fmt.Sprintf("cron:scan_watermark:%s", name)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
 	"gin-fataMorgana/utils"
 
 	"github.com/redis/go-redis/v9"
@@ -16,13 +17,15 @@ type CurrencyConfig struct {
 
 // CurrencyService 货币服务
 type CurrencyService struct {
-	redisClient *redis.Client
+	redisClient  *redis.Client
+	auditService *AdminAuditService
 }
 
 // NewCurrencyService 创建货币服务实例
 func NewCurrencyService() *CurrencyService {
 	return &CurrencyService{
-		redisClient: database.RedisClient,
+		redisClient:  database.RedisClient,
+		auditService: NewAdminAuditService(),
 	}
 }
 
@@ -57,6 +60,9 @@ func (s *CurrencyService) SetCurrentCurrency(ctx context.Context, config *Curren
 	// Redis key
 	key := "currency_config:current"
 
+	// 读取原配置用于审计
+	before, _ := s.GetCurrentCurrency(ctx)
+
 	// 序列化配置数据
 	data, err := json.Marshal(config)
 	if err != nil {
@@ -69,5 +75,6 @@ func (s *CurrencyService) SetCurrentCurrency(ctx context.Context, config *Curren
 		return utils.NewAppError(utils.CodeRedisError, "保存货币配置失败")
 	}
 
+	s.auditService.Record(ctx, models.AuditActionCurrencySet, models.AuditTargetCurrency, "current", before, config)
 	return nil
 } 
\ No newline at end of file
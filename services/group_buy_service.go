@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"time"
 
+	"gin-fataMorgana/config"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/models"
 	"gin-fataMorgana/utils"
@@ -17,6 +18,8 @@ type GroupBuyService struct {
 	walletRepo    *database.WalletRepository
 	cacheService  *WalletCacheService
 	walletService *WalletService
+	scoreService  *ScoreService
+	eventService  *UserEventService
 }
 
 // NewGroupBuyService 创建拼单服务实例
@@ -26,6 +29,8 @@ func NewGroupBuyService() *GroupBuyService {
 		walletRepo:    database.NewWalletRepository(),
 		cacheService:  NewWalletCacheService(),
 		walletService: NewWalletService(),
+		scoreService:  NewScoreService(),
+		eventService:  NewUserEventService(),
 	}
 }
 
@@ -74,8 +79,9 @@ func (s *GroupBuyService) GetActiveGroupBuyDetail(ctx context.Context, uid strin
 		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取拼单详情失败，请稍后重试")
 	}
 
-	// 5. 转换为响应格式
+	// 5. 转换为响应格式，附带拼单类型规则
 	response := groupBuy.ToDetailResponse()
+	response.Rules = s.buildRuleInfo(ctx, groupBuy)
 	return &response, nil
 }
 
@@ -110,8 +116,9 @@ func (s *GroupBuyService) checkGroupBuyQualification(ctx context.Context, uid st
 	}
 	// 状态2（无法提现）不影响拼单资格
 
-	// 6. 检查用户经验值是否达到最低要求（假设最低经验值为1）
-	if user.Experience < 1 {
+	// 6. 检查用户经验值和信用分是否达到最低要求，两者由经验值与信用分规则随订单、拼单等事件变化
+	policy := config.GlobalConfig.Score
+	if user.Experience < *policy.GroupBuyMinExperience || user.CreditScore < *policy.GroupBuyMinCreditScore {
 		return false, nil
 	}
 
@@ -156,8 +163,13 @@ func (s *GroupBuyService) ensureUserWallet(ctx context.Context, uid string) erro
 	return nil
 }
 
-// JoinGroupBuy 确认参与拼单
+// JoinGroupBuy 通过拼单编号确认参与拼单，用户发起的拼单只能通过邀请码参与
 func (s *GroupBuyService) JoinGroupBuy(ctx context.Context, groupBuyNo, uid string) (*models.JoinGroupBuyResponse, error) {
+	return s.joinGroupBuy(ctx, groupBuyNo, uid, false)
+}
+
+// joinGroupBuy 确认参与拼单，invited表示已通过邀请码校验
+func (s *GroupBuyService) joinGroupBuy(ctx context.Context, groupBuyNo, uid string, invited bool) (*models.JoinGroupBuyResponse, error) {
 	// 1. 根据拼单编号查询拼单信息
 	groupBuy, err := s.groupBuyRepo.GetGroupBuyByNo(ctx, groupBuyNo)
 	if err != nil {
@@ -167,52 +179,131 @@ func (s *GroupBuyService) JoinGroupBuy(ctx context.Context, groupBuyNo, uid stri
 		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询拼单信息失败")
 	}
 
-	// 2. 检查拼单是否为该用户的
-	if groupBuy.Uid != uid {
-		return nil, utils.NewAppError(utils.CodeGroupBuyOccupied, "该拼单不属于您，无法参与")
+	// 2. 检查拼单状态，只有未开启和进行中的拼单可以参与
+	if groupBuy.Status != models.GroupBuyStatusNotStarted && groupBuy.Status != models.GroupBuyStatusPending {
+		return nil, utils.NewAppError(utils.CodeGroupBuyClosed, "拼单已结束，无法参与")
 	}
 
-	// 3. 检查拼单是否已经被参与（已有订单编号）
-	if groupBuy.OrderNo != nil && *groupBuy.OrderNo != "" {
-		return nil, utils.NewAppError(utils.CodeGroupBuyOccupied, "该拼单已被参与，无法重复参与")
+	// 用户发起的拼单仅限通过有效的邀请码参与
+	if groupBuy.InviteOnly && !invited {
+		return nil, utils.NewAppError(utils.CodeGroupBuyInvite, "该拼单仅限通过邀请码参与")
 	}
 
-	// 4. 检查截止时间是否已经过了
+	// 3. 检查截止时间是否已经过了
 	if time.Now().After(groupBuy.Deadline) {
 		return nil, utils.NewAppError(utils.CodeGroupBuyExpired, "该拼单已超过截止时间")
 	}
 
-	// 5. 使用并发安全的钱包服务检查余额
-	wallet, err := s.walletService.GetWallet(uid)
+	// 4. 检查是否已参与
+	joined, err := s.groupBuyRepo.HasParticipant(ctx, groupBuyNo, uid)
 	if err != nil {
-		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取钱包失败，请稍后重试")
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询参与记录失败")
+	}
+	if joined {
+		return nil, utils.NewAppError(utils.CodeGroupBuyJoined, "您已参与该拼单")
 	}
 
-	// 检查钱包状态
-	if wallet.IsFrozen() {
-		return nil, utils.NewAppError(utils.CodeOperationFailed, "钱包已被冻结，无法参与拼单")
+	// 5. 检查拼单资格
+	hasQualification, err := s.checkGroupBuyQualification(ctx, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "检查拼单资格失败，请稍后重试")
+	}
+	if !hasQualification {
+		return nil, utils.NewAppError(utils.CodeGroupBuyNoQualify, "您暂无拼单资格")
 	}
-	// 状态2（无法提现）不影响拼单参与
 
-	// 检查余额是否足够
-	if wallet.Balance < groupBuy.PerPersonAmount {
-		return nil, utils.NewAppError(utils.CodeOperationFailed,
-			fmt.Sprintf("余额不足，当前余额: %.2f，拼单金额: %.2f", wallet.Balance, groupBuy.PerPersonAmount))
+	// 6. 按拼单类型规则检查会员等级和保留名额
+	rule := s.groupBuyTypeRule(groupBuy.GroupBuyType)
+	if err := s.checkMemberLevel(ctx, rule, uid); err != nil {
+		return nil, err
+	}
+	reservedSeats, err := s.reservedSeats(ctx, groupBuy, rule, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询参与记录失败")
 	}
 
-	// 6. 使用并发安全的钱包服务扣减余额
-	err = s.walletService.WithdrawBalance(ctx, uid, groupBuy.PerPersonAmount, fmt.Sprintf("参与拼单 %s", groupBuy.GroupBuyNo))
+	// 7. 构建订单、参与记录和交易流水，流水的变动前后余额在扣款事务内回填
+	order, participant, transaction := s.buildJoinRecords(groupBuy, uid, 0)
+
+	// 8. 持有钱包锁，在同一事务内校验名额和钱包、扣减余额并保存参与数据，达到目标人数时同时成团
+	var currentParticipants int
+	var completed bool
+	err = s.walletService.LockedWalletWrite(ctx, uid, func() (*models.Wallet, error) {
+		wallet, count, done, err := s.groupBuyRepo.JoinGroupBuy(ctx, groupBuy.GroupBuyNo, reservedSeats, participant, order, transaction)
+		currentParticipants, completed = count, done
+		return wallet, err
+	})
 	if err != nil {
-		return nil, err
+		if appErr, ok := err.(*utils.AppError); ok {
+			return nil, appErr
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "参与拼单失败，请稍后重试")
 	}
 
-	// 7. 根据拼单的利润比例计算利润金额
-	profitAmount := s.calculateProfitAmountByGroupBuy(groupBuy.PerPersonAmount, groupBuy.ProfitMargin)
+	// 9. 成团后计算经验值与信用分
+	status := models.GroupBuyStatusPending
+	if completed {
+		status = models.GroupBuyStatusSuccess
+		s.awardGroupBuyCompleted(ctx, groupBuy.GroupBuyNo)
+	}
+	publishOrderEvent(ctx, s.eventService, order)
+	s.publishGroupBuyProgress(ctx, groupBuy.GroupBuyNo)
 
-	// 9. 生成订单编号
-	orderNo := utils.GenerateOrderNo()
+	// 10. 返回参与结果
+	response := &models.JoinGroupBuyResponse{
+		OrderID:             order.ID,
+		Status:              status,
+		CurrentParticipants: currentParticipants,
+		TargetParticipants:  groupBuy.TargetParticipants,
+	}
 
-	// 10. 随机选择1-4个类型，每个类型数量为1
+	return response, nil
+}
+
+// awardGroupBuyCompleted 成团后为全部参与者计算经验值与信用分，失败时由定时任务补偿
+func (s *GroupBuyService) awardGroupBuyCompleted(ctx context.Context, groupBuyNo string) {
+	participants, err := s.groupBuyRepo.GetParticipants(ctx, groupBuyNo)
+	if err != nil {
+		utils.LogWarn(nil, "获取拼单参与者失败 - 拼单: %s, 错误: %v", groupBuyNo, err)
+		return
+	}
+	s.scoreService.ApplyGroupBuyCompleted(ctx, groupBuyNo, participants)
+}
+
+// buildJoinRecords 构建参与拼单所需的订单、参与记录和交易流水
+func (s *GroupBuyService) buildJoinRecords(groupBuy *models.GroupBuy, uid string, balanceBefore float64) (*models.Order, *models.GroupBuyParticipant, *models.WalletTransaction) {
+	order := s.buildGroupBuyOrder(groupBuy, uid)
+
+	participant := &models.GroupBuyParticipant{
+		GroupBuyNo: groupBuy.GroupBuyNo,
+		Uid:        uid,
+		Amount:     groupBuy.PerPersonAmount,
+		OrderNo:    order.OrderNo,
+		Status:     models.GroupBuyParticipantStatusJoined,
+	}
+
+	transaction := &models.WalletTransaction{
+		TransactionNo:  utils.GenerateTransactionNo("GROUP"),
+		Uid:            uid,
+		Type:           models.TransactionTypeGroupBuy,
+		Amount:         groupBuy.PerPersonAmount,
+		BalanceBefore:  balanceBefore,
+		BalanceAfter:   balanceBefore - groupBuy.PerPersonAmount,
+		Status:         models.TransactionStatusSuccess,
+		Description:    fmt.Sprintf("参与拼单 %s", groupBuy.GroupBuyNo),
+		RelatedOrderNo: order.OrderNo,
+		OperatorUid:    "system",
+	}
+
+	return order, participant, transaction
+}
+
+// buildGroupBuyOrder 构建拼单订单
+func (s *GroupBuyService) buildGroupBuyOrder(groupBuy *models.GroupBuy, uid string) *models.Order {
+	// 根据拼单的利润比例计算利润金额
+	profitAmount := s.calculateProfitAmountByGroupBuy(groupBuy.PerPersonAmount, groupBuy.ProfitMargin)
+
+	// 随机选择1-4个类型，每个类型数量为1
 	likeCount := 0
 	shareCount := 0
 	followCount := 0
@@ -241,9 +332,8 @@ func (s *GroupBuyService) JoinGroupBuy(ctx context.Context, groupBuyNo, uid stri
 		}
 	}
 
-	// 11. 创建订单数据
-	order := &models.Order{
-		OrderNo:        orderNo,
+	return &models.Order{
+		OrderNo:        utils.GenerateOrderNo(),
 		Uid:            uid,
 		PeriodNumber:   groupBuy.GroupBuyNo, // 将拼单编号写入period_number字段
 		Amount:         groupBuy.PerPersonAmount,
@@ -262,65 +352,152 @@ func (s *GroupBuyService) JoinGroupBuy(ctx context.Context, groupBuyNo, uid stri
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
+}
+
+// GroupBuyRefundStats 拼单过期退款统计
+type GroupBuyRefundStats struct {
+	CompletedGroupBuys int           `json:"completed_group_buys"` // 补偿成团的满员拼单数
+	FailedGroupBuys    int           `json:"failed_group_buys"`    // 处理的未成团拼单数
+	RefundedCount      int           `json:"refunded_count"`       // 退款成功人数
+	RefundFailedCount  int           `json:"refund_failed_count"`  // 退款失败人数（下次重试）
+	RefundedAmount     float64       `json:"refunded_amount"`      // 退款总金额
+	ProcessTime        time.Duration `json:"process_time"`         // 处理耗时
+}
+
+// ProcessExpiredGroupBuys 处理截止时间已过但未满员的拼单：标记为未成团并给所有参与者退款
+// 同时将已满员但仍处于进行中的拼单补偿为成团
+func (s *GroupBuyService) ProcessExpiredGroupBuys(ctx context.Context) (*GroupBuyRefundStats, error) {
+	startTime := time.Now()
+	stats := &GroupBuyRefundStats{}
 
-	// 12. 保存订单
-	err = s.groupBuyRepo.CreateOrder(ctx, order)
+	fullGroupBuyNos, err := s.groupBuyRepo.GetFullPendingGroupBuyNos(ctx, 100)
 	if err != nil {
-		// 如果创建订单失败，使用并发安全的钱包服务回滚余额
-		if rollbackErr := s.walletService.AddBalance(ctx, uid, groupBuy.PerPersonAmount, "拼单订单创建失败回滚"); rollbackErr != nil {
-			// 回滚失败，记录严重错误
-			utils.LogError(nil, "拼单订单创建失败且余额回滚失败: %v, 回滚错误: %v", err, rollbackErr)
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询满员拼单失败")
+	}
+	for _, groupBuyNo := range fullGroupBuyNos {
+		completed, err := s.groupBuyRepo.CompleteGroupBuyIfFull(ctx, groupBuyNo)
+		if err != nil {
+			utils.LogError(nil, "更新拼单成团状态失败 - 拼单: %s, 错误: %v", groupBuyNo, err)
+			continue
+		}
+		if completed {
+			stats.CompletedGroupBuys++
+			s.awardGroupBuyCompleted(ctx, groupBuyNo)
+			s.publishGroupBuyProgress(ctx, groupBuyNo)
 		}
-		return nil, utils.NewAppError(utils.CodeDatabaseError, "创建订单失败，请稍后重试")
 	}
 
-	// 13. 获取扣减后的钱包信息用于创建交易记录
-	updatedWallet, err := s.walletService.GetWallet(uid)
+	groupBuys, err := s.groupBuyRepo.GetExpiredUnfilledGroupBuys(ctx, 100)
 	if err != nil {
-		return nil, utils.NewAppError(utils.CodeWalletGetFailed, "获取钱包失败")
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询过期拼单失败")
 	}
 
-	// 14. 创建钱包流水记录
-	transaction := &models.WalletTransaction{
-		TransactionNo:  utils.GenerateTransactionNo("GROUP"),
-		Uid:            uid,
-		Type:           models.TransactionTypeGroupBuy,
-		Amount:         groupBuy.PerPersonAmount,
-		BalanceBefore:  wallet.Balance,
-		BalanceAfter:   updatedWallet.Balance,
-		Status:         models.TransactionStatusSuccess,
-		Description:    fmt.Sprintf("参与拼单 %s", groupBuy.GroupBuyNo),
-		RelatedOrderNo: orderNo,
-		OperatorUid:    "system",
-	}
+	for i := range groupBuys {
+		groupBuy := &groupBuys[i]
+
+		if err := s.groupBuyRepo.MarkGroupBuyFailed(ctx, groupBuy.GroupBuyNo); err != nil {
+			utils.LogError(nil, "标记拼单未成团失败 - 拼单: %s, 错误: %v", groupBuy.GroupBuyNo, err)
+			continue
+		}
+		stats.FailedGroupBuys++
+
+		refundDescription := fmt.Sprintf("拼单 %s 未成团退款", groupBuy.GroupBuyNo)
+		if groupBuy.Status == models.GroupBuyStatusCancelled {
+			refundDescription = fmt.Sprintf("拼单 %s 已取消退款", groupBuy.GroupBuyNo)
+		}
+
+		participants, err := s.groupBuyRepo.GetParticipantsByStatus(ctx, groupBuy.GroupBuyNo, models.GroupBuyParticipantStatusJoined)
+		if err != nil {
+			utils.LogError(nil, "查询拼单参与记录失败 - 拼单: %s, 错误: %v", groupBuy.GroupBuyNo, err)
+			continue
+		}
 
-	if err := s.walletRepo.CreateTransaction(ctx, transaction); err != nil {
-		// 如果创建交易记录失败，记录日志但不影响拼单参与
-		utils.LogWarn(nil, "创建拼单交易记录失败: %v", err)
+		refundedAny := false
+		for j := range participants {
+			refunded, err := s.refundParticipant(ctx, &participants[j], refundDescription)
+			if err != nil {
+				stats.RefundFailedCount++
+				utils.LogError(nil, "拼单退款失败 - 拼单: %s, UID: %s, 错误: %v", groupBuy.GroupBuyNo, participants[j].Uid, err)
+				continue
+			}
+			if refunded {
+				refundedAny = true
+				stats.RefundedCount++
+				stats.RefundedAmount += participants[j].Amount
+			}
+		}
+		// 只在本次有退款时推送，避免重试时重复通知
+		if refundedAny {
+			s.publishGroupBuyProgress(ctx, groupBuy.GroupBuyNo)
+		}
 	}
 
-	// 15. 更新拼单信息
-	groupBuy.OrderNo = &orderNo
-	groupBuy.Status = "pending" // 更新为pending状态
-	groupBuy.UpdatedAt = time.Now()
+	stats.ProcessTime = time.Since(startTime)
+	return stats, nil
+}
 
-	err = s.groupBuyRepo.UpdateGroupBuy(ctx, groupBuy)
-	if err != nil {
-		// 如果更新拼单失败，需要回滚扣减的余额和创建的订单
-		if rollbackErr := s.walletService.AddBalance(ctx, uid, groupBuy.PerPersonAmount, "拼单状态更新失败回滚"); rollbackErr != nil {
-			// 回滚失败，记录严重错误
-			utils.LogError(nil, "拼单状态更新失败且余额回滚失败: %v, 回滚错误: %v", err, rollbackErr)
+// refundParticipant 给单个参与者退款，返回是否实际执行了退款
+func (s *GroupBuyService) refundParticipant(ctx context.Context, participant *models.GroupBuyParticipant, description string) (bool, error) {
+	refunded, orderCancelled := false, false
+	err := s.walletService.LockedWalletWrite(ctx, participant.Uid, func() (*models.Wallet, error) {
+		// 参与状态、余额、退款流水和订单状态在同一事务内更新，失败时整体回滚等待下次重试
+		transaction := &models.WalletTransaction{
+			TransactionNo:  utils.GenerateTransactionNo("GBRF"),
+			Uid:            participant.Uid,
+			Type:           models.TransactionTypeGroupBuyRefund,
+			Amount:         participant.Amount,
+			Status:         models.TransactionStatusSuccess,
+			Description:    description,
+			RelatedOrderNo: participant.OrderNo,
+			OperatorUid:    "system",
 		}
-		// 注意：这里可能需要删除已创建的订单，但为了简化，我们只回滚余额
-		return nil, utils.NewAppError(utils.CodeDatabaseError, "更新拼单状态失败，请稍后重试")
+		wallet, cancelled, err := s.groupBuyRepo.RefundParticipant(ctx, participant, transaction)
+		refunded, orderCancelled = wallet != nil, cancelled
+		return wallet, err
+	})
+	if err != nil {
+		return false, err
 	}
 
-	// 16. 返回订单ID
-	response := &models.JoinGroupBuyResponse{
-		OrderID: order.ID,
+	if orderCancelled {
+		s.publishCancelledOrder(ctx, participant.OrderNo)
 	}
+	return refunded, nil
+}
 
-	return response, nil
+// publishCancelledOrder 推送拼单订单已取消事件
+func (s *GroupBuyService) publishCancelledOrder(ctx context.Context, orderNo string) {
+	orders, err := s.groupBuyRepo.GetOrdersByNos(ctx, []string{orderNo})
+	if err != nil || len(orders) == 0 {
+		utils.LogWarn(nil, "获取拼单订单失败 - 订单: %s, 错误: %v", orderNo, err)
+		return
+	}
+	publishOrderEvent(ctx, s.eventService, &orders[0])
+}
+
+// publishGroupBuyProgress 向拼单的全部参与者推送最新进度
+func (s *GroupBuyService) publishGroupBuyProgress(ctx context.Context, groupBuyNo string) {
+	groupBuy, err := s.groupBuyRepo.GetGroupBuyByNo(ctx, groupBuyNo)
+	if err != nil {
+		utils.LogWarn(nil, "获取拼单信息失败 - 拼单: %s, 错误: %v", groupBuyNo, err)
+		return
+	}
+	participants, err := s.groupBuyRepo.GetParticipants(ctx, groupBuyNo)
+	if err != nil {
+		utils.LogWarn(nil, "获取拼单参与者失败 - 拼单: %s, 错误: %v", groupBuyNo, err)
+		return
+	}
+
+	uids := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		uids = append(uids, participant.Uid)
+	}
+	s.eventService.PublishToUsers(ctx, uids, models.UserEventGroupBuy, models.GroupBuyEventData{
+		GroupBuyNo:          groupBuy.GroupBuyNo,
+		Status:              groupBuy.Status,
+		CurrentParticipants: groupBuy.CurrentParticipants,
+		TargetParticipants:  groupBuy.TargetParticipants,
+	})
 }
 
 // calculateProfitAmountByGroupBuy 根据拼单的利润比例计算利润金额
@@ -329,11 +506,422 @@ func (s *GroupBuyService) calculateProfitAmountByGroupBuy(amount, profitMargin f
 	return amount * profitMargin
 }
 
-// generateTransactionNo 生成交易流水号
-func (s *GroupBuyService) generateTransactionNo() string {
-	// 格式：TX + 年月日 + 时分秒 + 4位随机数
+// GetGroupBuyTemplates 获取用户可发起的拼单模板
+func (s *GroupBuyService) GetGroupBuyTemplates() []models.GroupBuyTemplateResponse {
+	templates := config.GlobalConfig.GroupBuy.Templates
+	responses := make([]models.GroupBuyTemplateResponse, 0, len(templates))
+	for _, tpl := range templates {
+		t := s.applyTypeRule(tpl)
+		responses = append(responses, models.GroupBuyTemplateResponse{
+			Code:            t.Code,
+			Name:            t.Name,
+			GroupBuyType:    t.GroupBuyType,
+			PerPersonAmount: t.PerPersonAmount,
+			ProfitMargin:    t.ProfitMargin,
+			DurationMinutes: t.DurationMinutes,
+			MinParticipants: t.MinParticipants,
+			MaxParticipants: t.MaxParticipants,
+			Description:     t.Description,
+		})
+	}
+	return responses
+}
+
+// findGroupBuyTemplate 根据编码查找拼单模板
+func (s *GroupBuyService) findGroupBuyTemplate(code string) *config.GroupBuyTemplate {
+	for i := range config.GlobalConfig.GroupBuy.Templates {
+		if config.GlobalConfig.GroupBuy.Templates[i].Code == code {
+			return &config.GlobalConfig.GroupBuy.Templates[i]
+		}
+	}
+	return nil
+}
+
+// groupBuyTypeRule 获取拼单类型规则，未配置的类型按普通拼单处理
+func (s *GroupBuyService) groupBuyTypeRule(groupBuyType string) config.GroupBuyTypeRule {
+	if rule, ok := config.GlobalConfig.GroupBuy.TypeRules[groupBuyType]; ok {
+		return rule
+	}
+	return config.GlobalConfig.GroupBuy.TypeRules[models.GroupBuyTypeNormal]
+}
+
+// applyTypeRule 按拼单类型规则收紧模板的持续时间、名额上限和利润比例
+func (s *GroupBuyService) applyTypeRule(template config.GroupBuyTemplate) config.GroupBuyTemplate {
+	rule := s.groupBuyTypeRule(template.GroupBuyType)
+	if rule.MaxDurationMinutes > 0 && template.DurationMinutes > rule.MaxDurationMinutes {
+		template.DurationMinutes = rule.MaxDurationMinutes
+	}
+	if rule.MaxParticipants > 0 && template.MaxParticipants > rule.MaxParticipants {
+		template.MaxParticipants = rule.MaxParticipants
+	}
+	if template.MinParticipants > template.MaxParticipants {
+		template.MinParticipants = template.MaxParticipants
+	}
+	if rule.ProfitMargin > 0 {
+		template.ProfitMargin = rule.ProfitMargin
+	}
+	return template
+}
+
+// checkMemberLevel 检查用户会员等级是否满足拼单类型要求
+func (s *GroupBuyService) checkMemberLevel(ctx context.Context, rule config.GroupBuyTypeRule, uid string) error {
+	if rule.MinMemberLevel <= 0 {
+		return nil
+	}
+	level, err := NewUserLevelService().GetUserLevel(ctx, uid)
+	if err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "获取会员等级失败，请稍后重试")
+	}
+	if level < rule.MinMemberLevel {
+		return utils.NewAppError(utils.CodeGroupBuyLevelLimit,
+			fmt.Sprintf("该拼单要求会员等级达到%d级，当前等级%d级", rule.MinMemberLevel, level))
+	}
+	return nil
+}
+
+// reservedSeats 计算uid参与时需要避让的保留名额
+// 非先到先得的拼单为被推送的指定用户保留一个名额，直到其参与为止
+func (s *GroupBuyService) reservedSeats(ctx context.Context, groupBuy *models.GroupBuy, rule config.GroupBuyTypeRule, uid string) (int, error) {
+	if rule.FirstCome || groupBuy.Uid == "" || groupBuy.Uid == uid {
+		return 0, nil
+	}
+	joined, err := s.groupBuyRepo.HasParticipant(ctx, groupBuy.GroupBuyNo, groupBuy.Uid)
+	if err != nil {
+		return 0, err
+	}
+	if joined {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// buildRuleInfo 构建拼单类型规则信息
+func (s *GroupBuyService) buildRuleInfo(ctx context.Context, groupBuy *models.GroupBuy) *models.GroupBuyRuleInfo {
+	rule := s.groupBuyTypeRule(groupBuy.GroupBuyType)
+	reserved, err := s.reservedSeats(ctx, groupBuy, rule, "")
+	if err != nil {
+		// 查询失败时不展示保留名额，不影响详情返回
+		utils.LogError(nil, "查询拼单保留名额失败 - 拼单: %s, 错误: %v", groupBuy.GroupBuyNo, err)
+		reserved = 0
+	}
+	return &models.GroupBuyRuleInfo{
+		FirstCome:          rule.FirstCome,
+		MaxDurationMinutes: rule.MaxDurationMinutes,
+		MaxParticipants:    rule.MaxParticipants,
+		MinMemberLevel:     rule.MinMemberLevel,
+		ReservedSeats:      reserved,
+	}
+}
+
+// CreateGroupBuy 用户发起拼单
+// 发起人作为第一位参与者立即付款，返回带签名且会过期的邀请码和邀请链接
+func (s *GroupBuyService) CreateGroupBuy(ctx context.Context, uid string, req *models.CreateGroupBuyRequest) (*models.CreateGroupBuyResponse, error) {
+	// 1. 检查拼单资格
+	hasQualification, err := s.checkGroupBuyQualification(ctx, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "检查拼单资格失败，请稍后重试")
+	}
+	if !hasQualification {
+		return nil, utils.NewAppError(utils.CodeGroupBuyNoQualify, "您暂无拼单资格")
+	}
+
+	// 2. 校验模板、拼单类型规则和目标人数
+	found := s.findGroupBuyTemplate(req.TemplateCode)
+	if found == nil {
+		return nil, utils.NewAppError(utils.CodeGroupBuyTemplate, "拼单模板不存在")
+	}
+	template := s.applyTypeRule(*found)
+	if err := s.checkMemberLevel(ctx, s.groupBuyTypeRule(template.GroupBuyType), uid); err != nil {
+		return nil, err
+	}
+	if req.TargetParticipants < template.MinParticipants || req.TargetParticipants > template.MaxParticipants {
+		return nil, utils.NewAppError(utils.CodeGroupBuyTemplate,
+			fmt.Sprintf("目标人数需在%d-%d人之间", template.MinParticipants, template.MaxParticipants))
+	}
+
+	// 3. 检查钱包状态和余额
+	wallet, err := s.walletService.GetWallet(uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取钱包失败，请稍后重试")
+	}
+	if wallet.IsFrozen() {
+		return nil, utils.NewAppError(utils.CodeOperationFailed, "钱包已被冻结，无法发起拼单")
+	}
+	if wallet.Balance < template.PerPersonAmount {
+		return nil, utils.NewAppError(utils.CodeBalanceInsufficient,
+			fmt.Sprintf("余额不足，当前余额: %.2f，拼单金额: %.2f", wallet.Balance, template.PerPersonAmount))
+	}
+
+	// 4. 构建拼单，发起人计入第一位参与者
 	now := time.Now()
-	timestamp := now.Format("20060102150405")
-	random := utils.RandomString(4)
-	return fmt.Sprintf("TX%s%s", timestamp, random)
+	groupBuy := &models.GroupBuy{
+		GroupBuyNo:          utils.GenerateGroupBuyNo(),
+		CreatorUid:          uid,
+		Uid:                 uid,
+		InviteOnly:          true,
+		CurrentParticipants: 1,
+		TargetParticipants:  req.TargetParticipants,
+		GroupBuyType:        template.GroupBuyType,
+		TotalAmount:         template.PerPersonAmount * float64(req.TargetParticipants),
+		PaidAmount:          template.PerPersonAmount,
+		PerPersonAmount:     template.PerPersonAmount,
+		ProfitMargin:        template.ProfitMargin,
+		Deadline:            now.Add(time.Duration(template.DurationMinutes) * time.Minute),
+		Status:              models.GroupBuyStatusPending,
+		Description:         template.Name,
+	}
+
+	// 5. 扣减发起人余额
+	err = s.walletService.WithdrawBalance(ctx, uid, groupBuy.PerPersonAmount, fmt.Sprintf("发起拼单 %s", groupBuy.GroupBuyNo))
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. 在事务中保存拼单和发起人的参与数据
+	order, participant, transaction := s.buildJoinRecords(groupBuy, uid, wallet.Balance)
+	if err := s.groupBuyRepo.CreateGroupBuyWithCreator(ctx, groupBuy, participant, order, transaction); err != nil {
+		if rollbackErr := s.walletService.AddBalance(ctx, uid, groupBuy.PerPersonAmount, "发起拼单失败回滚"); rollbackErr != nil {
+			utils.LogError(nil, "发起拼单失败且余额回滚失败: %v, 回滚错误: %v", err, rollbackErr)
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "发起拼单失败，请稍后重试")
+	}
+	publishOrderEvent(ctx, s.eventService, order)
+	s.publishGroupBuyProgress(ctx, groupBuy.GroupBuyNo)
+
+	// 7. 生成邀请码，有效期不超过拼单截止时间
+	inviteExpireAt := now.Add(time.Duration(config.GlobalConfig.GroupBuy.InviteExpire) * time.Second)
+	if inviteExpireAt.After(groupBuy.Deadline) {
+		inviteExpireAt = groupBuy.Deadline
+	}
+	inviteCode := utils.GenerateGroupBuyInviteToken(groupBuy.GroupBuyNo, inviteExpireAt, s.inviteSecret())
+
+	return &models.CreateGroupBuyResponse{
+		GroupBuyNo:     groupBuy.GroupBuyNo,
+		OrderID:        order.ID,
+		InviteCode:     inviteCode,
+		InviteLink:     s.buildInviteLink(inviteCode),
+		InviteExpireAt: inviteExpireAt,
+		Deadline:       groupBuy.Deadline,
+	}, nil
+}
+
+// JoinGroupBuyByInvite 通过邀请码参与拼单
+func (s *GroupBuyService) JoinGroupBuyByInvite(ctx context.Context, inviteCode, uid string) (*models.JoinGroupBuyResponse, error) {
+	groupBuyNo, _, err := utils.ParseGroupBuyInviteToken(inviteCode, s.inviteSecret())
+	if err != nil {
+		if err == utils.ErrInviteTokenExpired {
+			return nil, utils.NewAppError(utils.CodeGroupBuyInvite, "拼单邀请码已过期")
+		}
+		return nil, utils.NewAppError(utils.CodeGroupBuyInvite, "拼单邀请码无效")
+	}
+	return s.joinGroupBuy(ctx, groupBuyNo, uid, true)
+}
+
+// GetGroupBuyProgress 获取拼单进度，仅发起人和参与者可查看
+func (s *GroupBuyService) GetGroupBuyProgress(ctx context.Context, groupBuyNo, uid string) (*models.GroupBuyProgressResponse, error) {
+	groupBuy, err := s.groupBuyRepo.GetGroupBuyByNo(ctx, groupBuyNo)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return nil, utils.NewAppError(utils.CodeGroupBuyNotFound, "拼单不存在或已被删除")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询拼单信息失败")
+	}
+
+	participants, err := s.groupBuyRepo.GetParticipants(ctx, groupBuyNo)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询参与记录失败")
+	}
+
+	isCreator := groupBuy.CreatorUid == uid
+	visible := isCreator
+	infos := make([]models.GroupBuyParticipantInfo, 0, len(participants))
+	for _, p := range participants {
+		displayUid := maskGroupBuyUid(p.Uid)
+		if p.Uid == uid {
+			visible = true
+			displayUid = p.Uid
+		}
+		infos = append(infos, models.GroupBuyParticipantInfo{
+			Uid:       displayUid,
+			IsCreator: p.Uid == groupBuy.CreatorUid,
+			Amount:    p.Amount,
+			Status:    p.Status,
+			JoinedAt:  p.CreatedAt,
+		})
+	}
+	if !visible {
+		return nil, utils.NewAppError(utils.CodeForbidden, "无权查看该拼单")
+	}
+
+	var remainingTime int64
+	if time.Now().Before(groupBuy.Deadline) {
+		remainingTime = int64(time.Until(groupBuy.Deadline).Seconds())
+	}
+
+	open := groupBuy.Status == models.GroupBuyStatusNotStarted || groupBuy.Status == models.GroupBuyStatusPending
+
+	detail := groupBuy.ToDetailResponse()
+	detail.Rules = s.buildRuleInfo(ctx, groupBuy)
+
+	return &models.GroupBuyProgressResponse{
+		GetGroupBuyDetailResponse: detail,
+		Status:                    groupBuy.Status,
+		IsCreator:                 isCreator,
+		CanCancel:                 isCreator && open && groupBuy.CurrentParticipants <= 1,
+		RemainingTime:             remainingTime,
+		Participants:              infos,
+	}, nil
+}
+
+// CancelGroupBuy 发起人取消拼单，仅在其他用户付款前允许，取消后退还发起人付款
+func (s *GroupBuyService) CancelGroupBuy(ctx context.Context, groupBuyNo, uid string) (*models.CancelGroupBuyResponse, error) {
+	groupBuy, err := s.groupBuyRepo.GetGroupBuyByNo(ctx, groupBuyNo)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return nil, utils.NewAppError(utils.CodeGroupBuyNotFound, "拼单不存在或已被删除")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询拼单信息失败")
+	}
+
+	if groupBuy.CreatorUid != uid {
+		return nil, utils.NewAppError(utils.CodeForbidden, "只有发起人可以取消拼单")
+	}
+	if groupBuy.Status != models.GroupBuyStatusNotStarted && groupBuy.Status != models.GroupBuyStatusPending {
+		return nil, utils.NewAppError(utils.CodeGroupBuyClosed, "拼单已结束，无法取消")
+	}
+
+	// 条件更新保证与并发参与互斥：已有他人占用名额时取消失败
+	cancelled, err := s.groupBuyRepo.CancelGroupBuyByCreator(ctx, groupBuyNo, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "取消拼单失败，请稍后重试")
+	}
+	if !cancelled {
+		return nil, utils.NewAppError(utils.CodeGroupBuyCancel, "拼单已有其他用户付款，无法取消")
+	}
+
+	participants, err := s.groupBuyRepo.GetParticipantsByStatus(ctx, groupBuyNo, models.GroupBuyParticipantStatusJoined)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询参与记录失败")
+	}
+
+	response := &models.CancelGroupBuyResponse{GroupBuyNo: groupBuyNo}
+	for i := range participants {
+		refunded, err := s.refundParticipant(ctx, &participants[i], fmt.Sprintf("拼单 %s 已取消退款", groupBuyNo))
+		if err != nil {
+			// 退款失败由过期拼单定时任务在截止后重试
+			utils.LogError(nil, "取消拼单退款失败 - 拼单: %s, UID: %s, 错误: %v", groupBuyNo, participants[i].Uid, err)
+			continue
+		}
+		if refunded {
+			response.RefundedAmount += participants[i].Amount
+		}
+	}
+	s.publishGroupBuyProgress(ctx, groupBuyNo)
+
+	return response, nil
+}
+
+// inviteSecret 获取邀请码签名密钥
+func (s *GroupBuyService) inviteSecret() string {
+	if config.GlobalConfig.GroupBuy.InviteSecret != "" {
+		return config.GlobalConfig.GroupBuy.InviteSecret
+	}
+	return config.GlobalConfig.JWT.Secret
+}
+
+// buildInviteLink 生成邀请链接
+func (s *GroupBuyService) buildInviteLink(inviteCode string) string {
+	baseURL := config.GlobalConfig.GroupBuy.InviteBaseURL
+	if baseURL == "" {
+		domain := config.GlobalConfig.Server.Domain
+		if domain == "" {
+			domain = fmt.Sprintf("%s:%d", config.GlobalConfig.Server.Host, config.GlobalConfig.Server.Port)
+		}
+		baseURL = "http://" + domain + "/groupBuy/invite"
+	}
+	return baseURL + "?code=" + inviteCode
+}
+
+// maskGroupBuyUid 参与者UID脱敏
+func maskGroupBuyUid(uid string) string {
+	if len(uid) <= 4 {
+		return uid
+	}
+	return uid[:2] + "****" + uid[len(uid)-2:]
+}
+
+// GetGroupBuyHistory 获取用户发起或参与过的拼单历史
+func (s *GroupBuyService) GetGroupBuyHistory(ctx context.Context, uid string, req *models.GroupBuyHistoryRequest) (*models.GroupBuyHistoryResponse, error) {
+	participants, total, err := s.groupBuyRepo.GetUserGroupBuyHistory(ctx, uid, req.Status, req.GroupBuyType, req.Page, req.PageSize)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeGroupBuyListGetFailed, "获取拼单历史失败")
+	}
+
+	// 批量加载拼单和关联订单
+	groupBuyNos := make([]string, 0, len(participants))
+	orderNos := make([]string, 0, len(participants))
+	for _, p := range participants {
+		groupBuyNos = append(groupBuyNos, p.GroupBuyNo)
+		if p.OrderNo != "" {
+			orderNos = append(orderNos, p.OrderNo)
+		}
+	}
+	groupBuys, err := s.groupBuyRepo.GetGroupBuysByNos(ctx, groupBuyNos)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeGroupBuyListGetFailed, "获取拼单历史失败")
+	}
+	orders, err := s.groupBuyRepo.GetOrdersByNos(ctx, orderNos)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeGroupBuyListGetFailed, "获取拼单历史失败")
+	}
+
+	groupBuyMap := make(map[string]*models.GroupBuy, len(groupBuys))
+	for i := range groupBuys {
+		groupBuyMap[groupBuys[i].GroupBuyNo] = &groupBuys[i]
+	}
+	orderMap := make(map[string]*models.Order, len(orders))
+	for i := range orders {
+		orderMap[orders[i].OrderNo] = &orders[i]
+	}
+
+	list := make([]models.GroupBuyHistoryItem, 0, len(participants))
+	for _, p := range participants {
+		groupBuy, ok := groupBuyMap[p.GroupBuyNo]
+		if !ok {
+			continue
+		}
+		item := models.GroupBuyHistoryItem{
+			GetGroupBuyDetailResponse: groupBuy.ToDetailResponse(),
+			Status:                    groupBuy.Status,
+			StatusName:                groupBuy.GetStatusName(),
+			IsCreator:                 groupBuy.CreatorUid == uid,
+			ParticipantStatus:         p.Status,
+			Contribution:              p.Amount,
+			OrderNo:                   p.OrderNo,
+			JoinedAt:                  p.CreatedAt,
+			RefundedAt:                p.RefundedAt,
+		}
+		if order, ok := orderMap[p.OrderNo]; ok {
+			item.OrderStatus = order.Status
+			// 利润在订单完成后才算到账
+			if order.Status == models.OrderStatusSuccess {
+				item.ProfitCredited = order.ProfitAmount
+			}
+		}
+		list = append(list, item)
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+	return &models.GroupBuyHistoryResponse{
+		HasData: len(list) > 0,
+		List:    list,
+		Pagination: models.PaginationInfo{
+			CurrentPage: req.Page,
+			PageSize:    req.PageSize,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     req.Page < totalPages,
+			HasPrev:     req.Page > 1,
+		},
+	}, nil
 }
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+)
+
+// 登录锁定维度
+const (
+	LoginLockScopeAccount = "account" // 按账号锁定
+	LoginLockScopeIP      = "ip"      // 按IP锁定
+)
+
+// LoginLockedError 登录已被锁定
+type LoginLockedError struct {
+	Scope     string        // 锁定维度：account、ip
+	Remaining time.Duration // 剩余锁定时长
+}
+
+// Error 实现error接口
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("登录失败次数过多，账户已被锁定，请%s后重试", formatLockRemaining(e.Remaining))
+}
+
+// RemainingSeconds 剩余锁定秒数，不足一秒按一秒计算
+func (e *LoginLockedError) RemainingSeconds() int64 {
+	seconds := int64(e.Remaining / time.Second)
+	if e.Remaining%time.Second > 0 {
+		seconds++
+	}
+	return seconds
+}
+
+// LoginLockoutService 登录锁定服务
+// 按账号和IP分别统计连续登录失败次数，达到阈值后锁定，重复锁定时锁定时长按指数翻倍
+type LoginLockoutService struct {
+	operationFailureService *OperationFailureService
+}
+
+// NewLoginLockoutService 创建登录锁定服务实例
+func NewLoginLockoutService() *LoginLockoutService {
+	return &LoginLockoutService{
+		operationFailureService: NewOperationFailureService(),
+	}
+}
+
+// 获取失败次数的Redis key
+func (s *LoginLockoutService) getFailKey(scope, target string) string {
+	return fmt.Sprintf("login_lock:%s:%s:fails", scope, target)
+}
+
+// 获取锁定状态的Redis key
+func (s *LoginLockoutService) getLockKey(scope, target string) string {
+	return fmt.Sprintf("login_lock:%s:%s:lock", scope, target)
+}
+
+// 获取锁定等级的Redis key，用于计算指数退避时长
+func (s *LoginLockoutService) getLevelKey(scope, target string) string {
+	return fmt.Sprintf("login_lock:%s:%s:level", scope, target)
+}
+
+// normalizeAccount 统一账号格式，避免大小写不同绕过锁定
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+// CheckLocked 检查账号或IP是否处于锁定状态，锁定时返回*LoginLockedError
+func (s *LoginLockoutService) CheckLocked(ctx context.Context, account, ip string) error {
+	targets := []struct {
+		scope  string
+		target string
+	}{
+		{LoginLockScopeAccount, normalizeAccount(account)},
+		{LoginLockScopeIP, ip},
+	}
+
+	for _, t := range targets {
+		if t.target == "" {
+			continue
+		}
+		ttl, err := database.RedisClient.TTL(ctx, s.getLockKey(t.scope, t.target)).Result()
+		if err != nil {
+			// Redis异常时不阻断登录，避免缓存故障导致全部用户无法登录
+			utils.LogError(nil, "检查登录锁定失败 - 维度: %s, 错误: %v", t.scope, err)
+			continue
+		}
+		if ttl > 0 {
+			return &LoginLockedError{Scope: t.scope, Remaining: ttl}
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时锁定
+// uid为空表示账号不存在，此时仍按账号字符串计数，避免通过锁定行为判断账号是否注册
+func (s *LoginLockoutService) RecordFailure(ctx context.Context, account, ip, uid string) {
+	cfg := config.GlobalConfig.Auth
+	s.recordScopeFailure(ctx, LoginLockScopeAccount, normalizeAccount(account), uid, cfg.MaxLoginAttempts)
+	s.recordScopeFailure(ctx, LoginLockScopeIP, ip, uid, cfg.IPMaxLoginAttempts)
+}
+
+// recordScopeFailure 累加指定维度的失败次数
+func (s *LoginLockoutService) recordScopeFailure(ctx context.Context, scope, target, uid string, maxAttempts int) {
+	if target == "" || maxAttempts <= 0 {
+		return
+	}
+
+	window := time.Duration(config.GlobalConfig.Auth.LockoutDuration) * time.Second
+	failKey := s.getFailKey(scope, target)
+
+	count, err := database.RedisClient.Incr(ctx, failKey).Result()
+	if err != nil {
+		utils.LogError(nil, "记录登录失败次数失败 - 维度: %s, 错误: %v", scope, err)
+		return
+	}
+	if count == 1 {
+		database.SetExpire(ctx, failKey, window)
+	}
+
+	// 达到阈值即加锁；加锁时清除失败次数，此前加锁失败时后续失败仍会重新加锁
+	if count < int64(maxAttempts) {
+		return
+	}
+
+	s.lock(ctx, scope, target, uid, count)
+}
+
+// lock 锁定账号或IP，锁定时长 = 首次锁定时长 * 2^(锁定等级-1)，不超过最长锁定时长
+func (s *LoginLockoutService) lock(ctx context.Context, scope, target, uid string, failures int64) {
+	cfg := config.GlobalConfig.Auth
+	maxDuration := time.Duration(cfg.MaxLockoutDuration) * time.Second
+
+	levelKey := s.getLevelKey(scope, target)
+	level, err := database.RedisClient.Incr(ctx, levelKey).Result()
+	if err != nil {
+		utils.LogError(nil, "更新登录锁定等级失败 - 维度: %s, 错误: %v", scope, err)
+		level = 1
+	}
+	database.SetExpire(ctx, levelKey, maxDuration)
+
+	duration := time.Duration(cfg.LockoutDuration) * time.Second
+	for i := int64(1); i < level && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+
+	unlockAt := time.Now().Add(duration)
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Set(ctx, s.getLockKey(scope, target), unlockAt.Unix(), duration)
+	pipe.Del(ctx, s.getFailKey(scope, target))
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LogError(nil, "写入登录锁定失败 - 维度: %s, 错误: %v", scope, err)
+		return
+	}
+
+	utils.LogSecurityEvent(nil, "login_locked", fmt.Sprintf("维度: %s, 等级: %d, 时长: %s", scope, level, duration))
+
+	var uidPtr *string
+	if uid != "" {
+		uidPtr = &uid
+	}
+	s.operationFailureService.RecordFailure(ctx, uidPtr, models.OperationTypeLoginLock, map[string]interface{}{
+		"scope":    scope,
+		"target":   s.maskTarget(scope, target),
+		"failures": failures,
+	}, map[string]interface{}{
+		"level":        level,
+		"lock_seconds": int64(duration / time.Second),
+		"unlock_at":    unlockAt,
+	})
+}
+
+// Reset 登录成功后清除账号的失败次数和锁定等级
+// IP维度不清除，避免攻击者用自己的账号登录来重置IP计数
+func (s *LoginLockoutService) Reset(ctx context.Context, account string) {
+	target := normalizeAccount(account)
+	if err := database.RedisClient.Del(ctx,
+		s.getFailKey(LoginLockScopeAccount, target),
+		s.getLevelKey(LoginLockScopeAccount, target),
+	).Err(); err != nil {
+		utils.LogError(nil, "清除登录失败次数失败 - 错误: %v", err)
+	}
+}
+
+// Unlock 管理员解除账号或IP的登录锁定，同时清除失败次数和锁定等级
+func (s *LoginLockoutService) Unlock(ctx context.Context, req *models.LoginUnlockRequest, operator string) (*models.LoginUnlockResponse, error) {
+	account := normalizeAccount(req.Account)
+	ip := strings.TrimSpace(req.IP)
+	if account == "" && ip == "" {
+		return nil, utils.NewAppError(utils.CodeInvalidParams, "账号和IP不能同时为空")
+	}
+
+	response := &models.LoginUnlockResponse{}
+	if account != "" {
+		unlocked, err := s.unlockScope(ctx, LoginLockScopeAccount, account)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeRedisError, "解除锁定失败")
+		}
+		response.AccountUnlocked = unlocked
+	}
+	if ip != "" {
+		unlocked, err := s.unlockScope(ctx, LoginLockScopeIP, ip)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeRedisError, "解除锁定失败")
+		}
+		response.IPUnlocked = unlocked
+	}
+
+	s.operationFailureService.RecordFailure(ctx, nil, models.OperationTypeLoginUnlock, map[string]interface{}{
+		"account":  s.maskTarget(LoginLockScopeAccount, account),
+		"ip":       ip,
+		"operator": operator,
+	}, response)
+
+	return response, nil
+}
+
+// unlockScope 删除指定维度的锁定信息，返回此前是否处于锁定状态
+func (s *LoginLockoutService) unlockScope(ctx context.Context, scope, target string) (bool, error) {
+	lockKey := s.getLockKey(scope, target)
+	locked, err := database.RedisClient.Exists(ctx, lockKey).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if err := database.RedisClient.Del(ctx,
+		lockKey,
+		s.getFailKey(scope, target),
+		s.getLevelKey(scope, target),
+	).Err(); err != nil {
+		return false, err
+	}
+
+	return locked > 0, nil
+}
+
+// maskTarget 脱敏账号，IP原样记录
+func (s *LoginLockoutService) maskTarget(scope, target string) string {
+	if scope != LoginLockScopeAccount || target == "" {
+		return target
+	}
+	if utils.IsEmailAccount(target) {
+		return utils.MaskEmail(target)
+	}
+	return utils.MaskPhone(target)
+}
+
+// formatLockRemaining 格式化剩余锁定时长
+func formatLockRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d秒", int64(d/time.Second)+1)
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%d分钟", int64((d+time.Minute-1)/time.Minute))
+	}
+	hours := int64(d / time.Hour)
+	minutes := int64((d % time.Hour) / time.Minute)
+	if minutes == 0 {
+		return fmt.Sprintf("%d小时", hours)
+	}
+	return fmt.Sprintf("%d小时%d分钟", hours, minutes)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
 	"time"
 )
 
@@ -67,6 +68,35 @@ func (s *LoginLogService) GetUserLoginHistory(ctx context.Context, uid string, p
 	return responses, total, nil
 }
 
+// GetUserLoginHistoryByCursor 游标分页获取用户登录历史，返回下一页游标
+func (s *LoginLogService) GetUserLoginHistoryByCursor(ctx context.Context, uid string, cursorStr string, size int) ([]models.UserLoginLogResponse, string, error) {
+	cursor, err := utils.DecodeCursor(cursorStr)
+	if err != nil {
+		return nil, "", utils.NewAppError(utils.CodeInvalidCursor, "分页游标无效")
+	}
+
+	logs, hasNext, err := s.loginLogRepo.GetUserLoginHistoryByCursor(ctx, uid, cursor, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	responses := make([]models.UserLoginLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = log.ToResponse()
+	}
+
+	return responses, LoginLogNextCursor(responses, hasNext), nil
+}
+
+// LoginLogNextCursor 根据本页最后一条登录记录生成下一页游标
+func LoginLogNextCursor(logs []models.UserLoginLogResponse, hasNext bool) string {
+	if !hasNext || len(logs) == 0 {
+		return ""
+	}
+	last := logs[len(logs)-1]
+	return utils.EncodeCursor(last.CreatedAt, int64(last.ID))
+}
+
 // GetUserLastLogin 获取用户最后登录信息
 func (s *LoginLogService) GetUserLastLogin(ctx context.Context, uid string) (*models.UserLoginLogResponse, error) {
 	log, err := s.loginLogRepo.GetUserLastLogin(ctx, uid)
@@ -0,0 +1,550 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	memberLevelCacheExpire   = time.Hour // 等级配置缓存时间，配置变更时主动清除
+	levelOverrideCacheExpire = time.Hour // 用户等级覆盖缓存时间，设置或清除时主动清除
+	levelOverrideNone        = "none"    // 用户没有等级覆盖时的缓存值，避免每次查询数据库
+
+	legacyLevelConfigPrefix     = "user:level:config:"            // 旧版按用户保存的等级规则Key前缀
+	legacyLevelConfigDefaultKey = "user:level:config:default"     // 旧版默认等级规则Key
+	legacyLevelMigratedKey      = "migration:legacy_level_config" // 旧版等级规则迁移完成标记
+)
+
+// legacyLevelRule 旧版保存在Redis中的等级规则
+type legacyLevelRule struct {
+	Level           int    `json:"level"`
+	Name            string `json:"name"`
+	Logo            string `json:"logo"`
+	Requirement     int64  `json:"requirement"`
+	RequirementType string `json:"requirement_type"`
+	Remark          string `json:"remark"`
+}
+
+// legacyLevelConfig 旧版保存在Redis中的等级配置
+type legacyLevelConfig struct {
+	LevelRules []legacyLevelRule `json:"level_rules"`
+	UpdatedBy  string            `json:"updated_by"`
+}
+
+// errLegacyLevelConfigInvalid 旧版等级配置无法解析
+var errLegacyLevelConfigInvalid = errors.New("旧版等级配置格式错误")
+
+// legacyBuiltinLevelRules 旧版没有默认规则时使用的内置规则
+var legacyBuiltinLevelRules = []legacyLevelRule{
+	{Level: 1, Name: "青铜会员", Requirement: 70000, RequirementType: models.LevelRequirementBalance, Remark: "余额达到7万升级"},
+	{Level: 2, Name: "白银会员", Requirement: 100000, RequirementType: models.LevelRequirementBalance, Remark: "余额达到10万升级"},
+	{Level: 3, Name: "黄金会员", Requirement: 200000, RequirementType: models.LevelRequirementBalance, Remark: "余额达到20万升级"},
+}
+
+// MemberLevelService 会员等级配置服务
+// 等级配置和用户等级覆盖以MySQL为准，Redis只做读缓存，变更时清除缓存
+type MemberLevelService struct {
+	repo         *database.MemberLevelRepository
+	userRepo     *database.UserRepository
+	auditService *AdminAuditService
+}
+
+// NewMemberLevelService 创建会员等级配置服务实例
+func NewMemberLevelService() *MemberLevelService {
+	return &MemberLevelService{
+		repo:         database.NewMemberLevelRepository(),
+		userRepo:     database.NewUserRepository(),
+		auditService: NewAdminAuditService(),
+	}
+}
+
+// ListLevels 获取全部等级配置，按等级从低到高排序，优先读取缓存
+func (s *MemberLevelService) ListLevels(ctx context.Context) ([]models.MemberLevel, error) {
+	cacheKey := memberLevelCacheKey()
+	if data, err := database.GetKey(ctx, cacheKey); err == nil && data != "" {
+		var cached []models.MemberLevel
+		if json.Unmarshal([]byte(data), &cached) == nil {
+			return cached, nil
+		}
+	}
+
+	levels, err := s.repo.ListLevels(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeUserLevelGetFailed, "获取会员等级配置失败")
+	}
+
+	if data, err := json.Marshal(levels); err == nil {
+		database.SetKey(ctx, cacheKey, string(data), memberLevelCacheExpire)
+	}
+	return levels, nil
+}
+
+// GetOverride 获取用户等级覆盖，没有覆盖时返回nil，优先读取缓存
+func (s *MemberLevelService) GetOverride(ctx context.Context, uid string) (*models.UserLevelOverride, error) {
+	cacheKey := levelOverrideCacheKey(uid)
+	if data, err := database.GetKey(ctx, cacheKey); err == nil && data != "" {
+		if data == levelOverrideNone {
+			return nil, nil
+		}
+		var cached models.UserLevelOverride
+		if json.Unmarshal([]byte(data), &cached) == nil {
+			return &cached, nil
+		}
+	}
+
+	override, err := s.repo.FindOverride(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			database.SetKey(ctx, cacheKey, levelOverrideNone, levelOverrideCacheExpire)
+			return nil, nil
+		}
+		return nil, utils.NewAppError(utils.CodeUserLevelGetFailed, "获取用户等级覆盖失败")
+	}
+
+	if data, err := json.Marshal(override); err == nil {
+		database.SetKey(ctx, cacheKey, string(data), levelOverrideCacheExpire)
+	}
+	return override, nil
+}
+
+// AdminList 管理后台获取全部等级配置
+func (s *MemberLevelService) AdminList(ctx context.Context) ([]models.MemberLevelResponse, error) {
+	levels, err := s.repo.ListLevels(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取会员等级配置失败")
+	}
+
+	responses := make([]models.MemberLevelResponse, 0, len(levels))
+	for i := range levels {
+		responses = append(responses, levels[i].ToResponse())
+	}
+	return responses, nil
+}
+
+// Create 创建等级配置
+func (s *MemberLevelService) Create(ctx context.Context, req *models.MemberLevelRequest) (*models.MemberLevelResponse, error) {
+	levels, err := s.repo.ListLevels(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取会员等级配置失败")
+	}
+	for _, existing := range levels {
+		if existing.Level == req.Level {
+			return nil, utils.NewAppError(utils.CodeMemberLevelExists, fmt.Sprintf("等级%d已存在", req.Level))
+		}
+	}
+
+	level := &models.MemberLevel{
+		Level:           req.Level,
+		Name:            req.Name,
+		Logo:            req.Logo,
+		Remark:          req.Remark,
+		CashbackRatio:   req.CashbackRatio,
+		SingleAmount:    req.SingleAmount,
+		Requirement:     req.Requirement,
+		RequirementType: req.RequirementType,
+	}
+	if level.SingleAmount == 0 {
+		level.SingleAmount = 1
+	}
+	if level.RequirementType == "" {
+		level.RequirementType = models.LevelRequirementBalance
+	}
+	if err := validateLevelRequirements(append(levels, *level)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateLevel(ctx, level); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "创建会员等级失败")
+	}
+
+	s.invalidateLevels(ctx)
+	s.auditService.Record(ctx, models.AuditActionMemberLevelCreate, models.AuditTargetMemberLevel, fmt.Sprint(level.Level), nil, level)
+
+	resp := level.ToResponse()
+	return &resp, nil
+}
+
+// Update 修改等级配置，等级数值不可修改
+func (s *MemberLevelService) Update(ctx context.Context, req *models.MemberLevelUpdateRequest) (*models.MemberLevelResponse, error) {
+	before, err := s.findLevel(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	level := *before
+	if req.Name != nil {
+		level.Name = *req.Name
+	}
+	if req.Logo != nil {
+		level.Logo = *req.Logo
+	}
+	if req.Remark != nil {
+		level.Remark = *req.Remark
+	}
+	if req.CashbackRatio != nil {
+		level.CashbackRatio = *req.CashbackRatio
+	}
+	if req.SingleAmount != nil {
+		level.SingleAmount = *req.SingleAmount
+	}
+	if req.Requirement != nil {
+		level.Requirement = *req.Requirement
+	}
+	if req.RequirementType != nil {
+		level.RequirementType = *req.RequirementType
+	}
+
+	if req.Requirement != nil || req.RequirementType != nil {
+		levels, err := s.repo.ListLevels(ctx)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "获取会员等级配置失败")
+		}
+		for i := range levels {
+			if levels[i].ID == level.ID {
+				levels[i] = level
+			}
+		}
+		if err := validateLevelRequirements(levels); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateLevel(ctx, &level); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "修改会员等级失败")
+	}
+
+	s.invalidateLevels(ctx)
+	s.auditService.Record(ctx, models.AuditActionMemberLevelUpdate, models.AuditTargetMemberLevel, fmt.Sprint(level.Level), before, &level)
+
+	resp := level.ToResponse()
+	return &resp, nil
+}
+
+// Delete 删除等级配置，仍有用户固定在该等级时不允许删除
+func (s *MemberLevelService) Delete(ctx context.Context, id uint64) error {
+	level, err := s.findLevel(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountOverridesByLevel(ctx, level.Level)
+	if err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "查询用户等级覆盖失败")
+	}
+	if count > 0 {
+		return utils.NewAppError(utils.CodeMemberLevelInUse, fmt.Sprintf("仍有%d个用户固定在该等级，请先清除", count))
+	}
+
+	if err := s.repo.DeleteLevel(ctx, id); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "删除会员等级失败")
+	}
+
+	s.invalidateLevels(ctx)
+	s.auditService.Record(ctx, models.AuditActionMemberLevelDelete, models.AuditTargetMemberLevel, fmt.Sprint(level.Level), level, nil)
+	return nil
+}
+
+// SetOverride 为用户固定会员等级
+func (s *MemberLevelService) SetOverride(ctx context.Context, req *models.UserLevelOverrideSetRequest, operator string) (*models.UserLevelOverride, error) {
+	if _, err := s.userRepo.FindByUid(ctx, req.Uid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询用户失败")
+	}
+
+	levels, err := s.repo.ListLevels(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取会员等级配置失败")
+	}
+	if findMemberLevel(levels, req.Level) == nil {
+		return nil, utils.NewAppError(utils.CodeMemberLevelNotFound, fmt.Sprintf("等级%d不存在", req.Level))
+	}
+
+	before, err := s.repo.FindOverride(ctx, req.Uid)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询用户等级覆盖失败")
+	}
+
+	override := &models.UserLevelOverride{
+		Uid:       req.Uid,
+		Level:     req.Level,
+		Remark:    req.Remark,
+		CreatedBy: operator,
+	}
+	if err := s.repo.UpsertOverride(ctx, override); err != nil {
+		return nil, utils.NewAppError(utils.CodeUserLevelStoreFailed, "保存用户等级覆盖失败")
+	}
+
+	s.invalidateOverride(ctx, req.Uid)
+	s.auditService.Record(ctx, models.AuditActionUserLevelOverride, models.AuditTargetUser, req.Uid, before, override)
+	return override, nil
+}
+
+// ClearOverride 清除用户等级覆盖，恢复按升级要求计算等级
+func (s *MemberLevelService) ClearOverride(ctx context.Context, uid string) error {
+	before, err := s.repo.FindOverride(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return utils.NewAppError(utils.CodeDatabaseError, "查询用户等级覆盖失败")
+	}
+
+	if _, err := s.repo.DeleteOverride(ctx, uid); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "清除用户等级覆盖失败")
+	}
+
+	s.invalidateOverride(ctx, uid)
+	s.auditService.Record(ctx, models.AuditActionUserLevelOverrideClear, models.AuditTargetUser, uid, before, nil)
+	return nil
+}
+
+// findLevel 根据ID获取等级配置
+func (s *MemberLevelService) findLevel(ctx context.Context, id uint64) (*models.MemberLevel, error) {
+	level, err := s.repo.FindLevelByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeMemberLevelNotFound, "会员等级不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取会员等级失败")
+	}
+	return level, nil
+}
+
+// MigrateLegacyLevelConfigs 将旧版保存在Redis中的等级规则导入MySQL，只执行一次，返回导入的用户等级覆盖数量
+// 默认规则写入会员等级的升级要求，没有默认规则时只在等级都未设置升级要求时沿用旧版内置规则；
+// 按用户保存的规则与默认规则不同时，按用户当前余额算出等级写入等级覆盖。
+// 全部导入成功后才记录迁移标记并删除旧Key，失败时保留旧Key，下次启动重试
+func (s *MemberLevelService) MigrateLegacyLevelConfigs(ctx context.Context) (int, error) {
+	migrated, err := database.ExistsKey(ctx, legacyLevelMigratedKey)
+	if err != nil || migrated {
+		return 0, err
+	}
+
+	keys, err := database.ScanKeys(ctx, legacyLevelConfigPrefix+"*")
+	if err != nil {
+		return 0, err
+	}
+
+	levels, err := s.repo.ListLevels(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defaultRules, builtin, err := s.legacyDefaultRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// 使用内置规则时，已有等级设置了升级要求则不再导入，不覆盖管理员的修改
+	importRules := defaultRules
+	known := make(map[int]bool)
+	for _, level := range levels {
+		known[level.Level] = true
+		if builtin && level.Requirement > 0 {
+			importRules = nil
+		}
+	}
+
+	rules := make([]models.MemberLevel, 0, len(importRules))
+	for _, rule := range importRules {
+		requirementType := rule.RequirementType
+		if requirementType != models.LevelRequirementExperience {
+			requirementType = models.LevelRequirementBalance
+		}
+		rules = append(rules, models.MemberLevel{
+			Level:           rule.Level,
+			Name:            rule.Name,
+			Logo:            rule.Logo,
+			Remark:          rule.Remark,
+			SingleAmount:    1,
+			Requirement:     rule.Requirement,
+			RequirementType: requirementType,
+		})
+		known[rule.Level] = true
+	}
+
+	overrides, err := s.legacyLevelOverrides(ctx, keys, defaultRules, known)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.repo.ImportLegacyLevelConfigs(ctx, rules, overrides); err != nil {
+		return 0, err
+	}
+	s.invalidateLevels(ctx)
+	for _, override := range overrides {
+		s.invalidateOverride(ctx, override.Uid)
+	}
+
+	if err := database.SetKey(ctx, legacyLevelMigratedKey, time.Now().Unix(), 0); err != nil {
+		return 0, err
+	}
+	if err := database.DelKeys(ctx, keys); err != nil {
+		utils.LogWarn(nil, "删除旧版用户等级配置失败: %v", err)
+	}
+	return len(overrides), nil
+}
+
+// legacyDefaultRules 获取旧版生效的默认等级规则，与旧版读取逻辑一致，默认规则不存在或无法解析时使用内置规则，builtin表示使用了内置规则
+func (s *MemberLevelService) legacyDefaultRules(ctx context.Context) ([]legacyLevelRule, bool, error) {
+	config, err := loadLegacyLevelConfig(ctx, legacyLevelConfigDefaultKey)
+	if err != nil {
+		if !errors.Is(err, errLegacyLevelConfigInvalid) {
+			return nil, false, err
+		}
+		utils.LogWarn(nil, "解析旧版默认等级规则失败，使用内置规则: %v", err)
+	}
+	if config == nil {
+		return legacyBuiltinLevelRules, true, nil
+	}
+	return config.LevelRules, false, nil
+}
+
+// legacyLevelOverrides 将旧版按用户保存的等级规则转换为等级覆盖
+// 与默认规则相同的用户按会员等级表计算等级，不需要覆盖；用户不存在、规则无法解析或算出的等级不存在时跳过
+func (s *MemberLevelService) legacyLevelOverrides(ctx context.Context, keys []string, defaultRules []legacyLevelRule, known map[int]bool) ([]models.UserLevelOverride, error) {
+	walletRepo := database.NewWalletRepository()
+	var overrides []models.UserLevelOverride
+	for _, key := range keys {
+		if key == legacyLevelConfigDefaultKey {
+			continue
+		}
+		uid := strings.TrimPrefix(key, legacyLevelConfigPrefix)
+
+		config, err := loadLegacyLevelConfig(ctx, key)
+		if err != nil {
+			if !errors.Is(err, errLegacyLevelConfigInvalid) {
+				return nil, err
+			}
+			// 旧版同样无法使用这类配置
+			utils.LogWarn(nil, "跳过无法解析的旧版用户等级配置 - UID: %s, 错误: %v", uid, err)
+			continue
+		}
+		if config == nil || reflect.DeepEqual(config.LevelRules, defaultRules) {
+			continue
+		}
+
+		if _, err := s.userRepo.FindByUid(ctx, uid); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		// 旧版等级始终按钱包余额计算
+		var balance float64
+		wallet, err := walletRepo.FindWalletByUid(ctx, uid)
+		if err == nil {
+			balance = wallet.Balance
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		level := legacyLevelFor(config.LevelRules, balance)
+		if !known[level] {
+			utils.LogWarn(nil, "跳过旧版用户等级配置，等级%d不存在 - UID: %s", level, uid)
+			continue
+		}
+
+		createdBy := config.UpdatedBy
+		if createdBy == "" {
+			createdBy = "system"
+		}
+		overrides = append(overrides, models.UserLevelOverride{
+			Uid:       uid,
+			Level:     level,
+			Remark:    "由旧版用户等级配置迁移",
+			CreatedBy: createdBy,
+		})
+	}
+	return overrides, nil
+}
+
+// loadLegacyLevelConfig 读取旧版等级配置，Key不存在时返回nil
+func loadLegacyLevelConfig(ctx context.Context, key string) (*legacyLevelConfig, error) {
+	data, err := database.GetKeyOrDefault(ctx, key, "")
+	if err != nil || data == "" {
+		return nil, err
+	}
+	var config legacyLevelConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("%w: %v", errLegacyLevelConfigInvalid, err)
+	}
+	return &config, nil
+}
+
+// legacyLevelFor 按旧版规则计算等级，依次判断，遇到第一个余额未达到的规则即停止，默认等级为1
+func legacyLevelFor(rules []legacyLevelRule, balance float64) int {
+	level := 1
+	for _, rule := range rules {
+		if balance < float64(rule.Requirement) {
+			break
+		}
+		level = rule.Level
+	}
+	return level
+}
+
+// invalidateLevels 清除等级配置缓存
+func (s *MemberLevelService) invalidateLevels(ctx context.Context) {
+	if err := database.DelKey(ctx, memberLevelCacheKey()); err != nil {
+		utils.LogWarn(nil, "清除会员等级缓存失败 - 错误: %v", err)
+	}
+}
+
+// invalidateOverride 清除用户等级覆盖缓存
+func (s *MemberLevelService) invalidateOverride(ctx context.Context, uid string) {
+	if err := database.DelKey(ctx, levelOverrideCacheKey(uid)); err != nil {
+		utils.LogWarn(nil, "清除用户等级覆盖缓存失败 - UID: %s, 错误: %v", uid, err)
+	}
+}
+
+// validateLevelRequirements 同一要求类型下，等级越高升级要求必须越高
+func validateLevelRequirements(levels []models.MemberLevel) error {
+	sorted := make([]models.MemberLevel, len(levels))
+	copy(sorted, levels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Level < sorted[j].Level })
+
+	last := make(map[string]models.MemberLevel)
+	for _, level := range sorted {
+		if prev, ok := last[level.RequirementType]; ok && level.Requirement <= prev.Requirement {
+			return utils.NewAppError(utils.CodeMemberLevelInvalid,
+				fmt.Sprintf("等级%d的升级要求必须高于等级%d（%d）", level.Level, prev.Level, prev.Requirement))
+		}
+		last[level.RequirementType] = level
+	}
+	return nil
+}
+
+// findMemberLevel 按等级数值查找等级配置
+func findMemberLevel(levels []models.MemberLevel, level int) *models.MemberLevel {
+	for i := range levels {
+		if levels[i].Level == level {
+			return &levels[i]
+		}
+	}
+	return nil
+}
+
+// memberLevelCacheKey 等级配置缓存Key
+func memberLevelCacheKey() string {
+	return utils.RedisKeys.GenerateConfigCacheKey("member_level")
+}
+
+// levelOverrideCacheKey 用户等级覆盖缓存Key
+func levelOverrideCacheKey(uid string) string {
+	return fmt.Sprintf("user:level:override:%s", uid)
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	broadcastBatchSize = 500             // 每批处理的候选用户数
+	broadcastLease     = 2 * time.Minute // 发送任务租约，每批处理后续约
+	broadcastDueLimit  = 20              // 定时任务每次最多处理的群发消息数
+)
+
+// BroadcastProcessStats 群发消息处理统计
+type BroadcastProcessStats struct {
+	BroadcastCount int           `json:"broadcast_count"`
+	FailedCount    int           `json:"failed_count"`
+	ProcessTime    time.Duration `json:"process_time"`
+}
+
+// MessageBroadcastService 管理员群发消息服务
+// 消息内容只保存一份，后台任务按用户自增ID分批写入投递记录，用户通过收件箱读取
+// 任务通过租约防止多实例重复处理，中断后由定时任务从上次处理到的用户继续
+type MessageBroadcastService struct {
+	repo               *database.MessageBroadcastRepository
+	memberLevelRepo    *database.MemberLevelRepository
+	walletRepo         *database.WalletRepository
+	memberLevelService *MemberLevelService
+	auditService       *AdminAuditService
+	eventService       *UserEventService
+}
+
+// NewMessageBroadcastService 创建群发消息服务实例
+func NewMessageBroadcastService() *MessageBroadcastService {
+	return &MessageBroadcastService{
+		repo:               database.NewMessageBroadcastRepository(),
+		memberLevelRepo:    database.NewMemberLevelRepository(),
+		walletRepo:         database.NewWalletRepository(),
+		memberLevelService: NewMemberLevelService(),
+		auditService:       NewAdminAuditService(),
+		eventService:       NewUserEventService(),
+	}
+}
+
+// Send 创建群发消息，未指定发送时间时立即在后台开始发送
+func (s *MessageBroadcastService) Send(ctx context.Context, operator *models.AdminUser, req *models.MessageSendRequest) (*models.MessageBroadcast, error) {
+	broadcast := &models.MessageBroadcast{
+		MessageType: req.MessageType,
+		Content:     req.Content,
+		TargetType:  req.TargetType,
+		Status:      models.BroadcastStatusPending,
+		CreatedBy:   operator.Username,
+	}
+	if err := s.applyTarget(ctx, broadcast, req); err != nil {
+		return nil, err
+	}
+
+	if req.ScheduledAt != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.ScheduledAt, time.Local)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "定时发送时间格式错误，应为YYYY-MM-DD HH:MM:SS")
+		}
+		if !t.After(time.Now()) {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "定时发送时间必须晚于当前时间")
+		}
+		broadcast.ScheduledAt = &t
+	}
+
+	if err := s.repo.Create(ctx, broadcast); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "创建群发消息失败")
+	}
+
+	s.auditService.Record(ctx, models.AuditActionMessageBroadcast, models.AuditTargetMessageBroadcast, fmt.Sprint(broadcast.ID), nil, map[string]interface{}{
+		"message_type":    broadcast.MessageType,
+		"content":         broadcast.Content,
+		"target_type":     broadcast.TargetType,
+		"target_uids":     req.Uids,
+		"member_level":    broadcast.MemberLevel,
+		"invite_code":     broadcast.InviteCode,
+		"registered_from": broadcast.RegisteredFrom,
+		"registered_to":   broadcast.RegisteredTo,
+		"scheduled_at":    broadcast.ScheduledAt,
+	})
+
+	if broadcast.ScheduledAt == nil {
+		go func(id uint64) {
+			defer func() {
+				if r := recover(); r != nil {
+					utils.LogError(nil, "发送群发消息发生panic - ID: %d, 错误: %v", id, r)
+				}
+			}()
+			// 请求结束后任务仍需继续，不使用请求上下文
+			if err := s.Run(context.Background(), id); err != nil {
+				utils.LogWarn(nil, "发送群发消息失败，等待定时任务重试 - ID: %d, 错误: %v", id, err)
+			}
+		}(broadcast.ID)
+	}
+
+	return broadcast, nil
+}
+
+// List 分页获取群发消息及发送进度
+func (s *MessageBroadcastService) List(ctx context.Context, req *models.MessageBroadcastListRequest) (*models.MessageBroadcastListResponse, error) {
+	offset := (req.Page - 1) * req.PageSize
+	broadcasts, total, err := s.repo.List(ctx, req.Status, req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取群发消息失败")
+	}
+
+	return &models.MessageBroadcastListResponse{
+		Broadcasts: broadcasts,
+		Pagination: buildPaginationInfo(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// Detail 获取群发消息及发送进度
+func (s *MessageBroadcastService) Detail(ctx context.Context, id uint64) (*models.MessageBroadcast, error) {
+	return s.find(ctx, id)
+}
+
+// Cancel 取消等待发送或发送中的群发消息，已投递的用户仍会收到消息
+func (s *MessageBroadcastService) Cancel(ctx context.Context, id uint64) error {
+	broadcast, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	cancelled, err := s.repo.Cancel(ctx, id)
+	if err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "取消群发消息失败")
+	}
+	if !cancelled {
+		return utils.NewAppError(utils.CodeMessageBroadcastFinished, "群发消息已完成或已取消")
+	}
+
+	s.auditService.Record(ctx, models.AuditActionMessageBroadcastCancel, models.AuditTargetMessageBroadcast, fmt.Sprint(id),
+		map[string]interface{}{"status": broadcast.Status, "delivered_count": broadcast.DeliveredCount},
+		map[string]interface{}{"status": models.BroadcastStatusCancelled})
+	return nil
+}
+
+// ProcessDue 发送到达发送时间的群发消息，并继续租约已过期的中断任务（定时任务调用）
+func (s *MessageBroadcastService) ProcessDue(ctx context.Context) (*BroadcastProcessStats, error) {
+	startTime := time.Now()
+	stats := &BroadcastProcessStats{}
+
+	ids, err := s.repo.ListDueIDs(ctx, time.Now(), broadcastDueLimit)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询待发送群发消息失败")
+	}
+
+	for _, id := range ids {
+		if err := s.Run(ctx, id); err != nil {
+			utils.LogWarn(nil, "发送群发消息失败 - ID: %d, 错误: %v", id, err)
+			stats.FailedCount++
+			continue
+		}
+		stats.BroadcastCount++
+	}
+
+	stats.ProcessTime = time.Since(startTime)
+	return stats, nil
+}
+
+// Run 领取并发送群发消息，已被其他实例领取时直接返回
+func (s *MessageBroadcastService) Run(ctx context.Context, id uint64) error {
+	now := time.Now()
+	claimed, err := s.repo.Claim(ctx, id, now, now.Add(broadcastLease))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	broadcast, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	audience, err := broadcastAudience(broadcast)
+	if err != nil {
+		return err
+	}
+
+	// 首次开始发送时记录候选用户数，用于展示进度
+	if broadcast.LastUserID == 0 && broadcast.TotalCount == 0 {
+		total, err := s.repo.CountAudience(ctx, audience)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetTotalCount(ctx, id, total); err != nil {
+			return err
+		}
+	}
+
+	var levels []models.MemberLevel
+	if broadcast.MemberLevel > 0 {
+		if levels, err = s.memberLevelService.ListLevels(ctx); err != nil {
+			return err
+		}
+	}
+
+	lastUserID := broadcast.LastUserID
+	for {
+		users, err := s.repo.ListAudience(ctx, audience, lastUserID, broadcastBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			break
+		}
+
+		uids, err := s.filterByLevel(ctx, users, levels, broadcast.MemberLevel)
+		if err != nil {
+			return err
+		}
+		delivered, err := s.deliver(ctx, broadcast, uids)
+		if err != nil {
+			return err
+		}
+
+		lastUserID = users[len(users)-1].ID
+		ok, err := s.repo.UpdateProgress(ctx, id, lastUserID, int64(len(users)), int64(delivered), time.Now().Add(broadcastLease))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			utils.LogInfo(nil, "群发消息已取消，停止发送 - ID: %d", id)
+			return nil
+		}
+	}
+
+	if err := s.repo.Complete(ctx, id, time.Now()); err != nil {
+		return err
+	}
+	utils.LogInfo(nil, "群发消息发送完成 - ID: %d, 耗时: %v", id, time.Since(now))
+	return nil
+}
+
+// deliver 为一批用户写入投递记录并推送新消息事件，跳过已投递的用户，返回本次投递数
+func (s *MessageBroadcastService) deliver(ctx context.Context, broadcast *models.MessageBroadcast, uids []string) (int, error) {
+	existing, err := s.repo.ListDeliveredUids(ctx, broadcast.ID, uids)
+	if err != nil {
+		return 0, err
+	}
+	skip := make(map[string]bool, len(existing))
+	for _, uid := range existing {
+		skip[uid] = true
+	}
+
+	recipients := make([]models.MessageBroadcastRecipient, 0, len(uids))
+	newUids := make([]string, 0, len(uids))
+	for _, uid := range uids {
+		if !skip[uid] {
+			recipients = append(recipients, models.MessageBroadcastRecipient{Uid: uid, BroadcastID: broadcast.ID})
+			newUids = append(newUids, uid)
+		}
+	}
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.CreateRecipients(ctx, recipients); err != nil {
+		return 0, err
+	}
+
+	// 重新读取投递记录获取ID，推送失败不影响投递，用户仍可在收件箱中看到
+	created, err := s.repo.ListRecipients(ctx, broadcast.ID, newUids)
+	if err != nil {
+		utils.LogWarn(nil, "获取群发消息投递记录失败 - ID: %d, 错误: %v", broadcast.ID, err)
+		return len(recipients), nil
+	}
+	events := make(map[string]interface{}, len(created))
+	for _, recipient := range created {
+		events[recipient.Uid] = models.MessageListItem{
+			ID:          int64(recipient.ID),
+			Source:      models.MessageSourceBroadcast,
+			Status:      "sent",
+			MessageType: broadcast.MessageType,
+			Content:     broadcast.Content,
+			CreatedAt:   recipient.CreatedAt,
+		}
+	}
+	s.eventService.PublishEach(ctx, models.UserEventMessage, events)
+	return len(recipients), nil
+}
+
+// filterByLevel 按会员等级筛选用户，level为0时不筛选
+func (s *MessageBroadcastService) filterByLevel(ctx context.Context, users []models.User, levels []models.MemberLevel, level int) ([]string, error) {
+	uids := make([]string, 0, len(users))
+	for _, user := range users {
+		uids = append(uids, user.Uid)
+	}
+	if level == 0 {
+		return uids, nil
+	}
+
+	wallets, err := s.walletRepo.ListWalletsByUids(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	balances := make(map[string]float64, len(wallets))
+	for _, wallet := range wallets {
+		balances[wallet.Uid] = wallet.Balance
+	}
+	overrides, err := s.memberLevelRepo.ListOverridesByUids(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	overrideMap := make(map[string]*models.UserLevelOverride, len(overrides))
+	for i := range overrides {
+		overrideMap[overrides[i].Uid] = &overrides[i]
+	}
+
+	matched := make([]string, 0, len(users))
+	for _, user := range users {
+		info := calculateUserLevel(levels, overrideMap[user.Uid], userLevelMetrics{
+			Balance:    balances[user.Uid],
+			Experience: int64(user.Experience),
+		})
+		if info.CurrentLevel == level {
+			matched = append(matched, user.Uid)
+		}
+	}
+	return matched, nil
+}
+
+// applyTarget 校验发送目标并写入群发消息
+func (s *MessageBroadcastService) applyTarget(ctx context.Context, broadcast *models.MessageBroadcast, req *models.MessageSendRequest) error {
+	switch req.TargetType {
+	case models.BroadcastTargetUser, models.BroadcastTargetUsers:
+		uids := uniqueStrings(req.Uids)
+		if len(uids) == 0 {
+			return utils.NewAppError(utils.CodeInvalidParams, "请指定目标用户")
+		}
+		if req.TargetType == models.BroadcastTargetUser && len(uids) != 1 {
+			return utils.NewAppError(utils.CodeInvalidParams, "发送给单个用户时只能指定一个用户")
+		}
+		data, err := json.Marshal(uids)
+		if err != nil {
+			return utils.NewAppError(utils.CodeServer, "序列化目标用户失败")
+		}
+		broadcast.TargetUids = string(data)
+	case models.BroadcastTargetSegment:
+		segment := req.Segment
+		if segment == nil || (segment.MemberLevel == 0 && segment.InviteCode == "" && segment.RegisteredFrom == "" && segment.RegisteredTo == "") {
+			return utils.NewAppError(utils.CodeInvalidParams, "请至少指定一个分群条件")
+		}
+		start, end, err := parseDateRange(segment.RegisteredFrom, segment.RegisteredTo)
+		if err != nil {
+			return err
+		}
+		if segment.MemberLevel > 0 {
+			levels, err := s.memberLevelService.ListLevels(ctx)
+			if err != nil {
+				return err
+			}
+			if findMemberLevel(levels, segment.MemberLevel) == nil {
+				return utils.NewAppError(utils.CodeMemberLevelNotFound, "会员等级不存在")
+			}
+		}
+		broadcast.MemberLevel = segment.MemberLevel
+		broadcast.InviteCode = segment.InviteCode
+		broadcast.RegisteredFrom = start
+		broadcast.RegisteredTo = end
+	}
+	return nil
+}
+
+// find 获取群发消息
+func (s *MessageBroadcastService) find(ctx context.Context, id uint64) (*models.MessageBroadcast, error) {
+	broadcast, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeMessageBroadcastNotFound, "群发消息不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取群发消息失败")
+	}
+	return broadcast, nil
+}
+
+// broadcastAudience 根据群发消息的目标构建候选用户条件
+func broadcastAudience(broadcast *models.MessageBroadcast) (*database.BroadcastAudience, error) {
+	audience := &database.BroadcastAudience{
+		InviteCode:     broadcast.InviteCode,
+		RegisteredFrom: broadcast.RegisteredFrom,
+		RegisteredTo:   broadcast.RegisteredTo,
+	}
+	if broadcast.TargetUids != "" {
+		if err := json.Unmarshal([]byte(broadcast.TargetUids), &audience.Uids); err != nil {
+			return nil, err
+		}
+	}
+	return audience, nil
+}
+
+// uniqueStrings 去除重复和空字符串，保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
@@ -2,76 +2,62 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/models"
 	"gin-fataMorgana/utils"
-
-	"github.com/redis/go-redis/v9"
 )
 
 // MessageService 消息服务
 type MessageService struct {
-	repo        *database.MessageRepository
-	redisClient *redis.Client
+	repo          *database.MessageRepository
+	broadcastRepo *database.MessageBroadcastRepository
+	eventService  *UserEventService
 }
 
 // NewMessageService 创建消息服务实例
 func NewMessageService() *MessageService {
 	return &MessageService{
-		repo:        database.NewMessageRepository(),
-		redisClient: database.RedisClient,
+		repo:          database.NewMessageRepository(),
+		broadcastRepo: database.NewMessageBroadcastRepository(),
+		eventService:  NewUserEventService(),
 	}
 }
 
-// GetUserMessage 获取用户消息推送
-func (s *MessageService) GetUserMessage(ctx context.Context, uid string) (*models.UserMessageResponse, error) {
-	// Redis key
-	key := fmt.Sprintf("user_messages:%s", uid)
+// popRetries 弹出消息时并发请求已读取同一条消息的重试次数
+const popRetries = 3
 
-	// 获取列表长度
-	length, err := s.redisClient.LLen(ctx, key).Result()
-	if err != nil {
-		if err.Error() == "redis: nil" {
-			// 列表不存在，返回空
+// GetUserMessage 获取用户最早的一条未读消息并标记为已读，消息仍保留在收件箱中
+func (s *MessageService) GetUserMessage(ctx context.Context, uid string) (*models.UserMessageResponse, error) {
+	var after *utils.Cursor
+	for i := 0; i < popRetries; i++ {
+		message, err := s.repo.FindOldestUnread(ctx, uid, after)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "获取消息失败")
+		}
+		if message == nil {
 			return nil, nil
 		}
-		return nil, utils.NewAppError(utils.CodeRedisError, "获取消息列表失败")
-	}
-
-	// 如果列表为空，返回空
-	if length == 0 {
-		return nil, nil
-	}
 
-	// 从列表左侧pop一条消息
-	messageJSON, err := s.redisClient.LPop(ctx, key).Result()
-	if err != nil {
-		return nil, utils.NewAppError(utils.CodeRedisError, "获取消息失败")
-	}
-
-	// 解析消息JSON
-	var userMessage models.UserMessage
-	if err := json.Unmarshal([]byte(messageJSON), &userMessage); err != nil {
-		return nil, utils.NewAppError(utils.CodeServer, "解析消息数据失败")
-	}
-
-	// 更新消息状态为已读
-	if err := s.repo.UpdateMessageStatus(ctx, userMessage.ID); err != nil {
-		// 如果更新失败，将消息重新放回列表头部
-		s.redisClient.LPush(ctx, key, messageJSON)
-		return nil, utils.NewAppError(utils.CodeDatabaseError, "更新消息状态失败")
+		// 只有标记成功的请求返回该消息，并发请求跳过已被读取的消息
+		affected, err := s.markRead(ctx, uid, message.Source, []int64{message.ID})
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "更新消息状态失败")
+		}
+		if affected > 0 {
+			return &models.UserMessageResponse{
+				ID:          message.ID,
+				Source:      message.Source,
+				MessageType: message.MessageType,
+				Content:     message.Content,
+			}, nil
+		}
+		after = &utils.Cursor{CreatedAt: message.CreatedAt, ID: message.SortKey}
 	}
-
-	// 返回消息内容
-	return &models.UserMessageResponse{
-		MessageType: userMessage.MessageType,
-		Content:     userMessage.Content,
-	}, nil
+	return nil, nil
 }
 
 // PushUserMessage 推送消息到用户
+// 不单独记录审计日志，由触发推送的管理员操作（审核、修改钱包状态等）记录
 func (s *MessageService) PushUserMessage(ctx context.Context, uid string, messageType, content, createdBy string) error {
 	// 创建消息记录
 	message := &models.Message{
@@ -87,25 +73,153 @@ func (s *MessageService) PushUserMessage(ctx context.Context, uid string, messag
 		return utils.NewAppError(utils.CodeDatabaseError, "创建消息记录失败")
 	}
 
-	// 构建Redis消息
-	userMessage := models.UserMessage{
+	// 推送新消息事件
+	s.eventService.Publish(ctx, uid, models.UserEventMessage, models.MessageListItem{
 		ID:          message.ID,
+		Source:      models.MessageSourceDirect,
+		Status:      message.Status,
 		MessageType: message.MessageType,
 		Content:     message.Content,
-		CreatedAt:   message.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		CreatedAt:   message.CreatedAt,
+	})
+	return nil
+}
+
+// ListUserMessages 游标分页获取用户收件箱，包含单发消息和群发消息
+func (s *MessageService) ListUserMessages(ctx context.Context, uid string, req *models.MessageListRequest) (*models.MessageListResponse, error) {
+	// 限制page_size最大值
+	if req.PageSize > 50 {
+		req.PageSize = 50
+	}
+
+	cursor, err := utils.DecodeCursor(req.Cursor)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeInvalidCursor, "分页游标无效")
 	}
 
-	// 序列化为JSON
-	messageJSON, err := json.Marshal(userMessage)
+	messages, hasNext, err := s.repo.ListInbox(ctx, uid, cursor, req.PageSize, req.UnreadOnly)
 	if err != nil {
-		return utils.NewAppError(utils.CodeServer, "序列化消息数据失败")
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取消息列表失败")
 	}
 
-	// 推送到Redis列表
-	key := fmt.Sprintf("user_messages:%s", uid)
-	if err := s.redisClient.RPush(ctx, key, messageJSON).Err(); err != nil {
-		return utils.NewAppError(utils.CodeRedisError, "推送消息失败")
+	items := make([]models.MessageListItem, 0, len(messages))
+	for _, message := range messages {
+		status := "sent"
+		if message.ReadAt != nil {
+			status = "read"
+		}
+		items = append(items, models.MessageListItem{
+			ID:          message.ID,
+			Source:      message.Source,
+			Status:      status,
+			MessageType: message.MessageType,
+			Content:     message.Content,
+			ReadAt:      message.ReadAt,
+			CreatedAt:   message.CreatedAt,
+		})
 	}
 
-	return nil
-}
\ No newline at end of file
+	var nextCursor string
+	if hasNext && len(messages) > 0 {
+		last := messages[len(messages)-1]
+		nextCursor = utils.EncodeCursor(last.CreatedAt, last.SortKey)
+	}
+
+	return &models.MessageListResponse{
+		Messages:   items,
+		PageSize:   req.PageSize,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// CountUnread 统计用户未读消息数
+func (s *MessageService) CountUnread(ctx context.Context, uid string) (*models.MessageUnreadResponse, error) {
+	direct, err := s.repo.CountUnread(ctx, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "统计未读消息失败")
+	}
+	broadcast, err := s.broadcastRepo.CountUnreadRecipients(ctx, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "统计未读消息失败")
+	}
+	return &models.MessageUnreadResponse{Unread: direct + broadcast}, nil
+}
+
+// MarkRead 将用户的消息标记为已读
+func (s *MessageService) MarkRead(ctx context.Context, uid string, req *models.MessageBatchRequest) (*models.MessageBatchResponse, error) {
+	affected, err := s.applyBatch(ctx, uid, req, s.markRead)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "标记已读失败")
+	}
+	return &models.MessageBatchResponse{Affected: affected}, nil
+}
+
+// MarkUnread 将用户的消息标记为未读
+func (s *MessageService) MarkUnread(ctx context.Context, uid string, req *models.MessageBatchRequest) (*models.MessageBatchResponse, error) {
+	affected, err := s.applyBatch(ctx, uid, req, func(ctx context.Context, uid, source string, ids []int64) (int64, error) {
+		if source == models.MessageSourceBroadcast {
+			return s.broadcastRepo.MarkRecipientsUnread(ctx, uid, ids)
+		}
+		return s.repo.MarkUnread(ctx, uid, ids)
+	})
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "标记未读失败")
+	}
+	return &models.MessageBatchResponse{Affected: affected}, nil
+}
+
+// MarkAllRead 将用户的全部消息标记为已读
+func (s *MessageService) MarkAllRead(ctx context.Context, uid string) (*models.MessageBatchResponse, error) {
+	direct, err := s.repo.MarkRead(ctx, uid, nil)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "全部标记已读失败")
+	}
+	broadcast, err := s.broadcastRepo.MarkRecipientsRead(ctx, uid, nil)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "全部标记已读失败")
+	}
+	return &models.MessageBatchResponse{Affected: direct + broadcast}, nil
+}
+
+// DeleteMessages 删除用户的消息，删除后不再出现在收件箱中
+func (s *MessageService) DeleteMessages(ctx context.Context, uid string, req *models.MessageBatchRequest) (*models.MessageBatchResponse, error) {
+	affected, err := s.applyBatch(ctx, uid, req, func(ctx context.Context, uid, source string, ids []int64) (int64, error) {
+		if source == models.MessageSourceBroadcast {
+			return s.broadcastRepo.DeleteRecipients(ctx, uid, ids)
+		}
+		return s.repo.DeleteUserMessages(ctx, uid, ids)
+	})
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "删除消息失败")
+	}
+	return &models.MessageBatchResponse{Affected: affected}, nil
+}
+
+// markRead 按来源将用户的消息标记为已读
+func (s *MessageService) markRead(ctx context.Context, uid, source string, ids []int64) (int64, error) {
+	if source == models.MessageSourceBroadcast {
+		return s.broadcastRepo.MarkRecipientsRead(ctx, uid, ids)
+	}
+	return s.repo.MarkRead(ctx, uid, ids)
+}
+
+// applyBatch 按来源分组执行批量操作，返回合计更新数
+func (s *MessageService) applyBatch(ctx context.Context, uid string, req *models.MessageBatchRequest, apply func(ctx context.Context, uid, source string, ids []int64) (int64, error)) (int64, error) {
+	groups := make(map[string][]int64, 2)
+	for _, ref := range req.Messages {
+		groups[ref.Source] = append(groups[ref.Source], ref.ID)
+	}
+
+	var total int64
+	for _, source := range []string{models.MessageSourceDirect, models.MessageSourceBroadcast} {
+		if len(groups[source]) == 0 {
+			continue
+		}
+		affected, err := apply(ctx, uid, source, groups[source])
+		if err != nil {
+			return 0, err
+		}
+		total += affected
+	}
+	return total, nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/utils"
+)
+
+// 通知渠道
+const (
+	NotificationChannelEmail = "email" // 邮件
+	NotificationChannelSMS   = "sms"   // 短信
+)
+
+// Notification 待发送的通知
+type Notification struct {
+	Channel   string // 通知渠道：email、sms
+	Recipient string // 接收方邮箱或手机号
+	Subject   string // 标题，短信渠道忽略
+	Content   string // 正文
+}
+
+// NotificationProvider 通知发送接口，接入真实的邮件、短信服务商时实现该接口即可
+type NotificationProvider interface {
+	Send(ctx context.Context, notification *Notification) error
+}
+
+// LogNotificationProvider 本地文件通知渠道，不真正发送，仅用于开发测试
+type LogNotificationProvider struct {
+	filePath string
+	mutex    sync.Mutex
+}
+
+// NewLogNotificationProvider 创建本地文件通知渠道
+func NewLogNotificationProvider(filePath string) *LogNotificationProvider {
+	return &LogNotificationProvider{filePath: filePath}
+}
+
+// Send 将通知追加写入本地文件
+func (p *LogNotificationProvider) Send(ctx context.Context, notification *Notification) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(p.filePath), 0755); err != nil {
+		return fmt.Errorf("创建通知日志目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(p.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开通知日志文件失败: %w", err)
+	}
+	defer file.Close()
+
+	line := fmt.Sprintf("[%s] channel=%s recipient=%s subject=%q content=%q\n",
+		time.Now().Format("2006-01-02 15:04:05"),
+		notification.Channel,
+		notification.Recipient,
+		notification.Subject,
+		notification.Content,
+	)
+	if _, err := file.WriteString(line); err != nil {
+		return fmt.Errorf("写入通知日志失败: %w", err)
+	}
+
+	utils.LogInfo(nil, "通知已写入本地文件 - 渠道: %s, 接收方: %s", notification.Channel, maskRecipient(notification))
+	return nil
+}
+
+var (
+	notificationProvider     NotificationProvider
+	notificationProviderOnce sync.Once
+)
+
+// GetNotificationProvider 获取配置的通知渠道
+func GetNotificationProvider() NotificationProvider {
+	notificationProviderOnce.Do(func() {
+		cfg := config.GlobalConfig.Notification
+		switch cfg.Provider {
+		case "log", "":
+			notificationProvider = NewLogNotificationProvider(cfg.LogFile)
+		default:
+			utils.LogWarn(nil, "未知的通知渠道 %s，使用本地文件渠道", cfg.Provider)
+			notificationProvider = NewLogNotificationProvider(cfg.LogFile)
+		}
+	})
+	return notificationProvider
+}
+
+// SetNotificationProvider 替换通知渠道，用于接入真实服务商
+func SetNotificationProvider(provider NotificationProvider) {
+	notificationProviderOnce.Do(func() {})
+	notificationProvider = provider
+}
+
+// maskRecipient 脱敏接收方，避免日志中出现完整邮箱或手机号
+func maskRecipient(notification *Notification) string {
+	if notification.Channel == NotificationChannelSMS {
+		return utils.MaskPhone(notification.Recipient)
+	}
+	return utils.MaskEmail(notification.Recipient)
+}
@@ -16,6 +16,7 @@ type OrderService struct {
 	walletRepo *database.WalletRepository
 	// 使用并发安全的钱包服务
 	walletService *WalletService
+	eventService  *UserEventService
 }
 
 // NewOrderService 创建订单服务实例
@@ -24,6 +25,7 @@ func NewOrderService() *OrderService {
 		orderRepo:     database.NewOrderRepository(),
 		walletRepo:    database.NewWalletRepository(),
 		walletService: NewWalletService(),
+		eventService:  NewUserEventService(),
 	}
 }
 
@@ -59,13 +61,14 @@ type GetOrderStatsResponse struct {
 
 // 分页信息结构体
 type PaginationInfo struct {
-	Page        int  `json:"page"`
-	PageSize    int  `json:"page_size"`
-	Total       int  `json:"total"`
-	CurrentPage int  `json:"current_page"`
-	TotalPages  int  `json:"total_pages"`
-	HasNext     bool `json:"has_next"`
-	HasPrev     bool `json:"has_prev"`
+	Page        int    `json:"page"`
+	PageSize    int    `json:"page_size"`
+	Total       int    `json:"total"`
+	CurrentPage int    `json:"current_page"`
+	TotalPages  int    `json:"total_pages"`
+	HasNext     bool   `json:"has_next"`
+	HasPrev     bool   `json:"has_prev"`
+	NextCursor  string `json:"next_cursor"` // 下一页游标，没有下一页时为空
 }
 
 // CreateOrder 创建订单
@@ -183,6 +186,9 @@ func (s *OrderService) CreateOrder(req *CreateOrderRequest, operatorUid string)
 		utils.LogWarn(nil, "缓存订单数据失败: %v", err)
 	}
 
+	// 推送订单状态事件
+	publishOrderEvent(ctx, s.eventService, order)
+
 	return &CreateOrderResponse{
 		OrderNo: order.OrderNo,
 		Amount:  totalAmount, // 返回计算后的总价
@@ -191,6 +197,19 @@ func (s *OrderService) CreateOrder(req *CreateOrderRequest, operatorUid string)
 	}, nil
 }
 
+// publishOrderEvent 推送订单状态变化事件，系统订单不推送
+func publishOrderEvent(ctx context.Context, eventService *UserEventService, order *models.Order) {
+	if order.IsSystemOrder {
+		return
+	}
+	eventService.Publish(ctx, order.Uid, models.UserEventOrder, models.OrderEventData{
+		OrderNo:      order.OrderNo,
+		PeriodNumber: order.PeriodNumber,
+		Amount:       order.Amount,
+		Status:       order.Status,
+	})
+}
+
 // validatePeriod 校验期数
 func (s *OrderService) validatePeriod(ctx context.Context, periodNumber string) error {
 	// 创建期数Repository
@@ -240,44 +259,37 @@ func (s *OrderService) GetOrderList(req *models.GetOrderListRequest, uid string)
 		return nil, utils.NewAppError(utils.CodeOrderStatusInvalid, "状态类型参数无效，必须是1(进行中)、2(已完成)或3(全部)")
 	}
 
-	// 如果status为3，获取全部订单（不限制状态）
-	if req.Status == 3 {
-		// 获取全部订单，不限制状态
-		orders, total, err := s.orderRepo.GetUserOrdersByStatus(ctx, uid, "", req.Page, req.PageSize)
+	// status为3时GetStatusByType返回空字符串，即获取全部订单（不限制状态）
+	status := models.GetStatusByType(req.Status)
+
+	// 传入游标时按游标分页，不统计总数
+	if req.Cursor != "" {
+		cursor, err := utils.DecodeCursor(req.Cursor)
 		if err != nil {
-			return nil, utils.NewAppError(utils.CodeOrderListGetFailed, "获取订单列表失败")
+			return nil, utils.NewAppError(utils.CodeInvalidCursor, "分页游标无效")
 		}
-
-		// 转换为响应格式
-		var orderResponses []models.OrderResponse
-		for _, order := range orders {
-			orderResponses = append(orderResponses, order.ToResponse())
+		orders, hasNext, hasPrev, err := s.orderRepo.GetUserOrdersByCursor(ctx, uid, status, cursor, req.PageSize)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeOrderListGetFailed, "获取订单列表失败")
 		}
+		return buildCursorOrderListResponse(orders, req.PageSize, hasNext, hasPrev), nil
+	}
 
-		// 计算分页信息
-		totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
-		hasNext := req.Page < totalPages
-		hasPrev := req.Page > 1
-
-		return &GetOrderListResponse{
-			Orders: orderResponses,
-			Pagination: PaginationInfo{
-				CurrentPage: req.Page,
-				PageSize:    req.PageSize,
-				Total:       int(total),
-				TotalPages:  totalPages,
-				HasNext:     hasNext,
-				HasPrev:     hasPrev,
-			},
-		}, nil
+	if req.Page < 1 {
+		req.Page = 1
 	}
 
 	// 获取订单列表
-	orders, total, err := s.orderRepo.GetUserOrders(ctx, uid, req.Page, req.PageSize, req.Status)
+	orders, total, err := s.orderRepo.GetUserOrdersByStatus(ctx, uid, status, req.Page, req.PageSize)
 	if err != nil {
 		return nil, utils.NewAppError(utils.CodeOrderListGetFailed, "获取订单列表失败")
 	}
 
+	return buildOrderListResponse(orders, total, req.Page, req.PageSize), nil
+}
+
+// buildOrderListResponse 构建偏移分页的订单列表响应
+func buildOrderListResponse(orders []models.Order, total int64, page, pageSize int) *GetOrderListResponse {
 	// 转换为响应格式
 	var orderResponses []models.OrderResponse
 	for _, order := range orders {
@@ -285,21 +297,53 @@ func (s *OrderService) GetOrderList(req *models.GetOrderListRequest, uid string)
 	}
 
 	// 计算分页信息
-	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
-	hasNext := req.Page < totalPages
-	hasPrev := req.Page > 1
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	hasNext := page < totalPages
+	hasPrev := page > 1
+
+	// 有下一页时同时返回游标，客户端可从偏移分页切换到游标分页
+	var nextCursor string
+	if hasNext && len(orders) > 0 {
+		last := orders[len(orders)-1]
+		nextCursor = utils.EncodeCursor(last.CreatedAt, int64(last.ID))
+	}
 
 	return &GetOrderListResponse{
 		Orders: orderResponses,
 		Pagination: PaginationInfo{
-			CurrentPage: req.Page,
-			PageSize:    req.PageSize,
+			CurrentPage: page,
+			PageSize:    pageSize,
 			Total:       int(total),
 			TotalPages:  totalPages,
 			HasNext:     hasNext,
 			HasPrev:     hasPrev,
+			NextCursor:  nextCursor,
 		},
-	}, nil
+	}
+}
+
+// buildCursorOrderListResponse 构建游标分页的订单列表响应，游标模式下不返回总数
+func buildCursorOrderListResponse(orders []models.Order, pageSize int, hasNext, hasPrev bool) *GetOrderListResponse {
+	var orderResponses []models.OrderResponse
+	for _, order := range orders {
+		orderResponses = append(orderResponses, order.ToResponse())
+	}
+
+	var nextCursor string
+	if hasNext && len(orders) > 0 {
+		last := orders[len(orders)-1]
+		nextCursor = utils.EncodeCursor(last.CreatedAt, int64(last.ID))
+	}
+
+	return &GetOrderListResponse{
+		Orders: orderResponses,
+		Pagination: PaginationInfo{
+			PageSize:   pageSize,
+			HasNext:    hasNext,
+			HasPrev:    hasPrev,
+			NextCursor: nextCursor,
+		},
+	}
 }
 
 // getGroupBuyList 获取拼单列表
@@ -324,7 +368,7 @@ func (s *OrderService) getGroupBuyList(ctx context.Context, uid string, page, pa
 			Amount:        groupBuy.PerPersonAmount,
 			ProfitAmount:  0, // 拼单没有利润金额
 			Status:        groupBuy.Status,
-			StatusName:    s.getGroupBuyStatusName(groupBuy.Status),
+			StatusName:    groupBuy.GetStatusName(),
 			ExpireTime:    groupBuy.Deadline,
 			CreatedAt:     groupBuy.CreatedAt,
 			UpdatedAt:     groupBuy.UpdatedAt,
@@ -358,16 +402,6 @@ func (s *OrderService) getGroupBuyList(ctx context.Context, uid string, page, pa
 	}, nil
 }
 
-// getGroupBuyStatusName 获取拼单状态名称
-func (s *OrderService) getGroupBuyStatusName(status string) string {
-	statusNames := map[string]string{
-		models.GroupBuyStatusNotStarted: "未开启",
-		models.GroupBuyStatusPending:    "进行中",
-		models.GroupBuyStatusSuccess:    "已完成",
-	}
-	return statusNames[status]
-}
-
 // GetOrderDetail 获取订单详情
 func (s *OrderService) GetOrderDetail(req *models.GetOrderDetailRequest, uid string) (*models.OrderResponse, error) {
 	ctx := context.Background()
@@ -503,33 +537,32 @@ func (s *OrderService) GetAllOrderList(req *models.GetOrderListRequest) (*GetOrd
 		return nil, utils.NewAppError(utils.CodeOrderStatusInvalid, "状态类型参数无效，必须是1(进行中)、2(已完成)或3(全部)")
 	}
 
-	// 获取所有订单
 	status := models.GetStatusByType(req.Status)
-	orders, total, err := s.orderRepo.GetOrdersByStatus(ctx, status, req.Page, req.PageSize)
-	if err != nil {
-		return nil, utils.NewAppError(utils.CodeOrderListGetFailed, "获取订单列表失败")
+
+	// 传入游标时按游标分页，不统计总数
+	if req.Cursor != "" {
+		cursor, err := utils.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidCursor, "分页游标无效")
+		}
+		orders, hasNext, hasPrev, err := s.orderRepo.GetOrdersByCursor(ctx, status, cursor, req.PageSize)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeOrderListGetFailed, "获取订单列表失败")
+		}
+		return buildCursorOrderListResponse(orders, req.PageSize, hasNext, hasPrev), nil
 	}
 
-	var orderResponses []models.OrderResponse
-	for _, order := range orders {
-		orderResponses = append(orderResponses, order.ToResponse())
+	if req.Page < 1 {
+		req.Page = 1
 	}
 
-	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
-	hasNext := req.Page < totalPages
-	hasPrev := req.Page > 1
+	// 获取所有订单
+	orders, total, err := s.orderRepo.GetOrdersByStatus(ctx, status, req.Page, req.PageSize)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeOrderListGetFailed, "获取订单列表失败")
+	}
 
-	return &GetOrderListResponse{
-		Orders: orderResponses,
-		Pagination: PaginationInfo{
-			CurrentPage: req.Page,
-			PageSize:    req.PageSize,
-			Total:       int(total),
-			TotalPages:  totalPages,
-			HasNext:     hasNext,
-			HasPrev:     hasPrev,
-		},
-	}, nil
+	return buildOrderListResponse(orders, total, req.Page, req.PageSize), nil
 }
 
 // cacheOrderData 缓存订单数据到Redis
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetService 找回密码服务
+type PasswordResetService struct {
+	userRepo     *database.UserRepository
+	tokenService *TokenService
+	codeService  *VerifyCodeService
+}
+
+// NewPasswordResetService 创建找回密码服务实例
+func NewPasswordResetService() *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:     database.NewUserRepository(),
+		tokenService: NewTokenService(),
+		codeService:  NewVerifyCodeService(VerifyPurposePasswordReset, config.GlobalConfig.Auth.PasswordReset),
+	}
+}
+
+// ForgotPassword 发送找回密码验证码
+// 账号不存在或不可用时同样返回成功，不向调用方暴露账号是否注册
+func (s *PasswordResetService) ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest, clientIP string) (*models.ForgotPasswordResponse, error) {
+	account := strings.TrimSpace(req.Account)
+
+	if !utils.IsEmailAccount(account) && !utils.IsPhoneAccount(account) {
+		return nil, utils.NewAppError(utils.CodeAccountFormatInvalid, "账号格式错误，请输入正确的邮箱或手机号")
+	}
+
+	if err := s.codeService.CheckSendLimit(ctx, account, clientIP); err != nil {
+		return nil, err
+	}
+
+	response := &models.ForgotPasswordResponse{
+		ExpiresIn:      s.codeService.ExpiresIn(),
+		ResendInterval: s.codeService.ResendInterval(),
+	}
+
+	user, err := findUserByAccount(ctx, s.userRepo, account)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response, nil
+		}
+		return nil, utils.NewAppError(utils.CodeUserQueryFailed, "查询用户失败")
+	}
+	if user.DeletedAt != nil || user.Status != models.UserStatusActive {
+		utils.LogWarn(nil, "找回密码账号不可用 - UID: %s, 状态: %d", user.Uid, user.Status)
+		return response, nil
+	}
+
+	if err := s.codeService.Send(ctx, account, account, user.Uid,
+		"重置密码验证码",
+		"您正在重置登录密码，验证码：%s，%s分钟内有效。如非本人操作，请忽略。",
+	); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ResetPassword 校验验证码并重置密码，成功后登出该用户的全部会话
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	uid, err := s.codeService.Verify(ctx, strings.TrimSpace(req.Account), req.Code)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		return utils.NewAppError(utils.CodeUserQueryFailed, "查询用户失败")
+	}
+	if user.Status == models.UserStatusDisabled {
+		return utils.NewAppError(utils.CodeUserDisabledChangePwd, "账户已被禁用，无法修改密码")
+	}
+
+	user.Password = req.NewPassword
+	if err := user.HashPassword(); err != nil {
+		return utils.NewAppError(utils.CodePasswordEncryptFailed2, "密码加密失败")
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return utils.NewAppError(utils.CodePasswordUpdateFailed, "更新密码失败")
+	}
+
+	if err := s.tokenService.RevokeAllSessions(ctx, user.Uid); err != nil {
+		utils.LogError(nil, "重置密码后登出全部会话失败 - UID: %s, 错误: %v", user.Uid, err)
+	}
+
+	utils.LogSecurityEvent(nil, "password_reset", fmt.Sprintf("UID: %s", user.Uid))
+	return nil
+}
+
+// findUserByAccount 根据邮箱或手机号查找用户
+func findUserByAccount(ctx context.Context, userRepo *database.UserRepository, account string) (*models.User, error) {
+	if utils.IsEmailAccount(account) {
+		return userRepo.FindByEmail(ctx, account)
+	}
+	return userRepo.FindByPhone(ctx, account)
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	commissionScanBatch = 200          // 定时任务每批扫描的订单数量
+	commissionScanName  = "commission" // 扫描进度名称
+)
+
+// CommissionScanStats 推广佣金扫描统计
+type CommissionScanStats struct {
+	OrderCount       int           `json:"order_count"`       // 处理的订单数
+	CommissionCount  int           `json:"commission_count"`  // 入账的佣金笔数
+	CommissionAmount float64       `json:"commission_amount"` // 入账的佣金总额
+	PendingCount     int           `json:"pending_count"`     // 推荐人钱包冻结、等待入账的笔数（解冻后补发）
+	FailedCount      int           `json:"failed_count"`      // 入账失败的笔数（下次重试）
+	ProcessTime      time.Duration `json:"process_time"`      // 处理耗时
+}
+
+// ReferralService 用户推荐服务
+// 用户注册时填写其他用户的邀请码即成为其下级，下级完成订单后按层级佣金规则给各级推荐人发放佣金
+type ReferralService struct {
+	repo          *database.ReferralRepository
+	userRepo      *database.UserRepository
+	walletService *WalletService
+	auditService  *AdminAuditService
+}
+
+// NewReferralService 创建用户推荐服务实例
+func NewReferralService() *ReferralService {
+	return &ReferralService{
+		repo:          database.NewReferralRepository(),
+		userRepo:      database.NewUserRepository(),
+		walletService: NewWalletService(),
+		auditService:  NewAdminAuditService(),
+	}
+}
+
+// FindReferrer 根据用户邀请码获取推荐人，邀请码不存在或推荐人状态不正常时返回错误
+func (s *ReferralService) FindReferrer(ctx context.Context, inviteCode string) (*models.User, error) {
+	referrer, err := s.repo.FindUserByInviteCode(ctx, strings.ToUpper(inviteCode))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeInviteCodeAdminDisabled, "邀请码无效")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询邀请码失败")
+	}
+	if referrer.Status != models.UserStatusActive {
+		return nil, utils.NewAppError(utils.CodeInviteCodeAdminDisabled, "邀请码对应的用户账户不可用")
+	}
+	return referrer, nil
+}
+
+// CreateUserWithReferrer 创建新用户并建立与推荐人及推荐人各级上级的推荐关系，二者同时成功或同时失败
+func (s *ReferralService) CreateUserWithReferrer(ctx context.Context, user *models.User, referrerUid string) error {
+	if err := s.repo.CreateUserWithReferrals(ctx, user, referrerUid, config.GlobalConfig.Referral.MaxDepth); err != nil {
+		return utils.NewAppError(utils.CodeUserCreateFailed, "创建用户失败")
+	}
+	return nil
+}
+
+// EnsureInviteCode 获取用户的邀请码，还没有时生成，邀请码与管理员邀请码不重复
+func (s *ReferralService) EnsureInviteCode(ctx context.Context, uid string) (string, error) {
+	user, err := s.userRepo.FindByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		return "", utils.NewAppError(utils.CodeDatabaseError, "查询用户失败")
+	}
+	if user.MyInviteCode != nil {
+		return *user.MyInviteCode, nil
+	}
+
+	inviteCode, err := utils.GenerateUniqueInviteCode(func(code string) (bool, error) {
+		return s.repo.InviteCodeExists(ctx, code)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	updated, err := s.repo.SetUserInviteCode(ctx, uid, inviteCode)
+	if err != nil {
+		return "", utils.NewAppError(utils.CodeInviteCodeGenFailed, "生成邀请码失败")
+	}
+	if !updated {
+		// 并发请求已经生成了邀请码，以数据库中的为准
+		user, err = s.userRepo.FindByUid(ctx, uid)
+		if err != nil || user.MyInviteCode == nil {
+			return "", utils.NewAppError(utils.CodeInviteCodeGenFailed, "生成邀请码失败")
+		}
+		return *user.MyInviteCode, nil
+	}
+	return inviteCode, nil
+}
+
+// GetMyInviteCode 获取我的邀请码和下级人数
+func (s *ReferralService) GetMyInviteCode(ctx context.Context, uid string) (*models.MyReferralCodeResponse, error) {
+	inviteCode, err := s.EnsureInviteCode(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	directCount, err := s.repo.CountReferrals(ctx, uid, 1)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "统计下级人数失败")
+	}
+	teamCount, err := s.repo.CountReferrals(ctx, uid, 0)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "统计下级人数失败")
+	}
+
+	return &models.MyReferralCodeResponse{
+		InviteCode:  inviteCode,
+		DirectCount: directCount,
+		TeamCount:   teamCount,
+	}, nil
+}
+
+// ListMyReferrals 分页获取我的下级
+func (s *ReferralService) ListMyReferrals(ctx context.Context, uid string, req *models.MyReferralListRequest) (*models.MyReferralListResponse, error) {
+	offset := (req.Page - 1) * req.PageSize
+	items, total, err := s.repo.ListReferrals(ctx, uid, req.Depth, req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取下级列表失败")
+	}
+
+	for i := range items {
+		items[i].Username = utils.MaskName(items[i].Username)
+	}
+	if items == nil {
+		items = []models.MyReferralItem{}
+	}
+
+	return &models.MyReferralListResponse{
+		Referrals:  items,
+		Pagination: buildPaginationInfo(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// ListMyCommissions 分页获取我的佣金记录和已入账佣金总额
+func (s *ReferralService) ListMyCommissions(ctx context.Context, uid string, req *models.MyCommissionListRequest) (*models.MyCommissionListResponse, error) {
+	offset := (req.Page - 1) * req.PageSize
+	commissions, total, err := s.repo.ListUserCommissions(ctx, uid, req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取佣金记录失败")
+	}
+	totalAmount, err := s.repo.SumCreditedCommission(ctx, uid)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "统计佣金总额失败")
+	}
+
+	return &models.MyCommissionListResponse{
+		TotalAmount: totalAmount,
+		Commissions: commissions,
+		Pagination:  buildPaginationInfo(req.Page, req.PageSize, total),
+	}, nil
+}
+
+// ListCommissionRules 获取全部佣金规则
+func (s *ReferralService) ListCommissionRules(ctx context.Context) ([]models.CommissionRule, error) {
+	rules, err := s.repo.ListCommissionRules(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取佣金规则失败")
+	}
+	return rules, nil
+}
+
+// SaveCommissionRule 保存某一层级的佣金规则，已有规则时覆盖
+func (s *ReferralService) SaveCommissionRule(ctx context.Context, req *models.CommissionRuleSaveRequest) (*models.CommissionRule, error) {
+	if maxDepth := config.GlobalConfig.Referral.MaxDepth; req.Depth > maxDepth {
+		return nil, utils.NewAppError(utils.CodeCommissionRuleInvalid, fmt.Sprintf("推荐层级不能超过%d", maxDepth))
+	}
+
+	before, err := s.repo.FindCommissionRule(ctx, req.Depth)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取佣金规则失败")
+	}
+
+	rule := &models.CommissionRule{
+		Depth:   req.Depth,
+		Rate:    roundAmount(req.Rate),
+		Enabled: req.Enabled == nil || *req.Enabled,
+		Remark:  req.Remark,
+	}
+	if err := s.repo.SaveCommissionRule(ctx, rule); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "保存佣金规则失败")
+	}
+
+	s.auditService.Record(ctx, models.AuditActionCommissionRuleSave, models.AuditTargetCommissionRule, fmt.Sprint(rule.Depth), before, rule)
+	return rule, nil
+}
+
+// DeleteCommissionRule 删除某一层级的佣金规则，已产生的佣金不受影响
+func (s *ReferralService) DeleteCommissionRule(ctx context.Context, depth int) error {
+	before, err := s.repo.FindCommissionRule(ctx, depth)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewAppError(utils.CodeCommissionRuleNotFound, "佣金规则不存在")
+		}
+		return utils.NewAppError(utils.CodeDatabaseError, "获取佣金规则失败")
+	}
+
+	if err := s.repo.DeleteCommissionRule(ctx, depth); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "删除佣金规则失败")
+	}
+
+	s.auditService.Record(ctx, models.AuditActionCommissionRuleDelete, models.AuditTargetCommissionRule, fmt.Sprint(depth), before, nil)
+	return nil
+}
+
+// ScanCommissions 扫描上次扫描之后完成的下级订单，为还没有佣金记录的各级推荐人发放佣金
+// 推荐人钱包冻结时记录为待入账，不算失败；全部成功时记录本次扫描进度，有失败记录时保留原进度，下次重新扫描
+func (s *ReferralService) ScanCommissions(ctx context.Context) (*CommissionScanStats, error) {
+	startTime := time.Now()
+	stats := &CommissionScanStats{}
+
+	// 待入账的佣金已有记录，不在订单扫描范围内，单独补发，不影响扫描进度
+	s.retryPendingCommissions(ctx, stats)
+
+	rules, err := s.repo.ListCommissionRules(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取佣金规则失败")
+	}
+	ruleByDepth := make(map[int]models.CommissionRule)
+	var depths []int
+	for _, rule := range rules {
+		if rule.Enabled && rule.Rate > 0 {
+			ruleByDepth[rule.Depth] = rule
+			depths = append(depths, rule.Depth)
+		}
+	}
+	if len(depths) == 0 {
+		saveScanWatermark(ctx, commissionScanName, startTime)
+		stats.ProcessTime = time.Since(startTime)
+		return stats, nil
+	}
+
+	since := scanSince(ctx, commissionScanName, startTime, config.GlobalConfig.Referral.ScanLookbackHours)
+	var afterID uint
+	for {
+		orders, err := s.repo.ListCommissionCandidates(ctx, depths, since, afterID, commissionScanBatch)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "扫描已完成订单失败")
+		}
+
+		for i := range orders {
+			order := &orders[i]
+			afterID = order.ID
+			stats.OrderCount++
+
+			ancestors, err := s.repo.ListAncestors(ctx, order.Uid, depths)
+			if err != nil {
+				stats.FailedCount++
+				utils.LogWarn(nil, "获取上级推荐人失败 - 订单: %s, 错误: %v", order.OrderNo, err)
+				continue
+			}
+			for _, ancestor := range ancestors {
+				commission, err := s.creditCommission(ctx, order, ancestor, ruleByDepth[ancestor.Depth])
+				if err != nil {
+					stats.FailedCount++
+					utils.LogWarn(nil, "发放推广佣金失败 - 订单: %s, 推荐人: %s, 错误: %v", order.OrderNo, ancestor.AncestorUid, err)
+					continue
+				}
+				if commission == nil {
+					continue
+				}
+				if commission.Status == models.ReferralCommissionStatusPending {
+					stats.PendingCount++
+					continue
+				}
+				stats.CommissionCount++
+				stats.CommissionAmount += commission.Amount
+			}
+		}
+
+		if len(orders) < commissionScanBatch {
+			break
+		}
+	}
+
+	if stats.FailedCount == 0 {
+		saveScanWatermark(ctx, commissionScanName, startTime)
+	}
+
+	stats.ProcessTime = time.Since(startTime)
+	return stats, nil
+}
+
+// retryPendingCommissions 补发推荐人钱包冻结时记录的待入账佣金，钱包仍冻结的继续等待
+func (s *ReferralService) retryPendingCommissions(ctx context.Context, stats *CommissionScanStats) {
+	var afterID uint64
+	for {
+		commissions, err := s.repo.ListPendingCommissions(ctx, afterID, commissionScanBatch)
+		if err != nil {
+			utils.LogWarn(nil, "获取待入账佣金失败: %v", err)
+			return
+		}
+
+		for i := range commissions {
+			commission := &commissions[i]
+			afterID = commission.ID
+
+			credited := false
+			transaction := buildCommissionTransaction(commission)
+			err := s.walletService.LockedWalletWrite(ctx, commission.BeneficiaryUid, func() (*models.Wallet, error) {
+				wallet, err := s.repo.CreditPendingCommission(ctx, commission, transaction)
+				credited = wallet != nil
+				return wallet, err
+			})
+			if err != nil {
+				utils.LogWarn(nil, "补发待入账佣金失败 - 订单: %s, 推荐人: %s, 错误: %v", commission.OrderNo, commission.BeneficiaryUid, err)
+				continue
+			}
+			if credited {
+				stats.CommissionCount++
+				stats.CommissionAmount += commission.Amount
+			}
+		}
+
+		if len(commissions) < commissionScanBatch {
+			return
+		}
+	}
+}
+
+// creditCommission 给一位推荐人发放一笔订单的佣金，已发放过或金额不足0.01时返回nil
+// 推荐人钱包冻结时返回待入账状态的佣金记录
+func (s *ReferralService) creditCommission(ctx context.Context, order *models.Order, ancestor models.UserReferral, rule models.CommissionRule) (*models.ReferralCommission, error) {
+	amount := roundAmount(order.Amount * rule.Rate / 100)
+	if amount < 0.01 {
+		return nil, nil
+	}
+
+	commission := &models.ReferralCommission{
+		OrderNo:        order.OrderNo,
+		BeneficiaryUid: ancestor.AncestorUid,
+		RefereeUid:     order.Uid,
+		Depth:          ancestor.Depth,
+		OrderAmount:    order.Amount,
+		Rate:           rule.Rate,
+		Amount:         amount,
+	}
+	transaction := buildCommissionTransaction(commission)
+
+	// 佣金记录、余额和流水在同一事务中写入，佣金记录的唯一索引避免重复发放
+	created := false
+	err := s.walletService.LockedWalletWrite(ctx, ancestor.AncestorUid, func() (*models.Wallet, error) {
+		wallet, ok, err := s.repo.CreditCommission(ctx, commission, transaction)
+		created = ok
+		return wallet, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, nil
+	}
+	return commission, nil
+}
+
+// buildCommissionTransaction 构建佣金入账流水
+func buildCommissionTransaction(commission *models.ReferralCommission) *models.WalletTransaction {
+	return &models.WalletTransaction{
+		TransactionNo:  utils.GenerateTransactionNo("COMMISSION"),
+		Uid:            commission.BeneficiaryUid,
+		Type:           models.TransactionTypeCommission,
+		Amount:         commission.Amount,
+		Status:         models.TransactionStatusSuccess,
+		Description:    fmt.Sprintf("第%d级下级订单 %s 推广佣金", commission.Depth, commission.OrderNo),
+		RelatedOrderNo: commission.OrderNo,
+		OperatorUid:    "system",
+	}
+}
+
+// buildPaginationInfo 构建分页信息
+func buildPaginationInfo(page, pageSize int, total int64) models.PaginationInfo {
+	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+	return models.PaginationInfo{
+		CurrentPage: page,
+		PageSize:    pageSize,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrev:     page > 1,
+	}
+}
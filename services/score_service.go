@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	scoreRuleCacheExpire = time.Hour // 规则缓存时间，规则变更时主动清除
+	scoreScanBatch       = 500       // 定时任务每批扫描的事件数量
+	scoreScanName        = "score"   // 扫描进度名称
+)
+
+// ScoreScanStats 经验值与信用分事件扫描统计
+type ScoreScanStats struct {
+	Applied     map[string]int `json:"applied"`      // 按事件类型统计本次计算的记录数
+	FailedCount int            `json:"failed_count"` // 计算失败的记录数（下次重试）
+	ProcessTime time.Duration  `json:"process_time"` // 处理耗时
+}
+
+// scoreEventSource 从业务表扫描某种事件的方法
+type scoreEventSource struct {
+	event string
+	list  func(ctx context.Context, since time.Time, afterID uint64, limit int) ([]database.ScoreEventCandidate, error)
+}
+
+// ScoreService 经验值与信用分服务
+// 订单完成、拼单成团等事件按规则增减用户的经验值和信用分，每次变更写入变更记录
+// 同一事件只计算一次，可以在业务流程中即时触发，也由定时任务扫描补偿
+type ScoreService struct {
+	repo         *database.ScoreRepository
+	auditService *AdminAuditService
+}
+
+// NewScoreService 创建经验值与信用分服务实例
+func NewScoreService() *ScoreService {
+	return &ScoreService{
+		repo:         database.NewScoreRepository(),
+		auditService: NewAdminAuditService(),
+	}
+}
+
+// ListRules 获取全部规则，优先读取缓存
+func (s *ScoreService) ListRules(ctx context.Context) ([]models.ScoreRule, error) {
+	cacheKey := scoreRuleCacheKey()
+	if data, err := database.GetKey(ctx, cacheKey); err == nil && data != "" {
+		var cached []models.ScoreRule
+		if json.Unmarshal([]byte(data), &cached) == nil {
+			return cached, nil
+		}
+	}
+
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取经验值与信用分规则失败")
+	}
+
+	if data, err := json.Marshal(rules); err == nil {
+		database.SetKey(ctx, cacheKey, string(data), scoreRuleCacheExpire)
+	}
+	return rules, nil
+}
+
+// UpdateRule 修改规则，只修改请求中传入的字段
+func (s *ScoreService) UpdateRule(ctx context.Context, req *models.ScoreRuleUpdateRequest) (*models.ScoreRule, error) {
+	before, err := s.repo.FindRuleByEvent(ctx, req.Event)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeScoreRuleNotFound, "规则不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "获取规则失败")
+	}
+
+	rule := *before
+	if req.Experience != nil {
+		rule.Experience = *req.Experience
+	}
+	if req.CreditScore != nil {
+		rule.CreditScore = *req.CreditScore
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Remark != nil {
+		rule.Remark = *req.Remark
+	}
+
+	if err := s.repo.UpdateRule(ctx, &rule); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "修改规则失败")
+	}
+
+	if err := database.DelKey(ctx, scoreRuleCacheKey()); err != nil {
+		utils.LogWarn(nil, "清除经验值与信用分规则缓存失败 - 错误: %v", err)
+	}
+	s.auditService.Record(ctx, models.AuditActionScoreRuleUpdate, models.AuditTargetScoreRule, rule.Event, before, &rule)
+	return &rule, nil
+}
+
+// Apply 按规则为用户计算一次事件，规则未启用或已计算过时返回nil
+func (s *ScoreService) Apply(ctx context.Context, uid, event, refID string) (*models.UserScoreHistory, error) {
+	rules, err := s.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rule := findScoreRule(rules, event)
+	if rule == nil || !rule.IsEffective() {
+		return nil, nil
+	}
+
+	history := &models.UserScoreHistory{
+		Uid:               uid,
+		Event:             event,
+		RefID:             refID,
+		ExperienceChange:  rule.Experience,
+		CreditScoreChange: rule.CreditScore,
+		Operator:          utils.SystemAuditActor.Username,
+		Remark:            rule.Name,
+	}
+	return s.applyChange(ctx, history)
+}
+
+// Adjust 管理员手动调整用户经验值和信用分，信用分仍受上下限限制
+func (s *ScoreService) Adjust(ctx context.Context, req *models.ScoreAdjustRequest, operator string) (*models.UserScoreHistory, error) {
+	if req.Experience == 0 && req.CreditScore == 0 {
+		return nil, utils.NewAppError(utils.CodeInvalidParams, "经验值和信用分不能同时为0")
+	}
+
+	history := &models.UserScoreHistory{
+		Uid:               req.Uid,
+		Event:             models.ScoreEventAdminAdjust,
+		RefID:             utils.GenerateTransactionNo("ADJUST"),
+		ExperienceChange:  req.Experience,
+		CreditScoreChange: req.CreditScore,
+		Operator:          operator,
+		Remark:            req.Remark,
+	}
+	history, err := s.applyChange(ctx, history)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return nil, utils.NewAppError(utils.CodeScoreApplyFailed, "调整重复提交，请重试")
+	}
+
+	s.auditService.Record(ctx, models.AuditActionScoreAdjust, models.AuditTargetUser, req.Uid,
+		map[string]int{
+			"experience":   history.ExperienceAfter - history.ExperienceChange,
+			"credit_score": history.CreditScoreAfter - history.CreditScoreChange,
+		},
+		map[string]int{
+			"experience":   history.ExperienceAfter,
+			"credit_score": history.CreditScoreAfter,
+		})
+	return history, nil
+}
+
+// ApplyGroupBuyCompleted 拼单成团后为全部参与者计算拼单成团事件，单个用户失败不影响其他用户，由定时任务补偿
+func (s *ScoreService) ApplyGroupBuyCompleted(ctx context.Context, groupBuyNo string, participants []models.GroupBuyParticipant) {
+	for _, participant := range participants {
+		if _, err := s.Apply(ctx, participant.Uid, models.ScoreEventGroupBuyCompleted, groupBuyNo); err != nil {
+			utils.LogWarn(nil, "计算拼单成团经验值失败 - 拼单: %s, 用户: %s, 错误: %v", groupBuyNo, participant.Uid, err)
+		}
+	}
+}
+
+// ScanEvents 扫描上次扫描之后状态变化的订单、拼单和提现，计算尚未计算过的事件
+// 全部成功时记录本次扫描进度，有失败记录时保留原进度，下次重新扫描
+func (s *ScoreService) ScanEvents(ctx context.Context) (*ScoreScanStats, error) {
+	startTime := time.Now()
+	stats := &ScoreScanStats{Applied: make(map[string]int)}
+
+	rules, err := s.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	since := scanSince(ctx, scoreScanName, startTime, config.GlobalConfig.Score.ScanLookbackHours)
+	for _, source := range s.eventSources() {
+		rule := findScoreRule(rules, source.event)
+		if rule == nil || !rule.IsEffective() {
+			continue
+		}
+
+		var afterID uint64
+		for {
+			candidates, err := source.list(ctx, since, afterID, scoreScanBatch)
+			if err != nil {
+				return nil, utils.NewAppError(utils.CodeDatabaseError, "扫描经验值与信用分事件失败")
+			}
+
+			for _, candidate := range candidates {
+				afterID = candidate.ID
+				history, err := s.Apply(ctx, candidate.Uid, source.event, candidate.RefID)
+				if err != nil {
+					// 用户不存在的记录不再重试
+					if appErr, ok := err.(*utils.AppError); ok && appErr.Code == utils.CodeUserNotFound {
+						continue
+					}
+					stats.FailedCount++
+					utils.LogWarn(nil, "计算经验值与信用分失败 - 事件: %s, 用户: %s, 单号: %s, 错误: %v",
+						source.event, candidate.Uid, candidate.RefID, err)
+					continue
+				}
+				if history != nil {
+					stats.Applied[source.event]++
+				}
+			}
+
+			if len(candidates) < scoreScanBatch {
+				break
+			}
+		}
+	}
+
+	if stats.FailedCount == 0 {
+		saveScanWatermark(ctx, scoreScanName, startTime)
+	}
+
+	stats.ProcessTime = time.Since(startTime)
+	return stats, nil
+}
+
+// QueryHistory 分页查询经验值与信用分变更记录
+func (s *ScoreService) QueryHistory(ctx context.Context, req *models.ScoreHistoryQueryRequest) (*models.ScoreHistoryQueryResponse, error) {
+	filter := &database.ScoreHistoryFilter{
+		Uid:   req.Uid,
+		Event: req.Event,
+	}
+	if req.StartTime != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartTime, time.Local)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "开始时间格式错误，应为YYYY-MM-DD HH:MM:SS")
+		}
+		filter.Start = &t
+	}
+	if req.EndTime != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.EndTime, time.Local)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidParams, "结束时间格式错误，应为YYYY-MM-DD HH:MM:SS")
+		}
+		filter.End = &t
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	histories, total, err := s.repo.QueryHistories(ctx, filter, req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询变更记录失败")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+	return &models.ScoreHistoryQueryResponse{
+		Histories: histories,
+		Pagination: models.PaginationInfo{
+			CurrentPage: req.Page,
+			PageSize:    req.PageSize,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     req.Page < totalPages,
+			HasPrev:     req.Page > 1,
+		},
+	}, nil
+}
+
+// applyChange 写入变更记录并更新用户，已计算过时返回nil
+func (s *ScoreService) applyChange(ctx context.Context, history *models.UserScoreHistory) (*models.UserScoreHistory, error) {
+	policy := config.GlobalConfig.Score
+	applied, err := s.repo.ApplyChange(ctx, history, policy.MinCreditScore, policy.MaxCreditScore)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeScoreApplyFailed, "更新经验值与信用分失败")
+	}
+	if !applied {
+		return nil, nil
+	}
+	return history, nil
+}
+
+// eventSources 定时任务扫描的事件来源
+func (s *ScoreService) eventSources() []scoreEventSource {
+	return []scoreEventSource{
+		{
+			event: models.ScoreEventOrderCompleted,
+			list: func(ctx context.Context, since time.Time, afterID uint64, limit int) ([]database.ScoreEventCandidate, error) {
+				return s.repo.ListOrderCandidates(ctx, models.ScoreEventOrderCompleted, models.OrderStatusSuccess, since, afterID, limit)
+			},
+		},
+		{
+			event: models.ScoreEventOrderExpired,
+			list: func(ctx context.Context, since time.Time, afterID uint64, limit int) ([]database.ScoreEventCandidate, error) {
+				return s.repo.ListOrderCandidates(ctx, models.ScoreEventOrderExpired, models.OrderStatusExpired, since, afterID, limit)
+			},
+		},
+		{
+			event: models.ScoreEventGroupBuyCompleted,
+			list: func(ctx context.Context, since time.Time, afterID uint64, limit int) ([]database.ScoreEventCandidate, error) {
+				return s.repo.ListGroupBuyCandidates(ctx, models.ScoreEventGroupBuyCompleted, since, afterID, limit)
+			},
+		},
+		{
+			event: models.ScoreEventWithdrawCancelled,
+			list: func(ctx context.Context, since time.Time, afterID uint64, limit int) ([]database.ScoreEventCandidate, error) {
+				return s.repo.ListWithdrawCandidates(ctx, models.ScoreEventWithdrawCancelled, since, afterID, limit)
+			},
+		},
+	}
+}
+
+// findScoreRule 按事件类型查找规则
+func findScoreRule(rules []models.ScoreRule, event string) *models.ScoreRule {
+	for i := range rules {
+		if rules[i].Event == event {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// scoreRuleCacheKey 经验值与信用分规则缓存Key
+func scoreRuleCacheKey() string {
+	return utils.RedisKeys.GenerateConfigCacheKey("score_rule")
+}
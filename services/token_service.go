@@ -4,16 +4,24 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"gin-fataMorgana/config"
 	"gin-fataMorgana/database"
 	"gin-fataMorgana/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionTouchInterval = time.Minute     // 会话最后活跃时间的最小更新间隔，避免每个请求都写Redis
+	tokenExpirePadding   = 5 * time.Minute // 黑名单和失效记录比令牌有效期多保留的时长，确保覆盖令牌剩余有效期
 )
 
 // TokenService Token管理服务
+// 每次登录创建一个会话，会话ID即令牌家族ID，刷新轮换出的令牌都属于同一会话
 type TokenService struct{}
 
 // NewTokenService 创建Token服务实例
@@ -21,22 +29,15 @@ func NewTokenService() *TokenService {
 	return &TokenService{}
 }
 
-// TokenInfo Token信息
-type TokenInfo struct {
-	TokenHash  string    `json:"token_hash"`
-	LoginTime  time.Time `json:"login_time"`
-	DeviceInfo string    `json:"device_info"`
-	LoginIP    string    `json:"login_ip"`
-	UserAgent  string    `json:"user_agent"`
-}
-
 // SessionInfo 会话信息
 type SessionInfo struct {
-	CurrentTokenHash string    `json:"current_token_hash"`
-	LastLoginTime    time.Time `json:"last_login_time"`
-	DeviceInfo       string    `json:"device_info"`
-	LoginIP          string    `json:"login_ip"`
-	UserAgent        string    `json:"user_agent"`
+	SessionID  string    `json:"session_id"`   // 会话ID
+	DeviceInfo string    `json:"device_info"`  // 设备信息
+	IP         string    `json:"ip"`           // 最近使用的IP
+	UserAgent  string    `json:"user_agent"`   // 用户代理
+	LoginTime  time.Time `json:"login_time"`   // 登录时间
+	LastSeenAt time.Time `json:"last_seen_at"` // 最后活跃时间
+	IsCurrent  bool      `json:"is_current"`   // 是否为当前请求所用会话
 }
 
 // 生成token哈希
@@ -45,65 +46,29 @@ func (s *TokenService) generateTokenHash(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// 获取用户活跃token的Redis key
-func (s *TokenService) getUserActiveTokenKey(uid string) string {
-	return fmt.Sprintf("user:active_token:%s", uid)
-}
-
 // 获取token黑名单的Redis key
 func (s *TokenService) getTokenBlacklistKey(tokenHash string) string {
 	return fmt.Sprintf("token:blacklist:%s", tokenHash)
 }
 
-// 获取用户会话信息的Redis key
-func (s *TokenService) getUserSessionKey(uid string) string {
-	return fmt.Sprintf("user:session:%s", uid)
+// 获取刷新令牌家族（会话）的Redis key
+func (s *TokenService) getRefreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh_token:family:%s", familyID)
 }
 
-// GetUserActiveToken 获取用户当前活跃token
-func (s *TokenService) GetUserActiveToken(ctx context.Context, uid string) (*TokenInfo, error) {
-	key := s.getUserActiveTokenKey(uid)
-
-	value, err := database.GetKey(ctx, key)
-	if err != nil {
-		return nil, err
-	}
-
-	if value == "" {
-		return nil, nil
-	}
-
-	var tokenInfo TokenInfo
-	if err := json.Unmarshal([]byte(value), &tokenInfo); err != nil {
-		return nil, err
-	}
-
-	return &tokenInfo, nil
+// 获取用户会话列表的Redis key，有序集合按登录时间排序
+func (s *TokenService) getUserSessionsKey(uid string) string {
+	return fmt.Sprintf("user:sessions:%s", uid)
 }
 
-// SetUserActiveToken 设置用户活跃token
-func (s *TokenService) SetUserActiveToken(ctx context.Context, uid, token, deviceInfo, loginIP, userAgent string) error {
-	tokenHash := s.generateTokenHash(token)
-
-	tokenInfo := &TokenInfo{
-		TokenHash:  tokenHash,
-		LoginTime:  time.Now(),
-		DeviceInfo: deviceInfo,
-		LoginIP:    loginIP,
-		UserAgent:  userAgent,
-	}
-
-	// 序列化为JSON
-	data, err := json.Marshal(tokenInfo)
-	if err != nil {
-		return err
-	}
-
-	// 设置活跃token，过期时间与token一致
-	expiration := time.Duration(config.GlobalConfig.JWT.AccessTokenExpire) * time.Second
-	key := s.getUserActiveTokenKey(uid)
+// 获取管理员令牌失效时间的Redis key，早于该时间签发的管理员令牌全部失效
+func (s *TokenService) getAdminRevokedKey(adminID uint) string {
+	return fmt.Sprintf("admin:token_revoked:%d", adminID)
+}
 
-	return database.SetKey(ctx, key, string(data), expiration)
+// 获取用户全部会话失效时间的Redis key，早于该时间签发的无会话ID令牌全部失效
+func (s *TokenService) getUserSessionsRevokedKey(uid string) string {
+	return fmt.Sprintf("user:sessions_revoked_at:%s", uid)
 }
 
 // AddTokenToBlacklist 将token加入黑名单
@@ -112,7 +77,7 @@ func (s *TokenService) AddTokenToBlacklist(ctx context.Context, token string) er
 	key := s.getTokenBlacklistKey(tokenHash)
 
 	// 黑名单过期时间比token过期时间稍长，确保覆盖
-	expiration := time.Duration(config.GlobalConfig.JWT.AccessTokenExpire+300) * time.Second
+	expiration := time.Duration(config.GlobalConfig.JWT.AccessTokenExpire)*time.Second + tokenExpirePadding
 
 	return database.SetKey(ctx, key, time.Now().Unix(), expiration)
 }
@@ -130,71 +95,317 @@ func (s *TokenService) IsTokenBlacklisted(ctx context.Context, token string) (bo
 	return exists, nil
 }
 
-// IsActiveToken 检查token是否为当前活跃token
-func (s *TokenService) IsActiveToken(ctx context.Context, uid, token string) (bool, error) {
-	activeToken, err := s.GetUserActiveToken(ctx, uid)
+// AddAdminTokenToBlacklist 将管理员令牌加入黑名单，管理员令牌有效期与用户令牌不同
+func (s *TokenService) AddAdminTokenToBlacklist(ctx context.Context, token string) error {
+	key := s.getTokenBlacklistKey(s.generateTokenHash(token))
+	expiration := time.Duration(config.GlobalConfig.JWT.AdminTokenExpire)*time.Second + tokenExpirePadding
+	return database.SetKey(ctx, key, time.Now().Unix(), expiration)
+}
+
+// RevokeAdminTokens 使管理员此前签发的全部令牌失效，用于修改、重置密码等场景
+func (s *TokenService) RevokeAdminTokens(ctx context.Context, adminID uint) error {
+	expiration := time.Duration(config.GlobalConfig.JWT.AdminTokenExpire)*time.Second + tokenExpirePadding
+	return database.SetKey(ctx, s.getAdminRevokedKey(adminID), time.Now().UnixMilli(), expiration)
+}
+
+// IsAdminTokenRevoked 检查管理员令牌是否签发于失效时间之前，按毫秒比较
+// 同一毫秒内签发的令牌视为已失效；没有毫秒签发时间的令牌按秒级签发时间比较，同一秒内签发的同样视为已失效
+func (s *TokenService) IsAdminTokenRevoked(ctx context.Context, claims *utils.AdminClaims) (bool, error) {
+	value, err := database.GetKeyOrDefault(ctx, s.getAdminRevokedKey(claims.AdminID), "")
 	if err != nil {
 		return false, err
 	}
+	if value == "" {
+		return false, nil
+	}
 
-	if activeToken == nil {
+	var revokedAtMs int64
+	if _, err := fmt.Sscanf(value, "%d", &revokedAtMs); err != nil {
 		return false, nil
 	}
 
-	tokenHash := s.generateTokenHash(token)
-	return activeToken.TokenHash == tokenHash, nil
+	issuedAtMs := claims.IssuedAtMs
+	if issuedAtMs == 0 {
+		if claims.IssuedAt == nil {
+			return false, nil
+		}
+		// 秒级签发时间向上取整到该秒末尾，同一秒内的撤销同样生效
+		issuedAtMs = claims.IssuedAt.Time.UnixMilli() + 999
+	}
+	return issuedAtMs <= revokedAtMs, nil
+}
+
+// StartSession 登录时创建会话，记录当前有效的刷新令牌jti、访问令牌和设备信息
+// 超过最大并发会话数时，登出最早登录的会话
+func (s *TokenService) StartSession(ctx context.Context, uid, familyID, tokenID, accessToken, deviceInfo, loginIP, userAgent string) error {
+	key := s.getRefreshFamilyKey(familyID)
+	sessionsKey := s.getUserSessionsKey(uid)
+	expiration := time.Duration(config.GlobalConfig.JWT.RefreshTokenExpire) * time.Second
+	now := time.Now()
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key,
+		"uid", uid,
+		"jti", tokenID,
+		"access_hash", s.generateTokenHash(accessToken),
+		"device_info", deviceInfo,
+		"ip", loginIP,
+		"user_agent", userAgent,
+		"login_time", now.Unix(),
+		"last_seen", now.Unix(),
+	)
+	pipe.Expire(ctx, key, expiration)
+	pipe.ZAdd(ctx, sessionsKey, redis.Z{Score: float64(now.UnixNano()), Member: familyID})
+	pipe.Expire(ctx, sessionsKey, expiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	return s.enforceMaxSessions(ctx, uid)
 }
 
-// RevokeUserSession 撤销用户会话
-func (s *TokenService) RevokeUserSession(ctx context.Context, uid string) error {
-	// 获取当前活跃token
-	activeToken, err := s.GetUserActiveToken(ctx, uid)
+// enforceMaxSessions 清理已过期的会话，并在超过最大并发会话数时登出最早登录的会话
+func (s *TokenService) enforceMaxSessions(ctx context.Context, uid string) error {
+	maxSessions := config.GlobalConfig.Auth.MaxSessions
+	if maxSessions <= 0 {
+		return nil
+	}
+
+	sessionsKey := s.getUserSessionsKey(uid)
+	sessionIDs, err := database.RedisClient.ZRange(ctx, sessionsKey, 0, -1).Result()
 	if err != nil {
 		return err
 	}
 
-	if activeToken != nil {
-		// 将活跃token加入黑名单
-		key := s.getTokenBlacklistKey(activeToken.TokenHash)
-		expiration := time.Duration(config.GlobalConfig.JWT.AccessTokenExpire+300) * time.Second
-		database.SetKey(ctx, key, time.Now().Unix(), expiration)
+	active := make([]string, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		exists, err := database.ExistsKey(ctx, s.getRefreshFamilyKey(sessionID))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// 会话已过期，从列表移除
+			database.RedisClient.ZRem(ctx, sessionsKey, sessionID)
+			continue
+		}
+		active = append(active, sessionID)
 	}
 
-	// 删除活跃token记录
-	activeKey := s.getUserActiveTokenKey(uid)
-	database.DelKey(ctx, activeKey)
+	for i := 0; i < len(active)-maxSessions; i++ {
+		utils.LogInfo(nil, "超过最大会话数，登出最早的会话 - UID: %s, 会话: %s", uid, active[i])
+		if err := s.RevokeRefreshFamily(ctx, active[i]); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-// GetUserSessionInfo 获取用户会话信息
-func (s *TokenService) GetUserSessionInfo(ctx context.Context, uid string) (*SessionInfo, error) {
-	activeToken, err := s.GetUserActiveToken(ctx, uid)
+// RotateRefreshToken 轮换刷新令牌
+// 只有家族内最新的刷新令牌可以轮换；已轮换过的令牌再次出现视为被盗用，撤销整个令牌家族
+func (s *TokenService) RotateRefreshToken(ctx context.Context, claims *utils.Claims, newTokenID, newAccessToken, ip string) error {
+	key := s.getRefreshFamilyKey(claims.FamilyID)
+	expiration := time.Duration(config.GlobalConfig.JWT.RefreshTokenExpire) * time.Second
+
+	// 使用Lua脚本比较并替换当前jti，保证并发刷新时同一令牌只能成功一次
+	luaScript := `
+		local current = redis.call("HGET", KEYS[1], "jti")
+		if not current then
+			return {-1, ""}
+		end
+		if redis.call("HGET", KEYS[1], "uid") ~= ARGV[1] then
+			return {-1, ""}
+		end
+		if current ~= ARGV[2] then
+			return {0, ""}
+		end
+		local oldAccess = redis.call("HGET", KEYS[1], "access_hash") or ""
+		redis.call("HSET", KEYS[1], "jti", ARGV[3], "access_hash", ARGV[4], "ip", ARGV[5], "last_seen", ARGV[6])
+		redis.call("EXPIRE", KEYS[1], ARGV[7])
+		return {1, oldAccess}
+	`
+
+	result, err := database.RedisClient.Eval(ctx, luaScript, []string{key},
+		claims.Uid, claims.ID, newTokenID, s.generateTokenHash(newAccessToken), ip, time.Now().Unix(), int64(expiration.Seconds())).Slice()
+	if err != nil {
+		return utils.NewAppError(utils.CodeRedisError, "刷新令牌失败，请稍后重试")
+	}
+
+	status, _ := result[0].(int64)
+	switch status {
+	case 1:
+		// 旧访问令牌随轮换失效
+		if oldAccessHash, _ := result[1].(string); oldAccessHash != "" {
+			s.blacklistTokenHash(ctx, oldAccessHash)
+		}
+		database.SetExpire(ctx, s.getUserSessionsKey(claims.Uid), expiration)
+		return nil
+	case 0:
+		utils.LogWarn(nil, "检测到刷新令牌重复使用，撤销令牌家族 - UID: %s, 家族: %s", claims.Uid, claims.FamilyID)
+		if err := s.RevokeRefreshFamily(ctx, claims.FamilyID); err != nil {
+			utils.LogError(nil, "撤销令牌家族失败 - 家族: %s, 错误: %v", claims.FamilyID, err)
+		}
+		return utils.NewAppError(utils.CodeRefreshTokenReused, "刷新令牌已被使用，请重新登录")
+	default:
+		return utils.NewAppError(utils.CodeRefreshTokenInvalid, "无效的刷新令牌")
+	}
+}
+
+// RevokeRefreshFamily 撤销令牌家族（会话），家族内当前的访问令牌一并加入黑名单
+func (s *TokenService) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+
+	key := s.getRefreshFamilyKey(familyID)
+	values, err := database.RedisClient.HMGet(ctx, key, "uid", "access_hash").Result()
+	if err == nil {
+		if accessHash, _ := values[1].(string); accessHash != "" {
+			s.blacklistTokenHash(ctx, accessHash)
+		}
+		if uid, _ := values[0].(string); uid != "" {
+			database.RedisClient.ZRem(ctx, s.getUserSessionsKey(uid), familyID)
+		}
+	}
+
+	return database.DelKey(ctx, key)
+}
+
+// ListSessions 获取用户的全部有效会话，按登录时间倒序
+func (s *TokenService) ListSessions(ctx context.Context, uid, currentSessionID string) ([]SessionInfo, error) {
+	sessionsKey := s.getUserSessionsKey(uid)
+	sessionIDs, err := database.RedisClient.ZRevRange(ctx, sessionsKey, 0, -1).Result()
 	if err != nil {
 		return nil, err
 	}
 
-	if activeToken == nil {
-		return nil, nil
+	sessions := make([]SessionInfo, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		fields, err := database.RedisClient.HGetAll(ctx, s.getRefreshFamilyKey(sessionID)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 || fields["uid"] != uid {
+			// 会话已过期，从列表移除
+			database.RedisClient.ZRem(ctx, sessionsKey, sessionID)
+			continue
+		}
+		sessions = append(sessions, SessionInfo{
+			SessionID:  sessionID,
+			DeviceInfo: fields["device_info"],
+			IP:         fields["ip"],
+			UserAgent:  fields["user_agent"],
+			LoginTime:  parseUnixField(fields["login_time"]),
+			LastSeenAt: parseUnixField(fields["last_seen"]),
+			IsCurrent:  sessionID == currentSessionID,
+		})
 	}
 
-	return &SessionInfo{
-		CurrentTokenHash: activeToken.TokenHash,
-		LastLoginTime:    activeToken.LoginTime,
-		DeviceInfo:       activeToken.DeviceInfo,
-		LoginIP:          activeToken.LoginIP,
-		UserAgent:        activeToken.UserAgent,
-	}, nil
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LoginTime.After(sessions[j].LoginTime)
+	})
+
+	return sessions, nil
 }
 
-// CleanupExpiredSessions 清理过期会话
-func (s *TokenService) CleanupExpiredSessions(ctx context.Context) error {
-	// 这里可以实现定期清理逻辑
-	// 由于Redis会自动过期，主要清理逻辑在token验证时进行
+// RevokeSession 登出用户的指定会话
+func (s *TokenService) RevokeSession(ctx context.Context, uid, sessionID string) error {
+	owner, err := database.RedisClient.HGet(ctx, s.getRefreshFamilyKey(sessionID), "uid").Result()
+	if err != nil {
+		if err == redis.Nil {
+			return utils.NewAppError(utils.CodeSessionNotFound, "会话不存在或已失效")
+		}
+		return utils.NewAppError(utils.CodeRedisError, "查询会话失败")
+	}
+	if owner != uid {
+		return utils.NewAppError(utils.CodeSessionNotFound, "会话不存在或已失效")
+	}
+
+	if err := s.RevokeRefreshFamily(ctx, sessionID); err != nil {
+		return utils.NewAppError(utils.CodeRedisError, "登出会话失败")
+	}
 	return nil
 }
 
-// ValidateTokenWithBlacklist 验证令牌（包含黑名单检查）
+// RevokeAllSessions 登出用户的全部会话，用于重置密码等场景
+func (s *TokenService) RevokeAllSessions(ctx context.Context, uid string) error {
+	sessionsKey := s.getUserSessionsKey(uid)
+	sessionIDs, err := database.RedisClient.ZRange(ctx, sessionsKey, 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	// 旧版本签发的令牌没有会话ID，记录失效时间使其一并失效
+	expiration := time.Duration(config.GlobalConfig.JWT.AccessTokenExpire)*time.Second + tokenExpirePadding
+	if err := database.SetKey(ctx, s.getUserSessionsRevokedKey(uid), time.Now().Unix(), expiration); err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err := s.RevokeRefreshFamily(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+
+	return database.DelKey(ctx, sessionsKey)
+}
+
+// isFamilylessTokenRevoked 检查没有会话ID的令牌是否签发于用户全部会话失效之前
+// 失效后签发的令牌都带有会话ID，同一秒内签发的无会话ID令牌同样视为已失效
+func (s *TokenService) isFamilylessTokenRevoked(ctx context.Context, claims *utils.Claims) (bool, error) {
+	value, err := database.GetKeyOrDefault(ctx, s.getUserSessionsRevokedKey(claims.Uid), "")
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return !claims.IssuedAt.Time.After(parseUnixField(value)), nil
+}
+
+// touchSession 检查会话是否有效并按间隔更新最后活跃时间
+func (s *TokenService) touchSession(ctx context.Context, uid, sessionID string) (bool, error) {
+	key := s.getRefreshFamilyKey(sessionID)
+	values, err := database.RedisClient.HMGet(ctx, key, "uid", "last_seen").Result()
+	if err != nil {
+		return false, err
+	}
+
+	owner, _ := values[0].(string)
+	if owner == "" || owner != uid {
+		return false, nil
+	}
+
+	lastSeen, _ := values[1].(string)
+	if time.Since(parseUnixField(lastSeen)) >= sessionTouchInterval {
+		database.RedisClient.HSet(ctx, key, "last_seen", time.Now().Unix())
+	}
+
+	return true, nil
+}
+
+// blacklistTokenHash 按token哈希加入黑名单
+func (s *TokenService) blacklistTokenHash(ctx context.Context, tokenHash string) {
+	key := s.getTokenBlacklistKey(tokenHash)
+	expiration := time.Duration(config.GlobalConfig.JWT.AccessTokenExpire)*time.Second + tokenExpirePadding
+	if err := database.SetKey(ctx, key, time.Now().Unix(), expiration); err != nil {
+		utils.LogWarn(nil, "token加入黑名单失败: %v", err)
+	}
+}
+
+// parseUnixField 解析Redis中保存的Unix秒时间戳
+func parseUnixField(value string) time.Time {
+	var seconds int64
+	if _, err := fmt.Sscanf(value, "%d", &seconds); err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// ValidateTokenWithBlacklist 验证令牌（包含黑名单和会话检查）
 func (s *TokenService) ValidateTokenWithBlacklist(ctx context.Context, tokenString string) (*utils.Claims, error) {
 	// 首先进行基本的JWT验证
 	claims, err := utils.ValidateToken(tokenString)
@@ -202,38 +413,42 @@ func (s *TokenService) ValidateTokenWithBlacklist(ctx context.Context, tokenStri
 		return nil, err
 	}
 
+	// 刷新令牌只能用于换取新令牌，不能作为访问令牌使用
+	if claims.TokenType == utils.TokenTypeRefresh {
+		return nil, utils.NewAppError(utils.CodeTokenInvalid, "无效的令牌")
+	}
+
 	// 检查token是否在黑名单中
 	isBlacklisted, err := s.IsTokenBlacklisted(ctx, tokenString)
 	if err != nil {
 		// Redis错误时，记录日志但不阻止验证
 		utils.LogWarn(nil, "检查token黑名单失败: %v", err)
 	} else if isBlacklisted {
-		return nil, utils.NewAppError(utils.CodeTokenSingleLogin, "您的账号已在其他设备登录，请重新登录")
+		return nil, utils.NewAppError(utils.CodeSessionRevoked, "登录会话已失效，请重新登录")
 	}
 
-	// 检查是否为当前活跃token（放宽检查，如果Redis有问题则跳过）
-	isActive, err := s.IsActiveToken(ctx, claims.Uid, tokenString)
-	if err != nil {
-		// Redis错误时，记录日志但不阻止验证
-		utils.LogWarn(nil, "检查活跃token失败: %v", err)
-		// 如果Redis有问题，直接返回claims，不进行活跃token检查
-		return claims, nil
-	}
-
-	if !isActive {
-		// 如果不是活跃token，尝试重新设置（可能是Redis过期了）
-		utils.LogWarn(nil, "Token不是活跃token，尝试重新设置 - UID: %s", claims.Uid)
-
-		// 重新设置活跃token
-		err = s.SetUserActiveToken(ctx, claims.Uid, tokenString, "unknown", "unknown", "unknown")
+	// 旧版本签发的令牌没有会话ID，检查是否签发于重置密码等全部登出操作之前
+	if claims.FamilyID == "" {
+		revoked, err := s.isFamilylessTokenRevoked(ctx, claims)
 		if err != nil {
-			utils.LogWarn(nil, "重新设置活跃token失败: %v", err)
-			// 即使设置失败，也允许token通过验证
+			utils.LogWarn(nil, "检查令牌失效时间失败: %v", err)
+			return claims, nil
 		}
+		if revoked {
+			return nil, utils.NewAppError(utils.CodeSessionRevoked, "登录会话已失效，请重新登录")
+		}
+		return claims, nil
+	}
 
-		// 返回claims，允许验证通过
+	// 检查会话是否仍然有效（放宽检查，如果Redis有问题则跳过）
+	valid, err := s.touchSession(ctx, claims.Uid, claims.FamilyID)
+	if err != nil {
+		utils.LogWarn(nil, "检查会话失败: %v", err)
 		return claims, nil
 	}
+	if !valid {
+		return nil, utils.NewAppError(utils.CodeSessionRevoked, "登录会话已失效，请重新登录")
+	}
 
 	return claims, nil
 }
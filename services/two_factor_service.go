@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorSetupExpire      = 10 * time.Minute // 绑定流程有效期
+	twoFactorChallengeExpire  = 5 * time.Minute  // 登录挑战有效期
+	twoFactorChallengeMaxFail = 5                // 登录挑战最多允许的验证失败次数
+	twoFactorRecoveryCodeNum  = 10               // 每次生成的恢复码数量
+)
+
+// TwoFactorService 两步验证服务（TOTP）
+type TwoFactorService struct {
+	userRepo       *database.UserRepository
+	adminRepo      *database.AdminUserRepository
+	lockoutService *LoginLockoutService
+}
+
+// NewTwoFactorService 创建两步验证服务实例
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:       database.NewUserRepository(),
+		adminRepo:      database.NewAdminUserRepository(),
+		lockoutService: NewLoginLockoutService(),
+	}
+}
+
+// twoFactorChallenge 登录挑战信息，验证失败次数单独计数
+type twoFactorChallenge struct {
+	Uid     string `json:"uid"`
+	Account string `json:"account"` // 登录时输入的账号，验证失败计入该账号的登录锁定次数
+}
+
+// 获取绑定中密钥的Redis key
+func (s *TwoFactorService) getSetupKey(uid string) string {
+	return fmt.Sprintf("2fa:setup:%s", uid)
+}
+
+// 获取最近使用时间步的Redis key，用于防止验证码重放
+func (s *TwoFactorService) getLastStepKey(uid string) string {
+	return fmt.Sprintf("2fa:last_step:%s", uid)
+}
+
+// 获取登录挑战的Redis key
+func (s *TwoFactorService) getChallengeKey(token string) string {
+	return fmt.Sprintf("2fa:challenge:%s", token)
+}
+
+// 获取登录挑战验证失败次数的Redis key
+func (s *TwoFactorService) getChallengeFailKey(token string) string {
+	return fmt.Sprintf("2fa:challenge_fail:%s", token)
+}
+
+// findUser 查询用户
+func (s *TwoFactorService) findUser(ctx context.Context, uid string) (*models.User, error) {
+	user, err := s.userRepo.FindByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeUserQueryFailed, "查询用户失败")
+	}
+	return user, nil
+}
+
+// GetStatus 获取两步验证状态
+func (s *TwoFactorService) GetStatus(ctx context.Context, uid string) (*models.TwoFactorStatusResponse, error) {
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorStatusResponse{
+		Enabled:                user.TwoFactorEnabled,
+		RemainingRecoveryCodes: len(s.loadRecoveryCodes(user)),
+	}, nil
+}
+
+// Setup 开始绑定两步验证，生成密钥和otpauth链接，验证通过后才正式开启
+func (s *TwoFactorService) Setup(ctx context.Context, uid string) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, utils.NewAppError(utils.CodeTwoFactorAlreadyEnabled, "已开启两步验证")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeOperationFailed, "生成密钥失败，请稍后重试")
+	}
+	if err := database.SetKey(ctx, s.getSetupKey(uid), secret, twoFactorSetupExpire); err != nil {
+		return nil, utils.NewAppError(utils.CodeRedisError, "生成密钥失败，请稍后重试")
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.BuildTOTPURI(config.GlobalConfig.Auth.TwoFactor.Issuer, account, secret),
+		ExpiresIn:       int64(twoFactorSetupExpire.Seconds()),
+	}, nil
+}
+
+// Enable 校验绑定中密钥的验证码并开启两步验证，返回一次性恢复码
+func (s *TwoFactorService) Enable(ctx context.Context, uid, code string) (*models.TwoFactorRecoveryCodesResponse, error) {
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, utils.NewAppError(utils.CodeTwoFactorAlreadyEnabled, "已开启两步验证")
+	}
+
+	secret, err := database.GetKey(ctx, s.getSetupKey(uid))
+	if err != nil || secret == "" {
+		return nil, utils.NewAppError(utils.CodeTwoFactorInvalid, "绑定已过期，请重新开始")
+	}
+	if err := s.verifyTOTP(ctx, uid, secret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTwoFactor(ctx, uid, true, secret, hashed); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "开启两步验证失败，请稍后重试")
+	}
+	database.DelKey(ctx, s.getSetupKey(uid))
+
+	return &models.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable 校验验证码或恢复码后关闭两步验证
+func (s *TwoFactorService) Disable(ctx context.Context, uid, code string) error {
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return utils.NewAppError(utils.CodeTwoFactorNotEnabled, "未开启两步验证")
+	}
+	if err := s.verifyCodeOrRecovery(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTwoFactor(ctx, uid, false, "", ""); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "关闭两步验证失败，请稍后重试")
+	}
+	database.DelKey(ctx, s.getLastStepKey(uid))
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验动态验证码后重新生成恢复码，旧恢复码全部失效
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, uid, code string) (*models.TwoFactorRecoveryCodesResponse, error) {
+	user, err := s.findUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, utils.NewAppError(utils.CodeTwoFactorNotEnabled, "未开启两步验证")
+	}
+	if err := s.verifyTOTP(ctx, uid, user.TwoFactorSecret, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTwoFactor(ctx, uid, true, user.TwoFactorSecret, hashed); err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "生成恢复码失败，请稍后重试")
+	}
+
+	return &models.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RequireFreshCode 敏感操作前校验动态验证码，未开启两步验证的用户直接通过
+func (s *TwoFactorService) RequireFreshCode(ctx context.Context, user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+	if code == "" {
+		return utils.NewAppError(utils.CodeTwoFactorRequired, "请输入动态验证码")
+	}
+	return s.verifyTOTP(ctx, user.Uid, user.TwoFactorSecret, code)
+}
+
+// CreateLoginChallenge 密码校验通过后创建登录挑战，返回挑战令牌
+func (s *TwoFactorService) CreateLoginChallenge(ctx context.Context, uid, account string) (string, error) {
+	token := utils.NewTokenID()
+	data, err := json.Marshal(&twoFactorChallenge{Uid: uid, Account: account})
+	if err != nil {
+		return "", err
+	}
+	if err := database.SetKey(ctx, s.getChallengeKey(token), string(data), twoFactorChallengeExpire); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CompleteLoginChallenge 校验登录挑战的验证码或恢复码，成功后返回用户并作废挑战
+// 错误次数过多时挑战作废，需要重新输入密码；每次验证失败同样计入账号和IP的登录失败次数
+func (s *TwoFactorService) CompleteLoginChallenge(ctx context.Context, token, code, clientIP string) (*models.User, error) {
+	key := s.getChallengeKey(token)
+	value, err := database.GetKey(ctx, key)
+	if err != nil || value == "" {
+		return nil, utils.NewAppError(utils.CodeTwoFactorChallenge, "登录验证已失效，请重新登录")
+	}
+
+	var challenge twoFactorChallenge
+	if err := json.Unmarshal([]byte(value), &challenge); err != nil {
+		database.DelKey(ctx, key)
+		return nil, utils.NewAppError(utils.CodeTwoFactorChallenge, "登录验证已失效，请重新登录")
+	}
+
+	// 挑战期间账号或IP已被锁定时不再校验
+	if err := s.lockoutService.CheckLocked(ctx, challenge.Account, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUser(ctx, challenge.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCodeOrRecovery(ctx, user, code); err != nil {
+		s.lockoutService.RecordFailure(ctx, challenge.Account, clientIP, user.Uid)
+
+		// 使用INCR计数，并发提交时每次失败都会被计入
+		failKey := s.getChallengeFailKey(token)
+		failures, incrErr := database.RedisClient.Eval(ctx, `
+			local n = redis.call("INCR", KEYS[1])
+			if n == 1 then
+				redis.call("EXPIRE", KEYS[1], ARGV[1])
+			end
+			return n
+		`, []string{failKey}, int64(twoFactorChallengeExpire.Seconds())).Int64()
+		if incrErr != nil {
+			return nil, utils.NewAppError(utils.CodeRedisError, "登录验证失败，请稍后重试")
+		}
+		if failures >= twoFactorChallengeMaxFail {
+			database.RedisClient.Del(ctx, key, failKey)
+			return nil, utils.NewAppError(utils.CodeTwoFactorChallenge, "验证码错误次数过多，请重新登录")
+		}
+		return nil, err
+	}
+
+	database.RedisClient.Del(ctx, key, s.getChallengeFailKey(token))
+	s.lockoutService.Reset(ctx, challenge.Account)
+	return user, nil
+}
+
+// IsEnforcedForRole 检查管理员角色是否被强制要求开启两步验证
+func (s *TwoFactorService) IsEnforcedForRole(role int64) bool {
+	for _, enforced := range config.GlobalConfig.Auth.TwoFactor.EnforcedRoles {
+		if enforced == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSetupRequiredForAdmin 检查管理员是否因角色被强制要求但尚未绑定两步验证
+func (s *TwoFactorService) IsSetupRequiredForAdmin(admin *models.AdminUser) bool {
+	return !admin.TwoFactorEnabled && s.IsEnforcedForRole(admin.Role)
+}
+
+// AdminSetup 管理员开始绑定两步验证
+func (s *TwoFactorService) AdminSetup(ctx context.Context, admin *models.AdminUser) (*models.TwoFactorSetupResponse, error) {
+	if admin.TwoFactorEnabled {
+		return nil, utils.NewAppError(utils.CodeTwoFactorAlreadyEnabled, "已开启两步验证")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeOperationFailed, "生成密钥失败，请稍后重试")
+	}
+	if err := database.SetKey(ctx, s.getSetupKey(adminTwoFactorKey(admin.ID)), secret, twoFactorSetupExpire); err != nil {
+		return nil, utils.NewAppError(utils.CodeRedisError, "生成密钥失败，请稍后重试")
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.BuildTOTPURI(config.GlobalConfig.Auth.TwoFactor.Issuer, "admin:"+admin.Username, secret),
+		ExpiresIn:       int64(twoFactorSetupExpire.Seconds()),
+	}, nil
+}
+
+// AdminEnable 校验绑定中密钥的验证码并为管理员开启两步验证
+// 管理员不发放恢复码，丢失验证器时由上级管理员重置
+func (s *TwoFactorService) AdminEnable(ctx context.Context, admin *models.AdminUser, code string) error {
+	if admin.TwoFactorEnabled {
+		return utils.NewAppError(utils.CodeTwoFactorAlreadyEnabled, "已开启两步验证")
+	}
+
+	key := adminTwoFactorKey(admin.ID)
+	secret, err := database.GetKey(ctx, s.getSetupKey(key))
+	if err != nil || secret == "" {
+		return utils.NewAppError(utils.CodeTwoFactorInvalid, "绑定已过期，请重新开始")
+	}
+	if err := s.verifyTOTP(ctx, key, secret, code); err != nil {
+		return err
+	}
+
+	if err := s.adminRepo.UpdateFields(ctx, admin.ID, map[string]interface{}{
+		"two_factor_enabled": true,
+		"two_factor_secret":  secret,
+	}); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "开启两步验证失败，请稍后重试")
+	}
+	database.DelKey(ctx, s.getSetupKey(key))
+	return nil
+}
+
+// AdminReset 关闭管理员的两步验证，由上级管理员在其丢失验证器时操作
+func (s *TwoFactorService) AdminReset(ctx context.Context, adminID uint) error {
+	if err := s.adminRepo.UpdateFields(ctx, adminID, map[string]interface{}{
+		"two_factor_enabled": false,
+		"two_factor_secret":  "",
+	}); err != nil {
+		return utils.NewAppError(utils.CodeDatabaseError, "重置两步验证失败，请稍后重试")
+	}
+	database.DelKey(ctx, s.getLastStepKey(adminTwoFactorKey(adminID)))
+	return nil
+}
+
+// RequireAdminCode 校验管理员动态验证码，未开启两步验证的管理员直接通过
+func (s *TwoFactorService) RequireAdminCode(ctx context.Context, admin *models.AdminUser, code string) error {
+	if !admin.TwoFactorEnabled {
+		return nil
+	}
+	if code == "" {
+		return utils.NewAppError(utils.CodeTwoFactorRequired, "请输入动态验证码")
+	}
+	return s.verifyTOTP(ctx, adminTwoFactorKey(admin.ID), admin.TwoFactorSecret, code)
+}
+
+// adminTwoFactorKey 管理员两步验证相关Redis key的标识，与用户UID区分
+func adminTwoFactorKey(adminID uint) string {
+	return fmt.Sprintf("admin:%d", adminID)
+}
+
+// verifyTOTP 校验动态验证码，同一时间步的验证码只能使用一次
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, uid, secret, code string) error {
+	step, ok := utils.ValidateTOTPCode(secret, code, time.Now())
+	if !ok {
+		return utils.NewAppError(utils.CodeTwoFactorInvalid, "动态验证码错误")
+	}
+
+	// 只接受比上次使用更晚的时间步，防止验证码被截获后重放
+	// 使用Lua脚本比较并写入，并发提交同一验证码时只有一次成功
+	expire := int64((2*utils.TOTPSkew + 1) * utils.TOTPPeriod)
+	accepted, err := database.RedisClient.Eval(ctx, `
+		local last = tonumber(redis.call("GET", KEYS[1]) or "-1")
+		if tonumber(ARGV[1]) <= last then
+			return 0
+		end
+		redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2])
+		return 1
+	`, []string{s.getLastStepKey(uid)}, step, expire).Int64()
+	if err != nil {
+		return utils.NewAppError(utils.CodeRedisError, "校验动态验证码失败，请稍后重试")
+	}
+	if accepted == 0 {
+		return utils.NewAppError(utils.CodeTwoFactorInvalid, "动态验证码已使用，请等待下一个验证码")
+	}
+
+	return nil
+}
+
+// verifyCodeOrRecovery 校验动态验证码，失败时尝试作为恢复码使用，恢复码使用后作废
+func (s *TwoFactorService) verifyCodeOrRecovery(ctx context.Context, user *models.User, code string) error {
+	totpErr := s.verifyTOTP(ctx, user.Uid, user.TwoFactorSecret, code)
+	if totpErr == nil {
+		return nil
+	}
+
+	codes := s.loadRecoveryCodes(user)
+	hash := utils.HashRecoveryCode(code)
+	for i, stored := range codes {
+		if stored != hash {
+			continue
+		}
+		remaining := append(codes[:i:i], codes[i+1:]...)
+		data, err := json.Marshal(remaining)
+		if err != nil {
+			return utils.NewAppError(utils.CodeOperationFailed, "校验恢复码失败")
+		}
+		// 以读取时的恢复码作为条件更新，并发使用同一恢复码时只有一次成功
+		consumed, err := s.userRepo.ReplaceRecoveryCodes(ctx, user.Uid, user.TwoFactorRecoveryCodes, string(data))
+		if err != nil {
+			return utils.NewAppError(utils.CodeDatabaseError, "校验恢复码失败，请稍后重试")
+		}
+		if !consumed {
+			return totpErr
+		}
+		utils.LogInfo(nil, "用户使用恢复码完成两步验证 - UID: %s, 剩余恢复码: %d", user.Uid, len(remaining))
+		return nil
+	}
+
+	return totpErr
+}
+
+// loadRecoveryCodes 解析用户剩余的恢复码哈希
+func (s *TwoFactorService) loadRecoveryCodes(user *models.User) []string {
+	var codes []string
+	if user.TwoFactorRecoveryCodes == "" {
+		return codes
+	}
+	if err := json.Unmarshal([]byte(user.TwoFactorRecoveryCodes), &codes); err != nil {
+		return nil
+	}
+	return codes
+}
+
+// newRecoveryCodes 生成恢复码，返回明文和哈希JSON
+func (s *TwoFactorService) newRecoveryCodes() ([]string, string, error) {
+	codes, err := utils.GenerateRecoveryCodes(twoFactorRecoveryCodeNum)
+	if err != nil {
+		return nil, "", utils.NewAppError(utils.CodeOperationFailed, "生成恢复码失败，请稍后重试")
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashRecoveryCode(code))
+	}
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", utils.NewAppError(utils.CodeOperationFailed, "生成恢复码失败，请稍后重试")
+	}
+
+	return codes, string(data), nil
+}
@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	userEventChannel      = "user_events"  // 跨实例分发事件的Redis频道
+	userEventStreamMaxLen = 100            // 每个用户保留的最近事件数，用于断线续传
+	userEventTTL          = 24 * time.Hour // 事件保留时间，最后一次收到的事件早于该时间时需要重新拉取
+	userEventClientBuffer = 64             // 单个连接待发送的事件数，写满视为消费过慢并断开
+	userEventMaxClients   = 5              // 同一用户同时保持的连接数上限
+	eventStreamTokenTTL   = time.Minute    // 连接令牌有效期，只能使用一次
+)
+
+// userEventEnvelope 通过Redis频道分发的事件
+type userEventEnvelope struct {
+	Uid   string           `json:"uid"`
+	Event models.UserEvent `json:"event"`
+}
+
+// userEventItem 待发布的单个用户事件
+type userEventItem struct {
+	uid  string
+	data []byte
+}
+
+// UserEventService 用户实时事件服务
+// 事件先写入用户的Redis Stream用于断线续传，再通过Redis频道分发到所有实例，由持有该用户连接的实例推送
+// 发布失败只记录日志，不影响业务流程，客户端重连或刷新时可从接口获取最新数据
+type UserEventService struct {
+	redisClient *redis.Client
+}
+
+// NewUserEventService 创建用户实时事件服务实例
+func NewUserEventService() *UserEventService {
+	return &UserEventService{
+		redisClient: database.RedisClient,
+	}
+}
+
+// userEventStreamKey 用户事件Stream的Redis key
+func userEventStreamKey(uid string) string {
+	return fmt.Sprintf("user_events:%s", uid)
+}
+
+// Publish 向单个用户发布事件
+func (s *UserEventService) Publish(ctx context.Context, uid, eventType string, data interface{}) {
+	s.PublishToUsers(ctx, []string{uid}, eventType, data)
+}
+
+// PublishToUsers 向多个用户发布相同内容的事件
+func (s *UserEventService) PublishToUsers(ctx context.Context, uids []string, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		utils.LogWarn(nil, "序列化实时事件失败 - 类型: %s, 错误: %v", eventType, err)
+		return
+	}
+	items := make([]userEventItem, 0, len(uids))
+	for _, uid := range uids {
+		items = append(items, userEventItem{uid: uid, data: payload})
+	}
+	s.publish(ctx, eventType, items)
+}
+
+// PublishEach 向多个用户发布各自内容的事件，key为用户ID
+func (s *UserEventService) PublishEach(ctx context.Context, eventType string, data map[string]interface{}) {
+	items := make([]userEventItem, 0, len(data))
+	for uid, value := range data {
+		payload, err := json.Marshal(value)
+		if err != nil {
+			utils.LogWarn(nil, "序列化实时事件失败 - 类型: %s, 错误: %v", eventType, err)
+			return
+		}
+		items = append(items, userEventItem{uid: uid, data: payload})
+	}
+	s.publish(ctx, eventType, items)
+}
+
+// publish 批量写入事件Stream后分发到Redis频道，Stream生成的条目ID作为事件ID
+func (s *UserEventService) publish(ctx context.Context, eventType string, items []userEventItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	pipe := s.redisClient.Pipeline()
+	ids := make([]*redis.StringCmd, len(items))
+	for i, item := range items {
+		key := userEventStreamKey(item.uid)
+		ids[i] = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: userEventStreamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"type": eventType, "data": string(item.data)},
+		})
+		pipe.Expire(ctx, key, userEventTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LogWarn(nil, "写入实时事件失败 - 类型: %s, 错误: %v", eventType, err)
+		return
+	}
+
+	pipe = s.redisClient.Pipeline()
+	for i, item := range items {
+		envelope, err := json.Marshal(userEventEnvelope{
+			Uid:   item.uid,
+			Event: models.UserEvent{ID: ids[i].Val(), Type: eventType, Data: item.data},
+		})
+		if err != nil {
+			continue
+		}
+		pipe.Publish(ctx, userEventChannel, envelope)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LogWarn(nil, "分发实时事件失败 - 类型: %s, 错误: %v", eventType, err)
+	}
+}
+
+// Replay 获取lastID之后的事件用于断线续传，lastID为空时不续传
+// lastID之后的事件已过期或已被裁剪时resync为true，此时返回全部保留的事件，客户端需要先重新拉取完整数据
+func (s *UserEventService) Replay(ctx context.Context, uid, lastID string) ([]models.UserEvent, bool, error) {
+	if lastID == "" {
+		return nil, false, nil
+	}
+	ms, _, ok := ParseUserEventID(lastID)
+	if !ok {
+		return nil, false, utils.NewAppError(utils.CodeInvalidParams, "Last-Event-ID格式错误")
+	}
+	key := userEventStreamKey(uid)
+
+	resync := time.Since(time.UnixMilli(int64(ms))) > userEventTTL
+	if !resync {
+		first, err := s.redisClient.XRangeN(ctx, key, "-", "+", 1).Result()
+		if err != nil {
+			return nil, false, utils.NewAppError(utils.CodeRedisError, "获取历史事件失败")
+		}
+		if len(first) > 0 && CompareUserEventID(first[0].ID, lastID) > 0 {
+			length, err := s.redisClient.XLen(ctx, key).Result()
+			if err != nil {
+				return nil, false, utils.NewAppError(utils.CodeRedisError, "获取历史事件失败")
+			}
+			resync = length >= userEventStreamMaxLen
+		}
+	}
+
+	start := lastID
+	if resync {
+		start = "-"
+	}
+	messages, err := s.redisClient.XRange(ctx, key, start, "+").Result()
+	if err != nil {
+		return nil, false, utils.NewAppError(utils.CodeRedisError, "获取历史事件失败")
+	}
+
+	events := make([]models.UserEvent, 0, len(messages))
+	for _, message := range messages {
+		if !resync && CompareUserEventID(message.ID, lastID) <= 0 {
+			continue
+		}
+		eventType, _ := message.Values["type"].(string)
+		data, _ := message.Values["data"].(string)
+		events = append(events, models.UserEvent{ID: message.ID, Type: eventType, Data: json.RawMessage(data)})
+	}
+	return events, resync, nil
+}
+
+// IssueStreamToken 为访问令牌签发一次性连接令牌，供无法设置请求头的EventSource通过URL参数建立连接
+// 连接令牌只在Redis中映射到访问令牌，避免访问令牌出现在URL和访问日志中
+func (s *UserEventService) IssueStreamToken(ctx context.Context, accessToken string) (*models.EventStreamTokenResponse, error) {
+	token := utils.NewTokenID()
+	if err := s.redisClient.Set(ctx, eventStreamTokenKey(token), accessToken, eventStreamTokenTTL).Err(); err != nil {
+		return nil, utils.NewAppError(utils.CodeRedisError, "生成连接令牌失败")
+	}
+	return &models.EventStreamTokenResponse{
+		StreamToken: token,
+		ExpiresIn:   int(eventStreamTokenTTL / time.Second),
+	}, nil
+}
+
+// ConsumeStreamToken 使用连接令牌并返回对应的访问令牌，令牌不存在、已过期或已使用时返回空
+func (s *UserEventService) ConsumeStreamToken(ctx context.Context, token string) (string, error) {
+	accessToken, err := s.redisClient.Eval(ctx, `
+local value = redis.call('GET', KEYS[1])
+if value then
+	redis.call('DEL', KEYS[1])
+end
+return value`, []string{eventStreamTokenKey(token)}).Text()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", utils.NewAppError(utils.CodeRedisError, "验证连接令牌失败")
+	}
+	return accessToken, nil
+}
+
+// CheckStreamSession 检查推送连接的登录状态：访问令牌未登出、未被撤销且用户未被禁用
+// 长连接只在建立时经过认证中间件，需要定期调用，登录状态失效时断开连接
+func (s *UserEventService) CheckStreamSession(ctx context.Context, accessToken string) error {
+	claims, err := NewTokenService().ValidateTokenWithBlacklist(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	user, err := database.NewUserRepository().FindByUid(ctx, claims.Uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
+		}
+		// 数据库异常时不断开连接，下次心跳再检查
+		utils.LogWarn(nil, "检查推送连接用户状态失败 - UID: %s, 错误: %v", claims.Uid, err)
+		return nil
+	}
+	if user.DeletedAt != nil || user.Status != models.UserStatusActive {
+		return utils.NewAppError(utils.CodeSessionRevoked, "登录会话已失效，请重新登录")
+	}
+	return nil
+}
+
+// eventStreamTokenKey 连接令牌的Redis key
+func eventStreamTokenKey(token string) string {
+	return fmt.Sprintf("event_stream_token:%s", token)
+}
+
+// ParseUserEventID 解析事件ID（毫秒时间戳-序号）
+func ParseUserEventID(id string) (uint64, uint64, bool) {
+	parts := strings.Split(id, "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
+
+// CompareUserEventID 比较两个事件ID的先后，格式错误的ID视为最早
+func CompareUserEventID(a, b string) int {
+	aMs, aSeq, _ := ParseUserEventID(a)
+	bMs, bSeq, _ := ParseUserEventID(b)
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq < bSeq:
+		return -1
+	case aSeq > bSeq:
+		return 1
+	}
+	return 0
+}
+
+// UserEventClient 用户的一个实时推送连接
+type UserEventClient struct {
+	uid       string
+	events    chan models.UserEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Events 待推送的事件
+func (c *UserEventClient) Events() <-chan models.UserEvent {
+	return c.events
+}
+
+// Done 连接被服务端关闭（消费过慢或服务关闭）时关闭
+func (c *UserEventClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// close 关闭连接
+func (c *UserEventClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// UserEventHub 本实例持有的实时推送连接，订阅Redis频道并把事件分发给对应用户的连接
+type UserEventHub struct {
+	mu      sync.RWMutex
+	clients map[string]map[*UserEventClient]struct{}
+	pubsub  *redis.PubSub
+	closed  bool
+}
+
+var (
+	userEventHub     *UserEventHub
+	userEventHubOnce sync.Once
+)
+
+// GetUserEventHub 获取本实例的实时推送连接管理器，首次调用时订阅Redis频道
+func GetUserEventHub() *UserEventHub {
+	userEventHubOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		pubsub := database.RedisClient.Subscribe(ctx, userEventChannel)
+		// 等待订阅确认，保证连接注册后发布的事件都能收到；失败时由客户端自动重连订阅
+		if _, err := pubsub.Receive(ctx); err != nil {
+			utils.LogWarn(nil, "订阅实时事件频道失败: %v", err)
+		}
+
+		userEventHub = &UserEventHub{
+			clients: make(map[string]map[*UserEventClient]struct{}),
+			pubsub:  pubsub,
+		}
+		go userEventHub.run()
+	})
+	return userEventHub
+}
+
+// CloseUserEventHub 关闭全部实时推送连接并取消订阅，服务关闭时调用
+func CloseUserEventHub() {
+	userEventHubOnce.Do(func() {})
+	if userEventHub != nil {
+		userEventHub.close()
+	}
+}
+
+// run 接收Redis频道的事件并分发
+func (h *UserEventHub) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError(nil, "实时事件分发goroutine发生panic: %v", r)
+		}
+	}()
+
+	for message := range h.pubsub.Channel() {
+		var envelope userEventEnvelope
+		if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+			utils.LogWarn(nil, "解析实时事件失败: %v", err)
+			continue
+		}
+		h.dispatch(&envelope)
+	}
+}
+
+// dispatch 把事件发送给用户在本实例的全部连接
+func (h *UserEventHub) dispatch(envelope *userEventEnvelope) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients[envelope.Uid] {
+		select {
+		case client.events <- envelope.Event:
+		default:
+			// 连接消费过慢，断开后由客户端携带Last-Event-ID重连补齐
+			client.close()
+		}
+	}
+}
+
+// Subscribe 注册用户的实时推送连接，应在读取历史事件之前调用，避免遗漏期间发布的事件
+func (h *UserEventHub) Subscribe(uid string) (*UserEventClient, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, utils.NewAppError(utils.CodeServer, "服务正在关闭，请稍后重连")
+	}
+	if len(h.clients[uid]) >= userEventMaxClients {
+		return nil, utils.NewAppError(utils.CodeEventStreamLimit, "实时推送连接数已达上限")
+	}
+
+	client := &UserEventClient{
+		uid:    uid,
+		events: make(chan models.UserEvent, userEventClientBuffer),
+		done:   make(chan struct{}),
+	}
+	if h.clients[uid] == nil {
+		h.clients[uid] = make(map[*UserEventClient]struct{})
+	}
+	h.clients[uid][client] = struct{}{}
+	return client, nil
+}
+
+// Unsubscribe 移除实时推送连接
+func (h *UserEventHub) Unsubscribe(client *UserEventClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients[client.uid], client)
+	if len(h.clients[client.uid]) == 0 {
+		delete(h.clients, client.uid)
+	}
+	client.close()
+}
+
+// close 关闭全部连接并取消订阅
+func (h *UserEventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for _, clients := range h.clients {
+		for client := range clients {
+			client.close()
+		}
+	}
+	if err := h.pubsub.Close(); err != nil {
+		utils.LogWarn(nil, "取消订阅实时事件频道失败: %v", err)
+	}
+}
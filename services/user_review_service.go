@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+// errUserNotPending 用户已被其他管理员审核，条件更新未命中
+var errUserNotPending = errors.New("用户不是待审核状态")
+
+// UserReviewService 待审核用户审核服务
+// 超级管理员可审核全部用户，其他管理员只能审核自己邀请码注册的用户
+type UserReviewService struct {
+	userRepo       *database.UserRepository
+	auditService   *AdminAuditService
+	messageService *MessageService
+}
+
+// NewUserReviewService 创建待审核用户审核服务实例
+func NewUserReviewService() *UserReviewService {
+	return &UserReviewService{
+		userRepo:       database.NewUserRepository(),
+		auditService:   NewAdminAuditService(),
+		messageService: NewMessageService(),
+	}
+}
+
+// ListPending 查询当前管理员可审核的待审核用户
+func (s *UserReviewService) ListPending(ctx context.Context, operator *models.AdminUser, req *models.PendingUserListRequest) (*models.PendingUserListResponse, error) {
+	start, end, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	inviteCodes, err := s.allowedInviteCodes(ctx, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	users, total, err := s.userRepo.ListPendingUsers(ctx, inviteCodes, req.InviteCode, start, end, req.PageSize, offset)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeDatabaseError, "查询待审核用户失败")
+	}
+
+	items := make([]models.PendingUserItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, models.PendingUserItem{
+			Uid:       user.Uid,
+			Username:  user.Username,
+			Email:     utils.MaskEmail(user.Email),
+			Phone:     utils.MaskPhone(user.Phone),
+			InvitedBy: user.InvitedBy,
+			CreatedAt: user.CreatedAt,
+		})
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.PageSize)))
+	return &models.PendingUserListResponse{
+		Users: items,
+		Pagination: models.PaginationInfo{
+			CurrentPage: req.Page,
+			PageSize:    req.PageSize,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     req.Page < totalPages,
+			HasPrev:     req.Page > 1,
+		},
+	}, nil
+}
+
+// Approve 审核通过，用户状态改为正常
+func (s *UserReviewService) Approve(ctx context.Context, operator *models.AdminUser, req *models.UserApproveRequest) (*models.UserReviewResponse, error) {
+	return s.review(ctx, operator, req.Uids, models.UserStatusActive, req.Reason)
+}
+
+// Reject 审核拒绝，用户状态改为禁用
+func (s *UserReviewService) Reject(ctx context.Context, operator *models.AdminUser, req *models.UserRejectRequest) (*models.UserReviewResponse, error) {
+	return s.review(ctx, operator, req.Uids, models.UserStatusDisabled, req.Reason)
+}
+
+// review 逐个审核用户，单个用户失败不影响其他用户
+func (s *UserReviewService) review(ctx context.Context, operator *models.AdminUser, uids []string, status int, reason string) (*models.UserReviewResponse, error) {
+	inviteCodes, err := s.allowedInviteCodes(ctx, operator)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[string]bool, len(inviteCodes))
+	for _, code := range inviteCodes {
+		allowed[code] = true
+	}
+
+	action := models.AuditActionUserApprove
+	if status != models.UserStatusActive {
+		action = models.AuditActionUserReject
+	}
+
+	response := &models.UserReviewResponse{
+		Succeeded: []string{},
+		Failed:    []models.UserReviewFailure{},
+	}
+	seen := make(map[string]bool, len(uids))
+	for _, uid := range uids {
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		user, err := s.userRepo.FindByUid(ctx, uid)
+		if err != nil {
+			msg := "查询用户失败"
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				msg = "用户不存在"
+			}
+			response.Failed = append(response.Failed, models.UserReviewFailure{Uid: uid, Reason: msg})
+			continue
+		}
+		// 无权审核时与用户不存在返回相同提示，避免泄露其他代理的用户
+		if inviteCodes != nil && !allowed[user.InvitedBy] {
+			response.Failed = append(response.Failed, models.UserReviewFailure{Uid: uid, Reason: "用户不存在"})
+			continue
+		}
+		if user.DeletedAt != nil || user.Status != models.UserStatusPending {
+			response.Failed = append(response.Failed, models.UserReviewFailure{Uid: uid, Reason: "用户不是待审核状态"})
+			continue
+		}
+
+		// 条件更新与审计日志在同一事务中写入，并发审核同一用户时只有一个生效
+		err = s.auditService.RecordWith(ctx, action, models.AuditTargetUser, uid,
+			map[string]interface{}{"status": user.Status, "invited_by": user.InvitedBy},
+			map[string]interface{}{"status": status, "invited_by": user.InvitedBy, "reason": reason},
+			func(tx *gorm.DB) error {
+				updated, err := s.userRepo.UpdatePendingStatusInTx(tx, uid, status)
+				if err != nil {
+					return err
+				}
+				if !updated {
+					return errUserNotPending
+				}
+				return nil
+			})
+		if errors.Is(err, errUserNotPending) {
+			response.Failed = append(response.Failed, models.UserReviewFailure{Uid: uid, Reason: "用户不是待审核状态"})
+			continue
+		}
+		if err != nil {
+			response.Failed = append(response.Failed, models.UserReviewFailure{Uid: uid, Reason: "更新用户状态失败"})
+			continue
+		}
+
+		s.notifyUser(ctx, operator, user, status, reason)
+
+		response.Succeeded = append(response.Succeeded, uid)
+	}
+
+	utils.LogInfo(nil, "用户审核完成 - 管理员: %s, 操作: %s, 成功: %d, 失败: %d", operator.Username, action, len(response.Succeeded), len(response.Failed))
+	return response, nil
+}
+
+// notifyUser 通过邮件或短信通知用户审核结果，审核通过时同时发送站内消息
+func (s *UserReviewService) notifyUser(ctx context.Context, operator *models.AdminUser, user *models.User, status int, reason string) {
+	content := "您的账号已通过审核，现在可以登录使用。"
+	if status != models.UserStatusActive {
+		content = fmt.Sprintf("您的账号注册申请未通过审核，原因：%s。如有疑问请联系邀请人。", reason)
+	}
+
+	notification := &Notification{
+		Channel:   NotificationChannelEmail,
+		Recipient: user.Email,
+		Subject:   "账号审核结果",
+		Content:   content,
+	}
+	if user.Email == "" {
+		notification.Channel = NotificationChannelSMS
+		notification.Recipient = user.Phone
+		notification.Subject = ""
+	}
+	if notification.Recipient != "" {
+		if err := GetNotificationProvider().Send(ctx, notification); err != nil {
+			utils.LogWarn(nil, "发送审核结果通知失败 - UID: %s, 错误: %v", user.Uid, err)
+		}
+	}
+
+	if status == models.UserStatusActive {
+		if err := s.messageService.PushUserMessage(ctx, user.Uid, "info", content, operator.Username); err != nil {
+			utils.LogWarn(nil, "发送审核结果站内消息失败 - UID: %s, 错误: %v", user.Uid, err)
+		}
+	}
+}
+
+// allowedInviteCodes 获取管理员可审核的邀请码范围，超级管理员返回nil表示不限制
+// 其他管理员只能审核自己邀请码注册的用户，不包含下级管理员的邀请码
+func (s *UserReviewService) allowedInviteCodes(ctx context.Context, operator *models.AdminUser) ([]string, error) {
+	if operator.Role == models.RoleSuperAdmin {
+		return nil, nil
+	}
+	if operator.MyInviteCode == "" {
+		return []string{}, nil
+	}
+	return []string{operator.MyInviteCode}, nil
+}
+
+// parseDateRange 解析日期范围，结束日期包含当天
+func parseDateRange(startDate, endDate string) (*time.Time, *time.Time, error) {
+	var start, end *time.Time
+	if startDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			return nil, nil, utils.NewAppError(utils.CodeInvalidParams, "开始日期格式错误，应为YYYY-MM-DD")
+		}
+		start = &t
+	}
+	if endDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			return nil, nil, utils.NewAppError(utils.CodeInvalidParams, "结束日期格式错误，应为YYYY-MM-DD")
+		}
+		t = t.AddDate(0, 0, 1)
+		end = &t
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return nil, nil, utils.NewAppError(utils.CodeInvalidParams, "开始日期不能晚于结束日期")
+	}
+	return start, end, nil
+}
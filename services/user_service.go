@@ -17,15 +17,19 @@ import (
 
 // UserService 用户服务
 type UserService struct {
-	userRepo     *database.UserRepository
-	loginLogRepo *database.LoginLogRepository
+	userRepo        *database.UserRepository
+	loginLogRepo    *database.LoginLogRepository
+	lockoutService  *LoginLockoutService
+	referralService *ReferralService
 }
 
 // NewUserService 创建用户服务实例
 func NewUserService() *UserService {
 	return &UserService{
-		userRepo:     database.NewUserRepository(),
-		loginLogRepo: database.NewLoginLogRepository(),
+		userRepo:        database.NewUserRepository(),
+		loginLogRepo:    database.NewLoginLogRepository(),
+		lockoutService:  NewLoginLockoutService(),
+		referralService: NewReferralService(),
 	}
 }
 
@@ -71,19 +75,22 @@ func (s *UserService) Register(req *models.UserRegisterRequest) (*models.UserRes
 		return nil, utils.NewAppError(utils.CodePasswordNotMatch, "两次输入的密码不一致")
 	}
 
-	// 验证邀请码是否来自活跃的管理员
+	// 验证邀请码：管理员邀请码直接归属该管理员；用户邀请码成为该用户的下级，并归属推荐人所属的管理员
+	invitedBy := strings.ToUpper(req.InviteCode)
+	var referrer *models.User
 	if req.InviteCode != "" {
 		adminUserRepo := database.NewAdminUserRepository()
-		adminUser, err := adminUserRepo.GetActiveInviteCode(ctx, strings.ToUpper(req.InviteCode))
+		adminUser, err := adminUserRepo.GetActiveInviteCode(ctx, invitedBy)
 		if err != nil {
-
-			return nil, utils.NewAppError(utils.CodeInviteCodeAdminDisabled, "邀请码无效或管理员账户已被禁用")
-		}
-
-		// 可以在这里添加额外的邀请码验证逻辑
-		// 例如：检查管理员是否有权限邀请用户
-		if !adminUser.IsActive() {
-
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, utils.NewAppError(utils.CodeInviteCodeAdminDisabled, "邀请码无效或管理员账户已被禁用")
+			}
+			referrer, err = s.referralService.FindReferrer(ctx, invitedBy)
+			if err != nil {
+				return nil, err
+			}
+			invitedBy = referrer.InvitedBy
+		} else if !adminUser.IsActive() {
 			return nil, utils.NewAppError(utils.CodeInviteCodeAdminDisabled2, "邀请码对应的管理员账户已被禁用")
 		}
 	}
@@ -99,9 +106,9 @@ func (s *UserService) Register(req *models.UserRegisterRequest) (*models.UserRes
 		Uid:          userID,
 		Username:     username,
 		Password:     req.Password,
-		Status:       1,                                                                                   // 默认待审核
+		Status:       models.UserStatusPending,                                                            // 默认待审核
 		Experience:   1,                                                                                   // 新注册用户默认等级为1
-		InvitedBy:    strings.ToUpper(req.InviteCode),                                                     // 统一存储为大写格式
+		InvitedBy:    invitedBy,                                                                           // 统一存储为大写格式，填写用户邀请码时为推荐人所属管理员的邀请码
 		BankCardInfo: "{\"card_number\":\"\",\"card_holder\":\"\",\"bank_name\":\"\",\"card_type\":\"\"}", // 无条件赋值
 	}
 	if isEmail(req.Account) {
@@ -121,12 +128,21 @@ func (s *UserService) Register(req *models.UserRegisterRequest) (*models.UserRes
 		return nil, utils.NewAppError(utils.CodePasswordEncryptFailed, "加密密码失败")
 	}
 
-	// 保存用户到数据库
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	// 保存用户到数据库，填写用户邀请码时在同一事务中建立推荐关系
+	if referrer != nil {
+		if err := s.referralService.CreateUserWithReferrer(ctx, user, referrer.Uid); err != nil {
+			return nil, err
+		}
+	} else if err := s.userRepo.Create(ctx, user); err != nil {
 
 		return nil, utils.NewAppError(utils.CodeUserCreateFailed, "创建用户失败")
 	}
 
+	// 生成用户自己的邀请码，失败不影响注册，邀请码可在之后获取时再生成
+	if _, err := s.referralService.EnsureInviteCode(ctx, user.Uid); err != nil {
+		utils.LogWarn(nil, "用户注册后生成邀请码失败 - UID: %s, 错误: %v", user.Uid, err)
+	}
+
 	// 自动为用户创建钱包
 	walletService := NewWalletService()
 	wallet, err := walletService.CreateWallet(user.Uid)
@@ -197,6 +213,12 @@ func (s *UserService) Login(req *models.UserLoginRequest, loginIP, userAgent str
 		return phoneRegex.MatchString(account)
 	}
 
+	// 账号或IP处于锁定状态时直接拒绝，不校验密码
+	if lockErr := s.lockoutService.CheckLocked(ctx, req.Account, loginIP); lockErr != nil {
+		s.recordFailedLogin(ctx, req.Account, loginIP, userAgent, "账户已锁定")
+		return nil, lockErr
+	}
+
 	var user *models.User
 	var err error
 	if isEmail(req.Account) {
@@ -209,6 +231,7 @@ func (s *UserService) Login(req *models.UserLoginRequest, loginIP, userAgent str
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.recordFailedLogin(ctx, req.Account, loginIP, userAgent, "账号不存在")
+			s.lockoutService.RecordFailure(ctx, req.Account, loginIP, "")
 			return nil, utils.NewAppError(utils.CodeAccountNotFound, "账号不存在")
 		}
 		return nil, err
@@ -216,6 +239,7 @@ func (s *UserService) Login(req *models.UserLoginRequest, loginIP, userAgent str
 
 	if !user.CheckPassword(req.Password) {
 		s.recordFailedLogin(ctx, req.Account, loginIP, userAgent, "密码错误")
+		s.lockoutService.RecordFailure(ctx, req.Account, loginIP, user.Uid)
 		return nil, utils.NewAppError(utils.CodeLoginCredentialError, "邮箱或手机号或密码错误")
 	}
 
@@ -232,36 +256,75 @@ func (s *UserService) Login(req *models.UserLoginRequest, loginIP, userAgent str
 		s.recordFailedLogin(ctx, req.Account, loginIP, userAgent, "账户待审核")
 		return nil, utils.NewAppError(utils.CodeUserPendingApproval, "账户待审核，无法登录")
 	}
+	if err := NewContactVerifyService().CheckLoginAllowed(user, req.Account); err != nil {
+		s.recordFailedLogin(ctx, req.Account, loginIP, userAgent, "账号未验证")
+		return nil, err
+	}
+
+	// 开启两步验证时先返回挑战令牌，提交动态验证码后再签发令牌
+	// 失败次数在两步验证通过后才清除，避免反复输入密码来重置动态验证码的尝试次数
+	if user.TwoFactorEnabled {
+		challengeToken, err := NewTwoFactorService().CreateLoginChallenge(ctx, user.Uid, req.Account)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeRedisError, "登录失败，请稍后重试")
+		}
+		return &models.TokenResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		}, nil
+	}
+
+	s.lockoutService.Reset(ctx, req.Account)
+	return s.issueLoginTokens(ctx, user, loginIP, userAgent)
+}
+
+// LoginWithTwoFactor 两步验证登录第二步，校验动态验证码或恢复码后签发令牌
+func (s *UserService) LoginWithTwoFactor(req *models.TwoFactorLoginRequest, loginIP, userAgent string) (*models.TokenResponse, error) {
+	ctx := context.Background()
+
+	user, err := NewTwoFactorService().CompleteLoginChallenge(ctx, req.ChallengeToken, req.Code, loginIP)
+	if err != nil {
+		return nil, err
+	}
+
+	// 挑战期间账户状态可能已变化，重新校验
+	if user.DeletedAt != nil {
+		return nil, utils.NewAppError(utils.CodeUserDeletedLogin, "账户已被删除，无法登录")
+	}
+	if user.Status == 0 {
+		return nil, utils.NewAppError(utils.CodeUserDisabledLogin, "账户已被禁用，无法登录")
+	}
 
+	return s.issueLoginTokens(ctx, user, loginIP, userAgent)
+}
+
+// issueLoginTokens 记录登录成功并签发访问令牌和刷新令牌
+func (s *UserService) issueLoginTokens(ctx context.Context, user *models.User, loginIP, userAgent string) (*models.TokenResponse, error) {
 	// 记录成功的登录
 	s.recordSuccessfulLogin(ctx, user, loginIP, userAgent)
 
-	// 实现单点登录：检查并撤销旧会话
 	tokenService := NewTokenService()
-	activeToken, err := tokenService.GetUserActiveToken(ctx, user.Uid)
-	if err == nil && activeToken != nil {
-		// 将旧token加入黑名单
-		tokenService.AddTokenToBlacklist(ctx, activeToken.TokenHash)
-	}
+
+	// 每次登录开启新的令牌家族，后续刷新轮换出的令牌都属于该家族
+	familyID := utils.NewTokenID()
+	refreshTokenID := utils.NewTokenID()
 
 	// 生成访问令牌
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Uid, user.Username)
+	accessToken, err := utils.GenerateAccessToken(user.ID, user.Uid, user.Username, familyID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 生成刷新令牌
-	refreshToken, err := utils.GenerateRefreshToken(user.ID, user.Uid, user.Username)
+	refreshToken, err := utils.GenerateRefreshToken(user.ID, user.Uid, user.Username, familyID, refreshTokenID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 设置新的活跃token
+	// 创建会话（即令牌家族），刷新时据此轮换和检测重复使用，超出最大会话数时登出最早的设备
 	deviceInfo := s.extractDeviceInfo(userAgent)
-	err = tokenService.SetUserActiveToken(ctx, user.Uid, accessToken, deviceInfo, loginIP, userAgent)
-	if err != nil {
-		// 记录错误但不影响登录流程
-		// 这里不记录日志，因为不是关键错误
+	if err := tokenService.StartSession(ctx, user.Uid, familyID, refreshTokenID, accessToken, deviceInfo, loginIP, userAgent); err != nil {
+		return nil, utils.NewAppError(utils.CodeRedisError, "登录失败，请稍后重试")
 	}
 
 	return &models.TokenResponse{
@@ -273,7 +336,9 @@ func (s *UserService) Login(req *models.UserLoginRequest, loginIP, userAgent str
 }
 
 // RefreshToken 刷新访问令牌
-func (s *UserService) RefreshToken(req *models.RefreshTokenRequest) (*models.TokenResponse, error) {
+// 每次刷新都轮换出新的访问令牌和刷新令牌，旧刷新令牌立即失效；
+// 已轮换的刷新令牌再次使用时撤销整个令牌家族
+func (s *UserService) RefreshToken(req *models.RefreshTokenRequest, loginIP, userAgent string) (*models.TokenResponse, error) {
 	ctx := context.Background()
 
 	// 验证刷新令牌
@@ -282,13 +347,13 @@ func (s *UserService) RefreshToken(req *models.RefreshTokenRequest) (*models.Tok
 	}
 
 	// 解析刷新令牌
-	claims, err := utils.ValidateToken(req.RefreshToken)
+	claims, err := utils.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		return nil, utils.NewAppError(utils.CodeRefreshTokenInvalid, "无效的刷新令牌")
 	}
 
 	// 检查用户是否存在
-	user, err := s.userRepo.FindByUsername(ctx, claims.Username)
+	user, err := s.userRepo.FindByUid(ctx, claims.Uid)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
@@ -297,6 +362,9 @@ func (s *UserService) RefreshToken(req *models.RefreshTokenRequest) (*models.Tok
 	}
 
 	// 检查用户状态
+	if user.DeletedAt != nil {
+		return nil, utils.NewAppError(utils.CodeUserDeletedRefresh, "账户已被删除，无法刷新令牌")
+	}
 	if user.Status == 0 { // 禁用
 		return nil, utils.NewAppError(utils.CodeUserDisabledRefresh, "账户已被禁用，无法刷新令牌")
 	}
@@ -305,17 +373,24 @@ func (s *UserService) RefreshToken(req *models.RefreshTokenRequest) (*models.Tok
 	}
 
 	// 生成新的访问令牌
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Uid, user.Username)
+	accessToken, err := utils.GenerateAccessToken(user.ID, user.Uid, user.Username, claims.FamilyID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 生成新的刷新令牌
-	newRefreshToken, err := utils.GenerateRefreshToken(user.ID, user.Uid, user.Username)
+	newRefreshTokenID := utils.NewTokenID()
+	newRefreshToken, err := utils.GenerateRefreshToken(user.ID, user.Uid, user.Username, claims.FamilyID, newRefreshTokenID)
 	if err != nil {
 		return nil, err
 	}
 
+	// 轮换令牌家族，旧刷新令牌和旧访问令牌失效
+	tokenService := NewTokenService()
+	if err := tokenService.RotateRefreshToken(ctx, claims, newRefreshTokenID, accessToken, loginIP); err != nil {
+		return nil, err
+	}
+
 	return &models.TokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
@@ -341,23 +416,15 @@ func (s *UserService) GetUserByID(userID uint) (*models.UserResponse, error) {
 		return nil, utils.NewAppError(utils.CodeUserDeleted, "用户已被删除")
 	}
 
-	// 从Redis获取用户等级进度和动态计算经验值
-	userLevelService := NewUserLevelService()
-	rate, err := userLevelService.GetUserLevelRate(ctx, user.Uid)
-	if err != nil {
-		// 如果获取失败，使用默认值0
-		rate = 0
-	}
-
-	// 动态计算用户等级（experience）
-	level, err := userLevelService.GetUserLevel(ctx, user.Uid)
-	if err != nil {
-		// 如果获取失败，使用默认值1
-		level = 1
+	// 根据会员等级配置计算当前等级和下一等级的升级要求，获取失败时使用默认值
+	rate, level := 0, 1
+	if levelInfo, err := NewUserLevelService().GetUserLevelInfo(ctx, user.Uid); err == nil {
+		rate = levelInfo.NextLevelRequirement
+		level = levelInfo.CurrentLevel
 	}
 
 	response := user.ToResponse()
-	response.Rate = rate        // 设置从Redis获取的等级进度
+	response.Rate = rate        // 下一等级的升级要求
 	response.Experience = level // 动态计算的经验值（等级）
 
 	return &response, nil
@@ -422,6 +489,7 @@ type BindBankCardRequest struct {
 	CardHolder string `json:"card_holder" binding:"required"`
 	CardNumber string `json:"card_number" binding:"required"`
 	CardType   string `json:"card_type" binding:"required"` // 借记卡、信用卡等
+	TotpCode   string `json:"totp_code"`                    // 动态验证码，开启两步验证时必填
 }
 
 // BindBankCard 绑定银行卡
@@ -447,6 +515,11 @@ func (s *UserService) BindBankCard(req *BindBankCardRequest, uid string) (*model
 		return nil, utils.NewAppError(utils.CodeUserDisabledBindCard, "账户已被禁用，无法绑定银行卡")
 	}
 
+	// 开启两步验证的用户需要提供动态验证码
+	if err := NewTwoFactorService().RequireFreshCode(ctx, user, req.TotpCode); err != nil {
+		return nil, err
+	}
+
 	// 验证银行卡信息
 	if err := s.validateBankCardInfo(req); err != nil {
 		return nil, err
@@ -580,6 +653,11 @@ func (s *UserService) ChangePassword(req *models.ChangePasswordRequest, uid stri
 		return utils.NewAppError(utils.CodeCurrentPasswordWrong, "当前密码错误")
 	}
 
+	// 开启两步验证的用户需要提供动态验证码
+	if err := NewTwoFactorService().RequireFreshCode(ctx, user, req.TotpCode); err != nil {
+		return err
+	}
+
 	// 检查新密码是否与当前密码相同
 	if user.CheckPassword(req.NewPassword) {
 		return utils.NewAppError(utils.CodeNewPasswordSame, "新密码不能与当前密码相同")
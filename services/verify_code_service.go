@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-fataMorgana/config"
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/utils"
+)
+
+// 验证码用途，参与Redis key和哈希计算，不同用途的验证码互不通用
+const (
+	VerifyPurposePasswordReset = "password_reset" // 找回密码
+	VerifyPurposeContact       = "contact_verify" // 验证邮箱、手机号
+	VerifyPurposeContactChange = "contact_change" // 更换邮箱、手机号
+)
+
+const verifyCodeLimitWindow = time.Hour // 发送次数限制的统计窗口
+
+// VerifyCodeService 邮件、短信一次性验证码服务
+// 验证码只以哈希形式保存在Redis，按接收方和IP限制发送频率，按验证码限制错误次数
+type VerifyCodeService struct {
+	purpose string
+	cfg     config.VerifyCodeConfig
+}
+
+// NewVerifyCodeService 创建指定用途的验证码服务实例
+func NewVerifyCodeService(purpose string, cfg config.VerifyCodeConfig) *VerifyCodeService {
+	return &VerifyCodeService{
+		purpose: purpose,
+		cfg:     cfg,
+	}
+}
+
+// normalizeTarget 统一验证码归属的格式，邮箱不区分大小写
+func (s *VerifyCodeService) normalizeTarget(target string) string {
+	return strings.ToLower(strings.TrimSpace(target))
+}
+
+// 获取验证码的Redis key，hash结构：uid、code_hash、attempts
+func (s *VerifyCodeService) getCodeKey(target string) string {
+	return fmt.Sprintf("verify_code:%s:code:%s", s.purpose, target)
+}
+
+// 获取重新发送冷却的Redis key
+func (s *VerifyCodeService) getCooldownKey(target string) string {
+	return fmt.Sprintf("verify_code:%s:cooldown:%s", s.purpose, target)
+}
+
+// 获取接收方发送次数的Redis key
+func (s *VerifyCodeService) getTargetLimitKey(target string) string {
+	return fmt.Sprintf("verify_code:%s:limit:target:%s", s.purpose, target)
+}
+
+// 获取IP发送次数的Redis key
+func (s *VerifyCodeService) getIPLimitKey(ip string) string {
+	return fmt.Sprintf("verify_code:%s:limit:ip:%s", s.purpose, ip)
+}
+
+// ExpiresIn 验证码有效期（秒）
+func (s *VerifyCodeService) ExpiresIn() int {
+	return s.cfg.CodeExpire
+}
+
+// ResendInterval 重新发送间隔（秒）
+func (s *VerifyCodeService) ResendInterval() int {
+	return s.cfg.ResendInterval
+}
+
+// CheckSendLimit 检查发送冷却以及接收方、IP每小时发送次数
+func (s *VerifyCodeService) CheckSendLimit(ctx context.Context, target, clientIP string) error {
+	target = s.normalizeTarget(target)
+	ok, err := database.RedisClient.SetNX(ctx, s.getCooldownKey(target), time.Now().Unix(), time.Duration(s.cfg.ResendInterval)*time.Second).Result()
+	if err != nil {
+		return utils.NewAppError(utils.CodeRedisError, "发送验证码失败")
+	}
+	if !ok {
+		return utils.NewAppError(utils.CodeRateLimitExceeded, "验证码发送过于频繁，请稍后再试")
+	}
+
+	limits := []struct {
+		key   string
+		limit int
+	}{
+		{s.getTargetLimitKey(target), s.cfg.AccountLimit},
+		{s.getIPLimitKey(clientIP), s.cfg.IPLimit},
+	}
+	for _, l := range limits {
+		count, err := s.incrWithWindow(ctx, l.key)
+		if err != nil {
+			return utils.NewAppError(utils.CodeRedisError, "发送验证码失败")
+		}
+		if count > int64(l.limit) {
+			return utils.NewAppError(utils.CodeRateLimitExceeded, "验证码发送次数过多，请稍后再试")
+		}
+	}
+
+	return nil
+}
+
+// Send 生成验证码并发送到邮箱或手机号，新验证码覆盖旧验证码，错误次数重新计算
+// target为验证码归属（通常是邮箱或手机号），recipient为实际接收方，content中的%s依次替换为验证码和有效分钟数
+func (s *VerifyCodeService) Send(ctx context.Context, target, recipient, uid, subject, content string) error {
+	target = s.normalizeTarget(target)
+	code, err := utils.GenerateNumericCode(s.cfg.CodeLength)
+	if err != nil {
+		return utils.NewAppError(utils.CodeVerifyCodeSendFailed, "验证码生成失败")
+	}
+
+	key := s.getCodeKey(target)
+	codeHash := utils.HashVerifyCode(s.purpose, target, code, config.GlobalConfig.JWT.Secret)
+	pipe := database.RedisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "uid", uid, "code_hash", codeHash, "attempts", 0)
+	pipe.Expire(ctx, key, time.Duration(s.cfg.CodeExpire)*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return utils.NewAppError(utils.CodeRedisError, "保存验证码失败")
+	}
+
+	minutes := strconv.Itoa((s.cfg.CodeExpire + 59) / 60)
+	notification := &Notification{
+		Channel:   NotificationChannelEmail,
+		Recipient: recipient,
+		Subject:   subject,
+		Content:   fmt.Sprintf(content, code, minutes),
+	}
+	if !utils.IsEmailAccount(recipient) {
+		notification.Channel = NotificationChannelSMS
+		notification.Subject = ""
+	}
+
+	if err := GetNotificationProvider().Send(ctx, notification); err != nil {
+		utils.LogError(nil, "发送验证码失败 - 用途: %s, UID: %s, 错误: %v", s.purpose, uid, err)
+		database.DelKey(ctx, key)
+		return utils.NewAppError(utils.CodeVerifyCodeSendFailed, "验证码发送失败，请稍后重试")
+	}
+
+	return nil
+}
+
+// Verify 校验验证码，成功后验证码立即失效，返回发送时记录的UID
+func (s *VerifyCodeService) Verify(ctx context.Context, target, code string) (string, error) {
+	target = s.normalizeTarget(target)
+	key := s.getCodeKey(target)
+
+	fields, err := database.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return "", utils.NewAppError(utils.CodeRedisError, "校验验证码失败")
+	}
+	if len(fields) == 0 || fields["uid"] == "" {
+		return "", utils.NewAppError(utils.CodeVerifyCodeInvalid, "验证码错误或已过期")
+	}
+
+	// 先累加错误次数再比较，避免并发请求绕过次数限制
+	// 验证码在读取后过期时不累加，避免重新创建没有过期时间的key
+	attempts, err := database.RedisClient.Eval(ctx, `
+		if redis.call("EXISTS", KEYS[1]) == 0 then
+			return -1
+		end
+		return redis.call("HINCRBY", KEYS[1], "attempts", 1)
+	`, []string{key}).Int64()
+	if err != nil {
+		return "", utils.NewAppError(utils.CodeRedisError, "校验验证码失败")
+	}
+	if attempts < 0 {
+		return "", utils.NewAppError(utils.CodeVerifyCodeInvalid, "验证码错误或已过期")
+	}
+	if attempts > int64(s.cfg.MaxAttempts) {
+		database.DelKey(ctx, key)
+		return "", utils.NewAppError(utils.CodeVerifyCodeInvalid, "验证码错误次数过多，请重新获取")
+	}
+
+	code = strings.TrimSpace(code)
+	if !utils.VerifyCodeHash(fields["code_hash"], s.purpose, target, code, config.GlobalConfig.JWT.Secret) {
+		return "", utils.NewAppError(utils.CodeVerifyCodeInvalid, "验证码错误或已过期")
+	}
+
+	// 验证码只能使用一次，删除失败说明已被并发请求使用
+	deleted, err := database.RedisClient.Del(ctx, key).Result()
+	if err != nil {
+		return "", utils.NewAppError(utils.CodeRedisError, "校验验证码失败")
+	}
+	if deleted == 0 {
+		return "", utils.NewAppError(utils.CodeVerifyCodeInvalid, "验证码错误或已过期")
+	}
+
+	return fields["uid"], nil
+}
+
+// incrWithWindow 计数加一，首次计数时在同一脚本内设置统计窗口
+func (s *VerifyCodeService) incrWithWindow(ctx context.Context, key string) (int64, error) {
+	return database.RedisClient.Eval(ctx, `
+		local n = redis.call("INCR", KEYS[1])
+		if n == 1 then
+			redis.call("EXPIRE", KEYS[1], ARGV[1])
+		end
+		return n
+	`, []string{key}, int64(verifyCodeLimitWindow.Seconds())).Int64()
+}
@@ -17,12 +17,15 @@ type WalletService struct {
 	walletRepo *database.WalletRepository
 	// 缓存服务
 	cacheService *WalletCacheService
+	// 实时事件服务
+	eventService *UserEventService
 }
 
 func NewWalletService() *WalletService {
 	return &WalletService{
 		walletRepo:   database.NewWalletRepository(),
 		cacheService: NewWalletCacheService(),
+		eventService: NewUserEventService(),
 	}
 }
 
@@ -167,6 +170,7 @@ func (s *WalletService) AtomicBalanceOperationWithRetry(ctx context.Context, uid
 		// 缓存更新失败不影响主流程，只记录日志
 		utils.LogWarn(nil, "更新钱包余额缓存失败: %v", cacheErr)
 	}
+	s.publishBalance(ctx, wallet)
 
 	// 8. 记录操作日志
 	utils.LogInfo(nil, "钱包余额操作成功 - UID: %s, 操作前: %.2f, 操作后: %.2f, 变化金额: %.2f",
@@ -175,6 +179,38 @@ func (s *WalletService) AtomicBalanceOperationWithRetry(ctx context.Context, uid
 	return nil
 }
 
+// LockedWalletWrite 持有钱包分布式锁执行write，write在数据库事务内完成余额变更和关联记录的写入
+// 返回变更后的钱包，未变更时返回nil；提交成功后刷新余额缓存并推送余额变化
+func (s *WalletService) LockedWalletWrite(ctx context.Context, uid string, write func() (*models.Wallet, error)) error {
+	if uid == "" {
+		return utils.NewAppError(utils.CodeInvalidParams, "用户ID不能为空")
+	}
+
+	lockValue, err := s.acquireLockWithRetry(ctx, uid, 30*time.Second, 3, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if releaseErr := s.releaseLock(ctx, uid, lockValue); releaseErr != nil {
+			utils.LogWarn(nil, "释放分布式锁失败: %v", releaseErr)
+		}
+	}()
+
+	wallet, err := write()
+	if err != nil {
+		return err
+	}
+	if wallet == nil {
+		return nil
+	}
+
+	if cacheErr := s.cacheService.UpdateWalletBalanceOnEvent(ctx, uid, wallet.Balance); cacheErr != nil {
+		utils.LogWarn(nil, "更新钱包余额缓存失败: %v", cacheErr)
+	}
+	s.publishBalance(ctx, wallet)
+	return nil
+}
+
 // 扣减余额（跨进程并发安全）
 func (s *WalletService) WithdrawBalance(ctx context.Context, uid string, amount float64, description string) error {
 	if amount <= 0 {
@@ -311,6 +347,8 @@ func (s *WalletService) TransferBalance(ctx context.Context, fromUid, toUid stri
 	if cacheErr := s.cacheService.UpdateWalletBalanceOnEvent(ctx, toUid, toWallet.Balance); cacheErr != nil {
 		utils.LogWarn(nil, "更新转入方钱包缓存失败: %v", cacheErr)
 	}
+	s.publishBalance(ctx, fromWallet)
+	s.publishBalance(ctx, toWallet)
 
 	// 11. 记录操作日志
 	utils.LogInfo(nil, "转账操作成功 - 从: %s, 到: %s, 金额: %.2f", fromUid, toUid, amount)
@@ -318,6 +356,15 @@ func (s *WalletService) TransferBalance(ctx context.Context, fromUid, toUid stri
 	return nil
 }
 
+// publishBalance 推送钱包余额变化事件
+func (s *WalletService) publishBalance(ctx context.Context, wallet *models.Wallet) {
+	s.eventService.Publish(ctx, wallet.Uid, models.UserEventBalance, models.BalanceEventData{
+		Balance:   wallet.Balance,
+		Status:    wallet.EffectiveStatus(),
+		UpdatedAt: wallet.UpdatedAt,
+	})
+}
+
 // BalanceOperation 余额操作结构体
 type BalanceOperation struct {
 	UID    string  `json:"uid"`
@@ -526,6 +573,7 @@ type WithdrawRequest struct {
 	Uid         string  `json:"uid"` // 移除 binding:"required"，uid 从当前登录用户获取
 	Amount      float64 `json:"amount" binding:"required,gt=0"`
 	Description string  `json:"description"`
+	TotpCode    string  `json:"totp_code"` // 动态验证码，开启两步验证时必填
 }
 
 // GetUserTransactionsRequest 获取用户交易记录请求
@@ -533,7 +581,8 @@ type GetUserTransactionsRequest struct {
 	Uid      string `json:"uid" binding:"required"`
 	Page     int    `json:"page" binding:"required,min=1"`
 	PageSize int    `json:"page_size" binding:"required,min=1,max=100"`
-	Type     string `json:"type"` // 可选，交易类型过滤
+	Type     string `json:"type"`   // 可选，交易类型过滤
+	Cursor   string `json:"cursor"` // 可选，游标分页
 }
 
 // GetTransactionDetailRequest 获取交易详情请求
@@ -545,6 +594,27 @@ type GetTransactionDetailRequest struct {
 func (s *WalletService) GetUserTransactions(req *GetUserTransactionsRequest) (*models.GetTransactionsResponse, error) {
 	ctx := context.Background()
 
+	// 传入游标时按游标分页，不统计总数
+	if req.Cursor != "" {
+		cursor, err := utils.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeInvalidCursor, "分页游标无效")
+		}
+		transactions, hasNext, err := s.walletRepo.GetTransactionsByUidCursor(ctx, req.Uid, cursor, req.PageSize, req.Type)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "获取交易记录失败")
+		}
+		return &models.GetTransactionsResponse{
+			Transactions: transactions,
+			PageSize:     req.PageSize,
+			NextCursor:   transactionsNextCursor(transactions, hasNext),
+		}, nil
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+
 	// 获取交易记录
 	transactions, total, err := s.walletRepo.GetTransactionsByUid(ctx, req.Uid, req.Page, req.PageSize, req.Type)
 	if err != nil {
@@ -557,11 +627,21 @@ func (s *WalletService) GetUserTransactions(req *GetUserTransactionsRequest) (*m
 		Total:        total,
 		Page:         req.Page,
 		PageSize:     req.PageSize,
+		NextCursor:   transactionsNextCursor(transactions, int64(req.Page*req.PageSize) < total),
 	}
 
 	return response, nil
 }
 
+// transactionsNextCursor 根据本页最后一条交易记录生成下一页游标
+func transactionsNextCursor(transactions []models.WalletTransaction, hasNext bool) string {
+	if !hasNext || len(transactions) == 0 {
+		return ""
+	}
+	last := transactions[len(transactions)-1]
+	return utils.EncodeCursor(last.CreatedAt, int64(last.ID))
+}
+
 // GetWallet 获取钱包信息
 func (s *WalletService) GetWallet(uid string) (*models.Wallet, error) {
 	ctx := context.Background()
@@ -758,6 +838,16 @@ func (s *WalletService) RequestWithdraw(req *WithdrawRequest, userUid string) (*
 		return nil, utils.NewAppError(utils.CodeUserNotFound, "用户不存在")
 	}
 
+	// 开启两步验证的用户需要提供动态验证码
+	if err := NewTwoFactorService().RequireFreshCode(ctx, user, req.TotpCode); err != nil {
+		return nil, err
+	}
+
+	// 配置要求时，提现前需要完成邮箱或手机号验证
+	if err := NewContactVerifyService().CheckWithdrawAllowed(user); err != nil {
+		return nil, err
+	}
+
 	// 检查银行卡信息是否为空或为默认空值
 	if user.BankCardInfo == "" || user.BankCardInfo == "{\"card_number\":\"\",\"card_holder\":\"\",\"bank_name\":\"\",\"card_type\":\"\"}" {
 		return nil, utils.NewAppError(utils.CodeBankCardNotBound, "请先绑定银行卡后再进行提现操作")
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-fataMorgana/database"
+	"gin-fataMorgana/models"
+	"gin-fataMorgana/utils"
+
+	"gorm.io/gorm"
+)
+
+// walletStatusExpireBatch 每批恢复的到期钱包数量
+const walletStatusExpireBatch = 100
+
+// WalletStatusExpireStats 钱包状态到期恢复统计
+type WalletStatusExpireStats struct {
+	RevertedCount int           `json:"reverted_count"`
+	FailedCount   int           `json:"failed_count"`
+	ProcessTime   time.Duration `json:"process_time"`
+}
+
+// WalletStatusService 钱包状态管理服务
+// 状态修改在钱包分布式锁内进行，避免与并发的余额操作互相覆盖
+type WalletStatusService struct {
+	walletRepo     *database.WalletRepository
+	walletService  *WalletService
+	cacheService   *WalletCacheService
+	auditService   *AdminAuditService
+	messageService *MessageService
+}
+
+// NewWalletStatusService 创建钱包状态管理服务实例
+func NewWalletStatusService() *WalletStatusService {
+	return &WalletStatusService{
+		walletRepo:     database.NewWalletRepository(),
+		walletService:  NewWalletService(),
+		cacheService:   NewWalletCacheService(),
+		auditService:   NewAdminAuditService(),
+		messageService: NewMessageService(),
+	}
+}
+
+// Freeze 冻结钱包，冻结期间无法下单、充值和提现
+func (s *WalletStatusService) Freeze(ctx context.Context, operator *models.AdminUser, req *models.WalletRestrictRequest) (*models.WalletStatusResponse, error) {
+	expiresAt, err := parseWalletStatusExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return s.setStatus(ctx, operator, req.Uid, models.WalletStatusFrozen, req.Reason, expiresAt)
+}
+
+// BlockWithdraw 限制钱包提现，其他操作不受影响
+func (s *WalletStatusService) BlockWithdraw(ctx context.Context, operator *models.AdminUser, req *models.WalletRestrictRequest) (*models.WalletStatusResponse, error) {
+	expiresAt, err := parseWalletStatusExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return s.setStatus(ctx, operator, req.Uid, models.WalletStatusNoWithdraw, req.Reason, expiresAt)
+}
+
+// Unfreeze 解除冻结或提现限制，恢复正常状态
+func (s *WalletStatusService) Unfreeze(ctx context.Context, operator *models.AdminUser, req *models.WalletUnfreezeRequest) (*models.WalletStatusResponse, error) {
+	return s.setStatus(ctx, operator, req.Uid, models.WalletStatusNormal, req.Reason, nil)
+}
+
+// setStatus 修改钱包状态，已处于相同限制时更新原因和到期时间
+func (s *WalletStatusService) setStatus(ctx context.Context, operator *models.AdminUser, uid string, status int, reason string, expiresAt *time.Time) (*models.WalletStatusResponse, error) {
+	if _, err := s.walletRepo.FindWalletByUid(ctx, uid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.NewAppError(utils.CodeWalletNotFound, "用户钱包不存在")
+		}
+		return nil, utils.NewAppError(utils.CodeWalletGetFailed, "获取钱包失败")
+	}
+
+	var before, after map[string]interface{}
+	var updated *models.Wallet
+	err := s.walletService.AtomicBalanceOperation(ctx, uid, func(wallet *models.Wallet) error {
+		if status == models.WalletStatusNormal && wallet.EffectiveStatus() == models.WalletStatusNormal {
+			return utils.NewAppError(utils.CodeWalletStatusUnchanged, "钱包已是正常状态")
+		}
+		before = walletStatusSnapshot(wallet)
+		wallet.Status = status
+		wallet.StatusReason = reason
+		wallet.StatusExpiresAt = expiresAt
+		after = walletStatusSnapshot(wallet)
+		updated = wallet
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存中的钱包状态已过期，立即删除，下次读取时从数据库加载
+	s.cacheService.DeleteWalletBalance(ctx, uid)
+
+	s.auditService.Record(ctx, models.AuditActionWalletStatusUpdate, models.AuditTargetWallet, uid, before, after)
+	s.notifyUser(ctx, uid, status, reason, expiresAt, operator.Username)
+
+	utils.LogInfo(nil, "钱包状态已修改 - 管理员: %s, UID: %s, 状态: %d -> %d, 原因: %s", operator.Username, uid, before["status"], status, reason)
+	return toWalletStatusResponse(updated), nil
+}
+
+// RevertExpired 将冻结或限制提现已到期的钱包恢复为正常状态（定时任务调用）
+func (s *WalletStatusService) RevertExpired(ctx context.Context) (*WalletStatusExpireStats, error) {
+	startTime := time.Now()
+	stats := &WalletStatusExpireStats{}
+	failed := make(map[string]bool)
+
+	for {
+		wallets, err := s.walletRepo.ListStatusExpiredWallets(ctx, time.Now(), walletStatusExpireBatch)
+		if err != nil {
+			return nil, utils.NewAppError(utils.CodeDatabaseError, "查询到期钱包失败")
+		}
+
+		processed := 0
+		for _, wallet := range wallets {
+			// 本轮已失败的钱包不再重试，等待下次定时任务
+			if failed[wallet.Uid] {
+				continue
+			}
+			processed++
+			if err := s.revertWallet(ctx, wallet.Uid); err != nil {
+				utils.LogWarn(nil, "恢复到期钱包状态失败 - UID: %s, 错误: %v", wallet.Uid, err)
+				failed[wallet.Uid] = true
+				stats.FailedCount++
+				continue
+			}
+			stats.RevertedCount++
+		}
+
+		if processed == 0 || len(wallets) < walletStatusExpireBatch {
+			break
+		}
+	}
+
+	stats.ProcessTime = time.Since(startTime)
+	return stats, nil
+}
+
+// revertWallet 在钱包锁内再次确认已到期后恢复正常，避免覆盖管理员刚刚做出的修改
+func (s *WalletStatusService) revertWallet(ctx context.Context, uid string) error {
+	var before, after map[string]interface{}
+	err := s.walletService.AtomicBalanceOperation(ctx, uid, func(wallet *models.Wallet) error {
+		if wallet.Status == models.WalletStatusNormal || !wallet.IsStatusExpired(time.Now()) {
+			return nil
+		}
+		before = walletStatusSnapshot(wallet)
+		wallet.Status = models.WalletStatusNormal
+		wallet.StatusReason = ""
+		wallet.StatusExpiresAt = nil
+		after = walletStatusSnapshot(wallet)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return nil
+	}
+
+	s.cacheService.DeleteWalletBalance(ctx, uid)
+	s.auditService.Record(ctx, models.AuditActionWalletStatusExpire, models.AuditTargetWallet, uid, before, after)
+	if err := s.messageService.PushUserMessage(ctx, uid, "info", "您的钱包限制已到期，已自动恢复正常。", "system"); err != nil {
+		utils.LogWarn(nil, "发送钱包状态消息失败 - UID: %s, 错误: %v", uid, err)
+	}
+	return nil
+}
+
+// notifyUser 通过站内消息告知用户钱包状态变化
+func (s *WalletStatusService) notifyUser(ctx context.Context, uid string, status int, reason string, expiresAt *time.Time, operator string) {
+	messageType := "warning"
+	var content string
+	switch status {
+	case models.WalletStatusFrozen:
+		content = fmt.Sprintf("您的钱包已被冻结，原因：%s。冻结期间无法下单、充值和提现", reason)
+	case models.WalletStatusNoWithdraw:
+		content = fmt.Sprintf("您的钱包已被限制提现，原因：%s。限制期间无法提现", reason)
+	default:
+		messageType = "info"
+		content = fmt.Sprintf("您的钱包已恢复正常，原因：%s。", reason)
+	}
+	if status != models.WalletStatusNormal {
+		if expiresAt != nil {
+			content += fmt.Sprintf("，将于%s自动解除。", expiresAt.Format("2006-01-02 15:04:05"))
+		} else {
+			content += "，如有疑问请联系客服。"
+		}
+	}
+
+	if err := s.messageService.PushUserMessage(ctx, uid, messageType, content, operator); err != nil {
+		utils.LogWarn(nil, "发送钱包状态消息失败 - UID: %s, 错误: %v", uid, err)
+	}
+}
+
+// parseWalletStatusExpiry 解析到期时间，必须晚于当前时间
+func parseWalletStatusExpiry(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		return nil, utils.NewAppError(utils.CodeInvalidParams, "到期时间格式错误，应为YYYY-MM-DD HH:MM:SS")
+	}
+	if !t.After(time.Now()) {
+		return nil, utils.NewAppError(utils.CodeInvalidParams, "到期时间必须晚于当前时间")
+	}
+	return &t, nil
+}
+
+// walletStatusSnapshot 钱包状态快照，用于审计
+func walletStatusSnapshot(wallet *models.Wallet) map[string]interface{} {
+	return map[string]interface{}{
+		"status":            wallet.Status,
+		"status_reason":     wallet.StatusReason,
+		"status_expires_at": wallet.StatusExpiresAt,
+	}
+}
+
+// toWalletStatusResponse 转换为钱包状态响应
+func toWalletStatusResponse(wallet *models.Wallet) *models.WalletStatusResponse {
+	return &models.WalletStatusResponse{
+		Uid:             wallet.Uid,
+		Status:          wallet.Status,
+		StatusName:      wallet.GetStatusName(),
+		StatusReason:    wallet.StatusReason,
+		StatusExpiresAt: wallet.StatusExpiresAt,
+	}
+}
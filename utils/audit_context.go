@@ -0,0 +1,30 @@
+package utils
+
+import "context"
+
+// AuditActor 审计日志中的操作人信息，由管理员认证中间件写入请求上下文
+type AuditActor struct {
+	AdminID   uint
+	Username  string
+	IP        string
+	RequestID string
+}
+
+// auditActorKey 上下文中操作人信息的key
+type auditActorKey struct{}
+
+// SystemAuditActor 没有管理员上下文时（定时任务、系统内部调用）使用的操作人
+var SystemAuditActor = AuditActor{Username: "system"}
+
+// WithAuditActor 将操作人信息写入上下文
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// GetAuditActor 从上下文获取操作人信息，没有时返回系统操作人
+func GetAuditActor(ctx context.Context) AuditActor {
+	if actor, ok := ctx.Value(auditActorKey{}).(AuditActor); ok {
+		return actor
+	}
+	return SystemAuditActor
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInviteTokenInvalid 邀请码格式错误或签名不匹配
+var ErrInviteTokenInvalid = errors.New("invalid invite token")
+
+// ErrInviteTokenExpired 邀请码已过期
+var ErrInviteTokenExpired = errors.New("invite token expired")
+
+// GenerateGroupBuyInviteToken 生成拼单邀请码
+// 格式：base64url(拼单编号.过期时间戳.签名)，签名为HMAC-SHA256前16字节
+func GenerateGroupBuyInviteToken(groupBuyNo string, expireAt time.Time, secret string) string {
+	payload := groupBuyNo + "." + strconv.FormatInt(expireAt.Unix(), 10)
+	sig := signInvitePayload(payload, secret)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload + "." + sig))
+}
+
+// ParseGroupBuyInviteToken 解析并校验拼单邀请码，返回拼单编号和过期时间
+func ParseGroupBuyInviteToken(token, secret string) (string, time.Time, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", time.Time{}, ErrInviteTokenInvalid
+	}
+
+	parts := strings.Split(string(data), ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", time.Time{}, ErrInviteTokenInvalid
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signInvitePayload(payload, secret))) {
+		return "", time.Time{}, ErrInviteTokenInvalid
+	}
+
+	expireUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrInviteTokenInvalid
+	}
+	expireAt := time.Unix(expireUnix, 0)
+	if time.Now().After(expireAt) {
+		return parts[0], expireAt, ErrInviteTokenExpired
+	}
+
+	return parts[0], expireAt, nil
+}
+
+// signInvitePayload 计算邀请码签名
+func signInvitePayload(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
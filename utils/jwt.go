@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,73 +13,108 @@ var (
 	// Token过期时间
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
+	AdminTokenExpiry   time.Duration
 	JWTSecret          []byte
 )
 
 // InitJWT 初始化JWT配置
-func InitJWT(secret string, accessTokenExpire, refreshTokenExpire int) {
+func InitJWT(secret string, accessTokenExpire, refreshTokenExpire, adminTokenExpire int) {
 	JWTSecret = []byte(secret)
 	AccessTokenExpiry = time.Duration(accessTokenExpire) * time.Second
 	RefreshTokenExpiry = time.Duration(refreshTokenExpire) * time.Second
+	AdminTokenExpiry = time.Duration(adminTokenExpire) * time.Second
 }
 
+// 令牌受众，用户令牌和管理员令牌互不通用
+const (
+	AudienceUser  = "user"  // 用户端
+	AudienceAdmin = "admin" // 管理后台
+)
+
+// 令牌类型
+const (
+	TokenTypeAccess  = "access"  // 访问令牌
+	TokenTypeRefresh = "refresh" // 刷新令牌
+)
+
 // Claims JWT声明
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Uid      string `json:"uid"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Uid       string `json:"uid"`
+	Username  string `json:"username"`
+	TokenType string `json:"token_type,omitempty"` // 令牌类型，旧令牌为空按访问令牌处理
+	FamilyID  string `json:"fid,omitempty"`        // 令牌家族ID，同一次登录轮换出的令牌共享
 	jwt.RegisteredClaims
 }
 
+// NewTokenID 生成随机令牌ID，用于jti和令牌家族ID
+func NewTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// 随机源不可用时退化为时间戳，仍保证同一进程内不重复
+		return hex.EncodeToString([]byte(time.Now().Format("20060102150405.000000000")))
+	}
+	return hex.EncodeToString(b)
+}
+
 // GenerateAccessToken 生成访问令牌
-func GenerateAccessToken(userID uint, uid string, username string) (string, error) {
+func GenerateAccessToken(userID uint, uid string, username string, familyID string) (string, error) {
 	claims := Claims{
-		UserID:   userID,
-		Uid:      uid,
-		Username: username,
+		UserID:    userID,
+		Uid:       uid,
+		Username:  username,
+		TokenType: TokenTypeAccess,
+		FamilyID:  familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "gin-fataMorgana",
 			Subject:   username,
+			Audience:  jwt.ClaimStrings{AudienceUser},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JWTSecret)
+	return signToken(claims)
 }
 
-// GenerateRefreshToken 生成刷新令牌
-func GenerateRefreshToken(userID uint, uid string, username string) (string, error) {
+// GenerateRefreshToken 生成刷新令牌，tokenID作为jti用于轮换时识别是否为家族内最新的刷新令牌
+func GenerateRefreshToken(userID uint, uid string, username string, familyID string, tokenID string) (string, error) {
 	claims := Claims{
-		UserID:   userID,
-		Uid:      uid,
-		Username: username,
+		UserID:    userID,
+		Uid:       uid,
+		Username:  username,
+		TokenType: TokenTypeRefresh,
+		FamilyID:  familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
-					ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpiry)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "gin-fataMorgana",
 			Subject:   username,
+			Audience:  jwt.ClaimStrings{AudienceUser},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JWTSecret)
+	return signToken(claims)
 }
 
 // ParseToken 解析令牌
 func ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return JWTSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verifyKeyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// 管理员令牌不能访问用户端接口，旧令牌没有受众按用户令牌处理
+		for _, aud := range claims.Audience {
+			if aud == AudienceAdmin {
+				return nil, NewAppError(CodeTokenInvalid, "无效的令牌")
+			}
+		}
 		return claims, nil
 	}
 
@@ -97,3 +135,66 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// ValidateRefreshToken 验证刷新令牌，要求令牌类型为刷新令牌且带有家族ID和jti
+func ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeRefresh || claims.FamilyID == "" || claims.ID == "" {
+		return nil, NewAppError(CodeRefreshTokenInvalid, "无效的刷新令牌")
+	}
+
+	return claims, nil
+}
+
+// AdminClaims 管理员JWT声明
+type AdminClaims struct {
+	AdminID    uint   `json:"admin_id"`
+	Username   string `json:"username"`
+	Role       int64  `json:"role"`
+	IssuedAtMs int64  `json:"iat_ms"` // 毫秒级签发时间，用于与令牌失效时间精确比较
+	jwt.RegisteredClaims
+}
+
+// GenerateAdminToken 生成管理员访问令牌，受众为admin
+func GenerateAdminToken(adminID uint, username string, role int64) (string, error) {
+	now := time.Now()
+	claims := AdminClaims{
+		AdminID:    adminID,
+		Username:   username,
+		Role:       role,
+		IssuedAtMs: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        NewTokenID(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AdminTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "gin-fataMorgana",
+			Subject:   username,
+			Audience:  jwt.ClaimStrings{AudienceAdmin},
+		},
+	}
+
+	return signToken(claims)
+}
+
+// ValidateAdminToken 验证管理员令牌，要求受众为admin
+func ValidateAdminToken(tokenString string) (*AdminClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &AdminClaims{}, verifyKeyFunc, jwt.WithAudience(AudienceAdmin))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, NewAppError(CodeTokenExpired, "令牌已过期")
+		}
+		return nil, NewAppError(CodeTokenInvalid, "无效的令牌")
+	}
+
+	claims, ok := token.Claims.(*AdminClaims)
+	if !ok || !token.Valid || claims.AdminID == 0 {
+		return nil, NewAppError(CodeTokenInvalid, "无效的令牌")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 令牌签名算法
+const (
+	JWTAlgorithmHS256 = "HS256" // 单一对称密钥，默认
+	JWTAlgorithmRS256 = "RS256" // RSA非对称签名
+	JWTAlgorithmEdDSA = "EdDSA" // Ed25519非对称签名
+)
+
+// JWTKeySpec 非对称签名密钥配置
+type JWTKeySpec struct {
+	Kid            string
+	PrivateKeyFile string // PEM私钥文件，只用于验证的旧密钥可为空
+	PublicKeyFile  string // PEM公钥文件，为空时从私钥推导
+	ActiveFrom     string // 开始用于签名的时间（RFC3339），为空表示立即生效
+	RetireAt       string // 停止验证的时间（RFC3339），为空表示不退役
+}
+
+// signingKey 已加载的签名密钥
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+	activeFrom time.Time
+	retireAt   time.Time
+}
+
+// canSign 检查密钥在指定时间是否可用于签名
+func (k *signingKey) canSign(now time.Time) bool {
+	return k.privateKey != nil && !now.Before(k.activeFrom) && k.canVerify(now)
+}
+
+// canVerify 检查密钥在指定时间是否仍可用于验证
+func (k *signingKey) canVerify(now time.Time) bool {
+	return k.retireAt.IsZero() || now.Before(k.retireAt)
+}
+
+// jwtKeyRing 令牌签名密钥环
+// 同一时间只用最晚生效的密钥签名，未退役的密钥都可以验证，新旧密钥在重叠期内同时有效
+type jwtKeyRing struct {
+	mu                sync.RWMutex
+	algorithm         string
+	keys              []*signingKey
+	acceptLegacyHS256 bool
+}
+
+var keyRing = &jwtKeyRing{algorithm: JWTAlgorithmHS256}
+
+// InitJWTKeys 初始化令牌签名算法和密钥
+// algorithm为HS256时使用JWTSecret签名；非对称算法从文件加载密钥，acceptLegacyHS256为true时仍接受切换前签发的HS256令牌
+func InitJWTKeys(algorithm string, specs []JWTKeySpec, acceptLegacyHS256 bool) error {
+	if algorithm == "" {
+		algorithm = JWTAlgorithmHS256
+	}
+
+	var method jwt.SigningMethod
+	switch algorithm {
+	case JWTAlgorithmHS256:
+		keyRing.mu.Lock()
+		keyRing.algorithm = algorithm
+		keyRing.keys = nil
+		keyRing.acceptLegacyHS256 = false
+		keyRing.mu.Unlock()
+		return nil
+	case JWTAlgorithmRS256:
+		method = jwt.SigningMethodRS256
+	case JWTAlgorithmEdDSA:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return fmt.Errorf("不支持的JWT签名算法: %s", algorithm)
+	}
+
+	if len(specs) == 0 {
+		return fmt.Errorf("%s签名至少需要配置一个密钥", algorithm)
+	}
+
+	keys := make([]*signingKey, 0, len(specs))
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		if spec.Kid == "" {
+			return fmt.Errorf("JWT密钥缺少kid")
+		}
+		if seen[spec.Kid] {
+			return fmt.Errorf("JWT密钥kid重复: %s", spec.Kid)
+		}
+		seen[spec.Kid] = true
+
+		key, err := loadSigningKey(algorithm, method, spec)
+		if err != nil {
+			return fmt.Errorf("加载JWT密钥%s失败: %w", spec.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	// 按生效时间排序，签名时取最晚生效的可用密钥
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].activeFrom.Before(keys[j].activeFrom)
+	})
+
+	keyRing.mu.Lock()
+	keyRing.algorithm = algorithm
+	keyRing.keys = keys
+	keyRing.acceptLegacyHS256 = acceptLegacyHS256
+	keyRing.mu.Unlock()
+
+	if keyRing.currentKey(time.Now()) == nil {
+		return fmt.Errorf("当前没有可用于签名的JWT密钥，请检查私钥文件和生效时间")
+	}
+	return nil
+}
+
+// loadSigningKey 从PEM文件加载密钥
+func loadSigningKey(algorithm string, method jwt.SigningMethod, spec JWTKeySpec) (*signingKey, error) {
+	key := &signingKey{kid: spec.Kid, method: method}
+
+	var err error
+	if spec.ActiveFrom != "" {
+		if key.activeFrom, err = time.Parse(time.RFC3339, spec.ActiveFrom); err != nil {
+			return nil, fmt.Errorf("active_from格式错误: %w", err)
+		}
+	}
+	if spec.RetireAt != "" {
+		if key.retireAt, err = time.Parse(time.RFC3339, spec.RetireAt); err != nil {
+			return nil, fmt.Errorf("retire_at格式错误: %w", err)
+		}
+	}
+
+	if spec.PrivateKeyFile != "" {
+		data, err := os.ReadFile(spec.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		switch algorithm {
+		case JWTAlgorithmRS256:
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey = privateKey
+			key.publicKey = &privateKey.PublicKey
+		case JWTAlgorithmEdDSA:
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			edKey, ok := privateKey.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("私钥不是Ed25519密钥")
+			}
+			key.privateKey = edKey
+			key.publicKey = edKey.Public()
+		}
+	}
+
+	if spec.PublicKeyFile != "" {
+		data, err := os.ReadFile(spec.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		switch algorithm {
+		case JWTAlgorithmRS256:
+			if key.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		case JWTAlgorithmEdDSA:
+			if key.publicKey, err = jwt.ParseEdPublicKeyFromPEM(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if key.publicKey == nil {
+		return nil, fmt.Errorf("需要配置私钥文件或公钥文件")
+	}
+	return key, nil
+}
+
+// currentKey 获取当前用于签名的密钥，HS256模式返回nil
+func (r *jwtKeyRing) currentKey(now time.Time) *signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].canSign(now) {
+			return r.keys[i]
+		}
+	}
+	return nil
+}
+
+// signToken 使用当前签名密钥签发令牌，非对称签名在头部写入kid
+func signToken(claims jwt.Claims) (string, error) {
+	keyRing.mu.RLock()
+	algorithm := keyRing.algorithm
+	keyRing.mu.RUnlock()
+
+	if algorithm == JWTAlgorithmHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(JWTSecret)
+	}
+
+	key := keyRing.currentKey(time.Now())
+	if key == nil {
+		return "", NewAppError(CodeTokenValidationFailed, "没有可用的令牌签名密钥")
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.privateKey)
+}
+
+// verifyKeyFunc 根据令牌头部的算法和kid选择验证密钥，拒绝与配置不符的算法
+func verifyKeyFunc(token *jwt.Token) (interface{}, error) {
+	keyRing.mu.RLock()
+	defer keyRing.mu.RUnlock()
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if keyRing.algorithm == JWTAlgorithmHS256 || keyRing.acceptLegacyHS256 {
+			return JWTSecret, nil
+		}
+		return nil, fmt.Errorf("不接受HS256令牌")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	for _, key := range keyRing.keys {
+		if key.kid != kid {
+			continue
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("令牌算法与密钥不匹配")
+		}
+		if !key.canVerify(now) {
+			return nil, fmt.Errorf("签名密钥已退役: %s", kid)
+		}
+		return key.publicKey, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+}
+
+// JWK 公钥的JSON Web Key表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA模数
+	E   string `json:"e,omitempty"`   // RSA指数
+	Crv string `json:"crv,omitempty"` // OKP曲线
+	X   string `json:"x,omitempty"`   // Ed25519公钥
+}
+
+// JWKSet JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// GetJWKS 获取未退役的公钥集合，尚未生效的新密钥也会提前发布，便于其他服务在轮换前缓存
+func GetJWKS() JWKSet {
+	keyRing.mu.RLock()
+	defer keyRing.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	now := time.Now()
+	for _, key := range keyRing.keys {
+		if !key.canVerify(now) {
+			continue
+		}
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -249,6 +252,60 @@ func (qb *QueryBuilder) Paginate(page, pageSize int) *QueryBuilder {
 	return qb.Offset(offset).Limit(pageSize)
 }
 
+// Cursor 游标分页位置，按 (created_at, id) 倒序定位
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i"`
+}
+
+// ErrInvalidCursor 游标格式错误
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor 将最后一条记录的创建时间和ID编码为游标字符串
+func EncodeCursor(createdAt time.Time, id int64) string {
+	data, _ := json.Marshal(Cursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解析游标字符串，空字符串返回nil
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.CreatedAt.IsZero() || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// CursorPaginate 游标分页查询（keyset），按 created_at DESC, id DESC 排序，
+// 多取一条用于判断是否还有下一页，调用方需用 TrimCursorPage 截断
+func (qb *QueryBuilder) CursorPaginate(cursor *Cursor, pageSize int) *QueryBuilder {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if cursor != nil {
+		qb.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	return qb.Order("created_at DESC").Order("id DESC").Limit(pageSize + 1)
+}
+
+// TrimCursorPage 截断游标分页多取的一条记录，返回保留条数和是否有下一页
+func TrimCursorPage(fetched, pageSize int) (int, bool) {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if fetched > pageSize {
+		return pageSize, true
+	}
+	return fetched, false
+}
+
 // 高级查询方法
 
 // WhereCondition 根据条件map构建查询
@@ -67,6 +67,22 @@ const (
 	CodePasswordTooLong          = 2030 // 密码长度不能超过50位
 	CodeInviteCodeAdminDisabled  = 2031 // 邀请码无效或管理员账户已被禁用
 	CodeInviteCodeAdminDisabled2 = 2032 // 邀请码对应的管理员账户已被禁用
+	CodeRefreshTokenReused       = 2033 // 刷新令牌已被使用，登录会话已撤销
+	CodeTwoFactorRequired        = 2034 // 请输入动态验证码
+	CodeTwoFactorInvalid         = 2035 // 动态验证码错误
+	CodeTwoFactorNotEnabled      = 2036 // 未开启两步验证
+	CodeTwoFactorAlreadyEnabled  = 2037 // 已开启两步验证
+	CodeTwoFactorChallenge       = 2038 // 登录验证已失效，请重新登录
+	CodeVerifyCodeInvalid        = 2039 // 验证码错误或已过期
+	CodeVerifyCodeSendFailed     = 2040 // 验证码发送失败
+	CodeContactNotVerified       = 2041 // 邮箱或手机号未验证
+	CodeContactUnchanged         = 2042 // 新邮箱或手机号与当前相同
+	CodeAdminLoginFailed         = 2043 // 管理员用户名或密码错误
+	CodeAdminDisabled            = 2044 // 管理员账户已被禁用
+	CodeAdminNotFound            = 2045 // 管理员不存在
+	CodeAdminNoAuthority         = 2046 // 无权管理该管理员
+	CodeAdminUsernameExists      = 2047 // 管理员用户名已存在
+	CodeAdminRoleInvalid         = 2048 // 角色无效或超出可分配范围
 
 	// 业务逻辑错误码
 	CodeOrderStatusInvalid     = 3006 // 状态类型参数无效，必须是1(进行中)、2(已完成)或3(拼单数据)
@@ -85,10 +101,35 @@ const (
 	CodeBankCardNotBound      = 3019 // 请先绑定银行卡后再进行提现操作
 	CodeWithdrawPasswordWrong = 3020 // 登录密码错误
 	// CodeDailyWithdrawExceeded   = 3021 // 超过每日提现限额（已移除）
-	CodePeriodNotFound      = 3022 // 期数不存在
-	CodePeriodNotStarted    = 3023 // 期数还未开始
-	CodePeriodEnded         = 3024 // 期数已结束
-	CodePeriodAlreadyBought = 3025 // 您已经购买过期号的订单
+	CodePeriodNotFound         = 3022 // 期数不存在
+	CodePeriodNotStarted       = 3023 // 期数还未开始
+	CodePeriodEnded            = 3024 // 期数已结束
+	CodePeriodAlreadyBought    = 3025 // 您已经购买过期号的订单
+	CodeGroupBuyJoined         = 3026 // 已参与该拼单
+	CodeGroupBuyClosed         = 3027 // 拼单已结束
+	CodeGroupBuyTemplate       = 3028 // 拼单模板无效
+	CodeGroupBuyInvite         = 3029 // 拼单邀请码无效或已过期
+	CodeGroupBuyCancel         = 3030 // 拼单已有其他用户付款，无法取消
+	CodeGroupBuyNoQualify      = 3031 // 暂无拼单资格
+	CodeGroupBuyLevelLimit     = 3032 // 会员等级不足
+	CodeAmountConfigNotFound   = 3033 // 金额配置不存在
+	CodeAmountConfigDuplicate  = 3034 // 该类型下已存在相同金额
+	CodeAmountConfigOutOfRange = 3035 // 金额超出提现限额范围
+	CodeMemberLevelNotFound    = 3037 // 会员等级不存在
+	CodeMemberLevelExists      = 3038 // 会员等级已存在
+	CodeMemberLevelInUse       = 3039 // 会员等级正在被用户使用
+	CodeMemberLevelInvalid     = 3040 // 会员等级升级要求无效
+	CodeScoreRuleNotFound      = 3041 // 经验值与信用分规则不存在
+	CodeScoreApplyFailed       = 3042 // 更新经验值与信用分失败
+	CodeCommissionRuleNotFound = 3043 // 佣金规则不存在
+	CodeCommissionRuleInvalid  = 3044 // 佣金规则无效
+	CodeWalletNotFound         = 3045 // 用户钱包不存在
+	CodeWalletStatusUnchanged  = 3046 // 钱包状态未变化
+
+	// 群发消息与实时推送相关错误码
+	CodeMessageBroadcastNotFound = 3047 // 群发消息不存在
+	CodeMessageBroadcastFinished = 3048 // 群发消息已完成或已取消
+	CodeEventStreamLimit         = 3049 // 实时推送连接数已达上限
 
 	// 银行卡相关错误码
 	CodeBankCardLengthInvalid = 4001 // 银行卡号长度不正确，应为13-19位
@@ -104,6 +145,8 @@ const (
 	CodeTokenSingleLogin      = 5002 // 您的账号已在其他设备登录，请重新登录
 	CodeTokenInvalid          = 5003 // 无效的令牌
 	CodeTokenExpired          = 5004 // 令牌已过期
+	CodeSessionRevoked        = 5005 // 登录会话已失效，请重新登录
+	CodeSessionNotFound       = 5006 // 会话不存在或已失效
 
 	// 系统配置错误码
 	CodeConfigReadFailed   = 6001 // 读取配置文件失败
@@ -119,11 +162,13 @@ const (
 	CodeDBNotInitialized   = 6011 // 数据库未初始化
 	CodeDBMigrationFailed  = 6012 // 数据库迁移失败
 	CodeRedisConnectFailed = 6013 // Redis连接测试失败
+	CodeJWTSecretInsecure  = 6014 // JWT密钥不安全
 
 	// 工具类错误码
 	CodeInviteCodeGenFailed  = 7001 // 无法生成唯一邀请码，请稍后重试
 	CodeIdempotencyKeyExists = 7002 // 重复请求，幂等键已存在
 	CodeRecordNotFound       = 7003 // 记录不存在
+	CodeInvalidCursor        = 7004 // 分页游标无效
 
 	// 中间件错误码
 	CodeRateLimitExceeded  = 8001 // 请求过于频繁，请稍后再试
@@ -231,6 +276,22 @@ var ResponseMessage = map[int]string{
 	CodePasswordTooLong:          "密码长度不能超过50位",
 	CodeInviteCodeAdminDisabled:  "邀请码无效或管理员账户已被禁用",
 	CodeInviteCodeAdminDisabled2: "邀请码对应的管理员账户已被禁用",
+	CodeRefreshTokenReused:       "刷新令牌已被使用，登录会话已撤销",
+	CodeTwoFactorRequired:        "请输入动态验证码",
+	CodeTwoFactorInvalid:         "动态验证码错误",
+	CodeTwoFactorNotEnabled:      "未开启两步验证",
+	CodeTwoFactorAlreadyEnabled:  "已开启两步验证",
+	CodeTwoFactorChallenge:       "登录验证已失效，请重新登录",
+	CodeVerifyCodeInvalid:        "验证码错误或已过期",
+	CodeVerifyCodeSendFailed:     "验证码发送失败，请稍后重试",
+	CodeContactNotVerified:       "邮箱或手机号未验证",
+	CodeContactUnchanged:         "新邮箱或手机号与当前相同",
+	CodeAdminLoginFailed:         "用户名或密码错误",
+	CodeAdminDisabled:            "管理员账户已被禁用",
+	CodeAdminNotFound:            "管理员不存在",
+	CodeAdminNoAuthority:         "无权管理该管理员",
+	CodeAdminUsernameExists:      "管理员用户名已存在",
+	CodeAdminRoleInvalid:         "角色无效或超出可分配范围",
 
 	// 业务逻辑错误消息
 	CodeOrderStatusInvalid:     "状态类型参数无效，必须是1(进行中)、2(已完成)或3(拼单数据)",
@@ -249,10 +310,35 @@ var ResponseMessage = map[int]string{
 	CodeBankCardNotBound:      "请先绑定银行卡后再进行提现操作",
 	CodeWithdrawPasswordWrong: "登录密码错误",
 	// CodeDailyWithdrawExceeded:   "超过每日提现限额", // 已移除
-	CodePeriodNotFound:      "期数不存在",
-	CodePeriodNotStarted:    "期数还未开始",
-	CodePeriodEnded:         "期数已结束",
-	CodePeriodAlreadyBought: "您已经购买过期号的订单",
+	CodePeriodNotFound:         "期数不存在",
+	CodePeriodNotStarted:       "期数还未开始",
+	CodePeriodEnded:            "期数已结束",
+	CodePeriodAlreadyBought:    "您已经购买过期号的订单",
+	CodeGroupBuyJoined:         "您已参与该拼单",
+	CodeGroupBuyClosed:         "拼单已结束",
+	CodeGroupBuyTemplate:       "拼单模板无效",
+	CodeGroupBuyInvite:         "拼单邀请码无效或已过期",
+	CodeGroupBuyCancel:         "拼单已有其他用户付款，无法取消",
+	CodeGroupBuyNoQualify:      "暂无拼单资格",
+	CodeGroupBuyLevelLimit:     "会员等级不足",
+	CodeAmountConfigNotFound:   "金额配置不存在",
+	CodeAmountConfigDuplicate:  "该类型下已存在相同金额",
+	CodeAmountConfigOutOfRange: "金额超出提现限额范围",
+	CodeMemberLevelNotFound:    "会员等级不存在",
+	CodeMemberLevelExists:      "会员等级已存在",
+	CodeMemberLevelInUse:       "会员等级正在被用户使用",
+	CodeMemberLevelInvalid:     "会员等级升级要求无效",
+	CodeScoreRuleNotFound:      "经验值与信用分规则不存在",
+	CodeScoreApplyFailed:       "更新经验值与信用分失败",
+	CodeCommissionRuleNotFound: "佣金规则不存在",
+	CodeCommissionRuleInvalid:  "佣金规则无效",
+	CodeWalletNotFound:         "用户钱包不存在",
+	CodeWalletStatusUnchanged:  "钱包状态未变化",
+
+	// 群发消息与实时推送相关错误消息
+	CodeMessageBroadcastNotFound: "群发消息不存在",
+	CodeMessageBroadcastFinished: "群发消息已完成或已取消",
+	CodeEventStreamLimit:         "实时推送连接数已达上限",
 
 	// 银行卡相关错误消息
 	CodeBankCardLengthInvalid: "银行卡号长度不正确，应为13-19位",
@@ -268,6 +354,8 @@ var ResponseMessage = map[int]string{
 	CodeTokenSingleLogin:      "您的账号已在其他设备登录，请重新登录",
 	CodeTokenInvalid:          "无效的令牌",
 	CodeTokenExpired:          "令牌已过期",
+	CodeSessionRevoked:        "登录会话已失效，请重新登录",
+	CodeSessionNotFound:       "会话不存在或已失效",
 
 	// 系统配置错误消息
 	CodeConfigReadFailed:   "读取配置文件失败",
@@ -278,6 +366,7 @@ var ResponseMessage = map[int]string{
 	CodeDBNameEmpty:        "数据库名称不能为空",
 	CodeRedisHostEmpty:     "Redis主机地址不能为空",
 	CodeJWTSecretEmpty:     "JWT密钥不能为空",
+	CodeJWTSecretInsecure:  "JWT密钥不安全",
 	CodeDBConnectFailed:    "连接数据库失败",
 	CodeDBInstanceFailed:   "获取数据库实例失败",
 	CodeDBNotInitialized:   "数据库未初始化",
@@ -288,6 +377,7 @@ var ResponseMessage = map[int]string{
 	CodeInviteCodeGenFailed:  "无法生成唯一邀请码，请稍后重试",
 	CodeIdempotencyKeyExists: "重复请求，幂等键已存在",
 	CodeRecordNotFound:       "记录不存在",
+	CodeInvalidCursor:        "分页游标无效",
 
 	// 中间件错误消息
 	CodeRateLimitExceeded:  "请求过于频繁，请稍后再试",
@@ -386,6 +476,15 @@ func ErrorWithMessage(c *gin.Context, code int, message string) {
 	})
 }
 
+// HandleAppError 返回服务层的业务错误，非业务错误使用默认提示
+func HandleAppError(c *gin.Context, err error, defaultMessage string) {
+	if appErr, ok := err.(*AppError); ok {
+		ErrorWithMessage(c, appErr.Code, appErr.Message)
+		return
+	}
+	ErrorWithMessage(c, CodeOperationFailed, defaultMessage)
+}
+
 // ErrorWithData 带数据的错误响应
 func ErrorWithData(c *gin.Context, code int, data interface{}) {
 	message := ResponseMessage[code]
@@ -503,6 +602,19 @@ func AccountLocked(c *gin.Context) {
 	ErrorWithMessage(c, CodeAccountLocked, "账户已被锁定")
 }
 
+// AccountLockedWithRemaining 登录失败次数过多导致的账户锁定，返回剩余锁定秒数
+func AccountLockedWithRemaining(c *gin.Context, message string, remainingSeconds int64) {
+	c.JSON(getHTTPStatus(CodeAccountLocked), Response{
+		Code:    CodeAccountLocked,
+		Message: message,
+		Data: gin.H{
+			"remaining_seconds": remainingSeconds,
+			"unlock_at":         time.Now().Unix() + remainingSeconds,
+		},
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
 // 账户待审核
 func UserPendingApproval(c *gin.Context) {
 	ErrorWithMessage(c, CodeUserPendingApproval, ResponseMessage[CodeUserPendingApproval])
@@ -89,3 +89,8 @@ func GenerateUID() string {
 func GenerateOrderNo() string {
 	return "ORD" + GenerateUID()
 }
+
+// GenerateGroupBuyNo 生成用户发起的拼单编号
+func GenerateGroupBuyNo() string {
+	return "GB" + GenerateUID()
+}
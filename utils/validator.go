@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -288,3 +289,18 @@ func IsAllDigits(s string) bool {
 	}
 	return true
 }
+
+var (
+	emailAccountRegex = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+	phoneAccountRegex = regexp.MustCompile(`^\+?[1-9]\d{1,14}$`) // 国际手机号E.164格式
+)
+
+// IsEmailAccount 检查账号是否为邮箱
+func IsEmailAccount(account string) bool {
+	return emailAccountRegex.MatchString(account)
+}
+
+// IsPhoneAccount 检查账号是否为手机号
+func IsPhoneAccount(account string) bool {
+	return phoneAccountRegex.MatchString(account)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+)
+
+// GenerateNumericCode 生成指定位数的数字验证码（crypto/rand）
+func GenerateNumericCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
+
+// HashVerifyCode 计算验证码哈希，Redis中只保存哈希
+// purpose区分验证码用途，account参与计算，避免不同账号、不同用途的验证码哈希相同
+func HashVerifyCode(purpose, account, code, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + ":" + account + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCodeHash 常量时间比较验证码哈希
+func VerifyCodeHash(expected, purpose, account, code, secret string) bool {
+	actual := HashVerifyCode(purpose, account, code, secret)
+	return hmac.Equal([]byte(expected), []byte(actual))
+}